@@ -0,0 +1,75 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package conf
+
+import (
+	"errors"
+	"net"
+
+	"golang.zx2c4.com/wireguard/windows/l18n"
+)
+
+// Walking an IPv6 /64 address by address would never end, so the search gives up after this many candidates.
+const maxAddressPoolCandidates = 1 << 16
+
+// ParseAddressPool parses a subnet from which tunnel addresses are handed out, such as 10.8.0.0/24.
+func ParseAddressPool(s string) (*IPCidr, error) {
+	pool, err := parseIPCidr(s)
+	if err != nil {
+		return nil, err
+	}
+	if pool.Cidr+2 > pool.Bits() {
+		return nil, &ParseError{l18n.Sprintf("Address pool is too small"), s}
+	}
+	pool.MaskSelf()
+	return pool, nil
+}
+
+// NextFreeAddress returns the lowest host address of pool that none of the used addresses falls on. The first host
+// address is skipped, as by convention it belongs to the server, and so is the broadcast address of an IPv4 pool.
+func NextFreeAddress(pool *IPCidr, used []IPCidr) (*IPCidr, error) {
+	network := pool.IPNet()
+	taken := make(map[string]bool, len(used))
+	for i := range used {
+		if network.Contains(used[i].IP) {
+			taken[used[i].IP.String()] = true
+		}
+	}
+	candidate := make(net.IP, len(network.IP))
+	copy(candidate, network.IP)
+	incrementIP(candidate)
+	for i := 0; i < maxAddressPoolCandidates; i++ {
+		if !incrementIP(candidate) || !network.Contains(candidate) {
+			break
+		}
+		if pool.Bits() == 32 && isBroadcast(candidate, network.Mask) {
+			break
+		}
+		if !taken[candidate.String()] {
+			return &IPCidr{candidate, pool.Bits()}, nil
+		}
+	}
+	return nil, errors.New(l18n.Sprintf("Address pool %s has no free addresses", pool.String()))
+}
+
+func incrementIP(ip net.IP) bool {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func isBroadcast(ip net.IP, mask net.IPMask) bool {
+	for i := range ip {
+		if ip[i]|mask[i] != 0xff {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package conf
+
+import (
+	"testing"
+)
+
+func TestNextFreeAddress(t *testing.T) {
+	pool, err := ParseAddressPool("10.8.0.77/29")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool.String() != "10.8.0.72/29" {
+		t.Errorf("Pool is %s", pool.String())
+	}
+	var used []IPCidr
+	for _, expected := range []string{"10.8.0.74/32", "10.8.0.75/32", "10.8.0.76/32", "10.8.0.77/32", "10.8.0.78/32"} {
+		address, err := NextFreeAddress(pool, used)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if address.String() != expected {
+			t.Errorf("Next free address is %s although %s is expected", address.String(), expected)
+		}
+		used = append(used, *address)
+	}
+	if _, err = NextFreeAddress(pool, used); err == nil {
+		t.Error("A full pool should have no free addresses")
+	}
+
+	pool, err = ParseAddressPool("fd00::/64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	used = []IPCidr{{pool.IP, 64}}
+	used[0].IP = append(used[0].IP[:15:15], 2)
+	address, err := NextFreeAddress(pool, used)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address.String() != "fd00::3/128" {
+		t.Errorf("Next free address is %s", address.String())
+	}
+
+	if _, err = ParseAddressPool("10.8.0.1/32"); err == nil {
+		t.Error("A single address should not be a pool")
+	}
+}
@@ -34,3 +34,27 @@ func AdminBool(name string) bool {
 	}
 	return val != 0
 }
+
+func AdminInteger(name string) (uint64, bool) {
+	key, err := openAdminKey()
+	if err != nil {
+		return 0, false
+	}
+	val, _, err := key.GetIntegerValue(name)
+	if err != nil {
+		return 0, false
+	}
+	return val, true
+}
+
+func AdminString(name string) (string, bool) {
+	key, err := openAdminKey()
+	if err != nil {
+		return "", false
+	}
+	val, _, err := key.GetStringValue(name)
+	if err != nil {
+		return "", false
+	}
+	return val, true
+}
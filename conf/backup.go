@@ -0,0 +1,128 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package conf
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// A backup is of everything that is needed to set up a machine as it was: the tunnels, as wg-quick configurations,
+// their options, the settings, and which of the tunnels were running. Unlike the store, it is not encrypted with
+// DPAPI, which would tie it to the machine, but with a key derived from a passphrase, so that it can be restored on
+// a fresh install.
+type Backup struct {
+	Created  time.Time
+	Machine  string
+	Version  string
+	Tunnels  map[string]string // The wg-quick configuration of each tunnel, keyed by tunnel name
+	Options  map[string]TunnelOptions
+	Settings *Settings
+	LastUsed map[string]time.Time
+	Active   []string // The tunnels that were running when the backup was made
+}
+
+const (
+	backupMagic         = "WGBACKUP"
+	backupFormatVersion = 1
+	backupSaltSize      = 16
+	backupHeaderSize    = len(backupMagic) + 1 + 4 + 4 + 1 + backupSaltSize + chacha20poly1305.NonceSizeX
+)
+
+// The Argon2id parameters are those recommended as the second choice by RFC 9106, which needs far less memory than
+// the first, since backups may be made on small machines. They are stored in the header, so that they may be raised
+// later without old backups becoming unreadable.
+const (
+	backupArgonTime    = 3
+	backupArgonMemory  = 64 * 1024 // KiB
+	backupArgonThreads = 4
+)
+
+// Backups are restored by the manager, as Local System, so the parameters of a crafted file are bounded well above
+// those written, but far below what would exhaust the machine.
+const (
+	backupArgonMaxTime   = 16
+	backupArgonMaxMemory = 1024 * 1024 // KiB
+)
+
+var ErrBackupPassphrase = errors.New("The passphrase is wrong, or the backup is damaged")
+
+func backupKey(passphrase string, salt []byte, time, memory uint32, threads uint8) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, time, memory, threads, chacha20poly1305.KeySize)
+}
+
+// Encrypt returns the backup encrypted with the passphrase, which may not be empty. The header, which holds what is
+// needed to derive the key, is authenticated along with the rest.
+func (backup *Backup) Encrypt(passphrase string) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("The passphrase may not be empty")
+	}
+	plaintext, err := json.Marshal(backup)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 0, backupHeaderSize)
+	header = append(header, backupMagic...)
+	header = append(header, backupFormatVersion)
+	header = append(header, make([]byte, 8)...)
+	binary.LittleEndian.PutUint32(header[len(header)-8:], backupArgonTime)
+	binary.LittleEndian.PutUint32(header[len(header)-4:], backupArgonMemory)
+	header = append(header, backupArgonThreads)
+	random := make([]byte, backupSaltSize+chacha20poly1305.NonceSizeX)
+	_, err = rand.Read(random)
+	if err != nil {
+		return nil, err
+	}
+	header = append(header, random...)
+	salt, nonce := random[:backupSaltSize], random[backupSaltSize:]
+	aead, err := chacha20poly1305.NewX(backupKey(passphrase, salt, backupArgonTime, backupArgonMemory, backupArgonThreads))
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(header, nonce, plaintext, header), nil
+}
+
+// DecryptBackup returns the backup of the data, which Encrypt made with the passphrase. If the passphrase is wrong,
+// or if the data has been changed, ErrBackupPassphrase is returned, as the two cannot be told apart.
+func DecryptBackup(data []byte, passphrase string) (*Backup, error) {
+	if len(data) < backupHeaderSize || !bytes.HasPrefix(data, []byte(backupMagic)) {
+		return nil, errors.New("The file is not a WireGuard backup")
+	}
+	header := data[:backupHeaderSize]
+	fields := header[len(backupMagic):]
+	if fields[0] != backupFormatVersion {
+		return nil, errors.New("The backup was made by a newer version of WireGuard")
+	}
+	time := binary.LittleEndian.Uint32(fields[1:5])
+	memory := binary.LittleEndian.Uint32(fields[5:9])
+	threads := fields[9]
+	if time < 1 || time > backupArgonMaxTime || memory < 8*uint32(threads) || memory > backupArgonMaxMemory || threads < 1 {
+		return nil, errors.New("The backup has invalid key derivation parameters")
+	}
+	salt := fields[10 : 10+backupSaltSize]
+	nonce := fields[10+backupSaltSize:]
+	aead, err := chacha20poly1305.NewX(backupKey(passphrase, salt, time, memory, threads))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, data[backupHeaderSize:], header)
+	if err != nil {
+		return nil, ErrBackupPassphrase
+	}
+	backup := &Backup{}
+	err = json.Unmarshal(plaintext, backup)
+	if err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
@@ -0,0 +1,56 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package conf
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func TestBackupEncryption(t *testing.T) {
+	backup := &Backup{
+		Created: time.Date(2020, 11, 20, 15, 30, 0, 0, time.UTC),
+		Machine: "laptop",
+		Tunnels: map[string]string{"office": "[Interface]\nPrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=\n"},
+		Options: map[string]TunnelOptions{"office": {Favorite: true}},
+		Active:  []string{"office"},
+	}
+	data, err := backup.Encrypt("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := DecryptBackup(data, "correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restored.Created.Equal(backup.Created) || restored.Machine != backup.Machine || restored.Tunnels["office"] != backup.Tunnels["office"] ||
+		!restored.Options["office"].Favorite || len(restored.Active) != 1 || restored.Active[0] != "office" {
+		t.Errorf("Restored backup %+v differs from %+v", restored, backup)
+	}
+
+	if _, err = DecryptBackup(data, "wrong horse battery staple"); err != ErrBackupPassphrase {
+		t.Errorf("Decrypting with the wrong passphrase returned %v", err)
+	}
+	for _, i := range []int{len(backupMagic) + 10, len(data) - 1} {
+		tampered := append([]byte(nil), data...)
+		tampered[i] ^= 1
+		if _, err = DecryptBackup(tampered, "correct horse battery staple"); err != ErrBackupPassphrase {
+			t.Errorf("Decrypting with byte %d changed returned %v", i, err)
+		}
+	}
+	oversized := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(oversized[len(backupMagic)+5:], backupArgonMaxMemory+1)
+	if _, err = DecryptBackup(oversized, "correct horse battery staple"); err == nil || err == ErrBackupPassphrase {
+		t.Errorf("Decrypting with more than the maximum memory returned %v", err)
+	}
+	if _, err = DecryptBackup(data[:backupHeaderSize-1], "correct horse battery staple"); err == nil {
+		t.Error("Decrypting a truncated backup should fail")
+	}
+	if _, err = backup.Encrypt(""); err == nil {
+		t.Error("Encrypting with an empty passphrase should fail")
+	}
+}
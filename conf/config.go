@@ -146,6 +146,14 @@ func NewPrivateKeyFromString(b64 string) (*Key, error) {
 	return parseKeyBase64(b64)
 }
 
+func NewIPCidrFromString(s string) (*IPCidr, error) {
+	return parseIPCidr(s)
+}
+
+func NewEndpointFromString(s string) (*Endpoint, error) {
+	return parseEndpoint(s)
+}
+
 func (t HandshakeTime) IsEmpty() bool {
 	return t == HandshakeTime(0)
 }
@@ -169,19 +177,19 @@ func (t HandshakeTime) String() string {
 	seconds := left % 60
 	s := make([]string, 0, 5)
 	if years > 0 {
-		s = append(s, l18n.Sprintf("%d year(s)", years))
+		s = append(s, l18n.SprintfPlural(int(years), "%d year", "%d years", years))
 	}
 	if days > 0 {
-		s = append(s, l18n.Sprintf("%d day(s)", days))
+		s = append(s, l18n.SprintfPlural(int(days), "%d day", "%d days", days))
 	}
 	if hours > 0 {
-		s = append(s, l18n.Sprintf("%d hour(s)", hours))
+		s = append(s, l18n.SprintfPlural(int(hours), "%d hour", "%d hours", hours))
 	}
 	if minutes > 0 {
-		s = append(s, l18n.Sprintf("%d minute(s)", minutes))
+		s = append(s, l18n.SprintfPlural(int(minutes), "%d minute", "%d minutes", minutes))
 	}
 	if seconds > 0 {
-		s = append(s, l18n.Sprintf("%d second(s)", seconds))
+		s = append(s, l18n.SprintfPlural(int(seconds), "%d second", "%d seconds", seconds))
 	}
 	timestamp := strings.Join(s, l18n.UnitSeparator())
 	return l18n.Sprintf("%s ago", timestamp)
@@ -14,7 +14,9 @@ import (
 	"golang.org/x/sys/windows"
 )
 
-var encryptedFileSd unsafe.Pointer
+const encryptedFileSDDL = "O:SYG:SYD:PAI(A;;FA;;;SY)(A;;SD;;;BA)"
+
+var encryptedFileSd, auditedFileSd unsafe.Pointer
 
 func randomFileName() string {
 	var randBytes [32]byte
@@ -25,17 +27,41 @@ func randomFileName() string {
 	return hex.EncodeToString(randBytes[:]) + ".tmp"
 }
 
-func writeLockedDownFile(destination string, overwrite bool, contents []byte) error {
-	var err error
-	sa := &windows.SecurityAttributes{Length: uint32(unsafe.Sizeof(windows.SecurityAttributes{}))}
-	sa.SecurityDescriptor = (*windows.SECURITY_DESCRIPTOR)(atomic.LoadPointer(&encryptedFileSd))
-	if sa.SecurityDescriptor == nil {
-		sa.SecurityDescriptor, err = windows.SecurityDescriptorFromString("O:SYG:SYD:PAI(A;;FA;;;SY)(A;;SD;;;BA)")
+func cachedSecurityDescriptor(cache *unsafe.Pointer, sddl string) (*windows.SECURITY_DESCRIPTOR, error) {
+	sd := (*windows.SECURITY_DESCRIPTOR)(atomic.LoadPointer(cache))
+	if sd == nil {
+		var err error
+		sd, err = windows.SecurityDescriptorFromString(sddl)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		atomic.StorePointer(&encryptedFileSd, unsafe.Pointer(sa.SecurityDescriptor))
+		atomic.StorePointer(cache, unsafe.Pointer(sd))
+	}
+	return sd, nil
+}
+
+func writeLockedDownFile(destination string, overwrite bool, contents []byte) error {
+	sd, err := cachedSecurityDescriptor(&encryptedFileSd, encryptedFileSDDL)
+	if err != nil {
+		return err
 	}
+	return writeFileWithSecurityDescriptor(destination, overwrite, contents, sd)
+}
+
+// writeAuditedLockedDownFile is like writeLockedDownFile, but gives the file the audit policy of the configuration
+// directory as well, which it does not inherit, since it is written elsewhere and then moved into place.
+func writeAuditedLockedDownFile(destination string, overwrite bool, contents []byte) error {
+	sd, err := cachedSecurityDescriptor(&auditedFileSd, encryptedFileSDDL+storeAuditFileSDDL)
+	if err != nil {
+		return err
+	}
+	return withSecurityPrivilege(func() error {
+		return writeFileWithSecurityDescriptor(destination, overwrite, contents, sd)
+	})
+}
+
+func writeFileWithSecurityDescriptor(destination string, overwrite bool, contents []byte, sd *windows.SECURITY_DESCRIPTOR) error {
+	sa := &windows.SecurityAttributes{Length: uint32(unsafe.Sizeof(windows.SecurityAttributes{})), SecurityDescriptor: sd}
 	destination16, err := windows.UTF16FromString(destination)
 	if err != nil {
 		return err
@@ -80,6 +106,7 @@ func writeLockedDownFile(destination string, overwrite bool, contents []byte) er
 		return windows.ERROR_BUFFER_OVERFLOW
 	}
 	copy(fileRenameInfo.fileName[:], destination16[:])
+	noteOwnChange(destination)
 	err = windows.SetFileInformationByHandle(handle, windows.FileRenameInfo, (*byte)(unsafe.Pointer(fileRenameInfo)), uint32(unsafe.Sizeof(*fileRenameInfo)))
 	if err != nil {
 		deleteIt()
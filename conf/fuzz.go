@@ -0,0 +1,35 @@
+// +build gofuzz
+
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package conf
+
+// Fuzz is for go-fuzz, starting from the configurations in testdata/fuzz/corpus:
+//
+//	go-fuzz-build -func Fuzz golang.zx2c4.com/wireguard/windows/conf
+//	go-fuzz -bin conf-fuzz.zip -workdir testdata/fuzz
+//
+// Other than not panicking, the parser must agree with itself: what strict mode accepts, lenient mode must accept
+// without warnings, and what either accepts must be written back out as a configuration that strict mode accepts.
+// Inputs that crash it belong in TestParseCrashers.
+func Fuzz(data []byte) int {
+	FromUAPI(string(data), &Config{Name: "fuzz"})
+	strict, strictErr := FromWgQuick(string(data), "fuzz")
+	lenient, unknownKeys, lenientErr := FromWgQuickWithMode(string(data), "fuzz", ParseLenient)
+	if strictErr == nil && (lenientErr != nil || len(unknownKeys) > 0) {
+		panic("lenient parse is stricter than strict parse")
+	}
+	if lenientErr != nil {
+		return 0
+	}
+	if strictErr == nil {
+		lenient = strict
+	}
+	if _, err := FromWgQuick(lenient.ToWgQuick(), "fuzz"); err != nil {
+		panic("written configuration does not parse: " + err.Error())
+	}
+	return 1
+}
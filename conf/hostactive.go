@@ -0,0 +1,57 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package conf
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const hostActiveFileName = "TunnelHost.json"
+
+func hostActivePath() (string, error) {
+	root, err := RootDirectory(true)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, hostActiveFileName), nil
+}
+
+// LoadHostActive returns the paths of the configurations of the tunnels that the tunnel host is to start when it
+// starts, keyed by tunnel name.
+func LoadHostActive() (map[string]string, error) {
+	active := make(map[string]string)
+	path, err := hostActivePath()
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return active, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(bytes, &active)
+	if err != nil {
+		return nil, err
+	}
+	return active, nil
+}
+
+// SaveHostActive replaces the tunnels that the tunnel host is to start when it starts, as returned by LoadHostActive.
+func SaveHostActive(active map[string]string) error {
+	bytes, err := json.Marshal(active)
+	if err != nil {
+		return err
+	}
+	path, err := hostActivePath()
+	if err != nil {
+		return err
+	}
+	return writeLockedDownFile(path, true, bytes)
+}
@@ -0,0 +1,113 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package conf
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const lastUsedFileName = "LastUsed.json"
+
+var lastUsedLock sync.Mutex
+
+func lastUsedPath() (string, error) {
+	root, err := RootDirectory(true)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, lastUsedFileName), nil
+}
+
+// LoadLastUsed returns the time at which each tunnel was last activated, keyed by tunnel name. Tunnels that have
+// never been activated, as well as those that no longer exist, are absent.
+func LoadLastUsed() (map[string]time.Time, error) {
+	lastUsedLock.Lock()
+	defer lastUsedLock.Unlock()
+	return loadLastUsed()
+}
+
+func loadLastUsed() (map[string]time.Time, error) {
+	lastUsed := make(map[string]time.Time)
+	path, err := lastUsedPath()
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return lastUsed, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(bytes, &lastUsed)
+	if err != nil {
+		return nil, err
+	}
+	names, err := ListConfigNames()
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(names))
+	for _, name := range names {
+		existing[strings.ToLower(name)] = true
+	}
+	for name := range lastUsed {
+		if !existing[strings.ToLower(name)] {
+			delete(lastUsed, name)
+		}
+	}
+	return lastUsed, nil
+}
+
+// MarkLastUsed records that the named tunnel was activated just now.
+func MarkLastUsed(name string) error {
+	lastUsedLock.Lock()
+	defer lastUsedLock.Unlock()
+	lastUsed, err := loadLastUsed()
+	if err != nil {
+		lastUsed = make(map[string]time.Time)
+	}
+	lastUsed[name] = time.Now()
+	bytes, err := json.Marshal(lastUsed)
+	if err != nil {
+		return err
+	}
+	path, err := lastUsedPath()
+	if err != nil {
+		return err
+	}
+	return writeLockedDownFile(path, true, bytes)
+}
+
+// RestoreLastUsed records the times at which tunnels were last activated, as returned by LoadLastUsed, keeping any
+// that are later.
+func RestoreLastUsed(restored map[string]time.Time) error {
+	lastUsedLock.Lock()
+	defer lastUsedLock.Unlock()
+	lastUsed, err := loadLastUsed()
+	if err != nil {
+		lastUsed = make(map[string]time.Time)
+	}
+	for name, when := range restored {
+		if when.After(lastUsed[name]) {
+			lastUsed[name] = when
+		}
+	}
+	bytes, err := json.Marshal(lastUsed)
+	if err != nil {
+		return err
+	}
+	path, err := lastUsedPath()
+	if err != nil {
+		return err
+	}
+	return writeLockedDownFile(path, true, bytes)
+}
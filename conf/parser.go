@@ -49,8 +49,9 @@ func parseIPCidr(s string) (ipcidr *IPCidr, err error) {
 	}
 	if len(cidrStr) > 0 {
 		err = &ParseError{l18n.Sprintf("Invalid network prefix length"), s}
-		cidr, err = strconv.Atoi(cidrStr)
-		if err != nil || cidr < 0 || cidr > 128 {
+		var atoiErr error
+		cidr, atoiErr = strconv.Atoi(cidrStr)
+		if atoiErr != nil || cidr < 0 || cidr > 128 {
 			return
 		}
 		if cidr > 32 && maybeV4 != nil {
@@ -195,16 +196,46 @@ func (c *Config) maybeAddPeer(p *Peer) {
 	}
 }
 
+// ParseMode is what FromWgQuickWithMode does with keys that it does not know, such as those that other
+// implementations and vendors add to their configurations.
+type ParseMode int
+
+const (
+	ParseStrict  ParseMode = iota // Unknown keys are errors, as with FromWgQuick
+	ParseLenient                  // Unknown keys are skipped, and returned as warnings
+)
+
+// UnknownKey is a key that a lenient parse skipped.
+type UnknownKey struct {
+	Section string // "Interface" or "Peer"
+	Key     string // As written, rather than lowercased
+	Value   string
+	Line    int // Counting from 1
+}
+
+func (k *UnknownKey) String() string {
+	return l18n.Sprintf("Line %d: unknown key ‘%s’ in [%s] section", k.Line, k.Key, k.Section)
+}
+
 func FromWgQuick(s string, name string) (*Config, error) {
+	conf, _, err := FromWgQuickWithMode(s, name, ParseStrict)
+	return conf, err
+}
+
+// FromWgQuickWithMode is like FromWgQuick, but in lenient mode, rather than failing on keys that it does not know, it
+// skips them, and returns them, so that configurations with only extra keys can be told apart from ones that are
+// invalid. Anything else that is wrong is an error in either mode.
+func FromWgQuickWithMode(s string, name string, mode ParseMode) (*Config, []UnknownKey, error) {
 	if !TunnelNameIsValid(name) {
-		return nil, &ParseError{l18n.Sprintf("Tunnel name is not valid"), name}
+		return nil, nil, &ParseError{l18n.Sprintf("Tunnel name is not valid"), name}
 	}
 	lines := strings.Split(s, "\n")
 	parserState := notInASection
 	conf := Config{Name: name}
 	sawPrivateKey := false
 	var peer *Peer
-	for _, line := range lines {
+	var unknownKeys []UnknownKey
+	for lineNumber, line := range lines {
 		pound := strings.IndexByte(line, '#')
 		if pound >= 0 {
 			line = line[:pound]
@@ -216,6 +247,7 @@ func FromWgQuick(s string, name string) (*Config, error) {
 		}
 		if lineLower == "[interface]" {
 			conf.maybeAddPeer(peer)
+			peer = nil
 			parserState = inInterfaceSection
 			continue
 		}
@@ -226,53 +258,53 @@ func FromWgQuick(s string, name string) (*Config, error) {
 			continue
 		}
 		if parserState == notInASection {
-			return nil, &ParseError{l18n.Sprintf("Line must occur in a section"), line}
+			return nil, nil, &ParseError{l18n.Sprintf("Line must occur in a section"), line}
 		}
 		equals := strings.IndexByte(line, '=')
 		if equals < 0 {
-			return nil, &ParseError{l18n.Sprintf("Config key is missing an equals separator"), line}
+			return nil, nil, &ParseError{l18n.Sprintf("Config key is missing an equals separator"), line}
 		}
-		key, val := strings.TrimSpace(lineLower[:equals]), strings.TrimSpace(line[equals+1:])
+		key, val := strings.ToLower(strings.TrimSpace(line[:equals])), strings.TrimSpace(line[equals+1:])
 		if len(val) == 0 {
-			return nil, &ParseError{l18n.Sprintf("Key must have a value"), line}
+			return nil, nil, &ParseError{l18n.Sprintf("Key must have a value"), line}
 		}
 		if parserState == inInterfaceSection {
 			switch key {
 			case "privatekey":
 				k, err := parseKeyBase64(val)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 				conf.Interface.PrivateKey = *k
 				sawPrivateKey = true
 			case "listenport":
 				p, err := parsePort(val)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 				conf.Interface.ListenPort = p
 			case "mtu":
 				m, err := parseMTU(val)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 				conf.Interface.MTU = m
 			case "address":
 				addresses, err := splitList(val)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 				for _, address := range addresses {
 					a, err := parseIPCidr(address)
 					if err != nil {
-						return nil, err
+						return nil, nil, err
 					}
 					conf.Interface.Addresses = append(conf.Interface.Addresses, *a)
 				}
 			case "dns":
 				addresses, err := splitList(val)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 				for _, address := range addresses {
 					a := net.ParseIP(address)
@@ -291,63 +323,69 @@ func FromWgQuick(s string, name string) (*Config, error) {
 			case "postdown":
 				conf.Interface.PostDown = val
 			default:
-				return nil, &ParseError{l18n.Sprintf("Invalid key for [Interface] section"), key}
+				if mode == ParseStrict {
+					return nil, nil, &ParseError{l18n.Sprintf("Invalid key for [Interface] section"), key}
+				}
+				unknownKeys = append(unknownKeys, UnknownKey{"Interface", strings.TrimSpace(line[:equals]), val, lineNumber + 1})
 			}
 		} else if parserState == inPeerSection {
 			switch key {
 			case "publickey":
 				k, err := parseKeyBase64(val)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 				peer.PublicKey = *k
 			case "presharedkey":
 				k, err := parseKeyBase64(val)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 				peer.PresharedKey = *k
 			case "allowedips":
 				addresses, err := splitList(val)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 				for _, address := range addresses {
 					a, err := parseIPCidr(address)
 					if err != nil {
-						return nil, err
+						return nil, nil, err
 					}
 					peer.AllowedIPs = append(peer.AllowedIPs, *a)
 				}
 			case "persistentkeepalive":
 				p, err := parsePersistentKeepalive(val)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 				peer.PersistentKeepalive = p
 			case "endpoint":
 				e, err := parseEndpoint(val)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 				peer.Endpoint = *e
 			default:
-				return nil, &ParseError{l18n.Sprintf("Invalid key for [Peer] section"), key}
+				if mode == ParseStrict {
+					return nil, nil, &ParseError{l18n.Sprintf("Invalid key for [Peer] section"), key}
+				}
+				unknownKeys = append(unknownKeys, UnknownKey{"Peer", strings.TrimSpace(line[:equals]), val, lineNumber + 1})
 			}
 		}
 	}
 	conf.maybeAddPeer(peer)
 
 	if !sawPrivateKey {
-		return nil, &ParseError{l18n.Sprintf("An interface must have a private key"), l18n.Sprintf("[none specified]")}
+		return nil, nil, &ParseError{l18n.Sprintf("An interface must have a private key"), l18n.Sprintf("[none specified]")}
 	}
 	for _, p := range conf.Peers {
 		if p.PublicKey.IsZero() {
-			return nil, &ParseError{l18n.Sprintf("All peers must have public keys"), l18n.Sprintf("[none specified]")}
+			return nil, nil, &ParseError{l18n.Sprintf("All peers must have public keys"), l18n.Sprintf("[none specified]")}
 		}
 	}
 
-	return &conf, nil
+	return &conf, unknownKeys, nil
 }
 
 func FromWgQuickWithUnknownEncoding(s string, name string) (*Config, error) {
@@ -6,9 +6,13 @@
 package conf
 
 import (
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -126,3 +130,120 @@ func TestParseEndpoint(t *testing.T) {
 		t.Error("Error was expected")
 	}
 }
+
+func TestParseWgSet(t *testing.T) {
+	conf, err := FromWgQuick(testInput, "test")
+	if !noError(t, err) {
+		return
+	}
+	change, err := ParseWgSet([]string{
+		"listen-port", "51821",
+		"peer", "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=", "remove",
+		"peer", "TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0=", "allowed-ips", "10.192.122.4/32,10.192.125.0/24", "persistent-keepalive", "off",
+		"peer", "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=", "endpoint", "192.95.5.68:51820",
+	})
+	if !noError(t, err) {
+		return
+	}
+	conf.Apply(change)
+	equal(t, uint16(51821), conf.Interface.ListenPort)
+	if !lenTest(t, conf.Peers, 3) {
+		return
+	}
+	equal(t, "TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0=", conf.Peers[0].PublicKey.String())
+	equal(t, "10.192.125.0/24", conf.Peers[0].AllowedIPs[1].String())
+	equal(t, uint16(0), conf.Peers[0].PersistentKeepalive)
+	equal(t, "gN65BkIKy1eCE9pP1wdc8ROUtkHLF2PfAqYdyYBz6EA=", conf.Peers[1].PublicKey.String())
+	equal(t, "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=", conf.Peers[2].PublicKey.String())
+	equal(t, "192.95.5.68:51820", conf.Peers[2].Endpoint.String())
+
+	keyFile, err := ioutil.TempFile("", "wgset")
+	if !noError(t, err) {
+		return
+	}
+	defer os.Remove(keyFile.Name())
+	keyFile.WriteString("yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=\r\n")
+	keyFile.Close()
+	change, err = ParseWgSet([]string{"private-key", keyFile.Name(), "peer", "TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0=", "preshared-key", os.DevNull})
+	if !noError(t, err) {
+		return
+	}
+	conf.Apply(change)
+	equal(t, "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=", conf.Interface.PrivateKey.String())
+	equal(t, true, conf.Peers[0].PresharedKey.IsZero())
+
+	for _, args := range [][]string{{"remove"}, {"endpoint", "192.95.5.68:51820"}, {"listen-port"}, {"private-key", "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk="}, {"peer", "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=", "listen-port", "1"}} {
+		_, err = ParseWgSet(args)
+		if err == nil {
+			t.Errorf("Error was expected for %q", args)
+		}
+	}
+}
+
+func TestParseModes(t *testing.T) {
+	const vendorInput = testInput + `
+Table = off
+
+[Interface]
+SaveConfig = true`
+	_, err := FromWgQuick(vendorInput, "test")
+	if err == nil {
+		t.Error("Strict parse accepted unknown keys")
+	}
+	conf, unknownKeys, err := FromWgQuickWithMode(vendorInput, "test", ParseLenient)
+	if !noError(t, err) {
+		return
+	}
+	lenTest(t, conf.Peers, 3)
+	equal(t, []UnknownKey{{"Peer", "Table", "off", 24}, {"Interface", "SaveConfig", "true", 27}}, unknownKeys)
+
+	_, _, err = FromWgQuickWithMode(vendorInput+"\nListenPort = nonsense", "test", ParseLenient)
+	if err == nil {
+		t.Error("Lenient parse accepted an invalid value")
+	}
+}
+
+// TestParseCrashers runs the parser over the fuzzing corpus, which includes inputs that once made it panic.
+func TestParseCrashers(t *testing.T) {
+	files, err := ioutil.ReadDir(filepath.Join("testdata", "fuzz", "corpus"))
+	if !noError(t, err) {
+		return
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", "fuzz", "corpus", file.Name()))
+		if !noError(t, err) {
+			continue
+		}
+		FromUAPI(string(data), &Config{Name: "fuzz"})
+		_, strictErr := FromWgQuick(string(data), "fuzz")
+		lenient, unknownKeys, lenientErr := FromWgQuickWithMode(string(data), "fuzz", ParseLenient)
+		if strictErr == nil && (lenientErr != nil || len(unknownKeys) > 0) {
+			t.Errorf("%s: lenient parse is stricter than strict parse", file.Name())
+		}
+		if lenientErr == nil {
+			_, err = FromWgQuick(lenient.ToWgQuick(), "fuzz")
+			if err != nil {
+				t.Errorf("%s: written configuration does not parse: %v", file.Name(), err)
+			}
+		}
+	}
+}
+
+func TestExportRedacted(t *testing.T) {
+	conf, err := FromWgQuick(testInput, "test")
+	if !noError(t, err) {
+		return
+	}
+	for _, maskEndpoints := range []bool{false, true} {
+		redacted := conf.ExportRedacted(maskEndpoints)
+		if strings.Contains(redacted, conf.Interface.PrivateKey.String()) || !strings.Contains(redacted, "PresharedKey = (hidden)\n") {
+			t.Error("Private or preshared key was not redacted")
+		}
+		if strings.Contains(redacted, "192.95.5.67") == maskEndpoints {
+			t.Errorf("Endpoint was masked: %v, but should be: %v", !maskEndpoints, maskEndpoints)
+		}
+		if !strings.Contains(redacted, conf.Peers[0].PublicKey.String()) || !strings.Contains(redacted, ":2468\n") {
+			t.Error("Public key or endpoint port was redacted")
+		}
+	}
+}
@@ -0,0 +1,259 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type textField struct {
+	key   string // As ToWgQuick writes it
+	value string // Empty if absent
+}
+
+func joinIPCidrs(addresses []IPCidr) string {
+	addrStrings := make([]string, len(addresses))
+	for i := range addresses {
+		addrStrings[i] = addresses[i].String()
+	}
+	return strings.Join(addrStrings, ", ")
+}
+
+func interfaceFields(iface *Interface) []textField {
+	dns := make([]string, 0, len(iface.DNS)+len(iface.DNSSearch))
+	for _, address := range iface.DNS {
+		dns = append(dns, address.String())
+	}
+	dns = append(dns, iface.DNSSearch...)
+	fields := []textField{
+		{"PrivateKey", iface.PrivateKey.String()},
+		{"ListenPort", ""},
+		{"Address", joinIPCidrs(iface.Addresses)},
+		{"DNS", strings.Join(dns, ", ")},
+		{"MTU", ""},
+		{"PreUp", iface.PreUp},
+		{"PostUp", iface.PostUp},
+		{"PreDown", iface.PreDown},
+		{"PostDown", iface.PostDown},
+	}
+	if iface.ListenPort > 0 {
+		fields[1].value = fmt.Sprintf("%d", iface.ListenPort)
+	}
+	if iface.MTU > 0 {
+		fields[4].value = fmt.Sprintf("%d", iface.MTU)
+	}
+	return fields
+}
+
+func peerFields(peer *Peer) []textField {
+	fields := []textField{
+		{"PublicKey", peer.PublicKey.String()},
+		{"PresharedKey", ""},
+		{"AllowedIPs", joinIPCidrs(peer.AllowedIPs)},
+		{"Endpoint", ""},
+		{"PersistentKeepalive", ""},
+	}
+	if !peer.PresharedKey.IsZero() {
+		fields[1].value = peer.PresharedKey.String()
+	}
+	if !peer.Endpoint.IsEmpty() {
+		fields[3].value = peer.Endpoint.String()
+	}
+	if peer.PersistentKeepalive > 0 {
+		fields[4].value = fmt.Sprintf("%d", peer.PersistentKeepalive)
+	}
+	return fields
+}
+
+// textSection is a section of a wg-quick configuration as written, with the lines that hold each of its keys.
+type textSection struct {
+	header  int
+	lastKey int              // The last line of the section that holds a key, or the header if none does
+	keys    map[string][]int // Lines by lowercased key
+}
+
+// rewriter edits the lines of a wg-quick configuration, each line being replaced by any number of others, and
+// having any number of others inserted after it.
+type rewriter struct {
+	lines       []string
+	replacement map[int][]string
+	inserted    map[int][]string
+	eol         string
+}
+
+func (r *rewriter) replace(line int, with ...string) {
+	r.replacement[line] = with
+}
+
+func (r *rewriter) insertAfter(line int, text string) {
+	r.inserted[line] = append(r.inserted[line], text+r.eol)
+}
+
+func (r *rewriter) String() string {
+	output := make([]string, 0, len(r.lines))
+	for i, line := range r.lines {
+		if with, ok := r.replacement[i]; ok {
+			output = append(output, with...)
+		} else {
+			output = append(output, line)
+		}
+		output = append(output, r.inserted[i]...)
+	}
+	return strings.Join(output, "\n")
+}
+
+// withValue returns the line, which holds a key, with its value replaced, keeping what surrounds the value, such as
+// the spacing around the equals sign and a trailing comment.
+func withValue(line, value string) string {
+	start := strings.IndexByte(line, '=') + 1
+	for start < len(line) && (line[start] == ' ' || line[start] == '\t') {
+		start++
+	}
+	end := len(line)
+	if pound := strings.IndexByte(line, '#'); pound >= 0 {
+		end = pound
+	}
+	for end > start && strings.ContainsRune(" \t\r", rune(line[end-1])) {
+		end--
+	}
+	return line[:start] + value + line[end:]
+}
+
+func (r *rewriter) rewriteFields(section *textSection, oldFields, newFields []textField) {
+	for i := range newFields {
+		if oldFields[i].value == newFields[i].value {
+			continue
+		}
+		lines := section.keys[strings.ToLower(newFields[i].key)]
+		if len(newFields[i].value) == 0 {
+			for _, line := range lines {
+				r.replace(line)
+			}
+			continue
+		}
+		if len(lines) == 0 {
+			r.insertAfter(section.lastKey, fmt.Sprintf("%s = %s", newFields[i].key, newFields[i].value))
+			continue
+		}
+		r.replace(lines[0], withValue(r.lines[lines[0]], newFields[i].value))
+		for _, line := range lines[1:] {
+			r.replace(line)
+		}
+	}
+}
+
+// RewriteWgQuick returns text, a wg-quick configuration, changed so that it is of conf, while keeping its comments,
+// unknown keys, order, and formatting wherever they are not changed: only the values that differ are rewritten, in
+// place, and only the sections of peers that were removed are removed. Peers of text are matched with those of conf
+// by public key, and those left over in order, so that a peer whose public key was changed keeps its comments.
+func (conf *Config) RewriteWgQuick(text string) (string, error) {
+	old, _, err := FromWgQuickWithMode(text, "rewrite", ParseLenient)
+	if err != nil {
+		return "", err
+	}
+	r := &rewriter{lines: strings.Split(text, "\n"), replacement: make(map[int][]string), inserted: make(map[int][]string)}
+	if strings.Contains(text, "\r\n") {
+		r.eol = "\r"
+	}
+
+	var iface *textSection
+	var peers []*textSection
+	var section *textSection
+	for i, line := range r.lines {
+		if pound := strings.IndexByte(line, '#'); pound >= 0 {
+			line = line[:pound]
+		}
+		line = strings.ToLower(strings.TrimSpace(line))
+		if len(line) == 0 {
+			continue
+		}
+		if line == "[interface]" {
+			// Keys of further [Interface] sections are treated as being of the first, as the parser does.
+			if iface == nil {
+				iface = &textSection{header: i, lastKey: i, keys: make(map[string][]int)}
+			}
+			section = iface
+			continue
+		}
+		if line == "[peer]" {
+			section = &textSection{header: i, lastKey: i, keys: make(map[string][]int)}
+			peers = append(peers, section)
+			continue
+		}
+		if equals := strings.IndexByte(line, '='); equals >= 0 && section != nil {
+			key := strings.TrimSpace(line[:equals])
+			section.keys[key] = append(section.keys[key], i)
+			section.lastKey = i
+		}
+	}
+	if iface == nil || len(peers) != len(old.Peers) {
+		return "", errors.New("Unable to find the sections of the configuration")
+	}
+
+	r.rewriteFields(iface, interfaceFields(&old.Interface), interfaceFields(&conf.Interface))
+
+	matched := make([]int, len(conf.Peers))
+	used := make([]bool, len(old.Peers))
+	for i := range conf.Peers {
+		matched[i] = -1
+		for j := range old.Peers {
+			if !used[j] && old.Peers[j].PublicKey == conf.Peers[i].PublicKey {
+				matched[i] = j
+				used[j] = true
+				break
+			}
+		}
+	}
+	next := 0
+	for i := range conf.Peers {
+		if matched[i] >= 0 {
+			continue
+		}
+		for next < len(old.Peers) && used[next] {
+			next++
+		}
+		if next < len(old.Peers) {
+			matched[i] = next
+			used[next] = true
+		}
+	}
+	var added []*Peer
+	for i := range conf.Peers {
+		if matched[i] < 0 {
+			added = append(added, &conf.Peers[i])
+			continue
+		}
+		r.rewriteFields(peers[matched[i]], peerFields(&old.Peers[matched[i]]), peerFields(&conf.Peers[i]))
+	}
+	for j := range old.Peers {
+		if used[j] {
+			continue
+		}
+		// Comments after the last key are left, as they are more likely to be about what follows.
+		for line := peers[j].header; line <= peers[j].lastKey; line++ {
+			r.replace(line)
+		}
+		if peers[j].header > 0 && len(strings.TrimSpace(r.lines[peers[j].header-1])) == 0 {
+			r.replace(peers[j].header - 1)
+		}
+	}
+
+	result := r.String()
+	for _, peer := range added {
+		if len(result) > 0 && !strings.HasSuffix(result, "\n") {
+			result += r.eol + "\n"
+		}
+		result += r.eol + "\n[Peer]" + r.eol + "\n"
+		for _, field := range peerFields(peer) {
+			if len(field.value) > 0 {
+				result += fmt.Sprintf("%s = %s%s\n", field.key, field.value, r.eol)
+			}
+		}
+	}
+	return result, nil
+}
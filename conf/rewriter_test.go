@@ -0,0 +1,92 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package conf
+
+import (
+	"testing"
+)
+
+const rewriterInput = `# Office tunnel
+[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+Address = 10.192.122.1/24 # Assigned by IT
+
+# The office gateway
+[Peer]
+PublicKey = xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=
+AllowedIPs=10.192.122.0/24,10.192.124.0/24
+Endpoint = gateway.example.com:51820
+
+# The lab, which is down half of the time
+[Peer]
+PublicKey = TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0=
+AllowedIPs = 10.10.0.0/16
+FooBar = 1
+`
+
+func TestRewriteWgQuick(t *testing.T) {
+	config, _, err := FromWgQuickWithMode(rewriterInput, "office", ParseLenient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := config.RewriteWgQuick(rewriterInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != rewriterInput {
+		t.Errorf("Rewriting with nothing changed gave:\n%s", text)
+	}
+
+	config.Interface.Addresses[0].Cidr = 16
+	config.Peers[0].PersistentKeepalive = 25
+	lab := config.Peers[1]
+	lab.Endpoint = Endpoint{"lab.example.com", 51820}
+	config.Peers = []Peer{config.Peers[0]}
+	text, err = config.RewriteWgQuick(rewriterInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `# Office tunnel
+[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+Address = 10.192.122.1/16 # Assigned by IT
+
+# The office gateway
+[Peer]
+PublicKey = xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=
+AllowedIPs=10.192.122.0/24,10.192.124.0/24
+Endpoint = gateway.example.com:51820
+PersistentKeepalive = 25
+
+# The lab, which is down half of the time
+`
+	if text != expected {
+		t.Errorf("Rewriting with a peer removed gave:\n%s", text)
+	}
+
+	config.Peers = append(config.Peers, lab)
+	text, err = config.RewriteWgQuick(rewriterInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != expected[:len(expected)-len("\n")]+`
+[Peer]
+PublicKey = TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0=
+AllowedIPs = 10.10.0.0/16
+FooBar = 1
+Endpoint = lab.example.com:51820
+` {
+		t.Errorf("Rewriting with a peer changed gave:\n%s", text)
+	}
+
+	crlf, err := config.RewriteWgQuick("[Interface]\r\nPrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = FromWgQuick(crlf, "office"); err != nil {
+		t.Errorf("Rewriting with CRLF line endings gave %q: %v", crlf, err)
+	}
+}
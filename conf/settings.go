@@ -0,0 +1,179 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package conf
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.zx2c4.com/wireguard/windows/conf/dpapi"
+)
+
+const settingsFileName = "Settings.dpapi"
+const settingsDPAPIName = "WireGuard Settings"
+
+// MaxUpdateCheckInterval is the longest that background update checks may be apart, in hours, so that machines do
+// not go for more than a month without hearing of security fixes.
+const MaxUpdateCheckInterval = 24 * 30
+
+type TrayIconStyle int
+
+const (
+	TrayIconAutomatic       TrayIconStyle = iota // Color on dark taskbars, dark monochrome on light taskbars
+	TrayIconColor                                // Always the color logo
+	TrayIconMonochromeDark                       // A dark glyph, suited to light taskbars
+	TrayIconMonochromeLight                      // A light glyph, suited to dark taskbars
+)
+
+type Settings struct {
+	TunnelNotifications bool
+	ErrorNotifications  bool
+	UpdateNotifications bool
+	CheckForUpdates     bool
+	UpdateCheckInterval int    // Hours between background checks for updates
+	UpdateChannel       string // The release channel that updates come from, or empty for the stable channel
+	ExitStopsTunnels    bool
+
+	RequireReauthentication bool
+
+	ConfirmDelete          bool
+	ConfirmDeactivate      bool // Only when a peer has recently completed a handshake
+	ConfirmImportOverwrite bool
+
+	TrayIconStyle TrayIconStyle
+	Language      string // A BCP 47 tag such as "de" or "zh-TW", or empty to follow the Windows display language
+	TextScale     int    // The size of text in the main window and editor, as a percentage of the default
+
+	LogTimesUTC     bool // Timestamps in the log and its exports are in UTC rather than local time
+	LogTimesISO8601 bool // Timestamps are ISO 8601 with milliseconds, such as 2020-11-20T15:30:00.000Z
+
+	AddressPool string // A subnet such as 10.8.0.0/24 from which new tunnels are offered an address, or empty for none
+
+	MaintenanceWindow MaintenanceWindow
+}
+
+// MaintenanceWindow is the time of day during which an update that has been found is installed without waiting for
+// anybody to click, given in minutes after local midnight. A window that ends before it starts spans midnight.
+type MaintenanceWindow struct {
+	Enabled          bool
+	Start            int
+	End              int
+	OnlyWhenInactive bool // Wait until no tunnel is active, so as not to interrupt traffic
+}
+
+func (window *MaintenanceWindow) Contains(t time.Time) bool {
+	if !window.Enabled {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if window.Start <= window.End {
+		return minute >= window.Start && minute < window.End
+	}
+	return minute >= window.Start || minute < window.End
+}
+
+func DefaultSettings() *Settings {
+	return &Settings{
+		TunnelNotifications: true,
+		ErrorNotifications:  true,
+		UpdateNotifications: true,
+		CheckForUpdates:     true,
+		UpdateCheckInterval: 1,
+		ExitStopsTunnels:    true,
+		TextScale:           100,
+
+		ConfirmDelete:          true,
+		ConfirmImportOverwrite: true,
+
+		MaintenanceWindow: MaintenanceWindow{Start: 2 * 60, End: 4 * 60, OnlyWhenInactive: true},
+	}
+}
+
+func settingsPath() (string, error) {
+	root, err := RootDirectory(true)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, settingsFileName), nil
+}
+
+// LoadSettings returns the persisted settings, with defaults filled in for any that have never been saved.
+func LoadSettings() (*Settings, error) {
+	settings := DefaultSettings()
+	path, err := settingsPath()
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return settings, nil
+	} else if err != nil {
+		return nil, err
+	}
+	bytes, err = dpapi.Decrypt(bytes, settingsDPAPIName)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(bytes, settings)
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// EffectiveUpdateChannel returns the update channel that admins have pinned by policy, or otherwise the one chosen
+// in these settings.
+func (settings *Settings) EffectiveUpdateChannel() string {
+	if channel, ok := AdminString("UpdateChannel"); ok {
+		return channel
+	}
+	return settings.UpdateChannel
+}
+
+// EffectiveUpdateCheckInterval returns how long to wait between background checks for updates, which admins may set
+// by policy.
+func (settings *Settings) EffectiveUpdateCheckInterval() time.Duration {
+	hours := uint64(settings.UpdateCheckInterval)
+	if policy, ok := AdminInteger("UpdateCheckInterval"); ok {
+		hours = policy
+	}
+	if hours < 1 {
+		hours = 1
+	} else if hours > MaxUpdateCheckInterval {
+		hours = MaxUpdateCheckInterval
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// UpdatesDisabled reports whether admins have turned off updates entirely, including checks that users ask for.
+func UpdatesDisabled() bool {
+	return AdminBool("DisableUpdates")
+}
+
+// UpdatesNotifyOnly reports whether admins have forbidden installing updates without a user asking, even in the
+// maintenance window.
+func UpdatesNotifyOnly() bool {
+	return AdminBool("UpdateNotifyOnly")
+}
+
+func (settings *Settings) Save() error {
+	path, err := settingsPath()
+	if err != nil {
+		return err
+	}
+	bytes, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	bytes, err = dpapi.Encrypt(bytes, settingsDPAPIName)
+	if err != nil {
+		return err
+	}
+	return writeLockedDownFile(path, true, bytes)
+}
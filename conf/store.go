@@ -112,6 +112,7 @@ func MigrateUnencryptedConfigs(sharingBase int) (int, []error) {
 			e++
 			continue
 		}
+		noteOwnChange(path)
 		err = os.Remove(path)
 		if err != nil {
 			errs[e] = err
@@ -184,7 +185,7 @@ func (config *Config) Save(overwrite bool) error {
 	if err != nil {
 		return err
 	}
-	return writeLockedDownFile(filename, overwrite, bytes)
+	return writeAuditedLockedDownFile(filename, overwrite, bytes)
 }
 
 func (config *Config) Path() (string, error) {
@@ -206,7 +207,9 @@ func DeleteName(name string) error {
 	if err != nil {
 		return err
 	}
-	return os.Remove(filepath.Join(configFileDir, name+configFileSuffix))
+	path := filepath.Join(configFileDir, name+configFileSuffix)
+	noteOwnChange(path)
+	return os.Remove(path)
 }
 
 func (config *Config) Delete() error {
@@ -7,9 +7,40 @@ package conf
 
 import (
 	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+
+	"golang.org/x/sys/windows"
 )
 
+func checkAudited(t *testing.T, c *Config) {
+	path, err := c.Path()
+	if err != nil {
+		t.Errorf("Unable to find path of config: %s", err.Error())
+		return
+	}
+	var sd *windows.SECURITY_DESCRIPTOR
+	err = withSecurityPrivilege(func() (err error) {
+		sd, err = windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.SACL_SECURITY_INFORMATION)
+		return
+	})
+	if err != nil {
+		t.Errorf("Unable to read audit policy of config: %s", err.Error())
+		return
+	}
+	expected, err := windows.SecurityDescriptorFromString(storeAuditFileSDDL)
+	if err != nil {
+		t.Errorf("Unable to parse audit policy: %s", err.Error())
+		return
+	}
+	for _, ace := range regexp.MustCompile(`\([^)]*\)`).FindAllString(expected.String(), -1) {
+		if !strings.Contains(sd.String(), ace) {
+			t.Errorf("Saved config does not audit %s: %s", ace, sd.String())
+		}
+	}
+}
+
 func TestStorage(t *testing.T) {
 	c, err := FromWgQuick(testInput, "golangTest")
 	if err != nil {
@@ -17,10 +48,11 @@ func TestStorage(t *testing.T) {
 		return
 	}
 
-	err = c.Save()
+	err = c.Save(true)
 	if err != nil {
 		t.Errorf("Unable to save config: %s", err.Error())
 	}
+	checkAudited(t, c)
 
 	configs, err := ListConfigNames()
 	if err != nil {
@@ -54,10 +86,11 @@ func TestStorage(t *testing.T) {
 	}
 	c.Interface.PrivateKey = *k
 
-	err = c.Save()
+	err = c.Save(true)
 	if err != nil {
 		t.Errorf("Unable to save config a second time: %s", err.Error())
 	}
+	checkAudited(t, c)
 
 	loaded, err = LoadFromName("golangTest")
 	if err != nil {
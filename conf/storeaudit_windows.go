@@ -0,0 +1,143 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package conf
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// The configuration directory and everything in it audit writes, deletion, and changes of owner or permissions by
+// anyone, as well as failed attempts to read, so that with object access auditing enabled, Windows records in the
+// Security log who touched which file, and with which program.
+const storeAuditSDDL = "S:(AU;OICISAFA;0xd0116;;;WD)(AU;OICIFA;0x1;;;WD)"
+
+// storeAuditFileSDDL is the same policy, for the files that the store writes.
+const storeAuditFileSDDL = "S:(AU;SAFA;0xd0116;;;WD)(AU;FA;0x1;;;WD)"
+
+// Changes that the watcher sees within this long of the store making them are taken as its own.
+const ownChangeGrace = time.Second * 5
+
+var ownChanges sync.Map // Lowercase file name -> time.Time of the write or removal
+
+// noteOwnChange records that the store is about to write or remove the file, so that the watcher does not report it.
+func noteOwnChange(path string) {
+	ownChanges.Store(strings.ToLower(filepath.Base(path)), time.Now())
+}
+
+func isOwnChange(name string) bool {
+	when, ok := ownChanges.Load(strings.ToLower(name))
+	return ok && time.Since(when.(time.Time)) < ownChangeGrace
+}
+
+// The privilege is enabled for the whole process, so it is enabled for one caller at a time, lest one disable it in
+// the midst of another.
+var securityPrivilegeLock sync.Mutex
+
+func withSecurityPrivilege(f func() error) error {
+	securityPrivilegeLock.Lock()
+	defer securityPrivilegeLock.Unlock()
+	var processToken windows.Token
+	err := windows.OpenProcessToken(windows.CurrentProcess(), windows.TOKEN_ADJUST_PRIVILEGES|windows.TOKEN_QUERY, &processToken)
+	if err != nil {
+		return err
+	}
+	defer processToken.Close()
+	privileges := windows.Tokenprivileges{PrivilegeCount: 1}
+	privileges.Privileges[0].Attributes = windows.SE_PRIVILEGE_ENABLED
+	err = windows.LookupPrivilegeValue(nil, windows.StringToUTF16Ptr("SeSecurityPrivilege"), &privileges.Privileges[0].Luid)
+	if err != nil {
+		return err
+	}
+	var previous windows.Tokenprivileges
+	var previousLen uint32
+	err = windows.AdjustTokenPrivileges(processToken, false, &privileges, uint32(unsafe.Sizeof(previous)), &previous, &previousLen)
+	if err != nil {
+		return err
+	}
+	defer windows.AdjustTokenPrivileges(processToken, false, &previous, 0, nil, nil)
+	return f()
+}
+
+func describeChange(action uint32) string {
+	switch action {
+	case windows.FILE_ACTION_ADDED:
+		return "added"
+	case windows.FILE_ACTION_REMOVED:
+		return "removed"
+	case windows.FILE_ACTION_RENAMED_OLD_NAME:
+		return "renamed away"
+	case windows.FILE_ACTION_RENAMED_NEW_NAME:
+		return "renamed into place"
+	default:
+		return "modified"
+	}
+}
+
+// AuditStore sets the audit policy of the configuration directory, and then watches it, calling tampered for each
+// change made to it other than by the store itself, with the name of the file, or an empty name if changes were
+// lost, and what was done to it. Unencrypted
+// configurations are not reported, since copying them in is how configurations are meant to be provisioned, and
+// the store encrypts and removes them right after.
+func AuditStore(tampered func(file, change string)) error {
+	configFileDir, err := tunnelConfigurationsDirectory()
+	if err != nil {
+		return err
+	}
+	sd, err := windows.SecurityDescriptorFromString(storeAuditSDDL)
+	if err != nil {
+		return err
+	}
+	sacl, _, err := sd.SACL()
+	if err != nil {
+		return err
+	}
+	err = withSecurityPrivilege(func() error {
+		return windows.SetNamedSecurityInfo(configFileDir, windows.SE_FILE_OBJECT, windows.SACL_SECURITY_INFORMATION, nil, nil, nil, sacl)
+	})
+	if err != nil {
+		return err
+	}
+
+	dir, err := windows.CreateFile(windows.StringToUTF16Ptr(configFileDir), windows.FILE_LIST_DIRECTORY, windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE, nil, windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer windows.CloseHandle(dir)
+		buffer := make([]byte, 0x10000)
+		for {
+			var n uint32
+			err := windows.ReadDirectoryChanges(dir, &buffer[0], uint32(len(buffer)), false, fncFILE_NAME|fncATTRIBUTES|fncSIZE|fncLAST_WRITE|fncSECURITY, &n, nil, 0)
+			if err != nil {
+				log.Printf("Unable to watch config directory for tampering: %v", err)
+				return
+			}
+			if n == 0 {
+				tampered("", "changed faster than they could be checked")
+				continue
+			}
+			for offset := uint32(0); offset < n; {
+				info := (*windows.FileNotifyInformation)(unsafe.Pointer(&buffer[offset]))
+				name := windows.UTF16ToString((*[windows.MAX_LONG_PATH]uint16)(unsafe.Pointer(&info.FileName))[: info.FileNameLength/2 : info.FileNameLength/2])
+				if !isOwnChange(name) && !strings.HasSuffix(name, configFileUnencryptedSuffix) {
+					tampered(name, describeChange(info.Action))
+				}
+				if info.NextEntryOffset == 0 {
+					break
+				}
+				offset += info.NextEntryOffset
+			}
+		}
+	}()
+	return nil
+}
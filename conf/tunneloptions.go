@@ -0,0 +1,102 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package conf
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const tunnelOptionsFileName = "TunnelOptions.json"
+
+// TunnelOptions holds the per-tunnel behavior that has no place in a wg-quick configuration file.
+type TunnelOptions struct {
+	IdleTimeout time.Duration // Deactivate after this long without traffic, or never if zero
+	Favorite    bool          // Listed ahead of the other tunnels, whatever the sort order
+}
+
+var tunnelOptionsLock sync.Mutex
+
+func tunnelOptionsPath() (string, error) {
+	root, err := RootDirectory(true)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, tunnelOptionsFileName), nil
+}
+
+func loadAllTunnelOptions() (map[string]TunnelOptions, error) {
+	options := make(map[string]TunnelOptions)
+	path, err := tunnelOptionsPath()
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return options, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(bytes, &options)
+	if err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+func saveAllTunnelOptions(options map[string]TunnelOptions) error {
+	bytes, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	path, err := tunnelOptionsPath()
+	if err != nil {
+		return err
+	}
+	return writeLockedDownFile(path, true, bytes)
+}
+
+// LoadAllTunnelOptions returns the options of every tunnel that has any, keyed by tunnel name.
+func LoadAllTunnelOptions() (map[string]TunnelOptions, error) {
+	tunnelOptionsLock.Lock()
+	defer tunnelOptionsLock.Unlock()
+	return loadAllTunnelOptions()
+}
+
+// LoadTunnelOptions returns the options of the named tunnel, which are all zero if none have been saved.
+func LoadTunnelOptions(name string) (*TunnelOptions, error) {
+	tunnelOptionsLock.Lock()
+	defer tunnelOptionsLock.Unlock()
+	options, err := loadAllTunnelOptions()
+	if err != nil {
+		return nil, err
+	}
+	tunnelOptions := options[name]
+	return &tunnelOptions, nil
+}
+
+// SaveTunnelOptions replaces the options of the named tunnel, removing them entirely if they are all zero.
+func SaveTunnelOptions(name string, tunnelOptions *TunnelOptions) error {
+	tunnelOptionsLock.Lock()
+	defer tunnelOptionsLock.Unlock()
+	options, err := loadAllTunnelOptions()
+	if err != nil {
+		options = make(map[string]TunnelOptions)
+	}
+	if *tunnelOptions == (TunnelOptions{}) {
+		if _, ok := options[name]; !ok {
+			return nil
+		}
+		delete(options, name)
+	} else {
+		options[name] = *tunnelOptions
+	}
+	return saveAllTunnelOptions(options)
+}
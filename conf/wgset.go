@@ -0,0 +1,209 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package conf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"golang.zx2c4.com/wireguard/windows/l18n"
+)
+
+// PeerChange is what "peer" and the arguments after it, up to the next "peer", change, as with wg(8) set. Fields that
+// are nil are left as they are.
+type PeerChange struct {
+	PublicKey           Key
+	Remove              bool
+	PresharedKey        *Key
+	Endpoint            *Endpoint
+	PersistentKeepalive *uint16
+	AllowedIPs          *[]IPCidr // Replacing all of the peer's allowed IPs
+}
+
+// ConfigChange is a change to a tunnel, as given to wg(8) set, that can be applied both to the running tunnel and to
+// its stored configuration.
+type ConfigChange struct {
+	ListenPort *uint16
+	PrivateKey *Key
+	Peers      []PeerChange
+}
+
+// readKeyFile reads a key from a file, as wg(8) does, in which an empty file, such as NUL, stands for no key at all.
+func readKeyFile(path string) (*Key, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimSpace(string(contents))
+	if len(text) == 0 {
+		return &Key{}, nil
+	}
+	return parseKeyBase64(text)
+}
+
+// ParseWgSet parses the arguments that wg(8) set takes after the interface name. As with wg(8), private and preshared
+// keys are given as the paths of files that contain them, which are read here, so this is to be called by whoever was
+// given the arguments, with their rights to the files, rather than by the manager.
+func ParseWgSet(args []string) (*ConfigChange, error) {
+	change := &ConfigChange{}
+	var peer *PeerChange
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "remove" {
+			if peer == nil {
+				return nil, &ParseError{l18n.Sprintf("Only peers can be removed"), arg}
+			}
+			peer.Remove = true
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, &ParseError{l18n.Sprintf("Key must have a value"), arg}
+		}
+		i++
+		val := args[i]
+		switch {
+		case arg == "peer":
+			k, err := parseKeyBase64(val)
+			if err != nil {
+				return nil, err
+			}
+			change.Peers = append(change.Peers, PeerChange{PublicKey: *k})
+			peer = &change.Peers[len(change.Peers)-1]
+		case arg == "listen-port" && peer == nil:
+			p, err := parsePort(val)
+			if err != nil {
+				return nil, err
+			}
+			change.ListenPort = &p
+		case arg == "private-key" && peer == nil:
+			k, err := readKeyFile(val)
+			if err != nil {
+				return nil, err
+			}
+			change.PrivateKey = k
+		case arg == "preshared-key" && peer != nil:
+			k, err := readKeyFile(val)
+			if err != nil {
+				return nil, err
+			}
+			peer.PresharedKey = k
+		case arg == "endpoint" && peer != nil:
+			e, err := parseEndpoint(val)
+			if err != nil {
+				return nil, err
+			}
+			peer.Endpoint = e
+		case arg == "persistent-keepalive" && peer != nil:
+			p, err := parsePersistentKeepalive(val)
+			if err != nil {
+				return nil, err
+			}
+			peer.PersistentKeepalive = &p
+		case arg == "allowed-ips" && peer != nil:
+			allowedIPs := []IPCidr{}
+			for _, address := range strings.Split(val, ",") {
+				address = strings.TrimSpace(address)
+				if len(address) == 0 {
+					continue
+				}
+				a, err := parseIPCidr(address)
+				if err != nil {
+					return nil, err
+				}
+				allowedIPs = append(allowedIPs, *a)
+			}
+			peer.AllowedIPs = &allowedIPs
+		case peer == nil:
+			return nil, &ParseError{l18n.Sprintf("Invalid key for interface section"), arg}
+		default:
+			return nil, &ParseError{l18n.Sprintf("Invalid key for peer section"), arg}
+		}
+	}
+	return change, nil
+}
+
+// ToUAPI returns the lines that make a running tunnel apply the change, without the leading set=1. Like
+// Config.ToUAPI, it resolves the host names of endpoints.
+func (change *ConfigChange) ToUAPI() (uapi string, dnsErr error) {
+	var output strings.Builder
+	if change.PrivateKey != nil {
+		output.WriteString(fmt.Sprintf("private_key=%s\n", change.PrivateKey.HexString()))
+	}
+	if change.ListenPort != nil {
+		output.WriteString(fmt.Sprintf("listen_port=%d\n", *change.ListenPort))
+	}
+	for _, peer := range change.Peers {
+		output.WriteString(fmt.Sprintf("public_key=%s\n", peer.PublicKey.HexString()))
+		if peer.Remove {
+			output.WriteString("remove=true\n")
+			continue
+		}
+		if peer.PresharedKey != nil {
+			output.WriteString(fmt.Sprintf("preshared_key=%s\n", peer.PresharedKey.HexString()))
+		}
+		if peer.Endpoint != nil {
+			var resolvedIP string
+			resolvedIP, dnsErr = resolveHostname(peer.Endpoint.Host)
+			if dnsErr != nil {
+				return
+			}
+			resolvedEndpoint := Endpoint{resolvedIP, peer.Endpoint.Port}
+			output.WriteString(fmt.Sprintf("endpoint=%s\n", resolvedEndpoint.String()))
+		}
+		if peer.PersistentKeepalive != nil {
+			output.WriteString(fmt.Sprintf("persistent_keepalive_interval=%d\n", *peer.PersistentKeepalive))
+		}
+		if peer.AllowedIPs != nil {
+			output.WriteString("replace_allowed_ips=true\n")
+			for _, address := range *peer.AllowedIPs {
+				output.WriteString(fmt.Sprintf("allowed_ip=%s\n", address.String()))
+			}
+		}
+	}
+	return output.String(), nil
+}
+
+// Apply makes the change to the configuration, adding peers that it does not have yet.
+func (config *Config) Apply(change *ConfigChange) {
+	if change.PrivateKey != nil {
+		config.Interface.PrivateKey = *change.PrivateKey
+	}
+	if change.ListenPort != nil {
+		config.Interface.ListenPort = *change.ListenPort
+	}
+	for _, peerChange := range change.Peers {
+		var peer *Peer
+		for i := range config.Peers {
+			if config.Peers[i].PublicKey == peerChange.PublicKey {
+				peer = &config.Peers[i]
+				if peerChange.Remove {
+					config.Peers = append(config.Peers[:i], config.Peers[i+1:]...)
+				}
+				break
+			}
+		}
+		if peerChange.Remove {
+			continue
+		}
+		if peer == nil {
+			config.Peers = append(config.Peers, Peer{PublicKey: peerChange.PublicKey})
+			peer = &config.Peers[len(config.Peers)-1]
+		}
+		if peerChange.PresharedKey != nil {
+			peer.PresharedKey = *peerChange.PresharedKey
+		}
+		if peerChange.Endpoint != nil {
+			peer.Endpoint = *peerChange.Endpoint
+		}
+		if peerChange.PersistentKeepalive != nil {
+			peer.PersistentKeepalive = *peerChange.PersistentKeepalive
+		}
+		if peerChange.AllowedIPs != nil {
+			peer.AllowedIPs = append([]IPCidr(nil), *peerChange.AllowedIPs...)
+		}
+	}
+}
@@ -10,11 +10,29 @@ import (
 	"strings"
 )
 
+// redacted is what ExportRedacted writes in place of keys and endpoint hosts, as wg(8) show does for keys.
+const redacted = "(hidden)"
+
 func (conf *Config) ToWgQuick() string {
+	return conf.toWgQuick(false, false)
+}
+
+// ExportRedacted writes the configuration like ToWgQuick, but with the private key and preshared keys replaced by
+// placeholders, and, if maskEndpoints is true, the host of each endpoint too, so that it can be shared in bug reports
+// and forums. Public keys, addresses, and allowed IPs are kept, as they are what problems are usually about.
+func (conf *Config) ExportRedacted(maskEndpoints bool) string {
+	return conf.toWgQuick(true, maskEndpoints)
+}
+
+func (conf *Config) toWgQuick(redactKeys, maskEndpoints bool) string {
 	var output strings.Builder
 	output.WriteString("[Interface]\n")
 
-	output.WriteString(fmt.Sprintf("PrivateKey = %s\n", conf.Interface.PrivateKey.String()))
+	if redactKeys {
+		output.WriteString(fmt.Sprintf("PrivateKey = %s\n", redacted))
+	} else {
+		output.WriteString(fmt.Sprintf("PrivateKey = %s\n", conf.Interface.PrivateKey.String()))
+	}
 
 	if conf.Interface.ListenPort > 0 {
 		output.WriteString(fmt.Sprintf("ListenPort = %d\n", conf.Interface.ListenPort))
@@ -59,7 +77,9 @@ func (conf *Config) ToWgQuick() string {
 
 		output.WriteString(fmt.Sprintf("PublicKey = %s\n", peer.PublicKey.String()))
 
-		if !peer.PresharedKey.IsZero() {
+		if !peer.PresharedKey.IsZero() && redactKeys {
+			output.WriteString(fmt.Sprintf("PresharedKey = %s\n", redacted))
+		} else if !peer.PresharedKey.IsZero() {
 			output.WriteString(fmt.Sprintf("PresharedKey = %s\n", peer.PresharedKey.String()))
 		}
 
@@ -71,7 +91,9 @@ func (conf *Config) ToWgQuick() string {
 			output.WriteString(fmt.Sprintf("AllowedIPs = %s\n", strings.Join(addrStrings[:], ", ")))
 		}
 
-		if !peer.Endpoint.IsEmpty() {
+		if !peer.Endpoint.IsEmpty() && maskEndpoints {
+			output.WriteString(fmt.Sprintf("Endpoint = %s:%d\n", redacted, peer.Endpoint.Port))
+		} else if !peer.Endpoint.IsEmpty() {
 			output.WriteString(fmt.Sprintf("Endpoint = %s\n", peer.Endpoint.String()))
 		}
 
@@ -0,0 +1,161 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+// Package crashdump keeps what is left behind when a service crashes: the trace that the Go runtime prints, and a
+// minidump of the process, in a directory that holds only the few most recent of each.
+package crashdump
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+)
+
+const maxCrashes = 5 // Of each kind, traces and minidumps
+
+// A normal minidump holds the stacks of the threads and the list of modules, but none of the heap or of the data
+// segments, where the keys of tunnels are, so that it may be handed to support like the logs.
+const miniDumpNormal = 0x00000000
+
+var (
+	procMiniDumpWriteDump = windows.NewLazySystemDLL("dbghelp.dll").NewProc("MiniDumpWriteDump")
+
+	dir  string
+	name string // What the files of this process are named after, such as MGR-20201120-153000-1234
+)
+
+// Directory returns where crashes are kept.
+func Directory(create bool) (string, error) {
+	root, err := conf.RootDirectory(create)
+	if err != nil {
+		return "", err
+	}
+	crashes := filepath.Join(root, "Crashes")
+	if create {
+		err = os.Mkdir(crashes, os.ModeDir|0700)
+		if err != nil && !os.IsExist(err) {
+			return "", err
+		}
+	}
+	return crashes, nil
+}
+
+// Install sends standard error, where the Go runtime prints the trace of a panic that nothing recovers from or of a
+// fatal error, to a file, and prunes the files of earlier crashes. Services have no standard error otherwise, so all of
+// that would be lost. The file stays empty, and is removed the next time, unless the process crashes.
+func Install(tag string) error {
+	var err error
+	dir, err = Directory(true)
+	if err != nil {
+		return err
+	}
+	prune()
+	name = fmt.Sprintf("%s-%s-%d", tag, time.Now().Format("20060102-150405"), os.Getpid())
+	file, err := os.Create(filepath.Join(dir, name+".txt"))
+	if err != nil {
+		return err
+	}
+	err = windows.SetStdHandle(windows.STD_ERROR_HANDLE, windows.Handle(file.Fd()))
+	if err != nil {
+		file.Close()
+		return err
+	}
+	os.Stderr = file
+	return nil
+}
+
+// WriteMinidump writes a minidump of the process, such as from a deferred function that recovered a panic, before
+// letting it continue. Fatal errors of the runtime, and panics in goroutines that do not recover them, end the
+// process without one, leaving only the trace.
+func WriteMinidump() error {
+	if len(dir) == 0 {
+		return os.ErrNotExist
+	}
+	file, err := os.Create(filepath.Join(dir, name+".dmp"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	err = procMiniDumpWriteDump.Find()
+	if err != nil {
+		return err
+	}
+	ret, _, err := procMiniDumpWriteDump.Call(uintptr(windows.CurrentProcess()), uintptr(windows.GetCurrentProcessId()), file.Fd(), miniDumpNormal, 0, 0, 0)
+	if ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// Latest returns the paths of the most recent trace and minidump, either of which is empty if there is none.
+func Latest() (trace, minidump string, err error) {
+	crashes, err := Directory(false)
+	if err != nil {
+		return
+	}
+	traces, minidumps, err := list(crashes)
+	if err != nil {
+		return
+	}
+	if len(traces) > 0 {
+		trace = filepath.Join(crashes, traces[len(traces)-1].Name())
+	}
+	if len(minidumps) > 0 {
+		minidump = filepath.Join(crashes, minidumps[len(minidumps)-1].Name())
+	}
+	return
+}
+
+// list returns the traces that are not empty and the minidumps, each oldest first.
+func list(crashes string) (traces, minidumps []os.FileInfo, err error) {
+	files, err := ioutil.ReadDir(crashes)
+	if err != nil {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, file := range files {
+		switch {
+		case strings.HasSuffix(file.Name(), ".txt") && file.Size() > 0:
+			traces = append(traces, file)
+		case strings.HasSuffix(file.Name(), ".dmp"):
+			minidumps = append(minidumps, file)
+		}
+	}
+	return
+}
+
+// prune removes the empty traces of processes that did not crash, which fails for those of processes still running,
+// and all but the most recent crashes.
+func prune() {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".txt") && file.Size() == 0 {
+			os.Remove(filepath.Join(dir, file.Name()))
+		}
+	}
+	traces, minidumps, err := list(dir)
+	if err != nil {
+		return
+	}
+	for _, kind := range [][]os.FileInfo{traces, minidumps} {
+		for len(kind) > maxCrashes-1 {
+			os.Remove(filepath.Join(dir, kind[0].Name()))
+			kind = kind[1:]
+		}
+	}
+}
@@ -0,0 +1,109 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package elevate
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Reauthenticate prompts the user to enter Windows credentials again, and returns true only if
+// they belong either to the user running this process or to a member of the Administrators group.
+// A false return with a nil error means that the user cancelled the prompt.
+func Reauthenticate(owner uintptr, caption, message string) (bool, error) {
+	return reauthenticate(owner, caption, message, cCREDUIWIN_ENUMERATE_CURRENT_USER)
+}
+
+// ReauthenticateOnSecureDesktop is like Reauthenticate, but prompts on the secure desktop, where other programs of
+// the user cannot enter the credentials in their place, or see them being entered.
+func ReauthenticateOnSecureDesktop(caption, message string) (bool, error) {
+	return reauthenticate(0, caption, message, cCREDUIWIN_ENUMERATE_CURRENT_USER|cCREDUIWIN_SECURE_PROMPT)
+}
+
+func reauthenticate(owner uintptr, caption, message string, flags uint32) (bool, error) {
+	var self windows.Token
+	err := windows.OpenProcessToken(windows.CurrentProcess(), windows.TOKEN_QUERY, &self)
+	if err != nil {
+		return false, err
+	}
+	defer self.Close()
+	selfUser, err := self.GetTokenUser()
+	if err != nil {
+		return false, err
+	}
+
+	uiInfo := cCREDUI_INFO{
+		hwndParent:     owner,
+		pszMessageText: windows.StringToUTF16Ptr(message),
+		pszCaptionText: windows.StringToUTF16Ptr(caption),
+	}
+	uiInfo.cbSize = uint32(unsafe.Sizeof(uiInfo))
+	var authError uint32
+	for {
+		var authPackage uint32
+		var outBuffer unsafe.Pointer
+		var outBufferSize uint32
+		var save int32
+		err = credUIPromptForWindowsCredentials(&uiInfo, authError, &authPackage, nil, 0, &outBuffer, &outBufferSize, &save, flags)
+		if err == windows.ERROR_CANCELLED {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		token, err := logonWithAuthBuffer(outBuffer, outBufferSize)
+		windows.CoTaskMemFree(outBuffer)
+		if err == windows.ERROR_LOGON_FAILURE || err == windows.ERROR_ACCOUNT_RESTRICTION {
+			authError = uint32(err.(windows.Errno))
+			continue
+		} else if err != nil {
+			return false, err
+		}
+		tokenUser, err := token.GetTokenUser()
+		if err != nil {
+			token.Close()
+			return false, err
+		}
+		allowed := windows.EqualSid(tokenUser.User.Sid, selfUser.User.Sid) || TokenIsElevatedOrElevatable(token)
+		token.Close()
+		if !allowed {
+			authError = uint32(windows.ERROR_ACCESS_DENIED)
+			continue
+		}
+		return true, nil
+	}
+}
+
+func logonWithAuthBuffer(authBuffer unsafe.Pointer, authBufferSize uint32) (token windows.Token, err error) {
+	var userName, domain, password [windows.MAX_PATH]uint16
+	userNameLen, domainLen, passwordLen := uint32(len(userName)), uint32(len(domain)), uint32(len(password))
+	defer func() {
+		for i := range password {
+			password[i] = 0
+		}
+		buffer := (*[1 << 30]byte)(authBuffer)[:authBufferSize:authBufferSize]
+		for i := range buffer {
+			buffer[i] = 0
+		}
+	}()
+	err = credUnPackAuthenticationBuffer(cCRED_PACK_PROTECTED_CREDENTIALS, authBuffer, authBufferSize, &userName[0], &userNameLen, &domain[0], &domainLen, &password[0], &passwordLen)
+	if err != nil {
+		return
+	}
+	userNamePtr, domainPtr := &userName[0], &domain[0]
+	if domain[0] == 0 {
+		// The credential prompt usually returns "DOMAIN\user" as the user name, but LogonUser wants them separate.
+		for i := 0; i < len(userName) && userName[i] != 0; i++ {
+			if userName[i] == '\\' {
+				userName[i] = 0
+				domainPtr, userNamePtr = &userName[0], &userName[i+1]
+				break
+			}
+		}
+	}
+	err = logonUser(userNamePtr, domainPtr, &password[0], cLOGON32_LOGON_INTERACTIVE, cLOGON32_PROVIDER_DEFAULT, &token)
+	return
+}
@@ -73,9 +73,23 @@ type cPEB struct {
 	SessionId              uint32
 }
 
+type cCREDUI_INFO struct {
+	cbSize         uint32
+	hwndParent     uintptr
+	pszMessageText *uint16
+	pszCaptionText *uint16
+	hbmBanner      uintptr
+}
+
 const (
 	cCLSCTX_LOCAL_SERVER      = 4
 	cCOINIT_APARTMENTTHREADED = 2
+
+	cCREDUIWIN_ENUMERATE_CURRENT_USER = 0x200
+	cCREDUIWIN_SECURE_PROMPT          = 0x1000
+	cCRED_PACK_PROTECTED_CREDENTIALS  = 0x1
+	cLOGON32_LOGON_INTERACTIVE        = 2
+	cLOGON32_PROVIDER_DEFAULT         = 0
 )
 
 //sys	rtlInitUnicodeString(destinationString *cUNICODE_STRING, sourceString *uint16) = ntdll.RtlInitUnicodeString
@@ -87,3 +101,7 @@ const (
 
 //sys	getWindowThreadProcessId(hwnd uintptr, pid *uint32) (tid uint32, err error) = user32.GetWindowThreadProcessId
 //sys	getShellWindow() (hwnd uintptr) = user32.GetShellWindow
+
+//sys	credUIPromptForWindowsCredentials(uiInfo *cCREDUI_INFO, authError uint32, authPackage *uint32, inAuthBuffer unsafe.Pointer, inAuthBufferSize uint32, outAuthBuffer *unsafe.Pointer, outAuthBufferSize *uint32, save *int32, flags uint32) (ret error) = credui.CredUIPromptForWindowsCredentialsW
+//sys	credUnPackAuthenticationBuffer(flags uint32, authBuffer unsafe.Pointer, authBufferSize uint32, userName *uint16, maxUserName *uint32, domainName *uint16, maxDomainName *uint32, password *uint16, maxPassword *uint32) (err error) = credui.CredUnPackAuthenticationBufferW
+//sys	logonUser(userName *uint16, domain *uint16, password *uint16, logonType uint32, logonProvider uint32, token *windows.Token) (err error) = advapi32.LogonUserW
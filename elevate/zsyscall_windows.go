@@ -38,19 +38,48 @@ func errnoErr(e syscall.Errno) error {
 }
 
 var (
-	modntdll  = windows.NewLazySystemDLL("ntdll.dll")
-	modole32  = windows.NewLazySystemDLL("ole32.dll")
-	moduser32 = windows.NewLazySystemDLL("user32.dll")
-
-	procRtlGetCurrentPeb         = modntdll.NewProc("RtlGetCurrentPeb")
-	procRtlInitUnicodeString     = modntdll.NewProc("RtlInitUnicodeString")
-	procCoGetObject              = modole32.NewProc("CoGetObject")
-	procCoInitializeEx           = modole32.NewProc("CoInitializeEx")
-	procCoUninitialize           = modole32.NewProc("CoUninitialize")
-	procGetShellWindow           = moduser32.NewProc("GetShellWindow")
-	procGetWindowThreadProcessId = moduser32.NewProc("GetWindowThreadProcessId")
+	modadvapi32 = windows.NewLazySystemDLL("advapi32.dll")
+	modcredui   = windows.NewLazySystemDLL("credui.dll")
+	modntdll    = windows.NewLazySystemDLL("ntdll.dll")
+	modole32    = windows.NewLazySystemDLL("ole32.dll")
+	moduser32   = windows.NewLazySystemDLL("user32.dll")
+
+	procLogonUserW                         = modadvapi32.NewProc("LogonUserW")
+	procCredUIPromptForWindowsCredentialsW = modcredui.NewProc("CredUIPromptForWindowsCredentialsW")
+	procCredUnPackAuthenticationBufferW    = modcredui.NewProc("CredUnPackAuthenticationBufferW")
+	procRtlGetCurrentPeb                   = modntdll.NewProc("RtlGetCurrentPeb")
+	procRtlInitUnicodeString               = modntdll.NewProc("RtlInitUnicodeString")
+	procCoGetObject                        = modole32.NewProc("CoGetObject")
+	procCoInitializeEx                     = modole32.NewProc("CoInitializeEx")
+	procCoUninitialize                     = modole32.NewProc("CoUninitialize")
+	procGetShellWindow                     = moduser32.NewProc("GetShellWindow")
+	procGetWindowThreadProcessId           = moduser32.NewProc("GetWindowThreadProcessId")
 )
 
+func logonUser(userName *uint16, domain *uint16, password *uint16, logonType uint32, logonProvider uint32, token *windows.Token) (err error) {
+	r1, _, e1 := syscall.Syscall6(procLogonUserW.Addr(), 6, uintptr(unsafe.Pointer(userName)), uintptr(unsafe.Pointer(domain)), uintptr(unsafe.Pointer(password)), uintptr(logonType), uintptr(logonProvider), uintptr(unsafe.Pointer(token)))
+	if r1 == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func credUIPromptForWindowsCredentials(uiInfo *cCREDUI_INFO, authError uint32, authPackage *uint32, inAuthBuffer unsafe.Pointer, inAuthBufferSize uint32, outAuthBuffer *unsafe.Pointer, outAuthBufferSize *uint32, save *int32, flags uint32) (ret error) {
+	r0, _, _ := syscall.Syscall9(procCredUIPromptForWindowsCredentialsW.Addr(), 9, uintptr(unsafe.Pointer(uiInfo)), uintptr(authError), uintptr(unsafe.Pointer(authPackage)), uintptr(inAuthBuffer), uintptr(inAuthBufferSize), uintptr(unsafe.Pointer(outAuthBuffer)), uintptr(unsafe.Pointer(outAuthBufferSize)), uintptr(unsafe.Pointer(save)), uintptr(flags))
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func credUnPackAuthenticationBuffer(flags uint32, authBuffer unsafe.Pointer, authBufferSize uint32, userName *uint16, maxUserName *uint32, domainName *uint16, maxDomainName *uint32, password *uint16, maxPassword *uint32) (err error) {
+	r1, _, e1 := syscall.Syscall9(procCredUnPackAuthenticationBufferW.Addr(), 9, uintptr(flags), uintptr(authBuffer), uintptr(authBufferSize), uintptr(unsafe.Pointer(userName)), uintptr(unsafe.Pointer(maxUserName)), uintptr(unsafe.Pointer(domainName)), uintptr(unsafe.Pointer(maxDomainName)), uintptr(unsafe.Pointer(password)), uintptr(unsafe.Pointer(maxPassword)))
+	if r1 == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
 func rtlGetCurrentPeb() (peb *cPEB) {
 	r0, _, _ := syscall.Syscall(procRtlGetCurrentPeb.Addr(), 0, 0, 0, 0)
 	peb = (*cPEB)(unsafe.Pointer(r0))
@@ -0,0 +1,308 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package main
+
+import (
+	"C"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+
+	"golang.zx2c4.com/wireguard/ipc/winpipe"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/services"
+
+	"io/ioutil"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// These match the states that the manager reports, and are part of the interface documented in README.md.
+const (
+	tunnelUnknown = iota
+	tunnelStarted
+	tunnelStopped
+	tunnelStarting
+	tunnelStopping
+)
+
+const pipePrefix = `ProtectedPrefix\Administrators\WireGuard\` // As services.PipePathOfTunnel names them, under \\.\pipe\
+
+// peerStats is laid out as WIREGUARD_PEER_STATS in README.md.
+type peerStats struct {
+	publicKey     [32]byte
+	lastHandshake int64 // Nanoseconds since the Unix epoch, or 0 if there has not been one
+	rxBytes       uint64
+	txBytes       uint64
+}
+
+func talkToTunnel(tunnelName string, request string) (string, error) {
+	pipePath, err := services.PipePathOfTunnel(tunnelName)
+	if err != nil {
+		return "", err
+	}
+	localSystem, err := windows.CreateWellKnownSid(windows.WinLocalSystemSid)
+	if err != nil {
+		return "", err
+	}
+	pipe, err := winpipe.DialPipe(pipePath, nil, localSystem)
+	if err != nil {
+		return "", err
+	}
+	defer pipe.Close()
+	pipe.SetWriteDeadline(time.Now().Add(time.Second * 2))
+	_, err = pipe.Write([]byte(request))
+	if err != nil {
+		return "", err
+	}
+	pipe.SetReadDeadline(time.Now().Add(time.Second * 2))
+	resp, err := ioutil.ReadAll(pipe)
+	if err != nil {
+		return "", err
+	}
+	return string(resp), nil
+}
+
+// WireGuardEnumerateTunnels fills buffer with the names of the running tunnels, each terminated by a NUL and the list
+// by one more, as in a REG_MULTI_SZ. Their pipes are listed, rather than their services, which applications embedding
+// this may name as they like, and only those owned by Local System are taken for tunnels, as talkToTunnel requires,
+// since anything that runs as an administrator may make a pipe there. If the buffer, of *bufferLen characters, is too
+// small, it returns false and sets *bufferLen to the size needed.
+//
+//export WireGuardEnumerateTunnels
+func WireGuardEnumerateTunnels(buffer *uint16, bufferLen *uint32) bool {
+	localSystem, err := windows.CreateWellKnownSid(windows.WinLocalSystemSid)
+	if err != nil {
+		log.Printf("Unable to create Local System SID: %v", err)
+		return false
+	}
+	var data windows.Win32finddata
+	find, err := windows.FindFirstFile(windows.StringToUTF16Ptr(`\\.\pipe\*`), &data)
+	if err != nil {
+		log.Printf("Unable to enumerate tunnels: %v", err)
+		return false
+	}
+	var pipeNames []string
+	for {
+		pipeNames = append(pipeNames, windows.UTF16ToString(data.FileName[:]))
+		err = windows.FindNextFile(find, &data)
+		if err != nil {
+			break
+		}
+	}
+	windows.FindClose(find)
+	if err != windows.ERROR_NO_MORE_FILES {
+		log.Printf("Unable to enumerate tunnels: %v", err)
+		return false
+	}
+	var names []uint16
+	timeout := time.Second / 2
+	for _, pipeName := range pipeNames {
+		if !strings.HasPrefix(pipeName, pipePrefix) || !conf.TunnelNameIsValid(pipeName[len(pipePrefix):]) {
+			continue
+		}
+		pipe, err := winpipe.DialPipe(`\\.\pipe\`+pipeName, &timeout, localSystem)
+		if err != nil {
+			continue
+		}
+		pipe.Close()
+		names = append(names, windows.StringToUTF16(pipeName[len(pipePrefix):])...)
+	}
+	names = append(names, 0)
+	if uint32(len(names)) > *bufferLen {
+		*bufferLen = uint32(len(names))
+		return false
+	}
+	copy((*[(1 << 30) - 1]uint16)(unsafe.Pointer(buffer))[:len(names):len(names)], names)
+	*bufferLen = uint32(len(names))
+	return true
+}
+
+// WireGuardGetPeerStats fills stats with the public key, latest handshake, and transfer of each peer of the running
+// tunnel. If the array, of *count elements, is too small, it returns false and sets *count to the number of peers.
+//
+//export WireGuardGetPeerStats
+func WireGuardGetPeerStats(tunnelName16 *uint16, stats *peerStats, count *uint32) bool {
+	tunnelName := windows.UTF16PtrToString(tunnelName16)
+	resp, err := talkToTunnel(tunnelName, "get=1\n\n")
+	if err != nil {
+		log.Printf("Unable to query tunnel %s: %v", tunnelName, err)
+		return false
+	}
+	config, err := conf.FromUAPI(resp, &conf.Config{Name: tunnelName})
+	if err != nil {
+		log.Printf("Unable to parse state of tunnel %s: %v", tunnelName, err)
+		return false
+	}
+	if uint32(len(config.Peers)) > *count {
+		*count = uint32(len(config.Peers))
+		return false
+	}
+	*count = uint32(len(config.Peers))
+	if len(config.Peers) == 0 {
+		return true
+	}
+	out := (*[(1 << 20) - 1]peerStats)(unsafe.Pointer(stats))[:len(config.Peers):len(config.Peers)]
+	for i, peer := range config.Peers {
+		out[i] = peerStats{
+			publicKey:     peer.PublicKey,
+			lastHandshake: int64(peer.LastHandshakeTime),
+			rxBytes:       uint64(peer.RxBytes),
+			txBytes:       uint64(peer.TxBytes),
+		}
+	}
+	return true
+}
+
+// WireGuardUpdateTunnel applies the configuration, in the format of a .conf file, to the running tunnel, replacing its
+// private key, listen port, and peers without taking it down. Addresses, DNS servers, and the MTU are only set when the
+// tunnel starts, so changes to those require restarting its service.
+//
+//export WireGuardUpdateTunnel
+func WireGuardUpdateTunnel(tunnelName16 *uint16, config16 *uint16) bool {
+	tunnelName := windows.UTF16PtrToString(tunnelName16)
+	config, err := conf.FromWgQuickWithUnknownEncoding(windows.UTF16PtrToString(config16), tunnelName)
+	if err != nil {
+		log.Printf("Unable to parse configuration of tunnel %s: %v", tunnelName, err)
+		return false
+	}
+	uapi, err := config.ToUAPI()
+	if err != nil {
+		log.Printf("Unable to resolve endpoints of tunnel %s: %v", tunnelName, err)
+		return false
+	}
+	resp, err := talkToTunnel(tunnelName, "set=1\n"+uapi+"\n")
+	if err != nil {
+		log.Printf("Unable to update tunnel %s: %v", tunnelName, err)
+		return false
+	}
+	if !strings.Contains(resp, "errno=0\n") {
+		log.Printf("Tunnel %s refused the update: %s", tunnelName, strings.TrimSpace(resp))
+		return false
+	}
+	return true
+}
+
+var (
+	subscriptions     = make(map[uintptr]chan struct{})
+	subscriptionsLock sync.Mutex
+	lastSubscription  uintptr
+
+	serviceNotifyCallbackPtr = windows.NewCallback(func(notifier *windows.SERVICE_NOTIFY) uintptr {
+		return 0
+	})
+)
+
+func stateOfService(state uint32) uintptr {
+	switch svc.State(state) {
+	case svc.StartPending:
+		return tunnelStarting
+	case svc.Running:
+		return tunnelStarted
+	case svc.StopPending:
+		return tunnelStopping
+	case svc.Stopped:
+		return tunnelStopped
+	default:
+		return tunnelUnknown
+	}
+}
+
+func watchService(service *mgr.Service, callback, context uintptr, done chan struct{}) {
+	defer service.Close()
+
+	// As in the manager's tunnel tracker, the thread is intentionally never unlocked, because the queued APC of
+	// NotifyServiceStatusChange leaves it unfit for reuse by Go, so it is instead discarded when this returns.
+	runtime.LockOSThread()
+
+	const serviceNotifications = windows.SERVICE_NOTIFY_RUNNING | windows.SERVICE_NOTIFY_START_PENDING | windows.SERVICE_NOTIFY_STOP_PENDING | windows.SERVICE_NOTIFY_STOPPED | windows.SERVICE_NOTIFY_DELETE_PENDING
+	notifier := &windows.SERVICE_NOTIFY{
+		Version:        windows.SERVICE_NOTIFY_STATUS_CHANGE,
+		NotifyCallback: serviceNotifyCallbackPtr,
+	}
+	serviceName16 := windows.StringToUTF16Ptr(service.Name)
+	lastState := uintptr(tunnelUnknown)
+	for {
+		err := windows.NotifyServiceStatusChange(service.Handle, serviceNotifications, notifier)
+		switch err {
+		case nil:
+			for windows.SleepEx(uint32(time.Second/time.Millisecond), true) != windows.WAIT_IO_COMPLETION {
+				select {
+				case <-done:
+					return
+				default:
+				}
+			}
+		case windows.ERROR_SERVICE_NOTIFY_CLIENT_LAGGING:
+			continue
+		default:
+			syscall.Syscall(callback, 3, context, uintptr(unsafe.Pointer(serviceName16)), tunnelStopped)
+			return
+		}
+		select {
+		case <-done:
+			return
+		default:
+		}
+		state := stateOfService(notifier.ServiceStatus.CurrentState)
+		if state != lastState {
+			syscall.Syscall(callback, 3, context, uintptr(unsafe.Pointer(serviceName16)), state)
+			lastState = state
+		}
+	}
+}
+
+// WireGuardSubscribeTunnelState calls callback, as in WIREGUARD_STATE_CALLBACK in README.md, with context, the name
+// of the service, and its new state, each time that the tunnel service starts or stops, until the subscription is
+// passed to WireGuardUnsubscribeTunnelState. It returns 0 if the service cannot be watched.
+//
+//export WireGuardSubscribeTunnelState
+func WireGuardSubscribeTunnelState(serviceName16 *uint16, callback uintptr, context uintptr) uintptr {
+	serviceName := windows.UTF16PtrToString(serviceName16)
+	m, err := mgr.Connect()
+	if err != nil {
+		log.Printf("Unable to connect to the service manager: %v", err)
+		return 0
+	}
+	service, err := m.OpenService(serviceName)
+	m.Disconnect()
+	if err != nil {
+		log.Printf("Unable to open service %s: %v", serviceName, err)
+		return 0
+	}
+	done := make(chan struct{})
+	subscriptionsLock.Lock()
+	lastSubscription++
+	subscription := lastSubscription
+	subscriptions[subscription] = done
+	subscriptionsLock.Unlock()
+	go watchService(service, callback, context, done)
+	return subscription
+}
+
+// WireGuardUnsubscribeTunnelState stops the callbacks of a subscription. One already under way may still finish
+// after it returns.
+//
+//export WireGuardUnsubscribeTunnelState
+func WireGuardUnsubscribeTunnelState(subscription uintptr) bool {
+	subscriptionsLock.Lock()
+	defer subscriptionsLock.Unlock()
+	done, ok := subscriptions[subscription]
+	if !ok {
+		log.Printf("Unknown subscription %d", subscription)
+		return false
+	}
+	close(done)
+	delete(subscriptions, subscription)
+	return true
+}
@@ -0,0 +1,132 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+// Package etw makes WireGuard an Event Tracing for Windows provider, so that its activity shows up alongside that of
+// the rest of the system in Windows Performance Analyzer and in whatever collects ETW traces across a fleet. Events
+// are plain strings, which needs no manifest to be installed for them to be readable.
+package etw
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// ProviderID is what traces enable to receive the events, as in:
+//
+//	logman start wireguard -p {fd8af76b-d183-4200-95f2-471ea463a6c4} -ets
+//
+// It is part of the interface documented in docs/etw.md, so never change it.
+var ProviderID = windows.GUID{0xfd8af76b, 0xd183, 0x4200, [8]byte{0x95, 0xf2, 0x47, 0x1e, 0xa4, 0x63, 0xa6, 0xc4}}
+
+// Level is the ETW level of an event, from TRACE_LEVEL_ERROR to TRACE_LEVEL_VERBOSE.
+type Level uint8
+
+const (
+	LevelError   Level = 2
+	LevelWarning Level = 3
+	LevelInfo    Level = 4
+	LevelVerbose Level = 5
+)
+
+// Keyword lets traces select events by what they are about. Like the provider ID, these never change meaning.
+type Keyword uint64
+
+const (
+	KeywordLog       Keyword = 1 << iota // Every line of the diagnostic log
+	KeywordError                         // Lines of the log at the error level
+	KeywordHandshake                     // Completed handshakes, per peer
+	KeywordEndpoint                      // Peers roaming to a new endpoint
+	KeywordRoute                         // Addresses and routes being set on the interface
+	KeywordDNS                           // DNS servers and search domains being set on the interface
+	KeywordTamper                        // Configuration files changed by something other than WireGuard
+)
+
+var (
+	procEventRegister        = windows.NewLazySystemDLL("advapi32.dll").NewProc("EventRegister")
+	procEventProviderEnabled = windows.NewLazySystemDLL("advapi32.dll").NewProc("EventProviderEnabled")
+	procEventWriteString     = windows.NewLazySystemDLL("advapi32.dll").NewProc("EventWriteString")
+
+	register sync.Once
+	handle   uint64 // A REGHANDLE, or 0 if registration failed
+
+	prefix string
+
+	// Redact, if set, rewrites each message before it is written, such as to mask addresses.
+	Redact func(string) string
+)
+
+// SetPrefix sets what Eventf puts in front of each message, such as the tunnel name, so that events read like the
+// lines of the log.
+func SetPrefix(p string) {
+	prefix = p
+}
+
+func registerProvider() {
+	register.Do(func() {
+		if procEventRegister.Find() != nil {
+			return
+		}
+		r, _, _ := procEventRegister.Call(uintptr(unsafe.Pointer(&ProviderID)), 0, 0, uintptr(unsafe.Pointer(&handle)))
+		if r != 0 {
+			handle = 0
+		}
+	})
+}
+
+// Enabled reports whether any trace has enabled the provider at the level for any of the keywords, registering the
+// provider first if need be, so that callers may skip the work of making events that nobody receives.
+func Enabled(level Level, keywords Keyword) bool {
+	registerProvider()
+	if handle == 0 {
+		return false
+	}
+	var r uintptr
+	// Arguments are split as for EventWriteString, below.
+	switch {
+	case runtime.GOARCH == "arm":
+		r, _, _ = procEventProviderEnabled.Call(uintptr(handle), uintptr(handle>>32), uintptr(level), 0, uintptr(keywords), uintptr(uint64(keywords)>>32))
+	case unsafe.Sizeof(uintptr(0)) == 4:
+		r, _, _ = procEventProviderEnabled.Call(uintptr(handle), uintptr(handle>>32), uintptr(level), uintptr(keywords), uintptr(uint64(keywords)>>32))
+	default:
+		r, _, _ = procEventProviderEnabled.Call(uintptr(handle), uintptr(level), uintptr(keywords))
+	}
+	return byte(r) != 0
+}
+
+// Event writes the message as an event, if any trace has enabled the provider at that level and keyword.
+func Event(level Level, keywords Keyword, message string) {
+	if !Enabled(level, keywords) {
+		return
+	}
+	if Redact != nil {
+		message = Redact(message)
+	}
+	message16, err := windows.UTF16PtrFromString(message)
+	if err != nil {
+		return
+	}
+	// The REGHANDLE and the keyword are 64-bit, so on 32-bit platforms each takes two arguments, low half first, and
+	// on ARM the keyword must furthermore start at an even slot.
+	switch {
+	case runtime.GOARCH == "arm":
+		procEventWriteString.Call(uintptr(handle), uintptr(handle>>32), uintptr(level), 0, uintptr(keywords), uintptr(uint64(keywords)>>32), uintptr(unsafe.Pointer(message16)))
+	case unsafe.Sizeof(uintptr(0)) == 4:
+		procEventWriteString.Call(uintptr(handle), uintptr(handle>>32), uintptr(level), uintptr(keywords), uintptr(uint64(keywords)>>32), uintptr(unsafe.Pointer(message16)))
+	default:
+		procEventWriteString.Call(uintptr(handle), uintptr(level), uintptr(keywords), uintptr(unsafe.Pointer(message16)))
+	}
+}
+
+// Eventf is like Event, but only formats the message if it is to be written.
+func Eventf(level Level, keywords Keyword, format string, args ...interface{}) {
+	if !Enabled(level, keywords) {
+		return
+	}
+	Event(level, keywords, prefix+fmt.Sprintf(format, args...))
+}
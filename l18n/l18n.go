@@ -6,7 +6,9 @@
 package l18n
 
 import (
+	"sort"
 	"sync"
+	"unsafe"
 
 	"golang.org/x/sys/windows"
 	"golang.org/x/text/language"
@@ -14,30 +16,44 @@ import (
 )
 
 var printer *message.Printer
+var printerTag language.Tag
 var printerLock sync.Mutex
+var languageOverride string
 
-// prn returns the printer for user preferred UI language.
+// prn returns the printer for user preferred UI language. It is always read with the lock held, since
+// SetLanguageOverride may reset it at any time.
 func prn() *message.Printer {
-	if printer != nil {
-		return printer
-	}
 	printerLock.Lock()
-	if printer != nil {
-		printerLock.Unlock()
-		return printer
+	defer printerLock.Unlock()
+	if printer == nil {
+		printerTag = lang()
+		printer = message.NewPrinter(printerTag)
 	}
-	printer = message.NewPrinter(lang())
-	printerLock.Unlock()
 	return printer
 }
 
+// Language returns the language that strings are translated into, which is English when none of the preferred
+// languages has a translation.
+func Language() language.Tag {
+	prn()
+	printerLock.Lock()
+	defer printerLock.Unlock()
+	return printerTag
+}
+
 // lang returns the user preferred UI language we have most confident translation in the default catalog available.
 func lang() (tag language.Tag) {
 	tag = language.English
 	confidence := language.No
-	languages, err := windows.GetUserPreferredUILanguages(windows.MUI_LANGUAGE_NAME)
-	if err != nil {
-		return
+	var languages []string
+	if len(languageOverride) > 0 {
+		languages = []string{languageOverride}
+	} else {
+		var err error
+		languages, err = windows.GetUserPreferredUILanguages(windows.MUI_LANGUAGE_NAME)
+		if err != nil {
+			return
+		}
 	}
 	for i := range languages {
 		t, _, c := message.DefaultCatalog.Matcher().Match(message.MatchLanguage(languages[i]))
@@ -49,6 +65,38 @@ func lang() (tag language.Tag) {
 	return
 }
 
+// SetLanguageOverride makes subsequent translations use the named language, such as "de" or "zh-TW", regardless of
+// the Windows display language. An empty name reverts to the Windows display language. Strings that have already
+// been translated are of course not affected.
+func SetLanguageOverride(name string) {
+	printerLock.Lock()
+	languageOverride = name
+	printer = nil
+	printerLock.Unlock()
+}
+
+// SupportedLanguages returns the languages for which a translation is available, sorted by their tag.
+func SupportedLanguages() []language.Tag {
+	tags := message.DefaultCatalog.Languages()
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].String() < tags[j].String()
+	})
+	return tags
+}
+
+var procGetLocaleInfoEx = windows.NewLazySystemDLL("kernel32.dll").NewProc("GetLocaleInfoEx")
+
+// LanguageDisplayName returns the name of a language written in that language, as Windows knows it.
+func LanguageDisplayName(tag language.Tag) string {
+	const LOCALE_SNATIVEDISPLAYNAME = 0x73
+	var name [85]uint16
+	ret, _, _ := procGetLocaleInfoEx.Call(uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(tag.String()))), LOCALE_SNATIVEDISPLAYNAME, uintptr(unsafe.Pointer(&name[0])), uintptr(len(name)))
+	if ret == 0 {
+		return tag.String()
+	}
+	return windows.UTF16ToString(name[:])
+}
+
 // Sprintf is like fmt.Sprintf, but using language-specific formatting.
 func Sprintf(key message.Reference, a ...interface{}) string {
 	return prn().Sprintf(key, a...)
@@ -0,0 +1,35 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package l18n
+
+import (
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// nullRenderer lets the catalog be asked whether it has a message, without formatting it.
+type nullRenderer struct{}
+
+func (nullRenderer) Render(string)       {}
+func (nullRenderer) Arg(int) interface{} { return nil }
+
+func hasTranslation(tag language.Tag, key string) bool {
+	return message.DefaultCatalog.Context(tag, nullRenderer{}).Execute(key) != catalog.ErrNotFound
+}
+
+// SprintfPlural is like Sprintf, for messages whose wording depends on count, such as "Imported %d tunnels". The
+// translations in the catalog select among the plural forms of their language, following its CLDR rules, on the
+// count argument of other, just as for messages formatted with Sprintf. A message that the catalog does not have yet,
+// though, is shown as its English source, and so, as English has a form for one and another for everything else,
+// one is used for the counts that CLDR puts in the former, and other for the rest.
+func SprintfPlural(count int, one, other message.Reference, a ...interface{}) string {
+	if key, ok := other.(string); ok && !hasTranslation(Language(), key) && plural.Cardinal.MatchPlural(language.English, count, 0, 0, 0, 0) == plural.One {
+		return prn().Sprintf(one, a...)
+	}
+	return prn().Sprintf(other, a...)
+}
@@ -6,11 +6,17 @@
 package main
 
 import (
+	"archive/zip"
+	"context"
 	"debug/pe"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -20,6 +26,8 @@ import (
 	"golang.org/x/sys/windows"
 	"golang.zx2c4.com/wireguard/tun"
 
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/crashdump"
 	"golang.zx2c4.com/wireguard/windows/elevate"
 	"golang.zx2c4.com/wireguard/windows/l18n"
 	"golang.zx2c4.com/wireguard/windows/manager"
@@ -29,8 +37,16 @@ import (
 	"golang.zx2c4.com/wireguard/windows/updater"
 )
 
+// consoleVerb is set by verbs that are meant for consoles and scripts, which get errors and usage on standard error
+// rather than in a message box that nobody might be there to dismiss.
+var consoleVerb bool
+
 func fatal(v ...interface{}) {
-	windows.MessageBox(0, windows.StringToUTF16Ptr(fmt.Sprint(v...)), windows.StringToUTF16Ptr(l18n.Sprintf("Error")), windows.MB_ICONERROR)
+	if consoleVerb {
+		fmt.Fprintln(os.Stderr, fmt.Sprint(v...))
+	} else {
+		windows.MessageBox(0, windows.StringToUTF16Ptr(fmt.Sprint(v...)), windows.StringToUTF16Ptr(l18n.Sprintf("Error")), windows.MB_ICONERROR)
+	}
 	os.Exit(1)
 }
 
@@ -51,19 +67,76 @@ func usage() {
 		"/uninstalltunnelservice TUNNEL_NAME",
 		"/managerservice",
 		"/tunnelservice CONFIG_PATH",
+		"/tunnelhostservice",
 		"/ui CMD_READ_HANDLE CMD_WRITE_HANDLE CMD_EVENT_HANDLE LOG_MAPPING_HANDLE",
-		"/dumplog OUTPUT_PATH",
+		"/confirm REASON",
+		"/remoteui MACHINE",
+		"/dumplog [/json] [/redact] [/utc] [/iso8601] [/tunnel TUNNEL_NAME] OUTPUT_PATH",
+		"/taillog [TUNNEL_NAME] [/follow] [/level=error|warn|info|debug|trace]",
+		"/dumpdiagnostics OUTPUT_PATH",
+		"/loglevel error|warn|info|debug|trace",
+		"/show [TUNNEL_NAME|all|interfaces] [public-key|private-key|listen-port|fwmark|peers|preshared-keys|endpoints|allowed-ips|latest-handshakes|transfer|persistent-keepalive|dump]",
+		"/set [/persist] TUNNEL_NAME [listen-port PORT] [private-key FILE_PATH] [peer PUBLIC_KEY [remove] [preshared-key FILE_PATH] [endpoint HOST:PORT] [persistent-keepalive SECONDS|off] [allowed-ips IP/CIDR[,IP/CIDR]...]]...",
+		"/selftest [/json] [TUNNEL_NAME]",
+		"/backup PASSPHRASE_FILE OUTPUT_PATH",
+		"/restore [/overwrite] PASSPHRASE_FILE BACKUP_PATH",
 		"/update [LOG_FILE]",
+		"/updatefromfile MSI_PATH [LOG_FILE]",
+		"/activatetunnel TUNNEL_NAME",
+		"/deactivatetunnel TUNNEL_NAME",
 		"/removealladapters [LOG_FILE]",
 	}
 	builder := strings.Builder{}
 	for _, flag := range flags {
 		builder.WriteString(fmt.Sprintf("    %s\n", flag))
 	}
-	info(l18n.Sprintf("Command Line Options"), "Usage: %s [\n%s]", os.Args[0], builder.String())
+	if consoleVerb {
+		fmt.Fprint(os.Stderr, l18n.Sprintf("Usage: %s [\n%s]", os.Args[0], builder.String()), "\n")
+	} else {
+		info(l18n.Sprintf("Command Line Options"), "Usage: %s [\n%s]", os.Args[0], builder.String())
+	}
 	os.Exit(1)
 }
 
+const _ATTACH_PARENT_PROCESS = ^uint32(0)
+
+// useConsole marks the verb as a console verb and, since this is not a console program, gives standard output and
+// standard error that were not redirected to the console of the parent, if it has one, which is the case when run from
+// a command prompt or PowerShell.
+func useConsole() {
+	consoleVerb = true
+	stdoutValid := isValidStdHandle(windows.STD_OUTPUT_HANDLE)
+	stderrValid := isValidStdHandle(windows.STD_ERROR_HANDLE)
+	if stdoutValid && stderrValid {
+		return
+	}
+	r, _, _ := windows.NewLazySystemDLL("kernel32.dll").NewProc("AttachConsole").Call(uintptr(_ATTACH_PARENT_PROCESS))
+	if r == 0 {
+		return
+	}
+	console, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	if !stdoutValid {
+		windows.SetStdHandle(windows.STD_OUTPUT_HANDLE, windows.Handle(console.Fd()))
+		os.Stdout = console
+	}
+	if !stderrValid {
+		windows.SetStdHandle(windows.STD_ERROR_HANDLE, windows.Handle(console.Fd()))
+		os.Stderr = console
+	}
+}
+
+func isValidStdHandle(which uint32) bool {
+	handle, err := windows.GetStdHandle(which)
+	if err != nil || handle == 0 || handle == windows.InvalidHandle {
+		return false
+	}
+	fileType, err := windows.GetFileType(handle)
+	return err == nil && fileType != windows.FILE_TYPE_UNKNOWN
+}
+
 func checkForWow64() {
 	b, err := func() (bool, error) {
 		var processMachine, nativeMachine uint16
@@ -145,6 +218,7 @@ func windowsSetDllDirectory(directory string) (err error) {
 
 //TODO: move to x/sys/windows when https://go-review.googlesource.com/c/sys/+/273606 lands
 const _LOAD_LIBRARY_SEARCH_SYSTEM32 = 0x00000800
+
 func windowsSetDefaultDllDirectories(flags uint32) (err error) {
 	r, _, e := windows.NewLazySystemDLL("kernel32.dll").NewProc("SetDefaultDllDirectories").Call(uintptr(flags))
 	if r == 0 {
@@ -153,6 +227,68 @@ func windowsSetDefaultDllDirectories(flags uint32) (err error) {
 	return
 }
 
+// dumpDiagnostics writes a zip of what support would ask for first: the shared log, the log of each tunnel, and the
+// trace and minidump of the most recent crash.
+func dumpDiagnostics(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	bundle := zip.NewWriter(file)
+	addLog := func(entry, tunnelName string) error {
+		rl, err := ringlogger.OpenLog(tunnelName)
+		if err != nil {
+			return err
+		}
+		defer rl.Close()
+		out, err := bundle.Create(entry)
+		if err != nil {
+			return err
+		}
+		_, err = rl.WriteTo(out)
+		return err
+	}
+	addFile := func(entry, path string) error {
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := bundle.Create(entry)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, in)
+		return err
+	}
+
+	err = addLog("log.txt", "")
+	if err != nil {
+		return err
+	}
+	if root, err := conf.RootDirectory(false); err == nil {
+		tunnelLogs, _ := filepath.Glob(filepath.Join(root, "Logs", "*.bin"))
+		for _, tunnelLog := range tunnelLogs {
+			tunnelName := strings.TrimSuffix(filepath.Base(tunnelLog), ".bin")
+			if err := addLog("Logs/"+tunnelName+".txt", tunnelName); err != nil {
+				log.Printf("Unable to add the log of %s: %v", tunnelName, err)
+			}
+		}
+	}
+	trace, minidump, err := crashdump.Latest()
+	if err == nil && len(trace) > 0 {
+		err = addFile("Crashes/"+filepath.Base(trace), trace)
+	}
+	if err == nil && len(minidump) > 0 {
+		err = addFile("Crashes/"+filepath.Base(minidump), minidump)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return bundle.Close()
+}
+
 func main() {
 	if windowsSetDllDirectory("") != nil || windowsSetDefaultDllDirectories(_LOAD_LIBRARY_SEARCH_SYSTEM32) != nil {
 		panic("failed to restrict dll search path")
@@ -233,6 +369,15 @@ func main() {
 			fatal(err)
 		}
 		return
+	case "/tunnelhostservice":
+		if len(os.Args) != 2 {
+			usage()
+		}
+		err := tunnel.RunHost()
+		if err != nil {
+			fatal(err)
+		}
+		return
 	case "/ui":
 		if len(os.Args) != 6 {
 			usage()
@@ -270,37 +415,271 @@ func main() {
 		ui.IsAdmin = isAdmin
 		ui.RunUI()
 		return
-	case "/dumplog":
+	case "/confirm":
 		if len(os.Args) != 3 {
 			usage()
 		}
-		file, err := os.Create(os.Args[2])
+		confirmed, err := elevate.ReauthenticateOnSecureDesktop(l18n.Sprintf("WireGuard"), os.Args[2])
+		if err != nil {
+			fatal(err)
+		}
+		if !confirmed {
+			os.Exit(1)
+		}
+		return
+	case "/remoteui":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		err := manager.ConnectRemote(os.Args[2])
+		if err != nil {
+			fatalf("Unable to connect to the manager of %s: %v", os.Args[2], err)
+		}
+		ui.IsAdmin = true
+		ui.RemoteMachine = os.Args[2]
+		ui.RunUI()
+		return
+	case "/dumplog":
+		var asJSON, redact bool
+		var format ringlogger.StampFormat
+		var tunnelName string
+		args := os.Args[2:]
+		for len(args) > 1 {
+			if args[0] == "/json" {
+				asJSON = true
+				args = args[1:]
+			} else if args[0] == "/redact" {
+				redact = true
+				args = args[1:]
+			} else if args[0] == "/utc" {
+				format.UTC = true
+				args = args[1:]
+			} else if args[0] == "/iso8601" {
+				format.ISO8601 = true
+				args = args[1:]
+			} else if args[0] == "/tunnel" && len(args) > 2 {
+				tunnelName = args[1]
+				args = args[2:]
+			} else {
+				break
+			}
+		}
+		if len(args) != 1 {
+			usage()
+		}
+		rl, err := ringlogger.OpenLog(tunnelName)
+		if err != nil {
+			fatal(err)
+		}
+		defer rl.Close()
+		file, err := os.Create(args[0])
 		if err != nil {
 			fatal(err)
 		}
 		defer file.Close()
-		err = ringlogger.DumpTo(file, true)
+		lines, _, _ := rl.ReadFromCursor(ringlogger.CursorAll)
+		if redact {
+			lines = ringlogger.RedactLines(lines)
+		}
+		if asJSON {
+			_, err = ringlogger.WriteLinesJSONTo(file, lines, format)
+		} else {
+			_, err = ringlogger.WriteLinesTo(file, lines, format)
+		}
 		if err != nil {
 			fatal(err)
 		}
 		return
-	case "/update":
-		if len(os.Args) != 2 && len(os.Args) != 3 {
+	case "/taillog":
+		useConsole()
+		var follow bool
+		maxLevel := ringlogger.LevelTrace
+		var tunnelName string
+		for _, arg := range os.Args[2:] {
+			if arg == "/follow" {
+				follow = true
+			} else if strings.HasPrefix(arg, "/level=") {
+				level, err := ringlogger.ParseLevel(strings.TrimPrefix(arg, "/level="))
+				if err != nil {
+					fatal(err)
+				}
+				maxLevel = level
+			} else if !strings.HasPrefix(arg, "/") && len(tunnelName) == 0 {
+				tunnelName = arg
+			} else {
+				usage()
+			}
+		}
+		rl, err := ringlogger.OpenLog(tunnelName)
+		if err != nil {
+			fatal(err)
+		}
+		defer rl.Close()
+		var format ringlogger.StampFormat
+		lines, cursor, _ := rl.ReadFromCursor(ringlogger.CursorAll)
+		for {
+			for _, line := range lines {
+				if level, err := ringlogger.ParseLevel(ringlogger.ParseLine(line).Level); err == nil && level > maxLevel {
+					continue
+				}
+				fmt.Fprintf(os.Stdout, "%s: %s\n", format.Format(line.Stamp), line.Line)
+			}
+			if !follow {
+				return
+			}
+			var missed uint32
+			lines, cursor, missed, err = rl.FollowFromCursor(context.Background(), cursor)
+			if err != nil {
+				fatal(err)
+			}
+			if missed > 0 {
+				fmt.Fprintf(os.Stdout, "(%d lines were overwritten before they could be shown)\n", missed)
+			}
+		}
+	case "/dumpdiagnostics":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		err := dumpDiagnostics(os.Args[2])
+		if err != nil {
+			fatal(err)
+		}
+		return
+	case "/loglevel":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		level, err := ringlogger.ParseLevel(os.Args[2])
+		if err != nil {
+			fatal(err)
+		}
+		err = ringlogger.SetLevelOfShared(level)
+		if err != nil {
+			fatal(err)
+		}
+		return
+	case "/show":
+		useConsole()
+		if len(os.Args) > 4 {
+			usage()
+		}
+		tunnel, field := "all", ""
+		if len(os.Args) > 2 {
+			tunnel = os.Args[2]
+		}
+		if len(os.Args) > 3 {
+			field = os.Args[3]
+		}
+		output, err := manager.WgShow(tunnel, field)
+		if err != nil {
+			fatal(err)
+		}
+		os.Stdout.WriteString(output)
+		return
+	case "/set":
+		useConsole()
+		args := os.Args[2:]
+		persist := len(args) > 0 && args[0] == "/persist"
+		if persist {
+			args = args[1:]
+		}
+		if len(args) < 2 {
+			usage()
+		}
+		err := manager.SetTunnel(args[0], args[1:], persist)
+		if err != nil {
+			fatal(err)
+		}
+		return
+	case "/selftest":
+		useConsole()
+		args := os.Args[2:]
+		asJSON := len(args) > 0 && args[0] == "/json"
+		if asJSON {
+			args = args[1:]
+		}
+		if len(args) > 1 {
+			usage()
+		}
+		tunnelName := ""
+		if len(args) == 1 {
+			tunnelName = args[0]
+		}
+		report := manager.RunSelfTest(tunnelName)
+		if asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "\t")
+			encoder.Encode(report)
+		} else {
+			report.WriteText(os.Stdout)
+		}
+		if !report.Passed() {
+			os.Exit(1)
+		}
+		return
+	case "/backup", "/restore":
+		useConsole()
+		args := os.Args[2:]
+		overwrite := os.Args[1] == "/restore" && len(args) > 0 && args[0] == "/overwrite"
+		if overwrite {
+			args = args[1:]
+		}
+		if len(args) != 2 {
+			usage()
+		}
+		passphrase, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			fatal(err)
+		}
+		// The manager has a working directory of its own, so the path must not be relative.
+		path, err := filepath.Abs(args[1])
+		if err != nil {
+			fatal(err)
+		}
+		if os.Args[1] == "/backup" {
+			err = manager.Backup(strings.TrimSpace(string(passphrase)), path)
+		} else {
+			err = manager.Restore(strings.TrimSpace(string(passphrase)), path, overwrite)
+		}
+		if err != nil {
+			fatal(err)
+		}
+		return
+	case "/update", "/updatefromfile":
+		logArg := 2
+		if os.Args[1] == "/updatefromfile" {
+			logArg = 3
+		}
+		if len(os.Args) != logArg && len(os.Args) != logArg+1 {
 			usage()
 		}
 		var f *os.File
 		var err error
-		if len(os.Args) == 2 {
+		if len(os.Args) == logArg {
 			f = os.Stdout
 		} else {
-			f, err = os.Create(os.Args[2])
+			f, err = os.Create(os.Args[logArg])
 			if err != nil {
 				fatal(err)
 			}
 			defer f.Close()
 		}
 		l := log.New(f, "", log.LstdFlags)
-		for progress := range updater.DownloadVerifyAndExecute(0) {
+		var updateProgress chan updater.DownloadProgress
+		if os.Args[1] == "/updatefromfile" {
+			path, err := filepath.Abs(os.Args[2])
+			if err != nil {
+				fatal(err)
+			}
+			updateProgress = updater.VerifyAndExecuteFile(0, path)
+		} else {
+			settings, err := conf.LoadSettings()
+			if err != nil {
+				settings = conf.DefaultSettings()
+			}
+			updateProgress = updater.DownloadVerifyAndExecute(0, settings.EffectiveUpdateChannel())
+		}
+		for progress := range updateProgress {
 			if len(progress.Activity) > 0 {
 				if progress.BytesTotal > 0 || progress.BytesDownloaded > 0 {
 					var percent float64
@@ -320,6 +699,15 @@ func main() {
 			}
 		}
 		return
+	case "/activatetunnel", "/deactivatetunnel":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		err := manager.ForwardTunnelCommand(os.Args[1] == "/activatetunnel", os.Args[2])
+		if err != nil {
+			fatal(err)
+		}
+		return
 	case "/removealladapters":
 		if len(os.Args) != 2 && len(os.Args) != 3 {
 			usage()
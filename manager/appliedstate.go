@@ -0,0 +1,156 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"net"
+	"strings"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+
+	"golang.zx2c4.com/wireguard/windows/tunnel/firewall"
+	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
+)
+
+type AppliedRoute struct {
+	Destination net.IPNet
+	NextHop     net.IP
+	Metric      uint32
+}
+
+// AppliedNRPTRule is a rule of the Name Resolution Policy Table, which sends the queries for names under its namespaces
+// to its DNS servers, rather than to those of whichever interface would otherwise be asked.
+type AppliedNRPTRule struct {
+	Namespaces []string
+	DNS        []net.IP
+}
+
+type AppliedState struct {
+	Addresses       []net.IPNet
+	Routes          []AppliedRoute
+	DNS             []net.IP
+	DNSSuffix       string
+	NRPTRules       []AppliedNRPTRule
+	FirewallFilters []string
+}
+
+const nrptKeyPath = `SYSTEM\CurrentControlSet\Services\Dnscache\Parameters\DnsPolicyConfig`
+
+// nrptRulesOfServers returns the rules of the Name Resolution Policy Table that send queries to any of the servers.
+// Rules do not name an interface, so those of a tunnel are taken to be those that use its DNS servers.
+func nrptRulesOfServers(servers []net.IP) ([]AppliedNRPTRule, error) {
+	if len(servers) == 0 {
+		return nil, nil
+	}
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, nrptKeyPath, registry.ENUMERATE_SUB_KEYS)
+	if err == registry.ErrNotExist {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer key.Close()
+	names, err := key.ReadSubKeyNames(-1)
+	if err != nil {
+		return nil, err
+	}
+	var rules []AppliedNRPTRule
+	for _, name := range names {
+		ruleKey, err := registry.OpenKey(key, name, registry.QUERY_VALUE)
+		if err != nil {
+			continue
+		}
+		namespaces, _, err := ruleKey.GetStringsValue("Name")
+		if err != nil {
+			ruleKey.Close()
+			continue
+		}
+		serverList, _, err := ruleKey.GetStringValue("GenericDNSServers")
+		ruleKey.Close()
+		if err != nil {
+			continue
+		}
+		rule := AppliedNRPTRule{Namespaces: namespaces}
+		ours := false
+		for _, server := range strings.Split(serverList, ";") {
+			ip := net.ParseIP(strings.TrimSpace(server))
+			if ip == nil {
+				continue
+			}
+			rule.DNS = append(rule.DNS, ip)
+			for _, ourServer := range servers {
+				if ip.Equal(ourServer) {
+					ours = true
+				}
+			}
+		}
+		if ours {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func appliedStateOfInterface(interfaceName string) (*AppliedState, error) {
+	adapters, err := winipcfg.GetAdaptersAddresses(windows.AF_UNSPEC, winipcfg.GAAFlagDefault)
+	if err != nil {
+		return nil, err
+	}
+	var adapter *winipcfg.IPAdapterAddresses
+	for _, a := range adapters {
+		if a.FriendlyName() == interfaceName {
+			adapter = a
+			break
+		}
+	}
+	if adapter == nil {
+		return nil, windows.ERROR_NOT_FOUND
+	}
+
+	state := &AppliedState{DNSSuffix: adapter.DNSSuffix()}
+	for address := adapter.FirstUnicastAddress; address != nil; address = address.Next {
+		ip := address.Address.IP()
+		if ip == nil {
+			continue
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		state.Addresses = append(state.Addresses, net.IPNet{IP: ip, Mask: net.CIDRMask(int(address.OnLinkPrefixLength), bits)})
+	}
+	for dns := adapter.FirstDNSServerAddress; dns != nil; dns = dns.Next {
+		if ip := dns.Address.IP(); ip != nil {
+			state.DNS = append(state.DNS, ip)
+		}
+	}
+
+	state.NRPTRules, err = nrptRulesOfServers(state.DNS)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := winipcfg.GetIPForwardTable2(windows.AF_UNSPEC)
+	if err != nil {
+		return nil, err
+	}
+	for i := range routes {
+		if routes[i].InterfaceLUID != adapter.LUID {
+			continue
+		}
+		state.Routes = append(state.Routes, AppliedRoute{
+			Destination: routes[i].DestinationPrefix.IPNet(),
+			NextHop:     routes[i].NextHop.IP(),
+			Metric:      routes[i].Metric,
+		})
+	}
+
+	state.FirewallFilters, err = firewall.AppliedFilters(uint64(adapter.LUID))
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
@@ -0,0 +1,264 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
+	"golang.zx2c4.com/wireguard/windows/version"
+)
+
+// When the BackupPath policy is set, the manager writes a backup of everything to a file named after the machine in
+// that directory, which may be on a share, as often as the BackupInterval policy says, encrypting it with the
+// passphrase in the file that the BackupPassphraseFile policy names. Since the manager runs as Local System, shares
+// are accessed as the machine's account.
+const backupFileExtension = ".wgbackup"
+
+func collectBackup() (*conf.Backup, error) {
+	backup := &conf.Backup{
+		Created: time.Now(),
+		Version: version.Number,
+		Tunnels: make(map[string]string),
+	}
+	backup.Machine, _ = os.Hostname()
+	names, err := conf.ListConfigNames()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		config, err := conf.LoadFromName(name)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to load tunnel %q: %w", name, err)
+		}
+		backup.Tunnels[name] = config.ToWgQuick()
+	}
+	backup.Options, err = conf.LoadAllTunnelOptions()
+	if err != nil {
+		return nil, err
+	}
+	backup.Settings, err = conf.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	backup.LastUsed, err = conf.LoadLastUsed()
+	if err != nil {
+		return nil, err
+	}
+	trackedTunnelsLock.Lock()
+	for name, state := range trackedTunnels {
+		if state != TunnelStopped && state != TunnelStopping {
+			backup.Active = append(backup.Active, name)
+		}
+	}
+	trackedTunnelsLock.Unlock()
+	sort.Strings(backup.Active)
+	return backup, nil
+}
+
+// writeBackup writes an encrypted backup to the file at the path, replacing it only once the new one is complete.
+func writeBackup(passphrase, outputPath string) error {
+	backup, err := collectBackup()
+	if err != nil {
+		return err
+	}
+	data, err := backup.Encrypt(passphrase)
+	if err != nil {
+		return err
+	}
+	tempFile, err := ioutil.TempFile(filepath.Dir(outputPath), filepath.Base(outputPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tempFile.Write(data)
+	if err2 := tempFile.Close(); err == nil {
+		err = err2
+	}
+	if err == nil {
+		err = os.Rename(tempFile.Name(), outputPath)
+	}
+	if err != nil {
+		os.Remove(tempFile.Name())
+		return err
+	}
+	log.Printf("Backed up %d tunnels to %s", len(backup.Tunnels), outputPath)
+	return nil
+}
+
+// restoreBackup saves the tunnels of the backup at the path to the store, along with their options, and replaces the
+// settings with those of the backup. Tunnels that already exist are left as they are, unless overwrite is true, in
+// which case those that are running are restarted, so that they run as restored. The tunnels that were running when
+// the backup was made, of those that were restored, are then started, unless they are running already.
+func restoreBackup(passphrase, path string, overwrite bool) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	backup, err := conf.DecryptBackup(data, passphrase)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	names, err := conf.ListConfigNames()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		existing[strings.ToLower(name)] = true
+	}
+	var failures []string
+	restored := make(map[string]bool)
+	restarted := make(map[string]bool)
+	for name, text := range backup.Tunnels {
+		if existing[strings.ToLower(name)] && !overwrite {
+			log.Printf("[%s] Not restored, since a tunnel of that name exists already", name)
+			continue
+		}
+		config, err := conf.FromWgQuick(text, name)
+		if err == nil {
+			err = config.Save(overwrite)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		if options, ok := backup.Options[name]; ok {
+			if err := conf.SaveTunnelOptions(name, &options); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+		restored[name] = true
+		if existing[strings.ToLower(name)] && tunnelIsRunning(name) {
+			restarted[name] = true
+		}
+	}
+	if backup.Settings != nil {
+		if err := saveSettings(backup.Settings); err != nil {
+			failures = append(failures, fmt.Sprintf("Settings: %v", err))
+		}
+	}
+	if err := conf.RestoreLastUsed(backup.LastUsed); err != nil {
+		failures = append(failures, fmt.Sprintf("Last used times: %v", err))
+	}
+	for name := range restarted {
+		log.Printf("[%s] Restarting, since it was replaced by the restored tunnel", name)
+		err := UninstallTunnel(name)
+		if err == nil {
+			err = waitForStop(name)
+		}
+		if err == nil {
+			err = installTunnelOfName(name)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: Unable to restart: %v", name, err))
+		}
+	}
+	for _, name := range backup.Active {
+		if !restored[name] || restarted[name] || tunnelIsRunning(name) {
+			continue
+		}
+		if err := installTunnelOfName(name); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: Unable to start: %v", name, err))
+		}
+	}
+	log.Printf("Restored %d of %d tunnels from the backup of %s made %s", len(restored), len(backup.Tunnels), backup.Machine, backup.Created.Format(time.RFC3339))
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return errors.New(strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+func tunnelIsRunning(name string) bool {
+	trackedTunnelsLock.Lock()
+	_, running := trackedTunnels[name]
+	trackedTunnelsLock.Unlock()
+	return running || isHosted(name)
+}
+
+func installTunnelOfName(name string) error {
+	path, err := (&conf.Config{Name: name}).Path()
+	if err != nil {
+		return err
+	}
+	return InstallTunnel(path)
+}
+
+func scheduledBackupInterval() time.Duration {
+	hours, ok := conf.AdminInteger("BackupInterval")
+	if !ok {
+		hours = 24
+	} else if hours < 1 {
+		hours = 1
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// runScheduledBackups writes a backup whenever the last one, as told by the time at which its file was written, is
+// older than the interval, so that restarting the manager neither skips nor repeats one.
+func runScheduledBackups() {
+	defer printPanic()
+	for {
+		directory, ok := conf.AdminString("BackupPath")
+		if !ok || len(directory) == 0 {
+			return
+		}
+		interval := scheduledBackupInterval()
+		hostname, err := os.Hostname()
+		if err != nil {
+			ringlogger.Error.Printf("Unable to back up: %v", err)
+			time.Sleep(interval)
+			continue
+		}
+		outputPath := filepath.Join(directory, hostname+backupFileExtension)
+		if info, err := os.Stat(outputPath); err == nil {
+			if wait := interval - time.Since(info.ModTime()); wait > 0 {
+				time.Sleep(wait)
+				continue
+			}
+		}
+		err = func() error {
+			passphraseFile, ok := conf.AdminString("BackupPassphraseFile")
+			if !ok {
+				return errors.New("The BackupPassphraseFile policy is not set")
+			}
+			passphrase, err := ioutil.ReadFile(passphraseFile)
+			if err != nil {
+				return err
+			}
+			return writeBackup(strings.TrimSpace(string(passphrase)), outputPath)
+		}()
+		if err != nil {
+			ringlogger.Error.Printf("Unable to back up to %s: %v", outputPath, err)
+			// Retry sooner than the interval, since the share may just have been unreachable.
+			if interval > time.Hour {
+				interval = time.Hour
+			}
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Backup has the manager write a backup of all tunnels and settings to the file at the path, encrypted with the
+// passphrase.
+func Backup(passphrase, outputPath string) error {
+	return runManagerCommand(commandRequest{Verb: "backup", Args: []string{passphrase, outputPath}})
+}
+
+// Restore has the manager restore the backup at the path, which was encrypted with the passphrase, replacing tunnels
+// of the same names if overwrite is true.
+func Restore(passphrase, path string, overwrite bool) error {
+	return runManagerCommand(commandRequest{Verb: "restore", Args: []string{passphrase, path}, Overwrite: overwrite})
+}
@@ -0,0 +1,221 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows"
+
+	"golang.zx2c4.com/wireguard/ipc/winpipe"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
+	"golang.zx2c4.com/wireguard/windows/services"
+)
+
+// The command pipe lets invocations of wireguard.exe from the command line have the manager do what only it can, such
+// as changing the encrypted configurations, which only Local System can read and write. Unlike the UI, these are not
+// started by the manager, so they have no inherited pipes. Only Local System and elevated administrators may connect,
+// so every command is treated as coming from an administrator.
+const (
+	commandPipePath = `\\.\pipe\ProtectedPrefix\Administrators\WireGuardManager`
+	commandPipeSDDL = "O:SYD:P(A;;GA;;;SY)(A;;GA;;;BA)S:(ML;;NWNRNX;;;HI)"
+)
+
+type commandRequest struct {
+	Verb      string
+	Tunnel    string
+	Args      []string
+	Change    *conf.ConfigChange // Of "set", parsed by the client, which reads the key files
+	Persist   bool
+	Overwrite bool
+}
+
+type commandResponse struct {
+	Output string
+	Error  string
+}
+
+func serveCommands() {
+	sd, err := windows.SecurityDescriptorFromString(commandPipeSDDL)
+	if err != nil {
+		ringlogger.Error.Printf("Unable to create command pipe security descriptor: %v", err)
+		return
+	}
+	listener, err := winpipe.ListenPipe(commandPipePath, &winpipe.PipeConfig{SecurityDescriptor: sd})
+	if err != nil {
+		ringlogger.Error.Printf("Unable to listen for commands: %v", err)
+		return
+	}
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			ringlogger.Error.Printf("Unable to accept command connection: %v", err)
+			return
+		}
+		go serveCommand(conn)
+	}
+}
+
+func serveCommand(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Second * 30))
+	var request commandRequest
+	err := gob.NewDecoder(conn).Decode(&request)
+	if err != nil {
+		return
+	}
+	output, err := runCommand(&request)
+	gob.NewEncoder(conn).Encode(commandResponse{output, errToString(err)})
+}
+
+// runCommand carries out the request, returning what it prints. A command that panics, such as on arguments that
+// nothing checked, fails with an error, rather than taking down the manager, since only that command is affected.
+func runCommand(request *commandRequest) (output string, err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			logPanic(x)
+			output, err = "", fmt.Errorf("Command %q failed unexpectedly: %v", request.Verb, x)
+		}
+	}()
+	var out strings.Builder
+	switch request.Verb {
+	case "show":
+		if len(request.Args) != 1 {
+			err = errors.New("A field is required, even if empty")
+		} else {
+			err = writeWgShow(&out, request.Tunnel, request.Args[0])
+		}
+	case "set":
+		if request.Change == nil {
+			err = errors.New("A change is required")
+		} else {
+			err = setTunnel(request.Tunnel, request.Change, request.Persist)
+		}
+	case "backup", "restore":
+		if len(request.Args) != 2 {
+			err = errors.New("A passphrase and a path are required")
+		} else if request.Verb == "backup" {
+			err = writeBackup(request.Args[0], request.Args[1])
+		} else {
+			err = restoreBackup(request.Args[0], request.Args[1], request.Overwrite)
+		}
+	default:
+		err = fmt.Errorf("Unknown command: %q", request.Verb)
+	}
+	return out.String(), err
+}
+
+// setTunnel applies the change to the running tunnel, and if persist is true, to its stored configuration as well, in
+// which case the tunnel need not be running.
+func setTunnel(tunnelName string, change *conf.ConfigChange, persist bool) error {
+	pipePath, err := services.PipePathOfTunnel(tunnelName)
+	if err != nil {
+		return err
+	}
+	uapi, err := change.ToUAPI()
+	if err != nil {
+		return err
+	}
+	err = setRunningTunnel(pipePath, uapi)
+	if err != nil && !(persist && errors.Is(err, os.ErrNotExist)) {
+		return err
+	}
+	if persist {
+		config, err := conf.LoadFromName(tunnelName)
+		if err != nil {
+			return err
+		}
+		config.Apply(change)
+		err = config.Save(true)
+		if err != nil {
+			return err
+		}
+	}
+	log.Printf("[%s] Changed from the command line (persisted: %v)", tunnelName, persist)
+	return nil
+}
+
+func setRunningTunnel(pipePath, uapi string) error {
+	localSystem, err := windows.CreateWellKnownSid(windows.WinLocalSystemSid)
+	if err != nil {
+		return err
+	}
+	pipe, err := winpipe.DialPipe(pipePath, nil, localSystem)
+	if err != nil {
+		return err
+	}
+	defer pipe.Close()
+	pipe.SetWriteDeadline(time.Now().Add(time.Second * 2))
+	_, err = pipe.Write([]byte("set=1\n" + uapi + "\n"))
+	if err != nil {
+		return err
+	}
+	pipe.SetReadDeadline(time.Now().Add(time.Second * 2))
+	resp, err := ioutil.ReadAll(pipe)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(string(resp), "errno=0\n") {
+		return fmt.Errorf("Tunnel refused the change: %s", strings.TrimSpace(string(resp)))
+	}
+	return nil
+}
+
+// SetTunnel has the manager apply the arguments, as given to wg(8) set, to the running tunnel, and if persist is
+// true, to its stored configuration as well. The key files that they name are read here, as the caller.
+func SetTunnel(tunnelName string, args []string, persist bool) error {
+	change, err := conf.ParseWgSet(args)
+	if err != nil {
+		return err
+	}
+	return runManagerCommand(commandRequest{Verb: "set", Tunnel: tunnelName, Change: change, Persist: persist})
+}
+
+func runManagerCommand(request commandRequest) error {
+	_, err := queryManagerCommand(request)
+	return err
+}
+
+// queryManagerCommand is runManagerCommand for commands that print something, returning what they print.
+func queryManagerCommand(request commandRequest) (string, error) {
+	localSystem, err := windows.CreateWellKnownSid(windows.WinLocalSystemSid)
+	if err != nil {
+		return "", err
+	}
+	timeout := time.Second * 5
+	conn, err := winpipe.DialPipe(commandPipePath, &timeout, localSystem)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", errors.New("The manager service is not running")
+		}
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Second * 30))
+	err = gob.NewEncoder(conn).Encode(request)
+	if err != nil {
+		return "", err
+	}
+	var response commandResponse
+	err = gob.NewDecoder(conn).Decode(&response)
+	if err != nil {
+		return "", err
+	}
+	if len(response.Error) > 0 {
+		return "", errors.New(response.Error)
+	}
+	return response.Output, nil
+}
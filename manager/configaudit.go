@@ -0,0 +1,29 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/etw"
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
+)
+
+// auditConfigurations has the store audit access to the configuration files, and warns of every change to them that
+// WireGuard did not make itself, both in the log and as an ETW event that security monitoring can select on its own.
+func auditConfigurations() {
+	err := conf.AuditStore(func(file, change string) {
+		if len(file) == 0 {
+			ringlogger.Warn.Printf("Configuration files %s, so some may have been tampered with", change)
+			etw.Eventf(etw.LevelWarning, etw.KeywordTamper, "Configuration files %s", change)
+			return
+		}
+		ringlogger.Warn.Printf("Configuration file %s was %s by something other than WireGuard", file, change)
+		etw.Eventf(etw.LevelWarning, etw.KeywordTamper, "Configuration file %s was %s by something other than WireGuard", file, change)
+	})
+	if err != nil {
+		ringlogger.Warn.Printf("Unable to audit access to configuration files: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"net"
+
+	"golang.org/x/sys/windows"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
+)
+
+type RouteConflictKind int
+
+const (
+	AddressConflict RouteConflictKind = iota
+	AllowedIPConflict
+)
+
+type RouteConflict struct {
+	Kind        RouteConflictKind
+	Prefix      net.IPNet // The address or allowed IP of the tunnel being activated
+	Conflicting net.IPNet // The prefix that it clashes with
+	Owner       string    // The name of the adapter or tunnel that owns the conflicting prefix
+	IsTunnel    bool      // Whether the owner is another WireGuard tunnel
+}
+
+func prefixesOverlap(a, b *net.IPNet) bool {
+	if (a.IP.To4() == nil) != (b.IP.To4() == nil) {
+		return false
+	}
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+func isDefaultRoute(prefix *net.IPNet) bool {
+	ones, _ := prefix.Mask.Size()
+	return ones == 0
+}
+
+type ownedPrefix struct {
+	prefix   net.IPNet
+	owner    string
+	isTunnel bool
+}
+
+func routeConflictsOfConfig(config *conf.Config) ([]RouteConflict, error) {
+	multipleTunnels := conf.AdminBool("MultipleSimultaneousTunnels")
+	trackedTunnelsLock.Lock()
+	otherTunnels := make(map[string]bool, len(trackedTunnels))
+	for name, state := range trackedTunnels {
+		if name != config.Name {
+			otherTunnels[name] = state == TunnelStarted || state == TunnelStarting
+		}
+	}
+	trackedTunnelsLock.Unlock()
+
+	var owned []ownedPrefix
+	adapters, err := winipcfg.GetAdaptersAddresses(windows.AF_UNSPEC, winipcfg.GAAFlagDefault)
+	if err != nil {
+		return nil, err
+	}
+	for _, adapter := range adapters {
+		name := adapter.FriendlyName()
+		if name == config.Name || adapter.IfType == winipcfg.IfTypeSoftwareLoopback || adapter.OperStatus != winipcfg.IfOperStatusUp {
+			continue
+		}
+		if _, isTunnel := otherTunnels[name]; isTunnel {
+			// Other tunnels are compared below using their configuration, which includes their allowed IPs.
+			continue
+		}
+		for address := adapter.FirstUnicastAddress; address != nil; address = address.Next {
+			ip := address.Address.IP()
+			if ip == nil || ip.IsLinkLocalUnicast() {
+				continue
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			mask := net.CIDRMask(int(address.OnLinkPrefixLength), bits)
+			owned = append(owned, ownedPrefix{net.IPNet{IP: ip.Mask(mask), Mask: mask}, name, false})
+		}
+	}
+	if multipleTunnels {
+		for name, active := range otherTunnels {
+			if !active {
+				continue
+			}
+			other, err := conf.LoadFromName(name)
+			if err != nil {
+				continue
+			}
+			for _, address := range other.Interface.Addresses {
+				owned = append(owned, ownedPrefix{address.IPNet(), name, true})
+			}
+			for _, peer := range other.Peers {
+				for _, allowedIP := range peer.AllowedIPs {
+					owned = append(owned, ownedPrefix{allowedIP.IPNet(), name, true})
+				}
+			}
+		}
+	}
+
+	var conflicts []RouteConflict
+	check := func(kind RouteConflictKind, prefix *net.IPNet) {
+		for i := range owned {
+			if isDefaultRoute(prefix) != isDefaultRoute(&owned[i].prefix) {
+				// Default routes are split into two more specific ones, and thus never shadow on-link prefixes.
+				continue
+			}
+			if prefixesOverlap(prefix, &owned[i].prefix) {
+				conflicts = append(conflicts, RouteConflict{kind, *prefix, owned[i].prefix, owned[i].owner, owned[i].isTunnel})
+			}
+		}
+	}
+	for _, address := range config.Interface.Addresses {
+		prefix := address.IPNet()
+		check(AddressConflict, &prefix)
+	}
+	for _, peer := range config.Peers {
+		for _, allowedIP := range peer.AllowedIPs {
+			prefix := allowedIP.IPNet()
+			check(AllowedIPConflict, &prefix)
+		}
+	}
+	return conflicts, nil
+}
@@ -0,0 +1,86 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
+)
+
+// What the manager keeps only in memory is written here when it panics, so that the instance that the service control
+// manager restarts can pick up where it left off. The last use of each tunnel is already kept in conf, and the tunnel
+// services themselves keep running, to be found again by trackExistingTunnels.
+const crashStateFileName = "ManagerCrashState.json"
+
+type crashState struct {
+	Crashed      time.Time
+	TunnelErrors map[string]TunnelError
+}
+
+func crashStatePath() (string, error) {
+	root, err := conf.RootDirectory(true)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, crashStateFileName), nil
+}
+
+func saveCrashState() error {
+	path, err := crashStatePath()
+	if err != nil {
+		return err
+	}
+	state := crashState{Crashed: time.Now()}
+	lastTunnelErrorsLock.Lock()
+	state.TunnelErrors = make(map[string]TunnelError, len(lastTunnelErrors))
+	for name, tunnelError := range lastTunnelErrors {
+		state.TunnelErrors[name] = tunnelError
+	}
+	lastTunnelErrorsLock.Unlock()
+	bytes, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, bytes, 0600)
+}
+
+// restoreCrashState loads what the previous instance saved when it crashed, if it did, and removes it, so that it is
+// restored only once.
+func restoreCrashState() {
+	path, err := crashStatePath()
+	if err != nil {
+		return
+	}
+	bytes, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return
+	}
+	os.Remove(path)
+	if err != nil {
+		ringlogger.Error.Printf("Unable to read state saved by crashed manager: %v", err)
+		return
+	}
+	var state crashState
+	err = json.Unmarshal(bytes, &state)
+	if err != nil {
+		ringlogger.Error.Printf("Unable to parse state saved by crashed manager: %v", err)
+		return
+	}
+	ringlogger.Warn.Printf("Restarted after crashing at %s, restoring the last errors of %d tunnels", state.Crashed.Format(time.RFC3339), len(state.TunnelErrors))
+	lastTunnelErrorsLock.Lock()
+	for name, tunnelError := range state.TunnelErrors {
+		if _, ok := lastTunnelErrors[name]; !ok {
+			lastTunnelErrors[name] = tunnelError
+		}
+	}
+	lastTunnelErrorsLock.Unlock()
+}
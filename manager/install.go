@@ -7,8 +7,10 @@ package manager
 
 import (
 	"errors"
+	"log"
 	"os"
 	"time"
+	"unsafe"
 
 	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/svc"
@@ -84,6 +86,16 @@ func InstallManager() error {
 	if err != nil {
 		return err
 	}
+	// Should the manager crash, it is restarted, so that the tunnels that are still running can be controlled again,
+	// but if it keeps crashing, it is left stopped until the next day.
+	err = service.SetRecoveryActions([]mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: time.Second},
+		{Type: mgr.ServiceRestart, Delay: time.Second * 10},
+		{Type: mgr.NoAction},
+	}, 60*60*24)
+	if err != nil {
+		log.Printf("Unable to set recovery actions of manager service: %v", err)
+	}
 	service.Start()
 	return service.Close()
 }
@@ -126,21 +138,62 @@ func InstallTunnel(configPath string) error {
 	if err != nil {
 		return err
 	}
+	if isHosted(name) {
+		return errors.New("Tunnel already installed and running")
+	}
+	state, err := deleteStoppedService(m, serviceName)
+	if err != nil {
+		return err
+	}
+	if state != svc.Stopped {
+		return errors.New("Tunnel already installed and running")
+	}
+	if shouldHost(configPath) {
+		return installHostedTunnel(name, configPath)
+	}
+
+	config := mgr.Config{
+		ServiceType:  windows.SERVICE_WIN32_OWN_PROCESS,
+		StartType:    mgr.StartAutomatic,
+		ErrorControl: mgr.ErrorNormal,
+		Dependencies: []string{"Nsi", "TcpIp"},
+		DisplayName:  "WireGuard Tunnel: " + name,
+		SidType:      windows.SERVICE_SID_TYPE_UNRESTRICTED,
+	}
+	service, err := m.CreateService(serviceName, path, config, "/tunnelservice", configPath)
+	if err != nil {
+		return err
+	}
+	err = setRequiredPrivileges(service, services.TunnelServicePrivileges)
+	if err != nil {
+		service.Delete()
+		service.Close()
+		return err
+	}
+
+	err = service.Start()
+	go trackTunnelService(name, service) // Pass off reference to handle.
+	return err
+}
+
+// deleteStoppedService deletes the service, if it exists and is stopped, and waits for it to be gone. If it exists
+// but is not stopped, it is left as it is, and its state is returned.
+func deleteStoppedService(m *mgr.Mgr, serviceName string) (svc.State, error) {
 	service, err := m.OpenService(serviceName)
 	if err == nil {
 		status, err := service.Query()
 		if err != nil && err != windows.ERROR_SERVICE_MARKED_FOR_DELETE {
 			service.Close()
-			return err
+			return 0, err
 		}
 		if status.State != svc.Stopped && err != windows.ERROR_SERVICE_MARKED_FOR_DELETE {
 			service.Close()
-			return errors.New("Tunnel already installed and running")
+			return status.State, nil
 		}
 		err = service.Delete()
 		service.Close()
 		if err != nil && err != windows.ERROR_SERVICE_MARKED_FOR_DELETE {
-			return err
+			return 0, err
 		}
 		for {
 			service, err = m.OpenService(serviceName)
@@ -151,26 +204,13 @@ func InstallTunnel(configPath string) error {
 			time.Sleep(time.Second / 3)
 		}
 	}
-
-	config := mgr.Config{
-		ServiceType:  windows.SERVICE_WIN32_OWN_PROCESS,
-		StartType:    mgr.StartAutomatic,
-		ErrorControl: mgr.ErrorNormal,
-		Dependencies: []string{"Nsi", "TcpIp"},
-		DisplayName:  "WireGuard Tunnel: " + name,
-		SidType:      windows.SERVICE_SID_TYPE_UNRESTRICTED,
-	}
-	service, err = m.CreateService(serviceName, path, config, "/tunnelservice", configPath)
-	if err != nil {
-		return err
-	}
-
-	err = service.Start()
-	go trackTunnelService(name, service) // Pass off reference to handle.
-	return err
+	return svc.Stopped, nil
 }
 
 func UninstallTunnel(name string) error {
+	if isHosted(name) {
+		return uninstallHostedTunnel(name)
+	}
 	m, err := serviceManager()
 	if err != nil {
 		return err
@@ -191,3 +231,23 @@ func UninstallTunnel(name string) error {
 	}
 	return err2
 }
+
+type serviceRequiredPrivilegesInfo struct {
+	requiredPrivileges *uint16
+}
+
+// setRequiredPrivileges has the service control manager remove every privilege but these from the token of the
+// service when it starts.
+func setRequiredPrivileges(service *mgr.Service, privileges []string) error {
+	var multiSz []uint16
+	for _, privilege := range privileges {
+		privilege16, err := windows.UTF16FromString(privilege)
+		if err != nil {
+			return err
+		}
+		multiSz = append(multiSz, privilege16...)
+	}
+	multiSz = append(multiSz, 0)
+	info := serviceRequiredPrivilegesInfo{&multiSz[0]}
+	return windows.ChangeServiceConfig2(service.Handle, windows.SERVICE_CONFIG_REQUIRED_PRIVILEGES_INFO, (*byte)(unsafe.Pointer(&info)))
+}
@@ -32,6 +32,9 @@ func cleanupStaleWintunInterfaces() {
 			log.Printf("Removing Wintun interface because determining interface name failed: %v", err)
 			return true
 		}
+		if isHosted(interfaceName) {
+			return false
+		}
 		serviceName, err := services.ServiceNameOfTunnel(interfaceName)
 		if err != nil {
 			log.Printf("Removing Wintun interface ‘%s’ because determining tunnel service name failed: %v", interfaceName, err)
@@ -8,10 +8,12 @@ package manager
 import (
 	"encoding/gob"
 	"errors"
-	"os"
+	"io"
 	"sync"
+	"time"
 
 	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
 	"golang.zx2c4.com/wireguard/windows/updater"
 )
 
@@ -21,6 +23,12 @@ type Tunnel struct {
 
 type TunnelState int
 
+// TunnelTransfer is how much a tunnel has received and sent, summed over its peers.
+type TunnelTransfer struct {
+	Rx conf.Bytes
+	Tx conf.Bytes
+}
+
 const (
 	TunnelUnknown TunnelState = iota
 	TunnelStarted
@@ -37,6 +45,8 @@ const (
 	ManagerStoppingNotificationType
 	UpdateFoundNotificationType
 	UpdateProgressNotificationType
+	SettingsChangeNotificationType
+	TunnelCommandNotificationType
 )
 
 type MethodType int
@@ -55,6 +65,23 @@ const (
 	QuitMethodType
 	UpdateStateMethodType
 	UpdateMethodType
+	AppliedStateMethodType
+	SettingsMethodType
+	SetSettingsMethodType
+	RouteConflictsMethodType
+	LastUsedMethodType
+	CheckForUpdateMethodType
+	LastErrorMethodType
+	TunnelStatesMethodType
+	TunnelOptionsMethodType
+	SetTunnelOptionsMethodType
+	ReleaseNotesMethodType
+	AllTunnelOptionsMethodType
+	UpdateFromFileMethodType
+	TunnelLogMethodType
+	SelfTestMethodType
+	SuggestedAddressesMethodType
+	TunnelTransfersMethodType
 )
 
 var (
@@ -93,7 +120,19 @@ type UpdateProgressCallback struct {
 
 var updateProgressCallbacks = make(map[*UpdateProgressCallback]bool)
 
-func InitializeIPCClient(reader *os.File, writer *os.File, events *os.File) {
+type SettingsChangeCallback struct {
+	cb func(settings *conf.Settings)
+}
+
+var settingsChangeCallbacks = make(map[*SettingsChangeCallback]bool)
+
+type TunnelCommandCallback struct {
+	cb func(tunnel *Tunnel, activate bool)
+}
+
+var tunnelCommandCallbacks = make(map[*TunnelCommandCallback]bool)
+
+func InitializeIPCClient(reader io.Reader, writer io.Writer, events io.Reader) {
 	rpcDecoder = gob.NewDecoder(reader)
 	rpcEncoder = gob.NewEncoder(writer)
 	go func() {
@@ -102,6 +141,11 @@ func InitializeIPCClient(reader *os.File, writer *os.File, events *os.File) {
 			var notificationType NotificationType
 			err := decoder.Decode(&notificationType)
 			if err != nil {
+				// The manager went away without saying that it was stopping, which is to say that it crashed. Once
+				// restarted, it starts a new UI, so this one should go away as if it had been told to.
+				for cb := range managerStoppingCallbacks {
+					cb.cb()
+				}
 				return
 			}
 			switch notificationType {
@@ -160,6 +204,10 @@ func InitializeIPCClient(reader *os.File, writer *os.File, events *os.File) {
 				if err != nil {
 					continue
 				}
+				err = decoder.Decode(&dp.Phase)
+				if err != nil {
+					continue
+				}
 				err = decoder.Decode(&dp.BytesDownloaded)
 				if err != nil {
 					continue
@@ -168,6 +216,10 @@ func InitializeIPCClient(reader *os.File, writer *os.File, events *os.File) {
 				if err != nil {
 					continue
 				}
+				err = decoder.Decode(&dp.Remaining)
+				if err != nil {
+					continue
+				}
 				var errStr string
 				err = decoder.Decode(&errStr)
 				if err != nil {
@@ -183,6 +235,30 @@ func InitializeIPCClient(reader *os.File, writer *os.File, events *os.File) {
 				for cb := range updateProgressCallbacks {
 					cb.cb(dp)
 				}
+			case SettingsChangeNotificationType:
+				var settings conf.Settings
+				err = decoder.Decode(&settings)
+				if err != nil {
+					continue
+				}
+				for cb := range settingsChangeCallbacks {
+					cb.cb(&settings)
+				}
+			case TunnelCommandNotificationType:
+				var tunnel string
+				err = decoder.Decode(&tunnel)
+				if err != nil || len(tunnel) == 0 {
+					continue
+				}
+				var activate bool
+				err = decoder.Decode(&activate)
+				if err != nil {
+					continue
+				}
+				t := &Tunnel{tunnel}
+				for cb := range tunnelCommandCallbacks {
+					cb.cb(t, activate)
+				}
 			}
 		}
 	}()
@@ -240,6 +316,26 @@ func (t *Tunnel) RuntimeConfig() (c conf.Config, err error) {
 	return
 }
 
+func (t *Tunnel) AppliedState() (state AppliedState, err error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err = rpcEncoder.Encode(AppliedStateMethodType)
+	if err != nil {
+		return
+	}
+	err = rpcEncoder.Encode(t.Name)
+	if err != nil {
+		return
+	}
+	err = rpcDecoder.Decode(&state)
+	if err != nil {
+		return
+	}
+	err = rpcDecodeError()
+	return
+}
+
 func (t *Tunnel) Start() (err error) {
 	rpcMutex.Lock()
 	defer rpcMutex.Unlock()
@@ -272,6 +368,94 @@ func (t *Tunnel) Stop() (err error) {
 	return
 }
 
+func (t *Tunnel) RouteConflicts() (conflicts []RouteConflict, err error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err = rpcEncoder.Encode(RouteConflictsMethodType)
+	if err != nil {
+		return
+	}
+	err = rpcEncoder.Encode(t.Name)
+	if err != nil {
+		return
+	}
+	err = rpcDecoder.Decode(&conflicts)
+	if err != nil {
+		return
+	}
+	err = rpcDecodeError()
+	return
+}
+
+// LastError returns the most recent failure of the tunnel, or nil if it has not failed since it was last activated.
+func (t *Tunnel) LastError() (*TunnelError, error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err := rpcEncoder.Encode(LastErrorMethodType)
+	if err != nil {
+		return nil, err
+	}
+	err = rpcEncoder.Encode(t.Name)
+	if err != nil {
+		return nil, err
+	}
+	var tunnelError TunnelError
+	err = rpcDecoder.Decode(&tunnelError)
+	if err != nil {
+		return nil, err
+	}
+	err = rpcDecodeError()
+	if err != nil || len(tunnelError.Message) == 0 {
+		return nil, err
+	}
+	return &tunnelError, nil
+}
+
+func (t *Tunnel) Options() (*conf.TunnelOptions, error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err := rpcEncoder.Encode(TunnelOptionsMethodType)
+	if err != nil {
+		return nil, err
+	}
+	err = rpcEncoder.Encode(t.Name)
+	if err != nil {
+		return nil, err
+	}
+	var options conf.TunnelOptions
+	err = rpcDecoder.Decode(&options)
+	if err != nil {
+		return nil, err
+	}
+	err = rpcDecodeError()
+	if err != nil {
+		return nil, err
+	}
+	return &options, nil
+}
+
+func (t *Tunnel) SetOptions(options *conf.TunnelOptions) error {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err := rpcEncoder.Encode(SetTunnelOptionsMethodType)
+	if err != nil {
+		return err
+	}
+	err = rpcEncoder.Encode(t.Name)
+	if err != nil {
+		return err
+	}
+	err = rpcEncoder.Encode(*options)
+	if err != nil {
+		return err
+	}
+	return rpcDecodeError()
+}
+
 func (t *Tunnel) Toggle() (oldState TunnelState, err error) {
 	oldState, err = t.State()
 	if err != nil {
@@ -389,6 +573,58 @@ func IPCClientTunnels() (tunnels []Tunnel, err error) {
 	return
 }
 
+// IPCClientSuggestedAddresses returns the addresses to offer a new tunnel, being the first of the address pool that
+// no tunnel has, or none if there is no pool.
+func IPCClientSuggestedAddresses() (addresses []conf.IPCidr, err error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err = rpcEncoder.Encode(SuggestedAddressesMethodType)
+	if err != nil {
+		return
+	}
+	err = rpcDecoder.Decode(&addresses)
+	if err != nil {
+		return
+	}
+	err = rpcDecodeError()
+	return
+}
+
+// IPCClientTunnelStates returns the state of every tunnel that has a service. Tunnels missing from it are stopped.
+func IPCClientTunnelStates() (states map[string]TunnelState, err error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err = rpcEncoder.Encode(TunnelStatesMethodType)
+	if err != nil {
+		return
+	}
+	err = rpcDecoder.Decode(&states)
+	if err != nil {
+		return
+	}
+	err = rpcDecodeError()
+	return
+}
+
+// IPCClientTunnelTransfers returns how much each running tunnel has received and sent.
+func IPCClientTunnelTransfers() (transfers map[string]TunnelTransfer, err error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err = rpcEncoder.Encode(TunnelTransfersMethodType)
+	if err != nil {
+		return
+	}
+	err = rpcDecoder.Decode(&transfers)
+	if err != nil {
+		return
+	}
+	err = rpcDecodeError()
+	return
+}
+
 func IPCClientQuit(stopTunnelsOnQuit bool) (alreadyQuit bool, err error) {
 	rpcMutex.Lock()
 	defer rpcMutex.Unlock()
@@ -424,6 +660,93 @@ func IPCClientUpdateState() (updateState UpdateState, err error) {
 	return
 }
 
+func IPCClientAllTunnelOptions() (options map[string]conf.TunnelOptions, err error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err = rpcEncoder.Encode(AllTunnelOptionsMethodType)
+	if err != nil {
+		return
+	}
+	err = rpcDecoder.Decode(&options)
+	if err != nil {
+		return
+	}
+	err = rpcDecodeError()
+	return
+}
+
+func IPCClientReleaseNotes() (notes ReleaseNotes, err error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err = rpcEncoder.Encode(ReleaseNotesMethodType)
+	if err != nil {
+		return
+	}
+	err = rpcDecoder.Decode(&notes)
+	if err != nil {
+		return
+	}
+	err = rpcDecodeError()
+	return
+}
+
+// IPCClientTunnelLog returns the lines of the tunnel's own log after the cursor, which starts as
+// ringlogger.CursorAll, along with the cursor for the next call.
+func IPCClientTunnelLog(tunnelName string, cursor uint32) (lines []ringlogger.FollowLine, nextCursor uint32, err error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err = rpcEncoder.Encode(TunnelLogMethodType)
+	if err != nil {
+		return
+	}
+	err = rpcEncoder.Encode(tunnelName)
+	if err != nil {
+		return
+	}
+	err = rpcEncoder.Encode(cursor)
+	if err != nil {
+		return
+	}
+	err = rpcDecoder.Decode(&lines)
+	if err != nil {
+		return
+	}
+	err = rpcDecoder.Decode(&nextCursor)
+	if err != nil {
+		return
+	}
+	err = rpcDecodeError()
+	return
+}
+
+// IPCClientSelfTest has the manager run the self-test, checking the peers of the tunnel too if its name is not empty.
+func IPCClientSelfTest(tunnelName string) (report *SelfTestReport, err error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err = rpcEncoder.Encode(SelfTestMethodType)
+	if err != nil {
+		return
+	}
+	err = rpcEncoder.Encode(tunnelName)
+	if err != nil {
+		return
+	}
+	var r SelfTestReport
+	err = rpcDecoder.Decode(&r)
+	if err != nil {
+		return
+	}
+	err = rpcDecodeError()
+	if err == nil {
+		report = &r
+	}
+	return
+}
+
 func IPCClientUpdate() error {
 	rpcMutex.Lock()
 	defer rpcMutex.Unlock()
@@ -431,6 +754,74 @@ func IPCClientUpdate() error {
 	return rpcEncoder.Encode(UpdateMethodType)
 }
 
+// IPCClientUpdateFromFile asks the manager to install the update at path, which must sit next to the signed file list
+// it came with. Progress is reported just as for IPCClientUpdate.
+func IPCClientUpdateFromFile(path string) error {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err := rpcEncoder.Encode(UpdateFromFileMethodType)
+	if err != nil {
+		return err
+	}
+	return rpcEncoder.Encode(path)
+}
+
+func IPCClientCheckForUpdate() error {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	return rpcEncoder.Encode(CheckForUpdateMethodType)
+}
+
+func IPCClientSettings() (settings conf.Settings, err error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err = rpcEncoder.Encode(SettingsMethodType)
+	if err != nil {
+		return
+	}
+	err = rpcDecoder.Decode(&settings)
+	if err != nil {
+		return
+	}
+	err = rpcDecodeError()
+	return
+}
+
+func IPCClientSetSettings(settings *conf.Settings) (err error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err = rpcEncoder.Encode(SetSettingsMethodType)
+	if err != nil {
+		return
+	}
+	err = rpcEncoder.Encode(*settings)
+	if err != nil {
+		return
+	}
+	err = rpcDecodeError()
+	return
+}
+
+func IPCClientLastUsed() (lastUsed map[string]time.Time, err error) {
+	rpcMutex.Lock()
+	defer rpcMutex.Unlock()
+
+	err = rpcEncoder.Encode(LastUsedMethodType)
+	if err != nil {
+		return
+	}
+	err = rpcDecoder.Decode(&lastUsed)
+	if err != nil {
+		return
+	}
+	err = rpcDecodeError()
+	return
+}
+
 func IPCClientRegisterTunnelChange(cb func(tunnel *Tunnel, state TunnelState, globalState TunnelState, err error)) *TunnelChangeCallback {
 	s := &TunnelChangeCallback{cb}
 	tunnelChangeCallbacks[s] = true
@@ -471,3 +862,19 @@ func IPCClientRegisterUpdateProgress(cb func(dp updater.DownloadProgress)) *Upda
 func (cb *UpdateProgressCallback) Unregister() {
 	delete(updateProgressCallbacks, cb)
 }
+func IPCClientRegisterSettingsChange(cb func(settings *conf.Settings)) *SettingsChangeCallback {
+	s := &SettingsChangeCallback{cb}
+	settingsChangeCallbacks[s] = true
+	return s
+}
+func (cb *SettingsChangeCallback) Unregister() {
+	delete(settingsChangeCallbacks, cb)
+}
+func IPCClientRegisterTunnelCommand(cb func(tunnel *Tunnel, activate bool)) *TunnelCommandCallback {
+	s := &TunnelCommandCallback{cb}
+	tunnelCommandCallbacks[s] = true
+	return s
+}
+func (cb *TunnelCommandCallback) Unregister() {
+	delete(tunnelCommandCallbacks, cb)
+}
@@ -8,6 +8,7 @@ package manager
 import (
 	"bytes"
 	"encoding/gob"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -23,6 +24,8 @@ import (
 	"golang.zx2c4.com/wireguard/ipc/winpipe"
 
 	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/l18n"
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
 	"golang.zx2c4.com/wireguard/windows/services"
 	"golang.zx2c4.com/wireguard/windows/updater"
 )
@@ -32,10 +35,19 @@ var managerServicesLock sync.RWMutex
 var haveQuit uint32
 var quitManagersChan = make(chan struct{}, 1)
 
+type eventWriter interface {
+	io.Writer
+	SetWriteDeadline(time.Time) error
+}
+
 type ManagerService struct {
-	events        *os.File
+	events        eventWriter
 	eventLock     sync.Mutex
 	elevatedToken windows.Token
+	remote        bool   // Connected over the network, by a UI on another machine
+	session       uint32 // The session of the UI, if it is not remote
+	brokered      bool   // Running as the standard user, which confirms each thing that needs elevation
+	confirmLock   sync.Mutex
 }
 
 func (s *ManagerService) StoredConfig(tunnelName string) (*conf.Config, error) {
@@ -54,6 +66,19 @@ func (s *ManagerService) RuntimeConfig(tunnelName string) (*conf.Config, error)
 	if err != nil {
 		return nil, err
 	}
+	conf, err := QueryRuntimeConfig(storedConfig)
+	if err != nil {
+		return nil, err
+	}
+	if s.elevatedToken == 0 {
+		conf.Redact()
+	}
+	return conf, nil
+}
+
+// QueryRuntimeConfig asks the running tunnel service for its keys, listen port, and peers, along with their
+// statistics, and fills in the rest from storedConfig, which need only have its name set.
+func QueryRuntimeConfig(storedConfig *conf.Config) (*conf.Config, error) {
 	pipePath, err := services.PipePathOfTunnel(storedConfig.Name)
 	if err != nil {
 		return nil, err
@@ -77,14 +102,33 @@ func (s *ManagerService) RuntimeConfig(tunnelName string) (*conf.Config, error)
 	if err != nil {
 		return nil, err
 	}
-	conf, err := conf.FromUAPI(string(resp), storedConfig)
+	return conf.FromUAPI(string(resp), storedConfig)
+}
+
+func (s *ManagerService) AppliedState(tunnelName string) (*AppliedState, error) {
+	storedConfig, err := conf.LoadFromName(tunnelName)
 	if err != nil {
 		return nil, err
 	}
-	if s.elevatedToken == 0 {
-		conf.Redact()
+	return appliedStateOfInterface(storedConfig.Name)
+}
+
+func (s *ManagerService) RouteConflicts(tunnelName string) ([]RouteConflict, error) {
+	storedConfig, err := conf.LoadFromName(tunnelName)
+	if err != nil {
+		return nil, err
 	}
-	return conf, nil
+	return routeConflictsOfConfig(storedConfig)
+}
+
+func (s *ManagerService) SelfTest(tunnelName string) (*SelfTestReport, error) {
+	err := s.checkElevated(l18n.Sprintf("Enter your credentials to allow WireGuard to run a self-test of tunnel ‘%s’.", tunnelName))
+	if err != nil {
+		return nil, err
+	}
+	report := RunSelfTest(tunnelName)
+	log.Printf("Self-test run from the UI, with %d checks (passed: %v)", len(report.Checks), report.Passed())
+	return report, nil
 }
 
 func (s *ManagerService) Start(tunnelName string) error {
@@ -130,7 +174,20 @@ func (s *ManagerService) Start(tunnelName string) error {
 	if err != nil {
 		return err
 	}
-	return InstallTunnel(path)
+	err = InstallTunnel(path)
+	if err != nil {
+		recordTunnelError(tunnelName, err)
+		return err
+	}
+	err = conf.MarkLastUsed(tunnelName)
+	if err != nil {
+		ringlogger.Error.Printf("[%s] Unable to record last use: %v", tunnelName, err)
+	}
+	return nil
+}
+
+func (s *ManagerService) LastUsed() (map[string]time.Time, error) {
+	return conf.LoadLastUsed()
 }
 
 func (s *ManagerService) Stop(tunnelName string) error {
@@ -147,6 +204,19 @@ func (s *ManagerService) Stop(tunnelName string) error {
 }
 
 func (s *ManagerService) WaitForStop(tunnelName string) error {
+	return waitForStop(tunnelName)
+}
+
+// waitForStop returns once the tunnel is neither hosted nor has a service.
+func waitForStop(tunnelName string) error {
+	// The host gives up on stopping its tunnels after half a minute, so one that takes longer than this is wedged.
+	deadline := time.Now().Add(time.Minute)
+	for isHosted(tunnelName) {
+		if time.Now().After(deadline) {
+			return errors.New("Timed out waiting for the tunnel host to stop the tunnel")
+		}
+		time.Sleep(time.Second / 3)
+	}
 	serviceName, err := services.ServiceNameOfTunnel(tunnelName)
 	if err != nil {
 		return err
@@ -167,17 +237,77 @@ func (s *ManagerService) WaitForStop(tunnelName string) error {
 }
 
 func (s *ManagerService) Delete(tunnelName string) error {
-	if s.elevatedToken == 0 {
-		return windows.ERROR_ACCESS_DENIED
+	err := s.checkElevated(l18n.Sprintf("Enter your credentials to allow WireGuard to delete tunnel ‘%s’.", tunnelName))
+	if err != nil {
+		return err
 	}
-	err := s.Stop(tunnelName)
+	err = s.Stop(tunnelName)
 	if err != nil {
 		return err
 	}
-	return conf.DeleteName(tunnelName)
+	clearTunnelError(tunnelName)
+	err = conf.DeleteName(tunnelName)
+	if err != nil {
+		return err
+	}
+	// The service has the log of the tunnel open until it exits, and a tunnel of the same name may be created by then.
+	go func() {
+		defer printPanic()
+		if s.WaitForStop(tunnelName) != nil {
+			return
+		}
+		if _, err := conf.LoadFromName(tunnelName); err == nil {
+			return
+		}
+		if err := ringlogger.DeleteTunnelLog(tunnelName); err != nil {
+			ringlogger.Error.Printf("[%s] Unable to delete log: %v", tunnelName, err)
+		}
+	}()
+	return conf.SaveTunnelOptions(tunnelName, &conf.TunnelOptions{})
+}
+
+func (s *ManagerService) LastError(tunnelName string) (TunnelError, error) {
+	tunnelError, _ := lastTunnelError(tunnelName)
+	return tunnelError, nil
+}
+
+func (s *ManagerService) TunnelOptions(tunnelName string) (conf.TunnelOptions, error) {
+	options, err := conf.LoadTunnelOptions(tunnelName)
+	if err != nil {
+		return conf.TunnelOptions{}, err
+	}
+	return *options, nil
+}
+
+func (s *ManagerService) SetTunnelOptions(tunnelName string, options *conf.TunnelOptions) error {
+	if options.IdleTimeout < 0 {
+		return windows.ERROR_INVALID_PARAMETER
+	}
+	err := s.checkElevated(l18n.Sprintf("Enter your credentials to allow WireGuard to change the options of tunnel ‘%s’.", tunnelName))
+	if err != nil {
+		return err
+	}
+	err = conf.SaveTunnelOptions(tunnelName, options)
+	if err != nil {
+		return err
+	}
+	IPCServerNotifyTunnelsChange()
+	return nil
+}
+
+func (s *ManagerService) AllTunnelOptions() (map[string]conf.TunnelOptions, error) {
+	return conf.LoadAllTunnelOptions()
 }
 
 func (s *ManagerService) State(tunnelName string) (TunnelState, error) {
+	if isHosted(tunnelName) {
+		trackedTunnelsLock.Lock()
+		defer trackedTunnelsLock.Unlock()
+		if state, found := trackedTunnels[tunnelName]; found {
+			return state, nil
+		}
+		return TunnelStarting, nil
+	}
 	serviceName, err := services.ServiceNameOfTunnel(tunnelName)
 	if err != nil {
 		return 0, err
@@ -214,10 +344,11 @@ func (s *ManagerService) GlobalState() TunnelState {
 }
 
 func (s *ManagerService) Create(tunnelConfig *conf.Config) (*Tunnel, error) {
-	if s.elevatedToken == 0 {
-		return nil, windows.ERROR_ACCESS_DENIED
+	err := s.checkElevated(l18n.Sprintf("Enter your credentials to allow WireGuard to save tunnel ‘%s’.", tunnelConfig.Name))
+	if err != nil {
+		return nil, err
 	}
-	err := tunnelConfig.Save(true)
+	err = tunnelConfig.Save(true)
 	if err != nil {
 		return nil, err
 	}
@@ -239,10 +370,82 @@ func (s *ManagerService) Tunnels() ([]Tunnel, error) {
 	// TODO: account for running ones that aren't in the configuration store somehow
 }
 
+// SuggestedAddresses looks through the addresses of all tunnels here, rather than having the UI fetch each of their
+// configurations, which takes a round trip per tunnel.
+func (s *ManagerService) SuggestedAddresses() ([]conf.IPCidr, error) {
+	settings, err := conf.LoadSettings()
+	if err != nil || len(settings.AddressPool) == 0 {
+		return nil, err
+	}
+	pool, err := conf.ParseAddressPool(settings.AddressPool)
+	if err != nil {
+		return nil, err
+	}
+	names, err := conf.ListConfigNames()
+	if err != nil {
+		return nil, err
+	}
+	var used []conf.IPCidr
+	for _, name := range names {
+		config, err := conf.LoadFromName(name)
+		if err != nil {
+			continue
+		}
+		used = append(used, config.Interface.Addresses...)
+	}
+	address, err := conf.NextFreeAddress(pool, used)
+	if err != nil {
+		return nil, err
+	}
+	return []conf.IPCidr{*address}, nil
+}
+
+func (s *ManagerService) TunnelStates() (map[string]TunnelState, error) {
+	trackedTunnelsLock.Lock()
+	defer trackedTunnelsLock.Unlock()
+	states := make(map[string]TunnelState, len(trackedTunnels))
+	for name, state := range trackedTunnels {
+		states[name] = state
+	}
+	return states, nil
+}
+
+// TunnelTransfers asks each running tunnel for its transfer counters here, rather than having the UI fetch the runtime
+// configuration of each, which takes a round trip per tunnel.
+func (s *ManagerService) TunnelTransfers() (map[string]TunnelTransfer, error) {
+	trackedTunnelsLock.Lock()
+	var names []string
+	for name, state := range trackedTunnels {
+		if state == TunnelStarted {
+			names = append(names, name)
+		}
+	}
+	trackedTunnelsLock.Unlock()
+	transfers := make(map[string]TunnelTransfer, len(names))
+	for _, name := range names {
+		config, err := QueryRuntimeConfig(&conf.Config{Name: name})
+		if err != nil {
+			continue
+		}
+		var transfer TunnelTransfer
+		for _, peer := range config.Peers {
+			transfer.Rx += peer.RxBytes
+			transfer.Tx += peer.TxBytes
+		}
+		transfers[name] = transfer
+	}
+	return transfers, nil
+}
+
 func (s *ManagerService) Quit(stopTunnelsOnQuit bool) (alreadyQuit bool, err error) {
-	if s.elevatedToken == 0 {
+	// A remote UI may manage the tunnels, but not take away the manager that lets it.
+	if s.remote {
 		return false, windows.ERROR_ACCESS_DENIED
 	}
+	err = s.checkElevated(l18n.Sprintf("Enter your credentials to allow WireGuard to exit its manager."))
+	if err != nil {
+		return false, err
+	}
 	if !atomic.CompareAndSwapUint32(&haveQuit, 0, 1) {
 		return true, nil
 	}
@@ -273,11 +476,43 @@ func (s *ManagerService) UpdateState() UpdateState {
 	return updateState
 }
 
+func (s *ManagerService) ReleaseNotes() (ReleaseNotes, error) {
+	releaseNotesLock.Lock()
+	defer releaseNotesLock.Unlock()
+	return releaseNotes, nil
+}
+
 func (s *ManagerService) Update() {
-	if s.elevatedToken == 0 {
+	if s.elevatedToken == 0 || s.remote || conf.UpdatesDisabled() {
+		return
+	}
+	if s.checkElevated(l18n.Sprintf("Enter your credentials to allow WireGuard to install an update.")) != nil {
+		return
+	}
+	settings, err := conf.LoadSettings()
+	if err != nil {
+		settings = conf.DefaultSettings()
+	}
+	progress := updater.DownloadVerifyAndExecute(uintptr(s.elevatedToken), settings.EffectiveUpdateChannel())
+	go func() {
+		for {
+			dp := <-progress
+			IPCServerNotifyUpdateProgress(dp)
+			if dp.Complete || dp.Error != nil {
+				return
+			}
+		}
+	}()
+}
+
+func (s *ManagerService) UpdateFromFile(path string) {
+	if s.elevatedToken == 0 || s.remote || conf.UpdatesDisabled() {
+		return
+	}
+	if s.checkElevated(l18n.Sprintf("Enter your credentials to allow WireGuard to install an update from ‘%s’.", path)) != nil {
 		return
 	}
-	progress := updater.DownloadVerifyAndExecute(uintptr(s.elevatedToken))
+	progress := updater.VerifyAndExecuteFile(uintptr(s.elevatedToken), path)
 	go func() {
 		for {
 			dp := <-progress
@@ -289,6 +524,55 @@ func (s *ManagerService) Update() {
 	}()
 }
 
+func (s *ManagerService) TunnelLog(tunnelName string, cursor uint32) ([]ringlogger.FollowLine, uint32, error) {
+	rl, err := ringlogger.OpenLog(tunnelName)
+	if err != nil {
+		return nil, cursor, err
+	}
+	defer rl.Close()
+	lines, nextCursor, _ := rl.ReadFromCursor(cursor)
+	return lines, nextCursor, nil
+}
+
+func (s *ManagerService) CheckForUpdate() {
+	if s.elevatedToken == 0 || conf.UpdatesDisabled() {
+		return
+	}
+	requestUpdateCheck()
+}
+
+func (s *ManagerService) Settings() (*conf.Settings, error) {
+	return conf.LoadSettings()
+}
+
+func (s *ManagerService) SetSettings(settings *conf.Settings) error {
+	err := s.checkElevated(l18n.Sprintf("Enter your credentials to allow WireGuard to change its settings."))
+	if err != nil {
+		return err
+	}
+	return saveSettings(settings)
+}
+
+// saveSettings saves the settings, and applies what changed of them to the manager and to each UI.
+func saveSettings(settings *conf.Settings) error {
+	previous, err := conf.LoadSettings()
+	if err != nil {
+		previous = conf.DefaultSettings()
+	}
+	err = settings.Save()
+	if err != nil {
+		return err
+	}
+	if settings.EffectiveUpdateChannel() != previous.EffectiveUpdateChannel() {
+		requestUpdateCheck()
+	}
+	if settings.Language != previous.Language {
+		l18n.SetLanguageOverride(settings.Language)
+	}
+	IPCServerNotifySettingsChange(settings)
+	return nil
+}
+
 func (s *ManagerService) ServeConn(reader io.Reader, writer io.Writer) {
 	decoder := gob.NewDecoder(reader)
 	encoder := gob.NewEncoder(writer)
@@ -298,6 +582,7 @@ func (s *ManagerService) ServeConn(reader io.Reader, writer io.Writer) {
 		if err != nil {
 			return
 		}
+		ringlogger.Trace.Printf("IPC method %d", methodType)
 		switch methodType {
 		case StoredConfigMethodType:
 			var tunnelName string
@@ -335,6 +620,113 @@ func (s *ManagerService) ServeConn(reader io.Reader, writer io.Writer) {
 			if err != nil {
 				return
 			}
+		case AppliedStateMethodType:
+			var tunnelName string
+			err := decoder.Decode(&tunnelName)
+			if err != nil {
+				return
+			}
+			state, retErr := s.AppliedState(tunnelName)
+			if state == nil {
+				state = &AppliedState{}
+			}
+			err = encoder.Encode(*state)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
+		case LastUsedMethodType:
+			lastUsed, retErr := s.LastUsed()
+			err = encoder.Encode(lastUsed)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
+		case LastErrorMethodType:
+			var tunnelName string
+			err := decoder.Decode(&tunnelName)
+			if err != nil {
+				return
+			}
+			tunnelError, retErr := s.LastError(tunnelName)
+			err = encoder.Encode(tunnelError)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
+		case TunnelOptionsMethodType:
+			var tunnelName string
+			err := decoder.Decode(&tunnelName)
+			if err != nil {
+				return
+			}
+			options, retErr := s.TunnelOptions(tunnelName)
+			err = encoder.Encode(options)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
+		case SetTunnelOptionsMethodType:
+			var tunnelName string
+			err := decoder.Decode(&tunnelName)
+			if err != nil {
+				return
+			}
+			var options conf.TunnelOptions
+			err = decoder.Decode(&options)
+			if err != nil {
+				return
+			}
+			retErr := s.SetTunnelOptions(tunnelName, &options)
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
+		case SelfTestMethodType:
+			var tunnelName string
+			err := decoder.Decode(&tunnelName)
+			if err != nil {
+				return
+			}
+			report, retErr := s.SelfTest(tunnelName)
+			if report == nil {
+				report = &SelfTestReport{}
+			}
+			err = encoder.Encode(report)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
+		case RouteConflictsMethodType:
+			var tunnelName string
+			err := decoder.Decode(&tunnelName)
+			if err != nil {
+				return
+			}
+			conflicts, retErr := s.RouteConflicts(tunnelName)
+			err = encoder.Encode(conflicts)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
 		case StartMethodType:
 			var tunnelName string
 			err := decoder.Decode(&tunnelName)
@@ -428,6 +820,36 @@ func (s *ManagerService) ServeConn(reader io.Reader, writer io.Writer) {
 			if err != nil {
 				return
 			}
+		case SuggestedAddressesMethodType:
+			addresses, retErr := s.SuggestedAddresses()
+			err = encoder.Encode(addresses)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
+		case TunnelStatesMethodType:
+			states, retErr := s.TunnelStates()
+			err = encoder.Encode(states)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
+		case TunnelTransfersMethodType:
+			transfers, retErr := s.TunnelTransfers()
+			err = encoder.Encode(transfers)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
 		case QuitMethodType:
 			var stopTunnelsOnQuit bool
 			err := decoder.Decode(&stopTunnelsOnQuit)
@@ -443,6 +865,26 @@ func (s *ManagerService) ServeConn(reader io.Reader, writer io.Writer) {
 			if err != nil {
 				return
 			}
+		case AllTunnelOptionsMethodType:
+			options, retErr := s.AllTunnelOptions()
+			err = encoder.Encode(options)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
+		case ReleaseNotesMethodType:
+			notes, retErr := s.ReleaseNotes()
+			err = encoder.Encode(notes)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
 		case UpdateStateMethodType:
 			updateState := s.UpdateState()
 			err = encoder.Encode(updateState)
@@ -451,56 +893,129 @@ func (s *ManagerService) ServeConn(reader io.Reader, writer io.Writer) {
 			}
 		case UpdateMethodType:
 			s.Update()
+		case UpdateFromFileMethodType:
+			var path string
+			err := decoder.Decode(&path)
+			if err != nil {
+				return
+			}
+			s.UpdateFromFile(path)
+		case TunnelLogMethodType:
+			var tunnelName string
+			err := decoder.Decode(&tunnelName)
+			if err != nil {
+				return
+			}
+			var cursor uint32
+			err = decoder.Decode(&cursor)
+			if err != nil {
+				return
+			}
+			lines, nextCursor, retErr := s.TunnelLog(tunnelName, cursor)
+			err = encoder.Encode(lines)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(nextCursor)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
+		case CheckForUpdateMethodType:
+			s.CheckForUpdate()
+		case SettingsMethodType:
+			settings, retErr := s.Settings()
+			if settings == nil {
+				settings = conf.DefaultSettings()
+			}
+			err = encoder.Encode(*settings)
+			if err != nil {
+				return
+			}
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
+		case SetSettingsMethodType:
+			var settings conf.Settings
+			err := decoder.Decode(&settings)
+			if err != nil {
+				return
+			}
+			retErr := s.SetSettings(&settings)
+			err = encoder.Encode(errToString(retErr))
+			if err != nil {
+				return
+			}
 		default:
 			return
 		}
 	}
 }
 
-func IPCServerListen(reader *os.File, writer *os.File, events *os.File, elevatedToken windows.Token) {
+func IPCServerListen(reader *os.File, writer *os.File, events *os.File, elevatedToken windows.Token, session uint32, brokered bool) {
 	service := &ManagerService{
 		events:        events,
 		elevatedToken: elevatedToken,
+		session:       session,
+		brokered:      brokered,
 	}
 
 	go func() {
 		defer printPanic()
-		managerServicesLock.Lock()
-		managerServices[service] = true
-		managerServicesLock.Unlock()
-		service.ServeConn(reader, writer)
-		managerServicesLock.Lock()
-		service.eventLock.Lock()
-		service.events = nil
-		service.eventLock.Unlock()
-		delete(managerServices, service)
-		managerServicesLock.Unlock()
+		service.serve(reader, writer)
 	}()
 }
 
+// serve serves the methods and sends the notifications of the service until its client disconnects.
+func (service *ManagerService) serve(reader io.Reader, writer io.Writer) {
+	managerServicesLock.Lock()
+	managerServices[service] = true
+	managerServicesLock.Unlock()
+	service.ServeConn(reader, writer)
+	managerServicesLock.Lock()
+	service.eventLock.Lock()
+	service.events = nil
+	service.eventLock.Unlock()
+	delete(managerServices, service)
+	managerServicesLock.Unlock()
+}
+
 func notifyAll(notificationType NotificationType, adminOnly bool, ifaces ...interface{}) {
+	notify(func(m *ManagerService) bool {
+		return m.elevatedToken != 0 || !adminOnly
+	}, notificationType, ifaces...)
+}
+
+// notify sends the notification to the services that match, returning how many it was sent to.
+func notify(match func(m *ManagerService) bool, notificationType NotificationType, ifaces ...interface{}) int {
 	if len(managerServices) == 0 {
-		return
+		return 0
 	}
 
 	var buf bytes.Buffer
 	encoder := gob.NewEncoder(&buf)
 	err := encoder.Encode(notificationType)
 	if err != nil {
-		return
+		return 0
 	}
 	for _, iface := range ifaces {
 		err = encoder.Encode(iface)
 		if err != nil {
-			return
+			return 0
 		}
 	}
 
+	sent := 0
 	managerServicesLock.RLock()
 	for m := range managerServices {
-		if m.elevatedToken == 0 && adminOnly {
+		if !match(m) {
 			continue
 		}
+		sent++
 		go func(m *ManagerService) {
 			m.eventLock.Lock()
 			defer m.eventLock.Unlock()
@@ -511,6 +1026,7 @@ func notifyAll(notificationType NotificationType, adminOnly bool, ifaces ...inte
 		}(m)
 	}
 	managerServicesLock.RUnlock()
+	return sent
 }
 
 func errToString(err error) string {
@@ -533,7 +1049,19 @@ func IPCServerNotifyUpdateFound(state UpdateState) {
 }
 
 func IPCServerNotifyUpdateProgress(dp updater.DownloadProgress) {
-	notifyAll(UpdateProgressNotificationType, true, dp.Activity, dp.BytesDownloaded, dp.BytesTotal, errToString(dp.Error), dp.Complete)
+	notifyAll(UpdateProgressNotificationType, true, dp.Activity, dp.Phase, dp.BytesDownloaded, dp.BytesTotal, dp.Remaining, errToString(dp.Error), dp.Complete)
+}
+
+func IPCServerNotifySettingsChange(settings *conf.Settings) {
+	notifyAll(SettingsChangeNotificationType, false, *settings)
+}
+
+// IPCServerNotifyTunnelCommand asks the local UIs of the session to activate or deactivate the tunnel, returning how
+// many were asked.
+func IPCServerNotifyTunnelCommand(session uint32, tunnelName string, activate bool) int {
+	return notify(func(m *ManagerService) bool {
+		return !m.remote && m.session == session
+	}, TunnelCommandNotificationType, tunnelName, activate)
 }
 
 func IPCServerNotifyManagerStopping() {
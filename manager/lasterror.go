@@ -0,0 +1,60 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"errors"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.zx2c4.com/wireguard/windows/services"
+)
+
+type TunnelErrorSource int
+
+const (
+	TunnelErrorFromManager TunnelErrorSource = iota // Code is zero; Message describes what the manager was doing
+	TunnelErrorFromService                          // Code is a services.Error reported by the tunnel service
+	TunnelErrorFromSystem                           // Code is a Win32 error that the tunnel service exited with
+)
+
+type TunnelError struct {
+	Source  TunnelErrorSource
+	Code    uint32
+	Message string
+	Time    time.Time
+}
+
+var lastTunnelErrors = make(map[string]TunnelError)
+var lastTunnelErrorsLock sync.Mutex
+
+func recordTunnelError(tunnelName string, err error) {
+	tunnelError := TunnelError{Source: TunnelErrorFromManager, Message: err.Error(), Time: time.Now()}
+	var serviceError services.Error
+	var errno syscall.Errno
+	if errors.As(err, &serviceError) {
+		tunnelError.Source, tunnelError.Code = TunnelErrorFromService, uint32(serviceError)
+	} else if errors.As(err, &errno) {
+		tunnelError.Source, tunnelError.Code = TunnelErrorFromSystem, uint32(errno)
+	}
+	lastTunnelErrorsLock.Lock()
+	lastTunnelErrors[tunnelName] = tunnelError
+	lastTunnelErrorsLock.Unlock()
+}
+
+func clearTunnelError(tunnelName string) {
+	lastTunnelErrorsLock.Lock()
+	delete(lastTunnelErrors, tunnelName)
+	lastTunnelErrorsLock.Unlock()
+}
+
+func lastTunnelError(tunnelName string) (TunnelError, bool) {
+	lastTunnelErrorsLock.Lock()
+	defer lastTunnelErrorsLock.Unlock()
+	tunnelError, ok := lastTunnelErrors[tunnelName]
+	return tunnelError, ok
+}
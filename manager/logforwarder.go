@@ -0,0 +1,192 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
+)
+
+const (
+	logForwardInterval    = time.Second
+	logForwardBufferLines = 4096
+	logForwardMaxBackoff  = time.Minute * 5
+)
+
+// RFC 5424 severities, by the level of the line.
+var syslogSeverity = map[string]int{
+	"error": 3,
+	"warn":  4,
+	"info":  6,
+	"debug": 7,
+	"trace": 7,
+}
+
+type logForwarder struct {
+	network  string // udp, tcp, or tls
+	address  string
+	hostname string
+	conn     net.Conn
+}
+
+// forwardLogs ships every line of the shared log, from the manager and from the tunnel services alike, to the syslog
+// collector chosen by policy, for fleets where nobody looks at the log on each machine. Lines wait in a bounded
+// buffer while the collector is unreachable, and the oldest are dropped once it fills. Lines from before since were
+// either forwarded by the previous run of the manager or belong to a previous boot.
+func forwardLogs(since time.Time) {
+	server, ok := conf.AdminString("LogForwardingServer")
+	if !ok || len(server) == 0 {
+		return
+	}
+	forwarder, err := newLogForwarder(server)
+	if err != nil {
+		log.Printf("Log forwarder: %v", err)
+		return
+	}
+	log.Printf("Forwarding log to %s://%s", forwarder.network, forwarder.address)
+
+	var pending []ringlogger.Entry
+	dropped := 0
+	cursor := ringlogger.CursorAll
+	backoff := time.Second
+	var retryAt time.Time
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), logForwardInterval)
+		lines, nextCursor, missed, err := ringlogger.Global.FollowFromCursor(ctx, cursor)
+		cancel()
+		if err != nil && err != context.DeadlineExceeded {
+			return
+		}
+		cursor = nextCursor
+		// Lines overwritten before they could be read count as dropped too, since a busy log can wrap around within
+		// the interval.
+		dropped += int(missed)
+		for _, line := range lines {
+			if line.Stamp.Before(since) {
+				continue
+			}
+			pending = append(pending, ringlogger.ParseLine(line))
+		}
+		if len(pending) > logForwardBufferLines {
+			dropped += len(pending) - logForwardBufferLines
+			pending = pending[len(pending)-logForwardBufferLines:]
+		}
+		if (len(pending) > 0 || dropped > 0) && time.Now().After(retryAt) {
+			if dropped > 0 {
+				pending = append([]ringlogger.Entry{{Time: time.Now(), Level: "warn", Source: "MGR", Message: fmt.Sprintf("Log forwarder dropped %d lines while the collector was unreachable or the log wrapped around", dropped)}}, pending...)
+				dropped = 0
+			}
+			sent, err := forwarder.send(pending)
+			pending = pending[sent:]
+			if err != nil {
+				// This is not logged to the ring, since it would be forwarded along with everything else once the
+				// collector is back, and would even be what fills the buffer if it stays down.
+				forwarder.close()
+				retryAt = time.Now().Add(backoff)
+				backoff *= 2
+				if backoff > logForwardMaxBackoff {
+					backoff = logForwardMaxBackoff
+				}
+			} else {
+				backoff = time.Second
+			}
+		}
+	}
+}
+
+func newLogForwarder(server string) (*logForwarder, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "udp" && u.Scheme != "tcp" && u.Scheme != "tls" {
+		return nil, fmt.Errorf("Unsupported scheme %q, rather than udp, tcp, or tls", u.Scheme)
+	}
+	if len(u.Hostname()) == 0 {
+		return nil, fmt.Errorf("No host in %q", server)
+	}
+	address := u.Host
+	if len(u.Port()) == 0 {
+		port := "514"
+		if u.Scheme == "tls" {
+			port = "6514"
+		}
+		address = net.JoinHostPort(u.Hostname(), port)
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+	return &logForwarder{network: u.Scheme, address: address, hostname: hostname}, nil
+}
+
+func (forwarder *logForwarder) connect() error {
+	var err error
+	dialer := &net.Dialer{Timeout: time.Second * 10}
+	if forwarder.network == "tls" {
+		forwarder.conn, err = tls.DialWithDialer(dialer, "tcp", forwarder.address, &tls.Config{MinVersion: tls.VersionTLS12})
+	} else {
+		forwarder.conn, err = dialer.Dial(forwarder.network, forwarder.address)
+	}
+	return err
+}
+
+func (forwarder *logForwarder) close() {
+	if forwarder.conn != nil {
+		forwarder.conn.Close()
+		forwarder.conn = nil
+	}
+}
+
+// send writes the entries in order and returns how many made it. Over TCP and TLS, messages are framed by octet
+// counting, as RFC 5425 and RFC 6587 describe, since a message may itself contain newlines.
+func (forwarder *logForwarder) send(entries []ringlogger.Entry) (int, error) {
+	if forwarder.conn == nil {
+		err := forwarder.connect()
+		if err != nil {
+			return 0, err
+		}
+	}
+	for i, entry := range entries {
+		message := forwarder.format(entry)
+		if forwarder.network != "udp" {
+			message = fmt.Sprintf("%d %s", len(message), message)
+		}
+		forwarder.conn.SetWriteDeadline(time.Now().Add(time.Second * 10))
+		_, err := forwarder.conn.Write([]byte(message))
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(entries), nil
+}
+
+// format renders the entry as an RFC 5424 message from the daemon facility, with the source, such as MGR or TUN, as
+// the process and the tunnel, if there is one, as the message ID.
+func (forwarder *logForwarder) format(entry ringlogger.Entry) string {
+	severity, ok := syslogSeverity[entry.Level]
+	if !ok {
+		severity = 6
+	}
+	const facility = 3
+	source, tunnel := entry.Source, entry.Tunnel
+	if len(source) == 0 {
+		source = "-"
+	}
+	if len(tunnel) == 0 {
+		tunnel = "-"
+	}
+	return fmt.Sprintf("<%d>1 %s %s WireGuard %s %s - %s", facility*8+severity, entry.Time.Format(time.RFC3339Nano), forwarder.hostname, source, tunnel, entry.Message)
+}
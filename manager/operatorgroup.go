@@ -0,0 +1,72 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"golang.org/x/sys/windows"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
+)
+
+var operatorGroup struct {
+	sync.Mutex
+	policy string // What sid was resolved from, so that it is resolved again should the policy change
+	sid    *windows.SID
+}
+
+// resolveOperatorGroup turns the value of the policy, either a SID or the name of a group, such as CORP\VPN Users,
+// into the SID of the group.
+func resolveOperatorGroup(policy string) (*windows.SID, error) {
+	if strings.HasPrefix(strings.ToUpper(policy), "S-") {
+		return windows.StringToSid(policy)
+	}
+	sid, _, accType, err := windows.LookupSID("", policy)
+	if err != nil {
+		return nil, err
+	}
+	if accType != windows.SidTypeGroup && accType != windows.SidTypeWellKnownGroup && accType != windows.SidTypeAlias {
+		return nil, fmt.Errorf("%q is not a group", policy)
+	}
+	return sid, nil
+}
+
+// operatorGroupSid returns the group whose members get the limited UI, which is the group named by the
+// LimitedOperatorGroup policy, or else the builtin Network Configuration Operators. Only successful lookups are
+// cached, so that a domain group whose controller cannot be reached yet is looked up again at the next logon. Should
+// the policy name a group that cannot be resolved, nil is returned, rather than the builtin group, so that nobody is
+// given access that the policy did not mean to give.
+func operatorGroupSid() *windows.SID {
+	operatorGroup.Lock()
+	defer operatorGroup.Unlock()
+	policy, _ := conf.AdminString("LimitedOperatorGroup")
+	policy = strings.TrimSpace(policy)
+	if operatorGroup.sid != nil && operatorGroup.policy == policy {
+		return operatorGroup.sid
+	}
+	var sid *windows.SID
+	var err error
+	if len(policy) == 0 {
+		sid, err = windows.CreateWellKnownSid(windows.WinBuiltinNetworkConfigurationOperatorsSid)
+	} else {
+		sid, err = resolveOperatorGroup(policy)
+	}
+	if err != nil {
+		ringlogger.Error.Printf("Unable to resolve limited operator group %q: %v", policy, err)
+		return nil
+	}
+	if len(policy) > 0 {
+		log.Printf("Limited operator group %q resolved to %v", policy, sid)
+	}
+	operatorGroup.policy = policy
+	operatorGroup.sid = sid
+	return sid
+}
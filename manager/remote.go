@@ -0,0 +1,264 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"golang.zx2c4.com/wireguard/ipc/winpipe"
+
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
+)
+
+// When the RemoteManagement policy is set, the manager also serves the UI of administrators on other machines, which
+// connect to this pipe over SMB, and then authenticate with Kerberos over it, as remoteauth.go describes. A remote UI
+// has one connection rather than the three inherited pipes of a local one, so what is sent over it is framed, and
+// sealed, as either a method call, its response, or a notification.
+const (
+	remotePipeName = `ProtectedPrefix\Administrators\WireGuardManagerRemote`
+	remotePipeSDDL = "O:SYD:P(A;;GA;;;SY)(A;;GA;;;BA)S:(ML;;NWNRNX;;;HI)"
+)
+
+const (
+	remoteFrameMethod byte = iota
+	remoteFrameNotification
+)
+
+const remoteMaxFrame = 1 << 24
+
+// remoteFrameWriter writes each write as a sealed frame of its kind: one byte for the kind, and four for the length.
+type remoteFrameWriter struct {
+	conn    net.Conn
+	lock    *sync.Mutex
+	kind    byte
+	context *remoteContext
+}
+
+func (w *remoteFrameWriter) Write(p []byte) (int, error) {
+	// Frames are sealed in the order in which they are written, since they are checked for being reordered.
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	sealed, err := w.context.seal(p)
+	if err != nil {
+		return 0, err
+	}
+	var header [5]byte
+	header[0] = w.kind
+	binary.LittleEndian.PutUint32(header[1:], uint32(len(sealed)))
+	w.conn.SetWriteDeadline(time.Now().Add(time.Second * 10))
+	_, err = w.conn.Write(append(header[:], sealed...))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// SetWriteDeadline does nothing, since the connection is shared by both kinds of frames, and each write has its own
+// deadline anyway.
+func (w *remoteFrameWriter) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+// readRemoteFrame reads the next frame, returning its kind and what was sealed in it.
+func readRemoteFrame(conn net.Conn, context *remoteContext) (byte, []byte, error) {
+	var header [5]byte
+	_, err := io.ReadFull(conn, header[:])
+	if err != nil {
+		return 0, nil, err
+	}
+	length := binary.LittleEndian.Uint32(header[1:])
+	if length > remoteMaxFrame {
+		return 0, nil, errors.New("Frame too large")
+	}
+	frame := make([]byte, length)
+	_, err = io.ReadFull(conn, frame)
+	if err != nil {
+		return 0, nil, err
+	}
+	frame, err = context.unseal(frame)
+	if err != nil {
+		return 0, nil, err
+	}
+	return header[0], frame, nil
+}
+
+// remoteFrameReader reads the method calls of a remote UI, which are all that it sends.
+type remoteFrameReader struct {
+	conn    net.Conn
+	context *remoteContext
+	pending []byte
+}
+
+func (r *remoteFrameReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		kind, frame, err := readRemoteFrame(r.conn, r.context)
+		if err != nil {
+			return 0, err
+		}
+		if kind != remoteFrameMethod {
+			return 0, errors.New("Unexpected frame")
+		}
+		r.pending = frame
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+var procGetNamedPipeClientComputerNameW = modkernel32.NewProc("GetNamedPipeClientComputerNameW")
+
+func pipeClientComputerName(conn net.Conn) (string, error) {
+	file, ok := conn.(interface{ Fd() uintptr })
+	if !ok {
+		return "", errors.New("Connection has no handle")
+	}
+	var name [256]uint16
+	ret, _, err := procGetNamedPipeClientComputerNameW.Call(file.Fd(), uintptr(unsafe.Pointer(&name[0])), uintptr(len(name)*2))
+	if ret == 0 {
+		return "", err
+	}
+	return windows.UTF16ToString(name[:]), nil
+}
+
+func serveRemote() {
+	sd, err := windows.SecurityDescriptorFromString(remotePipeSDDL)
+	if err != nil {
+		ringlogger.Error.Printf("Unable to create remote management pipe security descriptor: %v", err)
+		return
+	}
+	listener := listenRemotePipe(`\\.\pipe\`+remotePipeName, sd)
+	ringlogger.Info.Println("Listening for remote management")
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			ringlogger.Error.Printf("Unable to accept remote management connection: %v", err)
+			return
+		}
+		go serveRemoteConn(conn)
+	}
+}
+
+func serveRemoteConn(conn net.Conn) {
+	defer printPanic()
+	defer conn.Close()
+	computer, err := pipeClientComputerName(conn)
+	if err != nil {
+		computer = "an unknown machine"
+	}
+	conn.SetDeadline(time.Now().Add(time.Second * 10))
+	context, err := acceptRemoteContext(conn)
+	if err != nil {
+		ringlogger.Warn.Printf("Remote management from %s refused, since it did not authenticate with Kerberos: %v", computer, err)
+		return
+	}
+	defer context.close()
+	conn.SetDeadline(time.Time{})
+	user, err := context.clientName()
+	if err != nil {
+		user = "an unknown user"
+	}
+	if isAdmin, err := context.isAdministrator(); !isAdmin {
+		ringlogger.Warn.Printf("Remote management by %s from %s refused, since they are not an administrator: %v", user, computer, err)
+		return
+	}
+	// Only administrators may connect at all, so the manager's own token stands in for theirs as what marks them as
+	// administrators. Nothing is run with it for them, since what would be, the updater, is refused to remote sessions.
+	var processToken windows.Token
+	err = windows.OpenProcessToken(windows.CurrentProcess(), windows.TOKEN_QUERY|windows.TOKEN_DUPLICATE, &processToken)
+	if err != nil {
+		ringlogger.Error.Printf("Unable to open process token for remote management: %v", err)
+		return
+	}
+	defer processToken.Close()
+	ringlogger.Info.Printf("Remote management session of %s from %s started", user, computer)
+	writeLock := &sync.Mutex{}
+	service := &ManagerService{
+		events:        &remoteFrameWriter{conn, writeLock, remoteFrameNotification, context},
+		elevatedToken: processToken,
+		remote:        true,
+	}
+	service.serve(&remoteFrameReader{conn: conn, context: context}, &remoteFrameWriter{conn, writeLock, remoteFrameMethod, context})
+	ringlogger.Info.Printf("Remote management session of %s from %s ended", user, computer)
+}
+
+// ConnectRemote connects the IPC client to the manager of the machine, which must have the RemoteManagement policy set,
+// as the user.
+func ConnectRemote(machine string) error {
+	if len(machine) == 0 || strings.ContainsAny(machine, `\/`) {
+		return errors.New("Machine name is not valid")
+	}
+	timeout := time.Second * 10
+	// The owner of the pipe is not checked, since that is only as trustworthy as whatever answers for the machine,
+	// which is instead made to prove that it is the machine with Kerberos.
+	conn, err := winpipe.DialPipe(`\\`+machine+`\pipe\`+remotePipeName, &timeout, nil)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	context, err := dialRemoteContext(conn, machine)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("Unable to authenticate %s with Kerberos, which is required for remote management: %w", machine, err)
+	}
+	conn.SetDeadline(time.Time{})
+	methodReader, methodWriter := io.Pipe()
+	notificationReader, notificationWriter := io.Pipe()
+	// Notifications are queued rather than written as they come, since the callbacks that handle them call methods,
+	// whose responses would otherwise be stuck behind the next notification.
+	notifications := make(chan []byte, 1024)
+	go func() {
+		for frame := range notifications {
+			if _, err := notificationWriter.Write(frame); err != nil {
+				break
+			}
+		}
+		for range notifications {
+		}
+	}()
+	go func() {
+		var err error
+		defer func() {
+			close(notifications)
+			methodWriter.CloseWithError(err)
+			notificationWriter.CloseWithError(err)
+			conn.Close()
+		}()
+		for {
+			var kind byte
+			var frame []byte
+			kind, frame, err = readRemoteFrame(conn, context)
+			if err != nil {
+				return
+			}
+			switch kind {
+			case remoteFrameMethod:
+				_, err = methodWriter.Write(frame)
+			case remoteFrameNotification:
+				select {
+				case notifications <- frame:
+				default:
+					err = errors.New("Too many notifications queued")
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	// The context is never deleted, since the UI exits once the connection is lost.
+	InitializeIPCClient(methodReader, &remoteFrameWriter{conn, &sync.Mutex{}, remoteFrameMethod, context}, notificationReader)
+	return nil
+}
@@ -0,0 +1,357 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Whoever answers for a machine over SMB is only authenticated as that machine when the session is Kerberos, so a
+// remote management connection is authenticated again over the pipe itself, with Kerberos alone, which unlike NTLM
+// authenticates the machine, by its HOST service principal, to the user as well as the user to the machine. Everything
+// sent over the connection afterwards is encrypted and checked with the key that the two agree on, so that whatever
+// answers in place of the machine, such as when its name resolves elsewhere, can neither pass for it nor read or change
+// what is sent, even by relaying the authentication to the real one.
+var (
+	modsecur32                     = windows.NewLazySystemDLL("secur32.dll")
+	procAcquireCredentialsHandleW  = modsecur32.NewProc("AcquireCredentialsHandleW")
+	procInitializeSecurityContextW = modsecur32.NewProc("InitializeSecurityContextW")
+	procAcceptSecurityContext      = modsecur32.NewProc("AcceptSecurityContext")
+	procQueryContextAttributesW    = modsecur32.NewProc("QueryContextAttributesW")
+	procQuerySecurityContextToken  = modsecur32.NewProc("QuerySecurityContextToken")
+	procEncryptMessage             = modsecur32.NewProc("EncryptMessage")
+	procDecryptMessage             = modsecur32.NewProc("DecryptMessage")
+	procDeleteSecurityContext      = modsecur32.NewProc("DeleteSecurityContext")
+	procFreeCredentialsHandle      = modsecur32.NewProc("FreeCredentialsHandle")
+	procFreeContextBuffer          = modsecur32.NewProc("FreeContextBuffer")
+)
+
+const (
+	secpkgCredInbound  = 1
+	secpkgCredOutbound = 2
+	securityNativeDrep = 0x10
+	secbufferVersion   = 0
+	secbufferData      = 1
+	secbufferToken     = 2
+	secpkgAttrSizes    = 0
+	secpkgAttrNames    = 1
+	secIContinueNeeded = 0x00090312
+	kerbWrapNoEncrypt  = 0x80000001
+	remoteMaxToken     = 1 << 16
+
+	// The request flags of InitializeSecurityContext and AcceptSecurityContext have the same values, but for integrity,
+	// as do the flags that they return.
+	contextMutualAuth       = 0x2
+	contextReplayDetect     = 0x4
+	contextSequenceDetect   = 0x8
+	contextConfidentiality  = 0x10
+	contextAllocateMemory   = 0x100
+	iscIntegrity            = 0x10000
+	ascIntegrity            = 0x20000
+	contextRequestedFlags   = contextMutualAuth | contextReplayDetect | contextSequenceDetect | contextConfidentiality | contextAllocateMemory
+	contextRequiredReturned = contextMutualAuth | contextReplayDetect | contextSequenceDetect | contextConfidentiality
+)
+
+type secHandle struct {
+	lower uintptr
+	upper uintptr
+}
+
+type secBuffer struct {
+	size   uint32
+	kind   uint32
+	buffer *byte
+}
+
+type secBufferDesc struct {
+	version uint32
+	count   uint32
+	buffers *secBuffer
+}
+
+type secPkgContextSizes struct {
+	maxToken        uint32
+	maxSignature    uint32
+	blockSize       uint32
+	securityTrailer uint32
+}
+
+// remoteContext is the Kerberos security context of a remote management connection, with which its frames are sealed.
+type remoteContext struct {
+	sync.Mutex
+	credentials     secHandle
+	context         secHandle
+	haveCredentials bool
+	haveContext     bool
+	sizes           secPkgContextSizes
+}
+
+func firstByte(b []byte) *byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return &b[0]
+}
+
+// takeContextBuffer returns a copy of a buffer that the security package allocated, which it frees.
+func takeContextBuffer(buffer *secBuffer) []byte {
+	if buffer.buffer == nil {
+		return nil
+	}
+	b := append([]byte(nil), (*[1 << 30]byte)(unsafe.Pointer(buffer.buffer))[:buffer.size:buffer.size]...)
+	procFreeContextBuffer.Call(uintptr(unsafe.Pointer(buffer.buffer)))
+	return b
+}
+
+func writeRemoteToken(conn net.Conn, token []byte) error {
+	var header [4]byte
+	binary.LittleEndian.PutUint32(header[:], uint32(len(token)))
+	_, err := conn.Write(append(header[:], token...))
+	return err
+}
+
+func readRemoteToken(conn net.Conn) ([]byte, error) {
+	var header [4]byte
+	_, err := io.ReadFull(conn, header[:])
+	if err != nil {
+		return nil, err
+	}
+	length := binary.LittleEndian.Uint32(header[:])
+	if length == 0 || length > remoteMaxToken {
+		return nil, errors.New("Authentication token is not valid")
+	}
+	token := make([]byte, length)
+	_, err = io.ReadFull(conn, token)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (ctx *remoteContext) acquireCredentials(use uint32) error {
+	ret, _, _ := procAcquireCredentialsHandleW.Call(0, uintptr(unsafe.Pointer(windows.StringToUTF16Ptr("Kerberos"))), uintptr(use), 0, 0, 0, 0, uintptr(unsafe.Pointer(&ctx.credentials)), 0)
+	if ret != 0 {
+		return windows.Errno(ret)
+	}
+	ctx.haveCredentials = true
+	return nil
+}
+
+func (ctx *remoteContext) querySizes() error {
+	ret, _, _ := procQueryContextAttributesW.Call(uintptr(unsafe.Pointer(&ctx.context)), secpkgAttrSizes, uintptr(unsafe.Pointer(&ctx.sizes)))
+	if ret != 0 {
+		return windows.Errno(ret)
+	}
+	return nil
+}
+
+// dialRemoteContext authenticates the user to the machine, and the machine to the user, over the connection.
+func dialRemoteContext(conn net.Conn, machine string) (*remoteContext, error) {
+	ctx := &remoteContext{}
+	err := ctx.acquireCredentials(secpkgCredOutbound)
+	if err != nil {
+		return nil, err
+	}
+	target, err := windows.UTF16PtrFromString("HOST/" + machine)
+	if err != nil {
+		ctx.close()
+		return nil, err
+	}
+	var input []byte
+	var attributes uint32
+	for {
+		var inputDesc *secBufferDesc
+		var inputContext *secHandle
+		if ctx.haveContext {
+			inputBuffer := secBuffer{uint32(len(input)), secbufferToken, firstByte(input)}
+			inputDesc = &secBufferDesc{secbufferVersion, 1, &inputBuffer}
+			inputContext = &ctx.context
+		}
+		outputBuffer := secBuffer{0, secbufferToken, nil}
+		outputDesc := secBufferDesc{secbufferVersion, 1, &outputBuffer}
+		ret, _, _ := procInitializeSecurityContextW.Call(uintptr(unsafe.Pointer(&ctx.credentials)), uintptr(unsafe.Pointer(inputContext)), uintptr(unsafe.Pointer(target)),
+			contextRequestedFlags|iscIntegrity, 0, securityNativeDrep, uintptr(unsafe.Pointer(inputDesc)), 0, uintptr(unsafe.Pointer(&ctx.context)), uintptr(unsafe.Pointer(&outputDesc)), uintptr(unsafe.Pointer(&attributes)), 0)
+		output := takeContextBuffer(&outputBuffer)
+		if ret != 0 && ret != secIContinueNeeded {
+			ctx.close()
+			return nil, windows.Errno(ret)
+		}
+		ctx.haveContext = true
+		if len(output) > 0 {
+			err = writeRemoteToken(conn, output)
+			if err != nil {
+				ctx.close()
+				return nil, err
+			}
+		}
+		if ret == 0 {
+			break
+		}
+		input, err = readRemoteToken(conn)
+		if err != nil {
+			ctx.close()
+			return nil, err
+		}
+	}
+	if attributes&(contextRequiredReturned|iscIntegrity) != contextRequiredReturned|iscIntegrity {
+		ctx.close()
+		return nil, errors.New("The machine did not authenticate itself")
+	}
+	err = ctx.querySizes()
+	if err != nil {
+		ctx.close()
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// acceptRemoteContext authenticates the user to this machine, and this machine to the user, over the connection.
+func acceptRemoteContext(conn net.Conn) (*remoteContext, error) {
+	ctx := &remoteContext{}
+	err := ctx.acquireCredentials(secpkgCredInbound)
+	if err != nil {
+		return nil, err
+	}
+	var attributes uint32
+	for {
+		input, err := readRemoteToken(conn)
+		if err != nil {
+			ctx.close()
+			return nil, err
+		}
+		var inputContext *secHandle
+		if ctx.haveContext {
+			inputContext = &ctx.context
+		}
+		inputBuffer := secBuffer{uint32(len(input)), secbufferToken, firstByte(input)}
+		inputDesc := secBufferDesc{secbufferVersion, 1, &inputBuffer}
+		outputBuffer := secBuffer{0, secbufferToken, nil}
+		outputDesc := secBufferDesc{secbufferVersion, 1, &outputBuffer}
+		ret, _, _ := procAcceptSecurityContext.Call(uintptr(unsafe.Pointer(&ctx.credentials)), uintptr(unsafe.Pointer(inputContext)), uintptr(unsafe.Pointer(&inputDesc)),
+			contextRequestedFlags|ascIntegrity, securityNativeDrep, uintptr(unsafe.Pointer(&ctx.context)), uintptr(unsafe.Pointer(&outputDesc)), uintptr(unsafe.Pointer(&attributes)), 0)
+		output := takeContextBuffer(&outputBuffer)
+		if ret != 0 && ret != secIContinueNeeded {
+			ctx.close()
+			return nil, windows.Errno(ret)
+		}
+		ctx.haveContext = true
+		if len(output) > 0 {
+			err = writeRemoteToken(conn, output)
+			if err != nil {
+				ctx.close()
+				return nil, err
+			}
+		}
+		if ret == 0 {
+			break
+		}
+	}
+	if attributes&(contextRequiredReturned|ascIntegrity) != contextRequiredReturned|ascIntegrity {
+		ctx.close()
+		return nil, errors.New("The client did not ask for mutual authentication and encryption")
+	}
+	err = ctx.querySizes()
+	if err != nil {
+		ctx.close()
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// clientName returns the name of the user that was authenticated, as DOMAIN\user.
+func (ctx *remoteContext) clientName() (string, error) {
+	var name *uint16
+	ret, _, _ := procQueryContextAttributesW.Call(uintptr(unsafe.Pointer(&ctx.context)), secpkgAttrNames, uintptr(unsafe.Pointer(&name)))
+	if ret != 0 {
+		return "", windows.Errno(ret)
+	}
+	defer procFreeContextBuffer.Call(uintptr(unsafe.Pointer(name)))
+	return windows.UTF16PtrToString(name), nil
+}
+
+// isAdministrator returns whether the user that was authenticated is an administrator of this machine.
+func (ctx *remoteContext) isAdministrator() (bool, error) {
+	var token windows.Token
+	ret, _, _ := procQuerySecurityContextToken.Call(uintptr(unsafe.Pointer(&ctx.context)), uintptr(unsafe.Pointer(&token)))
+	if ret != 0 {
+		return false, windows.Errno(ret)
+	}
+	defer token.Close()
+	administrators, err := windows.CreateWellKnownSid(windows.WinBuiltinAdministratorsSid)
+	if err != nil {
+		return false, err
+	}
+	return token.IsMember(administrators)
+}
+
+// seal encrypts the data, returning the length of the signature, the signature, and the encrypted data.
+func (ctx *remoteContext) seal(data []byte) ([]byte, error) {
+	ctx.Lock()
+	defer ctx.Unlock()
+	sealed := make([]byte, 4+int(ctx.sizes.securityTrailer)+len(data))
+	signature := sealed[4 : 4+ctx.sizes.securityTrailer]
+	payload := sealed[4+ctx.sizes.securityTrailer:]
+	copy(payload, data)
+	buffers := [2]secBuffer{
+		{uint32(len(signature)), secbufferToken, firstByte(signature)},
+		{uint32(len(payload)), secbufferData, firstByte(payload)},
+	}
+	desc := secBufferDesc{secbufferVersion, uint32(len(buffers)), &buffers[0]}
+	ret, _, _ := procEncryptMessage.Call(uintptr(unsafe.Pointer(&ctx.context)), 0, uintptr(unsafe.Pointer(&desc)), 0)
+	if ret != 0 {
+		return nil, windows.Errno(ret)
+	}
+	binary.LittleEndian.PutUint32(sealed, buffers[0].size)
+	copy(sealed[4+buffers[0].size:], payload)
+	return sealed[:4+int(buffers[0].size)+len(payload)], nil
+}
+
+// unseal decrypts what seal returned, in place, checking that it was neither changed, nor replayed, nor reordered.
+func (ctx *remoteContext) unseal(sealed []byte) ([]byte, error) {
+	if len(sealed) < 4 || binary.LittleEndian.Uint32(sealed) > uint32(len(sealed)-4) {
+		return nil, errors.New("Sealed frame is not valid")
+	}
+	signatureSize := binary.LittleEndian.Uint32(sealed)
+	signature := sealed[4 : 4+signatureSize]
+	payload := sealed[4+signatureSize:]
+	buffers := [2]secBuffer{
+		{uint32(len(signature)), secbufferToken, firstByte(signature)},
+		{uint32(len(payload)), secbufferData, firstByte(payload)},
+	}
+	desc := secBufferDesc{secbufferVersion, uint32(len(buffers)), &buffers[0]}
+	var qop uint32
+	ctx.Lock()
+	ret, _, _ := procDecryptMessage.Call(uintptr(unsafe.Pointer(&ctx.context)), uintptr(unsafe.Pointer(&desc)), 0, uintptr(unsafe.Pointer(&qop)))
+	ctx.Unlock()
+	if ret != 0 {
+		return nil, windows.Errno(ret)
+	}
+	if qop == kerbWrapNoEncrypt {
+		return nil, errors.New("Frame was not encrypted")
+	}
+	if buffers[1].buffer == nil {
+		return nil, nil
+	}
+	return (*[1 << 30]byte)(unsafe.Pointer(buffers[1].buffer))[:buffers[1].size:buffers[1].size], nil
+}
+
+func (ctx *remoteContext) close() {
+	if ctx.haveContext {
+		procDeleteSecurityContext.Call(uintptr(unsafe.Pointer(&ctx.context)))
+		ctx.haveContext = false
+	}
+	if ctx.haveCredentials {
+		procFreeCredentialsHandle.Call(uintptr(unsafe.Pointer(&ctx.credentials)))
+		ctx.haveCredentials = false
+	}
+}
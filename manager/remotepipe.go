@@ -0,0 +1,243 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// The pipes that winpipe listens on always reject remote clients, so the remote management pipe is created here
+// instead, without that flag. Its few connections are served with overlapped I/O that is waited upon directly, rather
+// than by the runtime, so that reads and writes may have deadlines and be cancelled when the connection is closed.
+var (
+	modkernel32          = windows.NewLazySystemDLL("kernel32.dll")
+	procCreateNamedPipeW = modkernel32.NewProc("CreateNamedPipeW")
+	procConnectNamedPipe = modkernel32.NewProc("ConnectNamedPipe")
+)
+
+const (
+	pipeAccessDuplex        = 0x3
+	pipeAcceptRemoteClients = 0x0
+	pipeUnlimitedInstances  = 255
+	remotePipeBufferSize    = 4096
+)
+
+type remotePipeAddress string
+
+func (a remotePipeAddress) Network() string { return "pipe" }
+func (a remotePipeAddress) String() string  { return string(a) }
+
+// remotePipeListener creates each instance of the pipe as it accepts a connection to it. Only the first instance is
+// created with the security descriptor, and it fails if the pipe already exists, so that the pipe is never one that
+// something else created.
+type remotePipeListener struct {
+	path               string
+	securityAttributes windows.SecurityAttributes
+	first              bool
+}
+
+func listenRemotePipe(path string, sd *windows.SECURITY_DESCRIPTOR) *remotePipeListener {
+	l := &remotePipeListener{path: path, first: true}
+	l.securityAttributes.Length = uint32(unsafe.Sizeof(l.securityAttributes))
+	l.securityAttributes.SecurityDescriptor = sd
+	return l
+}
+
+func (l *remotePipeListener) Accept() (*remotePipeConn, error) {
+	path16, err := windows.UTF16PtrFromString(l.path)
+	if err != nil {
+		return nil, err
+	}
+	flags := uint32(pipeAccessDuplex | windows.FILE_FLAG_OVERLAPPED)
+	if l.first {
+		flags |= windows.FILE_FLAG_FIRST_PIPE_INSTANCE
+	}
+	ret, _, err := procCreateNamedPipeW.Call(uintptr(unsafe.Pointer(path16)), uintptr(flags), pipeAcceptRemoteClients, pipeUnlimitedInstances,
+		remotePipeBufferSize, remotePipeBufferSize, 0, uintptr(unsafe.Pointer(&l.securityAttributes)))
+	if windows.Handle(ret) == windows.InvalidHandle {
+		return nil, &os.PathError{Op: "open", Path: l.path, Err: err}
+	}
+	l.first = false
+	conn, err := newRemotePipeConn(windows.Handle(ret), l.path)
+	if err != nil {
+		windows.CloseHandle(windows.Handle(ret))
+		return nil, err
+	}
+	// A client that connected before the pipe was waited on is already connected, and signals nothing.
+	ret, _, err = procConnectNamedPipe.Call(uintptr(conn.handle), uintptr(unsafe.Pointer(&conn.read.overlapped)))
+	if ret == 0 && err == windows.ERROR_IO_PENDING {
+		var done uint32
+		err = windows.GetOverlappedResult(conn.handle, &conn.read.overlapped, &done, true)
+	} else if ret != 0 || err == windows.ERROR_PIPE_CONNECTED {
+		err = nil
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// remotePipeOperation is one direction of a connection, whose operations are made one at a time.
+type remotePipeOperation struct {
+	sync.Mutex
+	overlapped   windows.Overlapped
+	deadline     time.Time
+	deadlineLock sync.Mutex
+}
+
+func (o *remotePipeOperation) setDeadline(deadline time.Time) {
+	o.deadlineLock.Lock()
+	o.deadline = deadline
+	o.deadlineLock.Unlock()
+}
+
+type remotePipeConn struct {
+	handle  windows.Handle
+	path    string
+	read    remotePipeOperation
+	write   remotePipeOperation
+	closing uint32
+	running sync.RWMutex // Held for reading by each operation, and for writing when the handle is closed
+	once    sync.Once
+}
+
+func newRemotePipeConn(handle windows.Handle, path string) (*remotePipeConn, error) {
+	conn := &remotePipeConn{handle: handle, path: path}
+	for _, operation := range []*remotePipeOperation{&conn.read, &conn.write} {
+		event, err := windows.CreateEvent(nil, 1, 0, nil)
+		if err != nil {
+			if conn.read.overlapped.HEvent != 0 {
+				windows.CloseHandle(conn.read.overlapped.HEvent)
+			}
+			return nil, err
+		}
+		operation.overlapped.HEvent = event
+	}
+	return conn, nil
+}
+
+// do starts an operation, and waits for it to complete, cancelling it if the deadline passes or the connection is
+// closed first.
+func (c *remotePipeConn) do(operation *remotePipeOperation, start func(*windows.Overlapped) error) (uint32, error) {
+	operation.Lock()
+	defer operation.Unlock()
+	operation.deadlineLock.Lock()
+	deadline := operation.deadline
+	operation.deadlineLock.Unlock()
+	c.running.RLock()
+	defer c.running.RUnlock()
+	if atomic.LoadUint32(&c.closing) != 0 {
+		return 0, os.ErrClosed
+	}
+	timeout := uint32(windows.INFINITE)
+	if !deadline.IsZero() {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return 0, os.ErrDeadlineExceeded
+		}
+		timeout = uint32((remaining + time.Millisecond - 1) / time.Millisecond)
+	}
+	overlapped := &operation.overlapped
+	windows.ResetEvent(overlapped.HEvent)
+	err := start(overlapped)
+	if err != nil && err != windows.ERROR_IO_PENDING {
+		return 0, err
+	}
+	// Closing cancels whatever is running, but not what starts after it does so.
+	if atomic.LoadUint32(&c.closing) != 0 {
+		windows.CancelIoEx(c.handle, overlapped)
+	}
+	timedOut := false
+	if event, _ := windows.WaitForSingleObject(overlapped.HEvent, timeout); event == uint32(windows.WAIT_TIMEOUT) {
+		timedOut = true
+		windows.CancelIoEx(c.handle, overlapped)
+	}
+	var done uint32
+	err = windows.GetOverlappedResult(c.handle, overlapped, &done, true)
+	if err == windows.ERROR_OPERATION_ABORTED {
+		if timedOut {
+			return done, os.ErrDeadlineExceeded
+		}
+		return done, os.ErrClosed
+	}
+	return done, err
+}
+
+func (c *remotePipeConn) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	n, err := c.do(&c.read, func(overlapped *windows.Overlapped) error {
+		return windows.ReadFile(c.handle, p, nil, overlapped)
+	})
+	if err == windows.ERROR_BROKEN_PIPE || err == windows.ERROR_PIPE_NOT_CONNECTED || (err == nil && n == 0) {
+		return 0, io.EOF
+	}
+	return int(n), err
+}
+
+func (c *remotePipeConn) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		n, err := c.do(&c.write, func(overlapped *windows.Overlapped) error {
+			return windows.WriteFile(c.handle, p[written:], nil, overlapped)
+		})
+		written += int(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+func (c *remotePipeConn) Close() error {
+	c.once.Do(func() {
+		atomic.StoreUint32(&c.closing, 1)
+		windows.CancelIoEx(c.handle, nil)
+		c.running.Lock()
+		defer c.running.Unlock()
+		windows.CloseHandle(c.handle)
+		windows.CloseHandle(c.read.overlapped.HEvent)
+		windows.CloseHandle(c.write.overlapped.HEvent)
+	})
+	return nil
+}
+
+// Fd returns the handle of the pipe, for the functions that ask about its client.
+func (c *remotePipeConn) Fd() uintptr {
+	return uintptr(c.handle)
+}
+
+func (c *remotePipeConn) LocalAddr() net.Addr  { return remotePipeAddress(c.path) }
+func (c *remotePipeConn) RemoteAddr() net.Addr { return remotePipeAddress(c.path) }
+
+// The deadlines apply to the operations that start after they are set, which is all that the remote management
+// connections need, since they only set them between operations.
+func (c *remotePipeConn) SetReadDeadline(deadline time.Time) error {
+	c.read.setDeadline(deadline)
+	return nil
+}
+
+func (c *remotePipeConn) SetWriteDeadline(deadline time.Time) error {
+	c.write.setDeadline(deadline)
+	return nil
+}
+
+func (c *remotePipeConn) SetDeadline(deadline time.Time) error {
+	c.SetReadDeadline(deadline)
+	return c.SetWriteDeadline(deadline)
+}
+
+var _ net.Conn = (*remotePipeConn)(nil)
@@ -0,0 +1,171 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"golang.zx2c4.com/wireguard/device"
+	"golang.zx2c4.com/wireguard/tun"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/tunnel/firewall"
+	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
+	"golang.zx2c4.com/wireguard/windows/version"
+)
+
+// The self-test does what a tunnel service does when it starts, on an interface of its own, so that what fails can
+// be told apart from what is wrong with a configuration. Like that of wg(8), the report is never translated, since it
+// is for whoever is asked for help, not for the user. The interface is named with a space, which no tunnel name may
+// have, so that it never replaces the interface of a tunnel.
+const selfTestInterfaceName = "WireGuard Self-Test"
+
+// Only one self-test may run at a time, since the firewall can only be enabled once in a process.
+var selfTestLock sync.Mutex
+
+// The DNS server set on the interface of the self-test is of the documentation range, so that it never answers.
+var selfTestDNSServer = net.IPv4(192, 0, 2, 53)
+
+type SelfTestCheck struct {
+	Name   string
+	Passed bool
+	Detail string
+}
+
+type SelfTestReport struct {
+	Time    time.Time
+	Version string
+	Tunnel  string // The tunnel whose peers were checked for reachability, or empty
+	Checks  []SelfTestCheck
+}
+
+func (report *SelfTestReport) Passed() bool {
+	for _, check := range report.Checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+func (report *SelfTestReport) add(name string, err error, detail string) bool {
+	check := SelfTestCheck{Name: name, Passed: err == nil, Detail: detail}
+	if err != nil {
+		check.Detail = err.Error()
+	}
+	report.Checks = append(report.Checks, check)
+	return check.Passed
+}
+
+// WriteText writes the report as one line for each check, followed by whether all of them passed.
+func (report *SelfTestReport) WriteText(out io.Writer) {
+	fmt.Fprintf(out, "WireGuard self-test, %s, %s\n", report.Version, report.Time.Format(time.RFC3339))
+	for _, check := range report.Checks {
+		result := "PASS"
+		if !check.Passed {
+			result = "FAIL"
+		}
+		fmt.Fprintf(out, "%s  %s", result, check.Name)
+		if len(check.Detail) > 0 {
+			fmt.Fprintf(out, ": %s", check.Detail)
+		}
+		fmt.Fprintln(out)
+	}
+	if report.Passed() {
+		fmt.Fprintf(out, "All %d checks passed\n", len(report.Checks))
+	} else {
+		failed := 0
+		for _, check := range report.Checks {
+			if !check.Passed {
+				failed++
+			}
+		}
+		fmt.Fprintf(out, "%d of %d checks failed\n", failed, len(report.Checks))
+	}
+}
+
+// RunSelfTest creates an interface, enables the firewall for it, without restricting anything, and sets its DNS
+// servers, as a tunnel service would, before removing it again. If tunnelName is not empty, the tunnel must be running,
+// and each of its peers must have completed a handshake recently. It must be run as an administrator, and it must not
+// be run by a process that enables the firewall itself.
+func RunSelfTest(tunnelName string) *SelfTestReport {
+	selfTestLock.Lock()
+	defer selfTestLock.Unlock()
+	report := &SelfTestReport{Time: time.Now(), Version: version.UserAgent(), Tunnel: tunnelName}
+	dev, err := tun.CreateTUN(selfTestInterfaceName, 0)
+	if report.add("Creating Wintun interface", err, "") {
+		nativeTun := dev.(*tun.NativeTun)
+		// The driver may be unloaded as soon as its last interface is removed, so it is asked for its version first.
+		wintunVersion, err := nativeTun.RunningVersion()
+		report.add("Loading Wintun driver", err, fmt.Sprintf("Wintun/%d.%d", (wintunVersion>>16)&0xffff, wintunVersion&0xffff))
+		luid := winipcfg.LUID(nativeTun.LUID())
+		selfTestFirewall(report, nativeTun.LUID())
+		selfTestDNS(report, luid)
+		err = dev.Close()
+		report.add("Removing Wintun interface", err, "")
+	}
+	if len(tunnelName) > 0 {
+		selfTestTunnel(report, tunnelName)
+	}
+	return report
+}
+
+func selfTestFirewall(report *SelfTestReport, luid uint64) {
+	err := firewall.EnableFirewall(luid, true, nil)
+	if report.add("Installing firewall sublayer", err, "") {
+		firewall.DisableFirewall()
+	}
+}
+
+func selfTestDNS(report *SelfTestReport, luid winipcfg.LUID) {
+	err := luid.SetDNS([]net.IP{selfTestDNSServer})
+	if err == nil {
+		var servers []net.IP
+		servers, err = luid.DNS()
+		if err == nil {
+			err = fmt.Errorf("Interface has DNS servers %v rather than %v", servers, selfTestDNSServer)
+			for _, server := range servers {
+				if server.Equal(selfTestDNSServer) {
+					err = nil
+				}
+			}
+		}
+	}
+	report.add("Setting DNS servers", err, "")
+}
+
+func selfTestTunnel(report *SelfTestReport, tunnelName string) {
+	name := fmt.Sprintf("Tunnel %s", tunnelName)
+	if !conf.TunnelNameIsValid(tunnelName) {
+		report.add(name, errors.New("Tunnel name is not valid"), "")
+		return
+	}
+	config, err := QueryRuntimeConfig(&conf.Config{Name: tunnelName})
+	if err != nil {
+		report.add(name, fmt.Errorf("Unable to query running tunnel: %w", err), "")
+		return
+	}
+	report.add(name, nil, fmt.Sprintf("running, with %d peers", len(config.Peers)))
+	for i := range config.Peers {
+		peer := &config.Peers[i]
+		name := fmt.Sprintf("Peer %s", peer.PublicKey.String())
+		if peer.LastHandshakeTime == 0 {
+			report.add(name, fmt.Errorf("No handshake yet, with endpoint %s", endpointOrNone(&peer.Endpoint)), "")
+			continue
+		}
+		age := time.Since(time.Unix(0, int64(peer.LastHandshakeTime))).Round(time.Second)
+		if age > device.RejectAfterTime {
+			report.add(name, fmt.Errorf("Last handshake %v ago, with endpoint %s", age, endpointOrNone(&peer.Endpoint)), "")
+		} else {
+			report.add(name, nil, fmt.Sprintf("handshake %v ago, received %d bytes", age, peer.RxBytes))
+		}
+	}
+}
@@ -0,0 +1,43 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelfTestReport(t *testing.T) {
+	report := &SelfTestReport{Time: time.Date(2020, 11, 25, 12, 30, 0, 0, time.UTC), Version: "WireGuard/0.3.1"}
+	report.add("Creating Wintun interface", nil, "")
+	report.add("Loading Wintun driver", nil, "Wintun/0.9")
+	if !report.Passed() {
+		t.Error("Report of passed checks did not pass")
+	}
+	var out strings.Builder
+	report.WriteText(&out)
+	expected := "WireGuard self-test, WireGuard/0.3.1, 2020-11-25T12:30:00Z\n" +
+		"PASS  Creating Wintun interface\n" +
+		"PASS  Loading Wintun driver: Wintun/0.9\n" +
+		"All 2 checks passed\n"
+	if out.String() != expected {
+		t.Errorf("Report was written as %q, rather than %q", out.String(), expected)
+	}
+
+	if report.add("Setting DNS servers", errors.New("Access is denied."), "unused") {
+		t.Error("Failed check was added as passed")
+	}
+	if report.Passed() {
+		t.Error("Report with a failed check passed")
+	}
+	out.Reset()
+	report.WriteText(&out)
+	if !strings.HasSuffix(out.String(), "FAIL  Setting DNS servers: Access is denied.\n1 of 3 checks failed\n") {
+		t.Errorf("Report with a failed check was written as %q", out.String())
+	}
+}
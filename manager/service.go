@@ -22,7 +22,9 @@ import (
 	"golang.org/x/sys/windows/svc"
 
 	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/crashdump"
 	"golang.zx2c4.com/wireguard/windows/elevate"
+	"golang.zx2c4.com/wireguard/windows/l18n"
 	"golang.zx2c4.com/wireguard/windows/ringlogger"
 	"golang.zx2c4.com/wireguard/windows/services"
 	"golang.zx2c4.com/wireguard/windows/version"
@@ -32,15 +34,26 @@ type managerService struct{}
 
 func printPanic() {
 	if x := recover(); x != nil {
-		for _, line := range append([]string{fmt.Sprint(x)}, strings.Split(string(debug.Stack()), "\n")...) {
-			if len(strings.TrimSpace(line)) > 0 {
-				log.Println(line)
-			}
+		if err := crashdump.WriteMinidump(); err != nil {
+			log.Printf("Unable to write minidump: %v", err)
+		}
+		if err := saveCrashState(); err != nil {
+			log.Printf("Unable to save state for restart: %v", err)
 		}
+		logPanic(x)
 		panic(x)
 	}
 }
 
+// logPanic logs x, as recovered, along with the stack of the goroutine that panicked.
+func logPanic(x interface{}) {
+	for _, line := range append([]string{fmt.Sprint(x)}, strings.Split(string(debug.Stack()), "\n")...) {
+		if len(strings.TrimSpace(line)) > 0 {
+			log.Println(line)
+		}
+	}
+}
+
 func (service *managerService) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (svcSpecificEC bool, exitCode uint32) {
 	changes <- svc.Status{State: svc.StartPending}
 
@@ -56,12 +69,16 @@ func (service *managerService) Execute(args []string, r <-chan svc.ChangeRequest
 		changes <- svc.Status{State: svc.StopPending}
 	}()
 
+	started := time.Now()
 	err = ringlogger.InitGlobalLogger("MGR")
 	if err != nil {
 		serviceError = services.ErrorRingloggerOpen
 		return
 	}
 	defer printPanic()
+	if err := crashdump.Install("MGR"); err != nil {
+		ringlogger.Warn.Printf("Unable to install crash handler: %v", err)
+	}
 
 	log.Println("Starting", version.UserAgent())
 
@@ -78,6 +95,7 @@ func (service *managerService) Execute(args []string, r <-chan svc.ChangeRequest
 	}
 
 	moveConfigsFromLegacyStore()
+	restoreCrashState()
 
 	err = trackExistingTunnels()
 	if err != nil {
@@ -92,7 +110,6 @@ func (service *managerService) Execute(args []string, r <-chan svc.ChangeRequest
 	aliveSessions := make(map[uint32]bool)
 	procsLock := sync.Mutex{}
 	stoppingManager := false
-	operatorGroupSid, _ := windows.CreateWellKnownSid(windows.WinBuiltinNetworkConfigurationOperatorsSid)
 
 	startProcess := func(session uint32) {
 		defer func() {
@@ -109,7 +126,11 @@ func (service *managerService) Execute(args []string, r <-chan svc.ChangeRequest
 		}
 		isAdmin := elevate.TokenIsElevatedOrElevatable(userToken)
 		isOperator := false
-		if !isAdmin && conf.AdminBool("LimitedOperatorUI") && operatorGroupSid != nil {
+		var operatorGroup *windows.SID
+		if !isAdmin && conf.AdminBool("LimitedOperatorUI") {
+			operatorGroup = operatorGroupSid()
+		}
+		if operatorGroup != nil {
 			linkedToken, err := userToken.GetLinkedToken()
 			var impersonationToken windows.Token
 			if err == nil {
@@ -119,7 +140,7 @@ func (service *managerService) Execute(args []string, r <-chan svc.ChangeRequest
 				err = windows.DuplicateTokenEx(userToken, windows.TOKEN_QUERY, nil, windows.SecurityImpersonation, windows.TokenImpersonation, &impersonationToken)
 			}
 			if err == nil {
-				isOperator, err = impersonationToken.IsMember(operatorGroupSid)
+				isOperator, err = impersonationToken.IsMember(operatorGroup)
 				isOperator = isOperator && err == nil
 				impersonationToken.Close()
 			}
@@ -130,13 +151,13 @@ func (service *managerService) Execute(args []string, r <-chan svc.ChangeRequest
 		}
 		user, err := userToken.GetTokenUser()
 		if err != nil {
-			log.Printf("Unable to lookup user from token: %v", err)
+			ringlogger.Error.Printf("Unable to lookup user from token: %v", err)
 			userToken.Close()
 			return
 		}
 		username, domain, accType, err := user.User.Sid.LookupAccount("")
 		if err != nil {
-			log.Printf("Unable to lookup username from sid: %v", err)
+			ringlogger.Error.Printf("Unable to lookup username from sid: %v", err)
 			userToken.Close()
 			return
 		}
@@ -146,23 +167,37 @@ func (service *managerService) Execute(args []string, r <-chan svc.ChangeRequest
 		}
 		userProfileDirectory, _ := userToken.GetUserProfileDirectory()
 		var elevatedToken, runToken windows.Token
+		var runAttributes *syscall.SecurityAttributes
+		brokered := false
 		if isAdmin {
 			if userToken.IsElevated() {
 				elevatedToken = userToken
+				runToken = elevatedToken
 			} else {
 				elevatedToken, err = userToken.GetLinkedToken()
-				userToken.Close()
+				if err == nil && !elevatedToken.IsElevated() {
+					elevatedToken.Close()
+					err = errors.New("Linked token is not elevated")
+				}
 				if err != nil {
-					log.Printf("Unable to elevate token: %v", err)
+					userToken.Close()
+					ringlogger.Error.Printf("Unable to elevate token: %v", err)
 					return
 				}
-				if !elevatedToken.IsElevated() {
-					elevatedToken.Close()
-					log.Println("Linked token is not elevated")
-					return
+				runToken = elevatedToken
+				if standardUserUI() {
+					uiToken, err := newStandardUserUIToken(userToken)
+					if err == nil {
+						defer elevatedToken.Close()
+						defer runtime.KeepAlive(uiToken)
+						runToken, runAttributes = uiToken.token, uiToken.objectAttributes
+						brokered = true
+					} else {
+						ringlogger.Warn.Printf("Unable to make a standard user token for the UI, so running it elevated: %v", err)
+					}
 				}
+				userToken.Close()
 			}
-			runToken = elevatedToken
 		} else {
 			runToken = userToken
 		}
@@ -193,25 +228,27 @@ func (service *managerService) Execute(args []string, r <-chan svc.ChangeRequest
 			runtime.LockOSThread()
 			ourReader, theirReader, theirReaderStr, ourWriter, theirWriter, theirWriterStr, err := inheritableSocketpairEmulation()
 			if err != nil {
-				log.Printf("Unable to create two inheritable RPC pipes: %v", err)
+				ringlogger.Error.Printf("Unable to create two inheritable RPC pipes: %v", err)
 				return
 			}
 			ourEvents, theirEvents, theirEventStr, err := inheritableEvents()
 			if err != nil {
-				log.Printf("Unable to create one inheritable events pipe: %v", err)
+				ringlogger.Error.Printf("Unable to create one inheritable events pipe: %v", err)
 				return
 			}
-			IPCServerListen(ourReader, ourWriter, ourEvents, elevatedToken)
+			IPCServerListen(ourReader, ourWriter, ourEvents, elevatedToken, session, brokered)
 			theirLogMapping, theirLogMappingHandle, err := ringlogger.Global.ExportInheritableMappingHandleStr()
 			if err != nil {
-				log.Printf("Unable to export inheritable mapping handle for logging: %v", err)
+				ringlogger.Error.Printf("Unable to export inheritable mapping handle for logging: %v", err)
 				return
 			}
 
 			log.Printf("Starting UI process for user ‘%s@%s’ for session %d", username, domain, session)
 			attr := &os.ProcAttr{
 				Sys: &syscall.SysProcAttr{
-					Token: syscall.Token(runToken),
+					Token:             syscall.Token(runToken),
+					ProcessAttributes: runAttributes,
+					ThreadAttributes:  runAttributes,
 				},
 				Files: []*os.File{devNull, devNull, devNull},
 				Dir:   userProfileDirectory,
@@ -233,7 +270,7 @@ func (service *managerService) Execute(args []string, r <-chan svc.ChangeRequest
 				ourReader.Close()
 				ourWriter.Close()
 				ourEvents.Close()
-				log.Printf("Unable to start manager UI process for user '%s@%s' for session %d: %v", username, domain, session, err)
+				ringlogger.Error.Printf("Unable to start manager UI process for user '%s@%s' for session %d: %v", username, domain, session, err)
 				return
 			}
 
@@ -249,7 +286,7 @@ func (service *managerService) Execute(args []string, r <-chan svc.ChangeRequest
 				const STATUS_DLL_INIT_FAILED_LOGOFF = 0xC000026B
 				sessionIsDead = exitCode == STATUS_DLL_INIT_FAILED_LOGOFF
 			} else {
-				log.Printf("Unable to wait for UI process for user '%s@%s' for session %d: %v", username, domain, session, err)
+				ringlogger.Error.Printf("Unable to wait for UI process for user '%s@%s' for session %d: %v", username, domain, session, err)
 			}
 
 			procsLock.Lock()
@@ -274,8 +311,20 @@ func (service *managerService) Execute(args []string, r <-chan svc.ChangeRequest
 		}()
 	}
 
+	if settings, err := conf.LoadSettings(); err == nil {
+		l18n.SetLanguageOverride(settings.Language)
+	}
+
 	time.AfterFunc(time.Second*10, cleanupStaleWintunInterfaces)
 	go checkForUpdates()
+	go forwardLogs(started)
+	go auditConfigurations()
+	go serveCommands()
+	go serveTunnelCommands()
+	if conf.AdminBool("RemoteManagement") {
+		go serveRemote()
+	}
+	go runScheduledBackups()
 
 	var sessionsPointer *windows.WTS_SESSION_INFO
 	var count uint32
@@ -325,7 +374,7 @@ loop:
 				}
 				sessionNotification := (*windows.WTSSESSION_NOTIFICATION)(unsafe.Pointer(c.EventData))
 				if uintptr(sessionNotification.Size) != unsafe.Sizeof(*sessionNotification) {
-					log.Printf("Unexpected size of WTSSESSION_NOTIFICATION: %d", sessionNotification.Size)
+					ringlogger.Error.Printf("Unexpected size of WTSSESSION_NOTIFICATION: %d", sessionNotification.Size)
 					continue
 				}
 				if c.EventType == windows.WTS_SESSION_LOGOFF {
@@ -347,7 +396,7 @@ loop:
 				}
 
 			default:
-				log.Printf("Unexpected service control request #%d", c)
+				ringlogger.Error.Printf("Unexpected service control request #%d", c)
 			}
 		}
 	}
@@ -364,7 +413,7 @@ loop:
 	if uninstall {
 		err = UninstallManager()
 		if err != nil {
-			log.Printf("Unable to uninstall manager when quitting: %v", err)
+			ringlogger.Error.Printf("Unable to uninstall manager when quitting: %v", err)
 		}
 	}
 	return
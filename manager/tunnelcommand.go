@@ -0,0 +1,123 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"encoding/gob"
+	"errors"
+	"net"
+	"os"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"golang.zx2c4.com/wireguard/ipc/winpipe"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
+)
+
+// Jump list tasks run /activatetunnel and /deactivatetunnel unelevated, so they can neither talk to an elevated UI
+// nor be trusted to have anything done without the user agreeing, since any other program of the user could do what
+// they do. They instead ask the manager, over this pipe, which interactive users may connect to, to pass the command
+// on to the UI of their session, which asks the user before carrying it out with its own rights.
+const (
+	tunnelCommandPipePath = `\\.\pipe\WireGuardTunnelCommand`
+	tunnelCommandPipeSDDL = "O:SYD:P(A;;GA;;;SY)(A;;GRGW;;;IU)"
+)
+
+type tunnelCommandRequest struct {
+	Tunnel   string
+	Activate bool
+}
+
+var procGetNamedPipeClientSessionId = windows.NewLazySystemDLL("kernel32.dll").NewProc("GetNamedPipeClientSessionId")
+
+func pipeClientSession(conn net.Conn) (uint32, error) {
+	file, ok := conn.(interface{ Fd() uintptr })
+	if !ok {
+		return 0, errors.New("Connection has no handle")
+	}
+	var session uint32
+	ret, _, err := procGetNamedPipeClientSessionId.Call(file.Fd(), uintptr(unsafe.Pointer(&session)))
+	if ret == 0 {
+		return 0, err
+	}
+	return session, nil
+}
+
+func serveTunnelCommands() {
+	sd, err := windows.SecurityDescriptorFromString(tunnelCommandPipeSDDL)
+	if err != nil {
+		ringlogger.Error.Printf("Unable to create tunnel command pipe security descriptor: %v", err)
+		return
+	}
+	listener, err := winpipe.ListenPipe(tunnelCommandPipePath, &winpipe.PipeConfig{SecurityDescriptor: sd})
+	if err != nil {
+		ringlogger.Error.Printf("Unable to listen for tunnel commands: %v", err)
+		return
+	}
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			ringlogger.Error.Printf("Unable to accept tunnel command connection: %v", err)
+			return
+		}
+		go serveTunnelCommand(conn)
+	}
+}
+
+func serveTunnelCommand(conn net.Conn) {
+	defer printPanic()
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Second * 5))
+	var request tunnelCommandRequest
+	err := gob.NewDecoder(conn).Decode(&request)
+	if err != nil {
+		return
+	}
+	session, err := pipeClientSession(conn)
+	if err == nil && !conf.TunnelNameIsValid(request.Tunnel) {
+		err = errors.New("Tunnel name is not valid")
+	}
+	if err == nil && IPCServerNotifyTunnelCommand(session, request.Tunnel, request.Activate) == 0 {
+		err = errors.New("WireGuard is not running")
+	}
+	gob.NewEncoder(conn).Encode(commandResponse{Error: errToString(err)})
+}
+
+// ForwardTunnelCommand asks the UI of this session, through the manager, to activate or deactivate the named tunnel,
+// once the user agrees to, as is done by jump list tasks.
+func ForwardTunnelCommand(activate bool, tunnelName string) error {
+	localSystem, err := windows.CreateWellKnownSid(windows.WinLocalSystemSid)
+	if err != nil {
+		return err
+	}
+	timeout := time.Second * 5
+	conn, err := winpipe.DialPipe(tunnelCommandPipePath, &timeout, localSystem)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return errors.New("The manager service is not running")
+		}
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Second * 5))
+	err = gob.NewEncoder(conn).Encode(tunnelCommandRequest{tunnelName, activate})
+	if err != nil {
+		return err
+	}
+	var response commandResponse
+	err = gob.NewDecoder(conn).Decode(&response)
+	if err != nil {
+		return err
+	}
+	if len(response.Error) > 0 {
+		return errors.New(response.Error)
+	}
+	return nil
+}
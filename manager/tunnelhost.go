@@ -0,0 +1,279 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/services"
+	"golang.zx2c4.com/wireguard/windows/tunnel"
+)
+
+// When the ConsolidatedTunnelService policy is set, tunnels of the configuration store that do not route all traffic
+// are run by the tunnel host, a single service that the manager installs when the first of them starts and removes
+// when the last of them stops, rather than by services of their own.
+var (
+	hostedTunnels     = make(map[string]bool) // Those that the host reports as not yet stopped
+	hostedTunnelsLock sync.Mutex
+
+	tunnelHostLock        sync.Mutex // Serializes starting and stopping the host with the requests made of it
+	tunnelHostTracked     bool
+	tunnelHostTrackedLock sync.Mutex
+)
+
+func isHosted(tunnelName string) bool {
+	hostedTunnelsLock.Lock()
+	defer hostedTunnelsLock.Unlock()
+	return hostedTunnels[tunnelName]
+}
+
+// shouldHost returns whether the tunnel of the configuration at the path is to be run by the tunnel host.
+func shouldHost(configPath string) bool {
+	if !conf.AdminBool("ConsolidatedTunnelService") || !conf.PathIsEncrypted(configPath) {
+		return false
+	}
+	config, err := conf.LoadFromPath(configPath)
+	if err != nil {
+		return false
+	}
+	return !tunnel.RoutesAllTraffic(config)
+}
+
+func tunnelHostRunning() bool {
+	m, err := serviceManager()
+	if err != nil {
+		return false
+	}
+	service, err := m.OpenService(services.TunnelHostServiceName)
+	if err != nil {
+		return false
+	}
+	defer service.Close()
+	status, err := service.Query()
+	return err == nil && (status.State == svc.Running || status.State == svc.StartPending)
+}
+
+// startTunnelHost installs and starts the tunnel host, unless it is already running, and returns once it is.
+func startTunnelHost() error {
+	m, err := serviceManager()
+	if err != nil {
+		return err
+	}
+	for {
+		state, err := deleteStoppedService(m, services.TunnelHostServiceName)
+		if err != nil {
+			return err
+		}
+		if state == svc.Running {
+			trackTunnelHost()
+			return nil
+		} else if state == svc.Stopped {
+			break
+		}
+		time.Sleep(time.Second / 3)
+	}
+
+	path, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	config := mgr.Config{
+		ServiceType:  windows.SERVICE_WIN32_OWN_PROCESS,
+		StartType:    mgr.StartAutomatic,
+		ErrorControl: mgr.ErrorNormal,
+		Dependencies: []string{"Nsi", "TcpIp"},
+		DisplayName:  "WireGuard Tunnel Host",
+		SidType:      windows.SERVICE_SID_TYPE_UNRESTRICTED,
+	}
+	service, err := m.CreateService(services.TunnelHostServiceName, path, config, "/tunnelhostservice")
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+	err = setRequiredPrivileges(service, services.TunnelServicePrivileges)
+	if err != nil {
+		service.Delete()
+		return err
+	}
+	err = service.Start()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < 90; i++ {
+		var status windows.SERVICE_STATUS
+		err := windows.QueryServiceStatus(service.Handle, &status)
+		if err != nil {
+			return err
+		}
+		switch svc.State(status.CurrentState) {
+		case svc.Running:
+			trackTunnelHost()
+			return nil
+		case svc.Stopped:
+			if err := errorOfExitCodes(status.Win32ExitCode, status.ServiceSpecificExitCode); err != nil {
+				return err
+			}
+			return errors.New("The tunnel host stopped while starting")
+		}
+		time.Sleep(time.Second / 3)
+	}
+	return errors.New("Timed out waiting for the tunnel host to start")
+}
+
+func stopTunnelHost() error {
+	m, err := serviceManager()
+	if err != nil {
+		return err
+	}
+	service, err := m.OpenService(services.TunnelHostServiceName)
+	if err != nil {
+		return err
+	}
+	service.Control(svc.Stop)
+	err = service.Delete()
+	err2 := service.Close()
+	if err != nil && err != windows.ERROR_SERVICE_MARKED_FOR_DELETE {
+		return err
+	}
+	return err2
+}
+
+func installHostedTunnel(tunnelName, configPath string) error {
+	tunnelHostLock.Lock()
+	defer tunnelHostLock.Unlock()
+	err := startTunnelHost()
+	if err != nil {
+		return fmt.Errorf("Unable to start the tunnel host: %w", err)
+	}
+	hostedTunnelsLock.Lock()
+	hostedTunnels[tunnelName] = true
+	hostedTunnelsLock.Unlock()
+	err = tunnel.HostStart(configPath)
+	if err != nil {
+		hostedTunnelsLock.Lock()
+		delete(hostedTunnels, tunnelName)
+		hostedTunnelsLock.Unlock()
+		return err
+	}
+	return nil
+}
+
+func uninstallHostedTunnel(tunnelName string) error {
+	tunnelHostLock.Lock()
+	defer tunnelHostLock.Unlock()
+	running, err := tunnel.HostStop(tunnelName)
+	if err != nil {
+		return err
+	}
+	if running == 0 {
+		log.Println("Stopping the tunnel host, which has no tunnels left")
+		return stopTunnelHost()
+	}
+	return nil
+}
+
+// trackTunnelHost starts following the states of the tunnels of the host, unless that is being done already. Should
+// the connection to the host be lost, the tunnels that it had not reported as stopped are marked stopped with an
+// error, and the connection is made again if the host is still running, or once it is started again.
+func trackTunnelHost() {
+	tunnelHostTrackedLock.Lock()
+	defer tunnelHostTrackedLock.Unlock()
+	if tunnelHostTracked {
+		return
+	}
+	tunnelHostTracked = true
+	go func() {
+		defer printPanic()
+		for {
+			err := tunnel.WatchHost(onTunnelHostEvent)
+
+			hostedTunnelsLock.Lock()
+			lost := hostedTunnels
+			hostedTunnels = make(map[string]bool)
+			hostedTunnelsLock.Unlock()
+			for tunnelName := range lost {
+				trackedTunnelsLock.Lock()
+				delete(trackedTunnels, tunnelName)
+				trackedTunnelsLock.Unlock()
+				lostErr := fmt.Errorf("Lost connection to the tunnel host: %w", err)
+				recordTunnelError(tunnelName, lostErr)
+				IPCServerNotifyTunnelChange(tunnelName, TunnelStopped, lostErr)
+			}
+
+			tunnelHostTrackedLock.Lock()
+			if !tunnelHostRunning() {
+				tunnelHostTracked = false
+				tunnelHostTrackedLock.Unlock()
+				log.Println("Tunnel host tracker finished")
+				return
+			}
+			tunnelHostTrackedLock.Unlock()
+			time.Sleep(time.Second)
+		}
+	}()
+}
+
+// stopIdleTunnelHost removes the tunnel host once the last of its tunnels has stopped on its own, unless another has
+// been started in it since.
+func stopIdleTunnelHost() {
+	defer printPanic()
+	tunnelHostLock.Lock()
+	defer tunnelHostLock.Unlock()
+	hostedTunnelsLock.Lock()
+	idle := len(hostedTunnels) == 0
+	hostedTunnelsLock.Unlock()
+	if !idle || !tunnelHostRunning() {
+		return
+	}
+	log.Println("Stopping the tunnel host, which has no tunnels left")
+	if err := stopTunnelHost(); err != nil {
+		log.Printf("Unable to stop the tunnel host: %v", err)
+	}
+}
+
+func onTunnelHostEvent(event tunnel.HostEvent) {
+	state := svcStateToTunState(event.State)
+	var tunnelError error
+	if state == TunnelStopped {
+		tunnelError = errorOfExitCodes(event.Win32ExitCode, event.ServiceSpecificExitCode)
+	}
+	if tunnelError != nil {
+		recordTunnelError(event.Tunnel, tunnelError)
+	} else if state == TunnelStarted {
+		clearTunnelError(event.Tunnel)
+	}
+	hostedTunnelsLock.Lock()
+	if state == TunnelStopped {
+		delete(hostedTunnels, event.Tunnel)
+	} else {
+		hostedTunnels[event.Tunnel] = true
+	}
+	hostedTunnelsLock.Unlock()
+	trackedTunnelsLock.Lock()
+	lastState, found := trackedTunnels[event.Tunnel]
+	if state == TunnelStopped {
+		delete(trackedTunnels, event.Tunnel)
+	} else {
+		trackedTunnels[event.Tunnel] = state
+	}
+	trackedTunnelsLock.Unlock()
+	if !found || lastState != state {
+		IPCServerNotifyTunnelChange(event.Tunnel, state, tunnelError)
+	}
+	if state == TunnelStopped && event.Running == 0 {
+		go stopIdleTunnelHost()
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"golang.org/x/sys/windows/svc/mgr"
 
 	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
 	"golang.zx2c4.com/wireguard/windows/services"
 )
 
@@ -39,8 +40,17 @@ func trackExistingTunnels() error {
 		if err != nil {
 			continue
 		}
+		// Services installed by older versions have every privilege of Local System, which this takes from them the
+		// next time that they start.
+		err = setRequiredPrivileges(service, services.TunnelServicePrivileges)
+		if err != nil {
+			ringlogger.Warn.Printf("[%s] Unable to limit the privileges of the tunnel service: %v", name, err)
+		}
 		go trackTunnelService(name, service)
 	}
+	if tunnelHostRunning() {
+		trackTunnelHost()
+	}
 	return nil
 }
 
@@ -82,6 +92,23 @@ func trackedTunnelsGlobalState() (state TunnelState) {
 	return
 }
 
+// errorOfExitCodes returns the error that a stopped tunnel exited with, if any.
+func errorOfExitCodes(win32ExitCode, serviceSpecificExitCode uint32) error {
+	if win32ExitCode == uint32(windows.ERROR_SERVICE_SPECIFIC_ERROR) {
+		maybeErr := services.Error(serviceSpecificExitCode)
+		if maybeErr != services.ErrorSuccess {
+			return maybeErr
+		}
+		return nil
+	}
+	switch win32ExitCode {
+	case uint32(windows.NO_ERROR), uint32(windows.ERROR_SERVICE_NEVER_STARTED):
+		return nil
+	default:
+		return syscall.Errno(win32ExitCode)
+	}
+}
+
 func trackTunnelService(tunnelName string, service *mgr.Service) {
 	defer func() {
 		service.Close()
@@ -158,7 +185,9 @@ func trackTunnelService(tunnelName string, service *mgr.Service) {
 			trackedTunnelsLock.Lock()
 			trackedTunnels[tunnelName] = TunnelStopped
 			trackedTunnelsLock.Unlock()
-			IPCServerNotifyTunnelChange(tunnelName, TunnelStopped, fmt.Errorf("Unable to continue monitoring service, so stopping: %w", err))
+			err = fmt.Errorf("Unable to continue monitoring service, so stopping: %w", err)
+			recordTunnelError(tunnelName, err)
+			IPCServerNotifyTunnelChange(tunnelName, TunnelStopped, err)
 			service.Control(svc.Stop)
 			return
 		}
@@ -166,18 +195,12 @@ func trackTunnelService(tunnelName string, service *mgr.Service) {
 		state := svcStateToTunState(svc.State(notifier.ServiceStatus.CurrentState))
 		var tunnelError error
 		if state == TunnelStopped {
-			if notifier.ServiceStatus.Win32ExitCode == uint32(windows.ERROR_SERVICE_SPECIFIC_ERROR) {
-				maybeErr := services.Error(notifier.ServiceStatus.ServiceSpecificExitCode)
-				if maybeErr != services.ErrorSuccess {
-					tunnelError = maybeErr
-				}
-			} else {
-				switch notifier.ServiceStatus.Win32ExitCode {
-				case uint32(windows.NO_ERROR), uint32(windows.ERROR_SERVICE_NEVER_STARTED):
-				default:
-					tunnelError = syscall.Errno(notifier.ServiceStatus.Win32ExitCode)
-				}
-			}
+			tunnelError = errorOfExitCodes(notifier.ServiceStatus.Win32ExitCode, notifier.ServiceStatus.ServiceSpecificExitCode)
+		}
+		if tunnelError != nil {
+			recordTunnelError(tunnelName, tunnelError)
+		} else if state == TunnelStarted {
+			clearTunnelError(tunnelName)
 		}
 		if state != lastState {
 			trackedTunnelsLock.Lock()
@@ -0,0 +1,141 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
+)
+
+// standardUserUI reports whether administrators have asked for the UI of other administrators to run with the
+// filtered token of their desktop, rather than elevated, with what needs elevation done by the manager on its behalf.
+func standardUserUI() bool {
+	return conf.AdminBool("StandardUserUI")
+}
+
+// Every operation of the UI already goes through the manager, which keeps the elevated token of the session, so the
+// UI itself needs no rights beyond those of the user. What it does need is protection from the other processes of the
+// user, which run at the same integrity level and could otherwise duplicate its end of the pipes to the manager, or
+// read and write its memory. Its process and threads are therefore labeled high integrity, which processes at medium
+// integrity cannot open for reading or writing, and only the system and elevated administrators have any access to
+// them, or to anything else that it creates without an inherited descriptor. The owner rights entry keeps the user
+// from simply granting access to itself. Those processes can still drive its windows, though, so whatever it asks of
+// the manager that needs elevation is first confirmed by the user, as confirmElevation describes.
+const (
+	uiObjectSDDL = "O:SYD:P(A;;GA;;;SY)(A;;GA;;;BA)(A;;0x00100000;;;%s)(A;;RC;;;OW)S:(ML;;NWNRNX;;;HI)"
+	uiTokenSDDL  = "O:SYD:P(A;;GA;;;SY)(A;;GA;;;BA)(A;;0x0002003e;;;%s)" // Query, duplicate, impersonate, and adjust privileges
+)
+
+type tokenDefaultDacl struct {
+	defaultDacl *windows.ACL
+}
+
+type standardUserUIToken struct {
+	token            windows.Token
+	objectAttributes *syscall.SecurityAttributes  // For the process and its main thread
+	objectSd         *windows.SECURITY_DESCRIPTOR // Which objectAttributes points to, kept here so that it stays alive
+}
+
+// newStandardUserUIToken makes a primary token from the filtered token of the user for running the UI, along with
+// the attributes that its process and main thread are to be created with.
+func newStandardUserUIToken(userToken windows.Token) (*standardUserUIToken, error) {
+	tokenUser, err := userToken.GetTokenUser()
+	if err != nil {
+		return nil, err
+	}
+	userSid := tokenUser.User.Sid.String()
+
+	tokenSd, err := windows.SecurityDescriptorFromString(fmt.Sprintf(uiTokenSDDL, userSid))
+	if err != nil {
+		return nil, err
+	}
+	tokenSa := &windows.SecurityAttributes{Length: uint32(unsafe.Sizeof(windows.SecurityAttributes{})), SecurityDescriptor: tokenSd}
+	var token windows.Token
+	err = windows.DuplicateTokenEx(userToken, windows.MAXIMUM_ALLOWED, tokenSa, windows.SecurityImpersonation, windows.TokenPrimary, &token)
+	if err != nil {
+		return nil, err
+	}
+
+	objectSd, err := windows.SecurityDescriptorFromString(fmt.Sprintf(uiObjectSDDL, userSid))
+	if err != nil {
+		token.Close()
+		return nil, err
+	}
+	dacl, _, err := objectSd.DACL()
+	if err != nil {
+		token.Close()
+		return nil, err
+	}
+	defaultDacl := tokenDefaultDacl{dacl}
+	err = windows.SetTokenInformation(token, windows.TokenDefaultDacl, (*byte)(unsafe.Pointer(&defaultDacl)), uint32(unsafe.Sizeof(defaultDacl)))
+	if err != nil {
+		token.Close()
+		return nil, err
+	}
+
+	objectSa := &syscall.SecurityAttributes{Length: uint32(unsafe.Sizeof(syscall.SecurityAttributes{})), SecurityDescriptor: uintptr(unsafe.Pointer(objectSd))}
+	return &standardUserUIToken{token, objectSa, objectSd}, nil
+}
+
+// confirmElevation asks the user to confirm, on the secure desktop, something that needs elevation and that their UI,
+// which runs as the standard user, asked for. The prompt is shown by a process that runs with the elevated token,
+// which other processes of the user can neither drive nor open, and which answers with its exit code. The credentials
+// must be entered, rather than consent merely given, since the secure desktop is not shown when User Account Control
+// is set to elevate without prompting.
+func (s *ManagerService) confirmElevation(reason string) error {
+	s.confirmLock.Lock()
+	defer s.confirmLock.Unlock()
+	path, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer devNull.Close()
+	attr := &os.ProcAttr{
+		Sys: &syscall.SysProcAttr{
+			Token: syscall.Token(s.elevatedToken),
+		},
+		Files: []*os.File{devNull, devNull, devNull},
+	}
+	proc, err := os.StartProcess(path, []string{path, "/confirm", reason}, attr)
+	if err != nil {
+		ringlogger.Error.Printf("Unable to start confirmation process for session %d: %v", s.session, err)
+		return err
+	}
+	processStatus, err := proc.Wait()
+	if err != nil {
+		return err
+	}
+	if processStatus.ExitCode() != 0 {
+		log.Printf("Standard user UI of session %d was not allowed to go on: %s", s.session, reason)
+		return windows.ERROR_CANCELLED
+	}
+	log.Printf("Standard user UI of session %d was allowed to go on: %s", s.session, reason)
+	return nil
+}
+
+// checkElevated returns nil if the UI may do something that needs elevation, which the user must first confirm if
+// the UI runs as the standard user.
+func (s *ManagerService) checkElevated(reason string) error {
+	if s.elevatedToken == 0 {
+		return windows.ERROR_ACCESS_DENIED
+	}
+	if s.brokered {
+		return s.confirmElevation(reason)
+	}
+	return nil
+}
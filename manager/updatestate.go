@@ -7,8 +7,11 @@ package manager
 
 import (
 	"log"
+	"sync"
 	"time"
 
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
 	"golang.zx2c4.com/wireguard/windows/updater"
 	"golang.zx2c4.com/wireguard/windows/version"
 )
@@ -21,7 +24,36 @@ const (
 	UpdateStateUpdatesDisabledUnofficialBuild
 )
 
+// ReleaseNotes describes the update that was found, so that users can see what they are installing.
+type ReleaseNotes struct {
+	Version string
+	Text    string // Empty if the release notes could not be fetched
+}
+
 var updateState = UpdateStateUnknown
+var releaseNotes ReleaseNotes
+var releaseNotesLock sync.Mutex
+var updateCheckRequested = make(chan struct{}, 1)
+
+// requestUpdateCheck wakes the update checker, so that it checks right away rather than at its next interval.
+func requestUpdateCheck() {
+	select {
+	case updateCheckRequested <- struct{}{}:
+	default:
+	}
+}
+
+// waitForUpdateCheck sleeps for d, returning early and true if an immediate check has been requested.
+func waitForUpdateCheck(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return false
+	case <-updateCheckRequested:
+		return true
+	}
+}
 
 func checkForUpdates() {
 	defer printPanic()
@@ -33,25 +65,83 @@ func checkForUpdates() {
 		return
 	}
 
+	if conf.UpdatesDisabled() {
+		log.Println("Updates are disabled by policy")
+		return
+	}
+
 	first := true
+	requested := false
 	for {
-		update, err := updater.CheckForUpdate()
+		settings, err := conf.LoadSettings()
+		if err != nil {
+			settings = conf.DefaultSettings()
+		} else if !settings.CheckForUpdates && !requested {
+			requested = waitForUpdateCheck(time.Hour)
+			continue
+		}
+		update, err := updater.CheckForUpdate(settings.EffectiveUpdateChannel())
 		if err == nil && update != nil {
 			log.Println("An update is available")
+			notes := ReleaseNotes{Version: update.Version()}
+			notes.Text, err = updater.FetchReleaseNotes(update)
+			if err != nil {
+				ringlogger.Error.Printf("Update checker: %v", err)
+			}
+			releaseNotesLock.Lock()
+			releaseNotes = notes
+			releaseNotesLock.Unlock()
 			updateState = UpdateStateFoundUpdate
 			IPCServerNotifyUpdateFound(updateState)
+			installInMaintenanceWindow()
 			return
 		}
 		if err != nil {
-			log.Printf("Update checker: %v", err)
+			ringlogger.Error.Printf("Update checker: %v", err)
 			if first {
-				time.Sleep(time.Minute * 4)
+				requested = waitForUpdateCheck(time.Minute * 4)
 				first = false
 			} else {
-				time.Sleep(time.Minute * 25)
+				requested = waitForUpdateCheck(time.Minute * 25)
 			}
 		} else {
-			time.Sleep(time.Hour)
+			requested = waitForUpdateCheck(settings.EffectiveUpdateCheckInterval())
+		}
+	}
+}
+
+// installInMaintenanceWindow installs the update that has been found once the maintenance window opens, if one has
+// been configured, so that the brief interruption of every tunnel comes at a time of the admin's choosing. Until
+// then, and if it fails, users may still install it themselves.
+func installInMaintenanceWindow() {
+	for {
+		time.Sleep(time.Minute)
+		settings, err := conf.LoadSettings()
+		if err != nil || !settings.MaintenanceWindow.Contains(time.Now()) || conf.UpdatesNotifyOnly() {
+			continue
+		}
+		if settings.MaintenanceWindow.OnlyWhenInactive && trackedTunnelsGlobalState() != TunnelStopped {
+			continue
+		}
+		log.Println("Installing update in maintenance window")
+		progress := updater.DownloadVerifyAndExecute(0, settings.EffectiveUpdateChannel())
+		for {
+			dp := <-progress
+			IPCServerNotifyUpdateProgress(dp)
+			if len(dp.Activity) > 0 {
+				ringlogger.Debug.Printf("Update checker: %s", dp.Activity)
+			}
+			if dp.Complete {
+				return
+			}
+			if dp.Error != nil {
+				ringlogger.Error.Printf("Update checker: unable to install update in maintenance window: %v", dp.Error)
+				break
+			}
+		}
+		// Rather than hammering the server for the rest of the window, try again in the next one.
+		for settings.MaintenanceWindow.Contains(time.Now()) {
+			time.Sleep(time.Minute)
 		}
 	}
 }
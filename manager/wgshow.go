@@ -0,0 +1,254 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+)
+
+// wgShowFields are what may follow the tunnel name, as with wg(8) show.
+var wgShowFields = []string{"public-key", "private-key", "listen-port", "fwmark", "peers", "preshared-keys", "endpoints", "allowed-ips", "latest-handshakes", "transfer", "persistent-keepalive", "dump"}
+
+// runningTunnels returns the names of the tunnels that the manager tracks as running, whether as services or hosted,
+// sorted, as wg(8) lists them.
+func runningTunnels() []string {
+	var names []string
+	trackedTunnelsLock.Lock()
+	for name, state := range trackedTunnels {
+		if state == TunnelStarted {
+			names = append(names, name)
+		}
+	}
+	trackedTunnelsLock.Unlock()
+	sort.Strings(names)
+	return names
+}
+
+// WgShow has the manager return what wg(8) would print for "wg show tunnel field", where tunnel may also be "all" or
+// "interfaces", and field may be empty for the full human-readable listing, so that scripts and monitoring checks
+// written against wg(8) work unchanged. As with wg(8), the output is never translated.
+func WgShow(tunnel, field string) (string, error) {
+	return queryManagerCommand(commandRequest{Verb: "show", Tunnel: tunnel, Args: []string{field}})
+}
+
+func writeWgShow(out io.Writer, tunnel, field string) error {
+	if len(field) > 0 {
+		known := false
+		for _, f := range wgShowFields {
+			known = known || f == field
+		}
+		if !known || tunnel == "interfaces" {
+			return fmt.Errorf("Invalid field: %q", field)
+		}
+	}
+	var names []string
+	if tunnel == "all" || tunnel == "interfaces" {
+		names = runningTunnels()
+		if tunnel == "interfaces" {
+			if len(names) > 0 {
+				fmt.Fprintln(out, strings.Join(names, " "))
+			}
+			return nil
+		}
+	} else if conf.TunnelNameIsValid(tunnel) {
+		names = []string{tunnel}
+	} else {
+		return errors.New("Tunnel name is not valid")
+	}
+	for i, name := range names {
+		config, err := QueryRuntimeConfig(&conf.Config{Name: name})
+		if err != nil {
+			return fmt.Errorf("Unable to access interface %s: %w", name, err)
+		}
+		prefix := ""
+		if tunnel == "all" {
+			prefix = name + "\t"
+		}
+		if len(field) == 0 {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			writeWgShowPretty(out, config)
+		} else {
+			writeWgShowField(out, config, prefix, field)
+		}
+	}
+	return nil
+}
+
+func keyOrNone(k *conf.Key) string {
+	if k.IsZero() {
+		return "(none)"
+	}
+	return k.String()
+}
+
+func endpointOrNone(e *conf.Endpoint) string {
+	if e.IsEmpty() {
+		return "(none)"
+	}
+	return e.String()
+}
+
+func allowedIPsOrNone(ips []conf.IPCidr, separator string) string {
+	if len(ips) == 0 {
+		return "(none)"
+	}
+	s := make([]string, len(ips))
+	for i := range ips {
+		s[i] = ips[i].String()
+	}
+	return strings.Join(s, separator)
+}
+
+func keepaliveOrOff(k uint16) string {
+	if k == 0 {
+		return "off"
+	}
+	return fmt.Sprint(k)
+}
+
+func handshakeSeconds(t conf.HandshakeTime) int64 {
+	return int64(time.Duration(t) / time.Second)
+}
+
+func writeWgShowField(out io.Writer, config *conf.Config, prefix, field string) {
+	iface := &config.Interface
+	var publicKey conf.Key
+	if !iface.PrivateKey.IsZero() {
+		publicKey = *iface.PrivateKey.Public()
+	}
+	switch field {
+	case "public-key":
+		fmt.Fprintf(out, "%s%s\n", prefix, keyOrNone(&publicKey))
+		return
+	case "private-key":
+		fmt.Fprintf(out, "%s%s\n", prefix, keyOrNone(&iface.PrivateKey))
+		return
+	case "listen-port":
+		fmt.Fprintf(out, "%s%d\n", prefix, iface.ListenPort)
+		return
+	case "fwmark":
+		fmt.Fprintf(out, "%soff\n", prefix)
+		return
+	case "dump":
+		fmt.Fprintf(out, "%s%s\t%s\t%d\toff\n", prefix, keyOrNone(&iface.PrivateKey), keyOrNone(&publicKey), iface.ListenPort)
+	}
+	for i := range config.Peers {
+		peer := &config.Peers[i]
+		fmt.Fprintf(out, "%s%s", prefix, peer.PublicKey.String())
+		switch field {
+		case "peers":
+		case "preshared-keys":
+			fmt.Fprintf(out, "\t%s", keyOrNone(&peer.PresharedKey))
+		case "endpoints":
+			fmt.Fprintf(out, "\t%s", endpointOrNone(&peer.Endpoint))
+		case "allowed-ips":
+			fmt.Fprintf(out, "\t%s", allowedIPsOrNone(peer.AllowedIPs, " "))
+		case "latest-handshakes":
+			fmt.Fprintf(out, "\t%d", handshakeSeconds(peer.LastHandshakeTime))
+		case "transfer":
+			fmt.Fprintf(out, "\t%d\t%d", peer.RxBytes, peer.TxBytes)
+		case "persistent-keepalive":
+			fmt.Fprintf(out, "\t%s", keepaliveOrOff(peer.PersistentKeepalive))
+		case "dump":
+			fmt.Fprintf(out, "\t%s\t%s\t%s\t%d\t%d\t%d\t%s", keyOrNone(&peer.PresharedKey), endpointOrNone(&peer.Endpoint), allowedIPsOrNone(peer.AllowedIPs, ","),
+				handshakeSeconds(peer.LastHandshakeTime), peer.RxBytes, peer.TxBytes, keepaliveOrOff(peer.PersistentKeepalive))
+		}
+		fmt.Fprintln(out)
+	}
+}
+
+// prettyTime and prettyBytes deliberately match wg(8), rather than conf.HandshakeTime and conf.Bytes, which are
+// localized for the UI.
+func prettyTime(seconds int64) string {
+	units := []struct {
+		name    string
+		seconds int64
+	}{{"year", 365 * 24 * 60 * 60}, {"day", 24 * 60 * 60}, {"hour", 60 * 60}, {"minute", 60}, {"second", 1}}
+	var s []string
+	for _, unit := range units {
+		n := seconds / unit.seconds
+		seconds %= unit.seconds
+		if n == 0 {
+			continue
+		}
+		plural := "s"
+		if n == 1 {
+			plural = ""
+		}
+		s = append(s, fmt.Sprintf("%d %s%s", n, unit.name, plural))
+	}
+	return strings.Join(s, ", ")
+}
+
+func prettyBytes(b conf.Bytes) string {
+	switch {
+	case b < 1024:
+		return fmt.Sprintf("%d B", b)
+	case b < 1024*1024:
+		return fmt.Sprintf("%.2f KiB", float64(b)/1024)
+	case b < 1024*1024*1024:
+		return fmt.Sprintf("%.2f MiB", float64(b)/(1024*1024))
+	case b < 1024*1024*1024*1024:
+		return fmt.Sprintf("%.2f GiB", float64(b)/(1024*1024*1024))
+	default:
+		return fmt.Sprintf("%.2f TiB", float64(b)/(1024*1024*1024*1024))
+	}
+}
+
+func writeWgShowPretty(out io.Writer, config *conf.Config) {
+	iface := &config.Interface
+	fmt.Fprintf(out, "interface: %s\n", config.Name)
+	if !iface.PrivateKey.IsZero() {
+		fmt.Fprintf(out, "  public key: %s\n", iface.PrivateKey.Public().String())
+		fmt.Fprintf(out, "  private key: (hidden)\n")
+	}
+	if iface.ListenPort > 0 {
+		fmt.Fprintf(out, "  listening port: %d\n", iface.ListenPort)
+	}
+	peers := make([]*conf.Peer, len(config.Peers))
+	for i := range config.Peers {
+		peers[i] = &config.Peers[i]
+	}
+	sort.SliceStable(peers, func(i, j int) bool {
+		return peers[i].LastHandshakeTime > peers[j].LastHandshakeTime
+	})
+	now := time.Now().Unix()
+	for _, peer := range peers {
+		fmt.Fprintf(out, "\npeer: %s\n", peer.PublicKey.String())
+		if !peer.PresharedKey.IsZero() {
+			fmt.Fprintf(out, "  preshared key: (hidden)\n")
+		}
+		if !peer.Endpoint.IsEmpty() {
+			fmt.Fprintf(out, "  endpoint: %s\n", peer.Endpoint.String())
+		}
+		fmt.Fprintf(out, "  allowed ips: %s\n", allowedIPsOrNone(peer.AllowedIPs, ", "))
+		if handshake := handshakeSeconds(peer.LastHandshakeTime); handshake > 0 {
+			switch {
+			case handshake == now:
+				fmt.Fprintf(out, "  latest handshake: Now\n")
+			case handshake > now:
+				fmt.Fprintf(out, "  latest handshake: (System clock wound backward; connection problems may ensue.)\n")
+			default:
+				fmt.Fprintf(out, "  latest handshake: %s ago\n", prettyTime(now-handshake))
+			}
+		}
+		if peer.RxBytes > 0 || peer.TxBytes > 0 {
+			fmt.Fprintf(out, "  transfer: %s received, %s sent\n", prettyBytes(peer.RxBytes), prettyBytes(peer.TxBytes))
+		}
+		if peer.PersistentKeepalive > 0 {
+			fmt.Fprintf(out, "  persistent keepalive: every %s\n", prettyTime(int64(peer.PersistentKeepalive)))
+		}
+	}
+}
@@ -0,0 +1,162 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ringlogger
+
+import (
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+)
+
+// The number of lines is a power of two, so that the ever increasing index stays in step with the ring when it wraps
+// around at 2^32.
+const (
+	defaultLines  = 2048
+	minLines      = 256
+	maxLinesLimit = 1 << 16
+
+	legacyMagic      = 0xbadbabe // Before the level and the capacity were in the header
+	legacyHeaderSize = 8
+)
+
+const (
+	lineSize   = int64(unsafe.Sizeof(logLine{}))
+	headerSize = int64(unsafe.Sizeof(logHeader{}))
+)
+
+var procVirtualQuery = windows.NewLazySystemDLL("kernel32.dll").NewProc("VirtualQuery")
+
+type memoryBasicInformation struct {
+	baseAddress       uintptr
+	allocationBase    uintptr
+	allocationProtect uint32
+	regionSize        uintptr
+	state             uint32
+	protect           uint32
+	memoryType        uint32
+}
+
+// viewSize returns how many bytes of the view at the address may be read, which is the size of the mapping rounded
+// up to a page, so that a header that claims more lines than the file holds is not believed.
+func viewSize(view uintptr) (uintptr, error) {
+	var info memoryBasicInformation
+	ret, _, err := procVirtualQuery.Call(view, uintptr(unsafe.Pointer(&info)), unsafe.Sizeof(info))
+	if ret == 0 {
+		return 0, err
+	}
+	return info.regionSize, nil
+}
+
+func lineCount(header *logHeader) uint32 {
+	if header.lineCount == 0 {
+		return defaultLines
+	}
+	return header.lineCount
+}
+
+// configuredLines returns the capacity that administrators have chosen, since busy machines with many tunnels can
+// cycle through the default in minutes.
+func configuredLines() uint32 {
+	lines, ok := conf.AdminInteger("LogLines")
+	if !ok {
+		return defaultLines
+	}
+	count := uint32(minLines)
+	for uint64(count) < lines && count < maxLinesLimit {
+		count <<= 1
+	}
+	return count
+}
+
+// prepareLogFile makes sure that the file holds a log of the current layout, creating one of the configured capacity
+// if it holds nothing intelligible, and otherwise migrating the existing lines to the configured capacity or from the
+// legacy layout. Other processes would go on using the layout that they mapped, so this happens only when nobody
+// else has the file open; a tunnel service that kept running while the manager restarted, for example, means that
+// the log keeps its capacity until the next time that nobody does.
+func prepareLogFile(filename string) error {
+	filename16, err := windows.UTF16PtrFromString(filename)
+	if err != nil {
+		return err
+	}
+	handle, err := windows.CreateFile(filename16, windows.GENERIC_READ|windows.GENERIC_WRITE, 0, nil, windows.OPEN_ALWAYS, windows.FILE_ATTRIBUTE_NORMAL, 0)
+	if err == windows.ERROR_SHARING_VIOLATION {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	file := os.NewFile(uintptr(handle), filename)
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	var header logHeader
+	headerBytes := (*[unsafe.Sizeof(logHeader{})]byte)(unsafe.Pointer(&header))[:]
+	if n, _ := file.ReadAt(headerBytes, 0); n != len(headerBytes) {
+		header = logHeader{}
+	}
+	want := configuredLines()
+
+	var oldHeaderSize int64
+	var oldCount uint32
+	switch {
+	case header.magic == magic && info.Size() == headerSize+int64(lineCount(&header))*lineSize:
+		if lineCount(&header) == want {
+			return nil
+		}
+		oldHeaderSize, oldCount = headerSize, lineCount(&header)
+	case header.magic == legacyMagic && info.Size() == legacyHeaderSize+defaultLines*lineSize:
+		oldHeaderSize, oldCount = legacyHeaderSize, defaultLines
+		header.level = uint32(DefaultLevel)
+	default:
+		err = file.Truncate(0)
+		if err == nil {
+			err = file.Truncate(headerSize + int64(want)*lineSize)
+		}
+		if err != nil {
+			return err
+		}
+		header = logHeader{magic: magic, level: uint32(DefaultLevel), lineCount: want}
+		_, err = file.WriteAt(headerBytes, 0)
+		return err
+	}
+
+	old := make([]byte, int64(oldCount)*lineSize)
+	_, err = file.ReadAt(old, oldHeaderSize)
+	if err != nil {
+		return err
+	}
+	oldLines := (*[maxLinesLimit]logLine)(unsafe.Pointer(&old[0]))[:oldCount:oldCount]
+	lines := make([]logLine, 0, want)
+	for i := uint32(0); i < oldCount; i++ {
+		line := &oldLines[(header.nextIndex+i)%oldCount]
+		if line.timeNs != 0 {
+			lines = append(lines, *line)
+		}
+	}
+	if len(lines) > int(want) {
+		lines = lines[len(lines)-int(want):]
+	}
+
+	err = file.Truncate(headerSize + int64(want)*lineSize)
+	if err != nil {
+		return err
+	}
+	header = logHeader{magic: magic, nextIndex: uint32(len(lines)), level: header.level, lineCount: want}
+	buffer := make([]byte, int64(want)*lineSize)
+	copy((*[maxLinesLimit]logLine)(unsafe.Pointer(&buffer[0]))[:want:want], lines)
+	_, err = file.WriteAt(buffer, headerSize)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteAt(headerBytes, 0)
+	return err
+}
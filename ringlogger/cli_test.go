@@ -6,11 +6,11 @@
 package ringlogger
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sync"
 	"testing"
-	"time"
 )
 
 func TestThreads(t *testing.T) {
@@ -73,10 +73,12 @@ func TestFollow(t *testing.T) {
 	cursor := CursorAll
 	for {
 		var lines []FollowLine
-		lines, cursor = rl.FollowFromCursor(cursor)
+		lines, cursor, _, err = rl.FollowFromCursor(context.Background(), cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
 		for _, line := range lines {
 			fmt.Printf("%v: %s\n", line.Stamp, line.Line)
 		}
-		time.Sleep(300 * time.Millisecond)
 	}
 }
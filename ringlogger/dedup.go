@@ -0,0 +1,159 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ringlogger
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	repeatWindow = time.Minute
+	lineRate     = 50 // Lines per second that may be written once the burst is spent
+	lineBurst    = 500
+)
+
+type repeat struct {
+	since    time.Time
+	count    int
+	levelTag string
+	prefix   []byte
+}
+
+// limiter keeps a flood of lines, such as handshake retries to an unreachable peer, from pushing everything else out
+// of the ring. Its zero value is ready to use.
+type limiter struct {
+	sync.Mutex
+	repeats  map[string]*repeat
+	flush    *time.Timer // Set while repeats remain to be flushed
+	closed   bool
+	tokens   float64
+	refilled time.Time
+	dropped  int
+}
+
+// splitPrefix separates the tunnel name that the log prefix puts in front of the message, so that summaries can go
+// after it and still read as belonging to the tunnel.
+func splitPrefix(p []byte) (prefix, message []byte) {
+	if len(p) > 0 && p[0] == '[' {
+		if end := bytes.Index(p, []byte("] ")); end > 0 {
+			return p[:end+2], p[end+2:]
+		}
+	}
+	return nil, p
+}
+
+// admit reports whether the line should be written. A line that was already written within the last repeatWindow is
+// instead counted, and the count is written once the window is over. Beyond that, lines are dropped once they come
+// faster than lineRate for longer than lineBurst allows, and a count of the dropped lines is written once they slow
+// down again.
+func (rl *Ringlogger) admit(level Level, p []byte) bool {
+	l := &rl.limiter
+	l.Lock()
+	defer l.Unlock()
+	now := time.Now()
+
+	if l.refilled.IsZero() {
+		l.tokens = lineBurst
+	} else {
+		l.tokens += now.Sub(l.refilled).Seconds() * lineRate
+		if l.tokens > lineBurst {
+			l.tokens = lineBurst
+		}
+	}
+	l.refilled = now
+
+	// Only lines that are exactly the same count as repeats, since lines that differ, if only by a number, such as an
+	// address or a count, may well be what someone is looking for.
+	prefix, message := splitPrefix(bytes.TrimSpace(p))
+	key := level.tag() + string(prefix) + string(message)
+	r, ok := l.repeats[key]
+	if ok && now.Sub(r.since) < repeatWindow {
+		r.count++
+		return false
+	}
+	if ok {
+		rl.writeRepeat(r)
+	}
+
+	if l.tokens < 1 {
+		l.dropped++
+		return false
+	}
+	l.tokens--
+	if l.dropped > 0 {
+		rl.writeSummary(LevelWarn.tag(), []byte(fmt.Sprintf("%sDropped %d lines that were logged too quickly", prefix, l.dropped)))
+		l.dropped = 0
+	}
+
+	if l.repeats == nil {
+		l.repeats = make(map[string]*repeat)
+	}
+	l.repeats[key] = &repeat{since: now, levelTag: level.tag(), prefix: append([]byte(nil), prefix...)}
+	if l.flush == nil && !l.closed {
+		l.flush = time.AfterFunc(repeatWindow, rl.flushRepeats)
+	}
+	return true
+}
+
+func (rl *Ringlogger) writeRepeat(r *repeat) {
+	if r.count > 0 {
+		rl.writeSummary(r.levelTag, []byte(fmt.Sprintf("%sPrevious message repeated %d more times", r.prefix, r.count)))
+	}
+}
+
+func (rl *Ringlogger) writeSummary(levelTag string, p []byte) {
+	if rl.also != nil {
+		rl.also.writeLine(levelTag, p)
+	}
+	rl.writeLine(levelTag, p)
+}
+
+// flushRepeats writes the counts of the repeats whose window is over and forgets them, checking again later for as
+// long as any remain.
+func (rl *Ringlogger) flushRepeats() {
+	l := &rl.limiter
+	l.Lock()
+	defer l.Unlock()
+	if l.closed {
+		return
+	}
+	now := time.Now()
+	for key, r := range l.repeats {
+		if now.Sub(r.since) < repeatWindow {
+			continue
+		}
+		rl.writeRepeat(r)
+		delete(l.repeats, key)
+	}
+	if len(l.repeats) > 0 {
+		l.flush = time.AfterFunc(repeatWindow/4, rl.flushRepeats)
+	} else {
+		l.flush = nil
+	}
+}
+
+// stopLimiting writes the counts of the repeats that remain and stops flushing them, so that nothing is written once
+// Close unmaps the log.
+func (rl *Ringlogger) stopLimiting() {
+	l := &rl.limiter
+	l.Lock()
+	defer l.Unlock()
+	if l.closed {
+		return
+	}
+	l.closed = true
+	if l.flush != nil {
+		l.flush.Stop()
+		l.flush = nil
+	}
+	for _, r := range l.repeats {
+		rl.writeRepeat(r)
+	}
+	l.repeats = nil
+}
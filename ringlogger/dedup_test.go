@@ -0,0 +1,43 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ringlogger
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAdmit(t *testing.T) {
+	rl := &Ringlogger{readOnly: true}
+	admitted := 0
+	for try := 2; try < 20; try++ {
+		if rl.admit(LevelInfo, []byte("[office] peer(xTIB…p8Dg) - Sending handshake initiation")) {
+			admitted++
+		}
+		if rl.admit(LevelInfo, []byte(fmt.Sprintf("[office] peer(xTIB…p8Dg) - Handshake did not complete after 5 seconds, retrying (try %d)", try))) {
+			admitted++
+		}
+	}
+	if admitted != 19 {
+		t.Errorf("Admitted %d lines, rather than the first repeat and every one that differs", admitted)
+	}
+	if !rl.admit(LevelInfo, []byte("[office] Shutting down")) {
+		t.Error("A new line was not admitted")
+	}
+	if rl.admit(LevelInfo, []byte("[office] Shutting down")) || !rl.admit(LevelWarn, []byte("[office] Shutting down")) {
+		t.Error("Lines of different levels were taken for repeats")
+	}
+	for i := 0; i < lineBurst*2; i++ {
+		rl.admit(LevelDebug, []byte(fmt.Sprintf("[office] Line %c%c", 'a'+i%26, 'a'+i/26)))
+	}
+	if rl.limiter.dropped == 0 {
+		t.Error("A flood of lines was not rate limited")
+	}
+	rl.Close()
+	if rl.limiter.flush != nil || rl.limiter.repeats != nil {
+		t.Error("Closing the log left repeats to be flushed")
+	}
+}
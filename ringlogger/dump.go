@@ -20,25 +20,32 @@ func DumpTo(out io.Writer, notSystem bool) error {
 	if err != nil {
 		return err
 	}
-	path := filepath.Join(root, "log.bin")
-	file, err := os.Open(path)
+	rl, err := openReadOnly(filepath.Join(root, "log.bin"))
+	if err != nil {
+		return err
+	}
+	defer rl.Close()
+	_, err = rl.WriteTo(out)
 	if err != nil {
 		return err
 	}
+	return nil
+}
+
+func openReadOnly(path string) (*Ringlogger, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
 	defer file.Close()
 	mapping, err := windows.CreateFileMapping(windows.Handle(file.Fd()), nil, windows.PAGE_READONLY, 0, 0, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	rl, err := newRingloggerFromMappingHandle(mapping, "DMP", windows.FILE_MAP_READ)
 	if err != nil {
 		windows.CloseHandle(mapping)
-		return err
-	}
-	defer rl.Close()
-	_, err = rl.WriteTo(out)
-	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return rl, nil
 }
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 
 	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/etw"
 )
 
 var Global *Ringlogger
@@ -26,6 +27,9 @@ func InitGlobalLogger(tag string) error {
 	if err != nil {
 		return err
 	}
+	if Redacting() {
+		etw.Redact = Redact
+	}
 	log.SetOutput(Global)
 	log.SetFlags(0)
 	return nil
@@ -0,0 +1,89 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ringlogger
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// Entry is a line of the log split into its parts, for log collectors that would rather not parse the text.
+type Entry struct {
+	Time    time.Time `json:"timestamp"`
+	Level   string    `json:"level"`
+	Source  string    `json:"source"` // MGR, TUN, UI, and so forth
+	Tunnel  string    `json:"tunnel,omitempty"`
+	Message string    `json:"message"`
+}
+
+// ParseLine splits a line as written by the ringlogger, "[TAG] [LEVEL] [tunnel] message", where the level and the
+// tunnel are each optional.
+func ParseLine(line FollowLine) Entry {
+	entry := Entry{Time: line.Stamp, Level: LevelInfo.String()}
+	rest := line.Line
+	bracketed := func() (string, bool) {
+		if !strings.HasPrefix(rest, "[") {
+			return "", false
+		}
+		end := strings.Index(rest, "] ")
+		if end < 0 {
+			return "", false
+		}
+		return rest[1:end], true
+	}
+	if source, ok := bracketed(); ok {
+		entry.Source = source
+		rest = rest[len(source)+3:]
+	}
+	if tag, ok := bracketed(); ok {
+		if level, err := ParseLevel(tag); err == nil && tag == strings.ToUpper(tag) {
+			entry.Level = level.String()
+			rest = rest[len(tag)+3:]
+		}
+	}
+	if tunnel, ok := bracketed(); ok {
+		entry.Tunnel = tunnel
+		rest = rest[len(tunnel)+3:]
+	}
+	entry.Message = rest
+	return entry
+}
+
+// WriteJSONTo writes the log as JSON Lines, one Entry object per line, oldest first.
+func (rl *Ringlogger) WriteJSONTo(out io.Writer) (n int64, err error) {
+	lines, _, _ := rl.ReadFromCursor(CursorAll)
+	return WriteLinesJSONTo(out, lines, StampFormat{})
+}
+
+// WriteLinesJSONTo is like WriteJSONTo, but for lines that have already been read, such as from another process. The
+// timestamps are always ISO 8601, but in the zone of the format.
+func WriteLinesJSONTo(out io.Writer, lines []FollowLine, format StampFormat) (n int64, err error) {
+	counter := &countingWriter{w: out}
+	encoder := json.NewEncoder(counter)
+	encoder.SetEscapeHTML(false)
+	for _, line := range lines {
+		entry := ParseLine(line)
+		entry.Time = format.In(entry.Time)
+		err = encoder.Encode(entry)
+		if err != nil {
+			break
+		}
+	}
+	return counter.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,32 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ringlogger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLine(t *testing.T) {
+	stamp := time.Now()
+	tests := []struct {
+		line  string
+		entry Entry
+	}{
+		{"[MGR] Starting WireGuard/0.3.1", Entry{stamp, "info", "MGR", "", "Starting WireGuard/0.3.1"}},
+		{"[TUN] [office] Startup complete", Entry{stamp, "info", "TUN", "office", "Startup complete"}},
+		{"[TUN] [DEBUG] [office] peer(abcd…) - Sending handshake initiation", Entry{stamp, "debug", "TUN", "office", "peer(abcd…) - Sending handshake initiation"}},
+		{"[MGR] [ERROR] Unable to elevate token: Access is denied.", Entry{stamp, "error", "MGR", "", "Unable to elevate token: Access is denied."}},
+		{"[TUN] [trace] Lowercase is a tunnel name", Entry{stamp, "info", "TUN", "trace", "Lowercase is a tunnel name"}},
+		{"No tags at all", Entry{stamp, "info", "", "", "No tags at all"}},
+	}
+	for _, test := range tests {
+		entry := ParseLine(FollowLine{test.line, stamp})
+		if entry != test.entry {
+			t.Errorf("ParseLine(%q) = %+v, want %+v", test.line, entry, test.entry)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ringlogger
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/sys/windows"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/etw"
+)
+
+type Level uint32
+
+const (
+	LevelError Level = iota
+	LevelWarn
+	LevelInfo
+	LevelDebug
+	LevelTrace
+)
+
+// DefaultLevel keeps the debug messages of wireguard-go, such as handshakes, since those are what most reports of a
+// problem need, and leaves tracing for when someone is looking.
+const DefaultLevel = LevelDebug
+
+var levelNames = [...]string{
+	LevelError: "error",
+	LevelWarn:  "warn",
+	LevelInfo:  "info",
+	LevelDebug: "debug",
+	LevelTrace: "trace",
+}
+
+func (level Level) String() string {
+	if int(level) < len(levelNames) {
+		return levelNames[level]
+	}
+	return fmt.Sprintf("level%d", level)
+}
+
+func ParseLevel(s string) (Level, error) {
+	for level, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(level), nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown log level %q", s)
+}
+
+func (level Level) etwLevel() etw.Level {
+	switch level {
+	case LevelError:
+		return etw.LevelError
+	case LevelWarn:
+		return etw.LevelWarning
+	case LevelInfo:
+		return etw.LevelInfo
+	}
+	return etw.LevelVerbose
+}
+
+// tag is what marks the lines of the level in the log. Info, which is what the standard logger writes, is left
+// unmarked, so that most lines read as they always have.
+func (level Level) tag() string {
+	if level == LevelInfo {
+		return ""
+	}
+	return "[" + strings.ToUpper(level.String()) + "] "
+}
+
+// Level returns the verbosity of the log. It is kept in the shared mapping itself, so that changing it affects the
+// manager and every tunnel service at once, without restarting any of them.
+func (rl *Ringlogger) Level() Level {
+	if rl.log == nil {
+		return DefaultLevel
+	}
+	return Level(atomic.LoadUint32(&rl.log.level))
+}
+
+func (rl *Ringlogger) SetLevel(level Level) error {
+	if rl.readOnly {
+		return windows.ERROR_ACCESS_DENIED
+	}
+	if rl.log == nil {
+		return os.ErrClosed
+	}
+	atomic.StoreUint32(&rl.log.level, uint32(level))
+	return nil
+}
+
+// Enabled reports whether lines of the level would be kept, for callers that would rather not format trace messages
+// for nothing.
+func Enabled(level Level) bool {
+	return Global == nil || level <= Global.Level()
+}
+
+type levelWriter Level
+
+func (level levelWriter) Write(p []byte) (int, error) {
+	if Global == nil {
+		return os.Stderr.Write(p)
+	}
+	return Global.WriteLevel(Level(level), p)
+}
+
+// These log at their level to the global logger, or to standard error before it is initialized. The standard logger
+// writes at LevelInfo.
+var (
+	Error = log.New(levelWriter(LevelError), "", 0)
+	Warn  = log.New(levelWriter(LevelWarn), "", 0)
+	Info  = log.New(levelWriter(LevelInfo), "", 0)
+	Debug = log.New(levelWriter(LevelDebug), "", 0)
+	Trace = log.New(levelWriter(LevelTrace), "", 0)
+)
+
+// SetPrefix sets the prefix of the leveled loggers and of the standard logger together, such as to the tunnel name.
+func SetPrefix(prefix string) {
+	log.SetPrefix(prefix)
+	for _, logger := range []*log.Logger{Error, Warn, Info, Debug, Trace} {
+		logger.SetPrefix(prefix)
+	}
+	etw.SetPrefix(prefix)
+}
+
+// SetLevelOfShared changes the verbosity of the log that the manager and tunnel services share, from outside of them.
+func SetLevelOfShared(level Level) error {
+	root, err := conf.RootDirectory(false)
+	if err != nil {
+		return err
+	}
+	rl, err := NewRinglogger(filepath.Join(root, "log.bin"), "CLI")
+	if err != nil {
+		return err
+	}
+	defer rl.Close()
+	previous := rl.Level()
+	err = rl.SetLevel(level)
+	if err != nil {
+		return err
+	}
+	// This bypasses the level, since the change should be on record whichever way it goes.
+	_, err = rl.writeLine("", []byte(fmt.Sprintf("Log level changed from %s to %s", previous, level)))
+	return err
+}
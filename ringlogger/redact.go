@@ -0,0 +1,123 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ringlogger
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+)
+
+var (
+	ipv4Pattern      = regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}\b`)
+	ipv6Pattern      = regexp.MustCompile(`\[?[0-9a-fA-F]{0,4}(:[0-9a-fA-F]{0,4}){2,7}(%\w+)?\]?`)
+	base64KeyPattern = regexp.MustCompile(`[A-Za-z0-9+/]{42}[AEIMQUYcgkosw048]=`)
+	hexKeyPattern    = regexp.MustCompile(`\b[0-9a-f]{64}\b`)
+
+	redactionOnce sync.Once
+	redacting     bool
+	redactionKey  []byte
+)
+
+// Redacting reports whether administrators have asked for lines to be redacted as they are logged.
+func Redacting() bool {
+	redactionOnce.Do(loadRedaction)
+	return redacting
+}
+
+// loadRedaction reads the key that the short hashes are made with, creating it the first time. It is kept per
+// machine, so that the same address hashes alike across the logs of a machine, yet without the key, nobody can
+// recover an address by hashing all four billion of them.
+func loadRedaction() {
+	redacting = conf.AdminBool("RedactLogs")
+	root, err := conf.RootDirectory(false)
+	if err == nil {
+		path := filepath.Join(root, "redaction.key")
+		redactionKey, err = ioutil.ReadFile(path)
+		if err != nil || len(redactionKey) != 32 {
+			redactionKey = make([]byte, 32)
+			if _, err = rand.Read(redactionKey); err == nil {
+				err = ioutil.WriteFile(path+".tmp", redactionKey, 0600)
+				if err == nil {
+					err = os.Rename(path+".tmp", path)
+				}
+			}
+		}
+	}
+	if err != nil {
+		// Hashes will not match those of other processes, but nothing is exposed either.
+		redactionKey = make([]byte, 32)
+		rand.Read(redactionKey)
+	}
+}
+
+func shortHash(kind string, b []byte) string {
+	mac := hmac.New(sha256.New, redactionKey)
+	mac.Write([]byte(kind))
+	mac.Write(b)
+	return hex.EncodeToString(mac.Sum(nil)[:4])
+}
+
+// Redact masks IP addresses in the text as ip#HASH and shortens public keys to their first four characters followed
+// by …#HASH, so that support staff can tell which lines concern the same peer or endpoint without learning either.
+// Ports, prefix lengths, and wireguard-go's own already shortened peer names are left as they are.
+func Redact(text string) string {
+	redactionOnce.Do(loadRedaction)
+	text = base64KeyPattern.ReplaceAllStringFunc(text, func(s string) string {
+		raw, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return s
+		}
+		return s[:4] + "…#" + shortHash("key", raw)
+	})
+	text = hexKeyPattern.ReplaceAllStringFunc(text, func(s string) string {
+		raw, err := hex.DecodeString(s)
+		if err != nil {
+			return s
+		}
+		return base64.StdEncoding.EncodeToString(raw)[:4] + "…#" + shortHash("key", raw)
+	})
+	ip := func(s string) string {
+		bracketed := strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]")
+		address := strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+		if i := strings.IndexByte(address, '%'); i >= 0 {
+			address = address[:i]
+		}
+		parsed := net.ParseIP(address)
+		if parsed == nil {
+			return s
+		}
+		masked := "ip#" + shortHash("ip", parsed.To16())
+		if bracketed {
+			masked = "[" + masked + "]"
+		}
+		return masked
+	}
+	text = ipv6Pattern.ReplaceAllStringFunc(text, ip)
+	text = ipv4Pattern.ReplaceAllStringFunc(text, ip)
+	return text
+}
+
+// RedactLines returns the lines with Redact applied to each, such as to share a log that was not redacted as it was
+// written.
+func RedactLines(lines []FollowLine) []FollowLine {
+	redacted := make([]FollowLine, len(lines))
+	for i, line := range lines {
+		redacted[i] = FollowLine{Redact(line.Line), line.Stamp}
+	}
+	return redacted
+}
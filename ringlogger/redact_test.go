@@ -0,0 +1,37 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ringlogger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	redactionOnce.Do(func() { redactionKey = make([]byte, 32) })
+	line := Redact("[TUN] [office] Handshake with xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg= at 192.0.2.1:51820 and [2001:db8::1]:51820 via 10.0.0.0/8")
+	for _, leaked := range []string{"192.0.2.1", "2001:db8::1", "10.0.0.0", "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="} {
+		if strings.Contains(line, leaked) {
+			t.Errorf("%q is still in %q", leaked, line)
+		}
+	}
+	for _, kept := range []string{"[TUN] [office] ", "xTIB…#", ":51820 and [ip#", "]:51820", "/8"} {
+		if !strings.Contains(line, kept) {
+			t.Errorf("%q is missing from %q", kept, line)
+		}
+	}
+	if Redact("at 192.0.2.1") != Redact("at 192.0.2.1") {
+		t.Error("The same address hashed differently")
+	}
+	if Redact("at 192.0.2.1") == Redact("at 192.0.2.2") {
+		t.Error("Different addresses hashed alike")
+	}
+	for _, unchanged := range []string{"Update at 02:00", "Started at 15:04:05", "WireGuard/0.3.1 (Windows 10.0.19041; amd64)"} {
+		if Redact(unchanged) != unchanged {
+			t.Errorf("%q changed to %q", unchanged, Redact(unchanged))
+		}
+	}
+}
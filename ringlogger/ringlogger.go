@@ -7,6 +7,8 @@ package ringlogger
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -17,39 +19,47 @@ import (
 	"unsafe"
 
 	"golang.org/x/sys/windows"
+
+	"golang.zx2c4.com/wireguard/windows/etw"
 )
 
 const (
 	maxLogLineLength = 512
-	maxLines         = 2048
-	magic            = 0xbadbabe
+	magic            = 0xbadbabf // Changes whenever the layout of logHeader does
 )
 
+var errLogFormat = errors.New("Log file is not in the expected format")
+
 type logLine struct {
 	timeNs int64
 	line   [maxLogLineLength]byte
 }
 
-type logMem struct {
+// logHeader is at the start of the mapping, followed by lineCount lines.
+type logHeader struct {
 	magic     uint32
 	nextIndex uint32
-	lines     [maxLines]logLine
+	level     uint32
+	lineCount uint32 // Or 0 in logs from before the capacity could be configured, which have defaultLines
 }
 
 type Ringlogger struct {
 	tag      string
 	file     *os.File
 	mapping  windows.Handle
-	log      *logMem
+	log      *logHeader
+	lines    []logLine
 	readOnly bool
+	also     *Ringlogger // Receives a copy of each line, such as the log of the tunnel
+	limiter  limiter
 }
 
 func NewRinglogger(filename string, tag string) (*Ringlogger, error) {
-	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0600)
+	err := prepareLogFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	err = file.Truncate(int64(unsafe.Sizeof(logMem{})))
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0600)
 	if err != nil {
 		return nil, err
 	}
@@ -59,18 +69,54 @@ func NewRinglogger(filename string, tag string) (*Ringlogger, error) {
 	}
 	rl, err := newRingloggerFromMappingHandle(mapping, tag, windows.FILE_MAP_WRITE)
 	if err != nil {
+		windows.CloseHandle(mapping)
+		file.Close()
+		if err == errLogFormat {
+			// Another process, such as a tunnel service of an older version, has the file open in a layout that
+			// prepareLogFile could not replace, and writing to it in this one would garble both.
+			return newMemoryRinglogger(tag)
+		}
 		return nil, err
 	}
 	rl.file = file
 	return rl, nil
 }
 
+// newMemoryRinglogger creates a log of the configured capacity in the paging file rather than in a file of its own,
+// which lasts only as long as the process, but can otherwise be used, and handed to the UI, as any other.
+func newMemoryRinglogger(tag string) (*Ringlogger, error) {
+	count := configuredLines()
+	size := uint64(headerSize) + uint64(count)*uint64(lineSize)
+	mapping, err := windows.CreateFileMapping(windows.InvalidHandle, nil, windows.PAGE_READWRITE, uint32(size>>32), uint32(size), nil)
+	if err != nil {
+		return nil, err
+	}
+	view, err := windows.MapViewOfFile(mapping, windows.FILE_MAP_WRITE, 0, 0, 0)
+	if err != nil {
+		windows.CloseHandle(mapping)
+		return nil, err
+	}
+	*(*logHeader)(unsafe.Pointer(view)) = logHeader{magic: magic, level: uint32(DefaultLevel), lineCount: count}
+	windows.UnmapViewOfFile(view)
+	rl, err := newRingloggerFromMappingHandle(mapping, tag, windows.FILE_MAP_WRITE)
+	if err != nil {
+		windows.CloseHandle(mapping)
+		return nil, err
+	}
+	return rl, nil
+}
+
 func NewRingloggerFromInheritedMappingHandle(handleStr string, tag string) (*Ringlogger, error) {
 	handle, err := strconv.ParseUint(handleStr, 10, 64)
 	if err != nil {
 		return nil, err
 	}
-	return newRingloggerFromMappingHandle(windows.Handle(handle), tag, windows.FILE_MAP_READ)
+	rl, err := newRingloggerFromMappingHandle(windows.Handle(handle), tag, windows.FILE_MAP_READ)
+	if err != nil {
+		windows.CloseHandle(windows.Handle(handle))
+		return nil, err
+	}
+	return rl, nil
 }
 
 func newRingloggerFromMappingHandle(mappingHandle windows.Handle, tag string, access uint32) (*Ringlogger, error) {
@@ -78,31 +124,67 @@ func newRingloggerFromMappingHandle(mappingHandle windows.Handle, tag string, ac
 	if err != nil {
 		return nil, err
 	}
+	log := (*logHeader)(unsafe.Pointer(view))
+	size, err := viewSize(view)
+	if err == nil && (uint64(size) < uint64(headerSize) || log.magic != magic || lineCount(log) > maxLinesLimit ||
+		lineCount(log)&(lineCount(log)-1) != 0 || uint64(size) < uint64(headerSize)+uint64(lineCount(log))*uint64(lineSize)) {
+		err = errLogFormat
+	}
 	if err != nil {
-		windows.CloseHandle(mappingHandle)
+		windows.UnmapViewOfFile(view)
 		return nil, err
 	}
-	log := (*logMem)(unsafe.Pointer(view))
-	if log.magic != magic {
-		bytes := (*[unsafe.Sizeof(logMem{})]byte)(unsafe.Pointer(log))
-		for i := range bytes {
-			bytes[i] = 0
-		}
-		log.magic = magic
-		windows.FlushViewOfFile(view, uintptr(len(bytes)))
-	}
+	count := lineCount(log)
 
 	rl := &Ringlogger{
 		tag:      tag,
 		mapping:  mappingHandle,
 		log:      log,
+		lines:    (*[maxLinesLimit]logLine)(unsafe.Pointer(view + unsafe.Sizeof(logHeader{})))[:count:count],
 		readOnly: access&windows.FILE_MAP_WRITE == 0,
 	}
 	runtime.SetFinalizer(rl, (*Ringlogger).Close)
 	return rl, nil
 }
 
+// Write logs the line at LevelInfo, which is how the standard logger ends up here.
 func (rl *Ringlogger) Write(p []byte) (n int, err error) {
+	return rl.WriteLevel(LevelInfo, p)
+}
+
+// WriteLevel logs the line marked with its level, unless the log is less verbose than that, in which case the line
+// is dropped without an error.
+func (rl *Ringlogger) WriteLevel(level Level, p []byte) (n int, err error) {
+	return rl.writeLevel(level, p, rl.also)
+}
+
+// writeLevel is WriteLevel, with the copy of the line going to also, which may be nil, rather than to the log that
+// InitTunnelLogger set.
+func (rl *Ringlogger) writeLevel(level Level, p []byte, also *Ringlogger) (n int, err error) {
+	// ETW has levels of its own, which traces choose for themselves, so every line goes there.
+	if !rl.readOnly {
+		keywords := etw.KeywordLog
+		if level == LevelError {
+			keywords |= etw.KeywordError
+		}
+		if etw.Enabled(level.etwLevel(), keywords) {
+			etw.Event(level.etwLevel(), keywords, fmt.Sprintf("[%s] %s", rl.tag, bytes.TrimSpace(p)))
+		}
+	}
+	if rl.log != nil && level > rl.Level() {
+		return len(p), nil
+	}
+	// Whoever asks for trace wants to see everything, repeats and all.
+	if rl.log != nil && rl.Level() < LevelTrace && !rl.admit(level, p) {
+		return len(p), nil
+	}
+	if also != nil {
+		also.writeLine(level.tag(), p)
+	}
+	return rl.writeLine(level.tag(), p)
+}
+
+func (rl *Ringlogger) writeLine(levelTag string, p []byte) (n int, err error) {
 	if rl.readOnly {
 		return 0, io.ErrShortWrite
 	}
@@ -114,9 +196,9 @@ func (rl *Ringlogger) Write(p []byte) (n int, err error) {
 		return 0, io.EOF
 	}
 
-	// Race: More than maxLines writers and this will clash.
+	// Race: More than len(rl.lines) writers and this will clash.
 	index := atomic.AddUint32(&rl.log.nextIndex, 1) - 1
-	line := &rl.log.lines[index%maxLines]
+	line := &rl.lines[index%uint32(len(rl.lines))]
 
 	// Race: Before this line executes, we'll display old data after new data.
 	atomic.StoreInt64(&line.timeNs, 0)
@@ -124,7 +206,10 @@ func (rl *Ringlogger) Write(p []byte) (n int, err error) {
 		line.line[i] = 0
 	}
 
-	text := []byte(fmt.Sprintf("[%s] %s", rl.tag, bytes.TrimSpace(p)))
+	text := []byte(fmt.Sprintf("[%s] %s%s", rl.tag, levelTag, bytes.TrimSpace(p)))
+	if Redacting() {
+		text = []byte(Redact(string(text)))
+	}
 	if len(text) > maxLogLineLength-1 {
 		text = text[:maxLogLineLength-1]
 	}
@@ -142,10 +227,10 @@ func (rl *Ringlogger) WriteTo(out io.Writer) (n int64, err error) {
 	if rl.log == nil {
 		return 0, io.EOF
 	}
-	log := *rl.log
-	i := log.nextIndex
-	for l := uint32(0); l < maxLines; l++ {
-		line := &log.lines[(i+l)%maxLines]
+	nextIndex := atomic.LoadUint32(&rl.log.nextIndex)
+	lines := rl.snapshot(nextIndex-uint32(len(rl.lines)), nextIndex)
+	for l := range lines {
+		line := &lines[l]
 		if line.timeNs == 0 {
 			continue
 		}
@@ -154,7 +239,7 @@ func (rl *Ringlogger) WriteTo(out io.Writer) (n int64, err error) {
 			continue
 		}
 		var bytes int
-		bytes, err = fmt.Fprintf(out, "%s: %s\n", time.Unix(0, line.timeNs).Format("2006-01-02 15:04:05.000000"), line.line[:index])
+		bytes, err = fmt.Fprintf(out, "%s: %s\n", time.Unix(0, line.timeNs).Format(dumpTimeFormat), line.line[:index])
 		if err != nil {
 			return
 		}
@@ -163,51 +248,116 @@ func (rl *Ringlogger) WriteTo(out io.Writer) (n int64, err error) {
 	return
 }
 
+// WriteLinesTo is like WriteTo, but for lines that have already been read, such as from another process, and with
+// the timestamps in the format given.
+func WriteLinesTo(out io.Writer, lines []FollowLine, format StampFormat) (n int64, err error) {
+	for _, line := range lines {
+		var bytes int
+		bytes, err = fmt.Fprintf(out, "%s: %s\n", format.Format(line.Stamp), line.Line)
+		if err != nil {
+			return
+		}
+		n += int64(bytes)
+	}
+	return
+}
+
+// CursorAll starts reading from the oldest line that the log still holds. Otherwise, a cursor is the index of the
+// next line to read, counted from the creation of the log, so that a reader that saved it can tell how many lines it
+// missed by the time it resumes.
 const CursorAll = ^uint32(0)
 
+const followInterval = time.Millisecond * 300
+
 type FollowLine struct {
 	Line  string
 	Stamp time.Time
 }
 
-func (rl *Ringlogger) FollowFromCursor(cursor uint32) (followLines []FollowLine, nextCursor uint32) {
-	followLines = make([]FollowLine, 0, maxLines)
+// FollowFromCursor waits until there are lines after the cursor and returns them, along with the cursor for the next
+// call, or returns ctx's error once it is done. When the log wrapped around past the cursor in the meantime, the lines
+// that were overwritten are lost, and missed says how many that was.
+func (rl *Ringlogger) FollowFromCursor(ctx context.Context, cursor uint32) (followLines []FollowLine, nextCursor uint32, missed uint32, err error) {
+	for {
+		followLines, nextCursor, missed = rl.ReadFromCursor(cursor)
+		if len(followLines) > 0 || missed > 0 {
+			return
+		}
+		if rl.log == nil {
+			return nil, cursor, 0, os.ErrClosed
+		}
+		cursor = nextCursor
+		select {
+		case <-ctx.Done():
+			return nil, cursor, 0, ctx.Err()
+		case <-time.After(followInterval):
+		}
+	}
+}
+
+// ReadFromCursor is like FollowFromCursor, but returns at once, with no lines if there are none yet.
+func (rl *Ringlogger) ReadFromCursor(cursor uint32) (followLines []FollowLine, nextCursor uint32, missed uint32) {
 	nextCursor = cursor
 
 	if rl.log == nil {
 		return
 	}
-	log := *rl.log
+	nextIndex := atomic.LoadUint32(&rl.log.nextIndex)
+	count := uint32(len(rl.lines))
 
-	i := cursor
-	if cursor == CursorAll {
-		i = log.nextIndex
+	// Since the count is a power of two, the indices stay in step with the ring even as they wrap around at 2^32.
+	fromOldest := cursor == CursorAll
+	if behind := nextIndex - cursor; !fromOldest && behind > count {
+		if behind < 1<<31 {
+			missed = behind - count
+		}
+		// Otherwise, the cursor is ahead of the log, which was recreated since, so all of it is new.
+		fromOldest = true
 	}
+	start := cursor
+	if fromOldest {
+		start = nextIndex - count
+	}
+	lines := rl.snapshot(start, nextIndex)
+	followLines = make([]FollowLine, 0, len(lines))
 
-	for l := 0; l < maxLines; l++ {
-		line := &log.lines[i%maxLines]
-		if cursor != CursorAll && i%maxLines == log.nextIndex%maxLines {
-			break
-		}
+	i := start
+	for ; i != nextIndex; i++ {
+		line := &lines[i-start]
 		if line.timeNs == 0 {
-			if cursor == CursorAll {
-				i++
+			// Slots never written are at the start of a log that has yet to wrap, while a slot whose line is still
+			// being written is at the end, and is read on the next call.
+			if fromOldest && len(followLines) == 0 {
 				continue
-			} else {
-				break
 			}
+			break
 		}
 		index := bytes.IndexByte(line.line[:], 0)
 		if index > 0 {
 			followLines = append(followLines, FollowLine{string(line.line[:index]), time.Unix(0, line.timeNs)})
 		}
-		i++
-		nextCursor = i % maxLines
 	}
+	nextCursor = i
 	return
 }
 
+// snapshot copies the lines from index start up to end, which are at most as many as the log holds, so that readers
+// see them as they were at one moment rather than as writers change them, without copying those they have read.
+func (rl *Ringlogger) snapshot(start, end uint32) []logLine {
+	count := uint32(len(rl.lines))
+	lines := make([]logLine, end-start)
+	first := start % count
+	n := copy(lines, rl.lines[first:])
+	copy(lines[n:], rl.lines)
+	return lines
+}
+
 func (rl *Ringlogger) Close() error {
+	rl.stopLimiting()
+	if rl.also != nil {
+		rl.also.Close()
+		rl.also = nil
+	}
 	if rl.file != nil {
 		rl.file.Close()
 		rl.file = nil
@@ -215,6 +365,7 @@ func (rl *Ringlogger) Close() error {
 	if rl.log != nil {
 		windows.UnmapViewOfFile((uintptr)(unsafe.Pointer(rl.log)))
 		rl.log = nil
+		rl.lines = nil
 	}
 	if rl.mapping != 0 {
 		windows.CloseHandle(rl.mapping)
@@ -224,6 +375,16 @@ func (rl *Ringlogger) Close() error {
 }
 
 func (rl *Ringlogger) ExportInheritableMappingHandleStr() (str string, handleToClose windows.Handle, err error) {
+	if rl.file == nil {
+		// A log in the paging file has only its mapping, of which the reader gets a copy that can only read.
+		process := windows.CurrentProcess()
+		err = windows.DuplicateHandle(process, rl.mapping, process, &handleToClose, windows.FILE_MAP_READ, true, 0)
+		if err != nil {
+			return
+		}
+		str = strconv.FormatUint(uint64(handleToClose), 10)
+		return
+	}
 	handleToClose, err = windows.CreateFileMapping(windows.Handle(rl.file.Fd()), nil, windows.PAGE_READONLY, 0, 0, nil)
 	if err != nil {
 		return
@@ -0,0 +1,42 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ringlogger
+
+import (
+	"time"
+)
+
+const (
+	dumpTimeFormat    = "2006-01-02 15:04:05.000000"
+	iso8601TimeFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// StampFormat is how the timestamps of lines are written when the log is shown or exported. Its zero value is local
+// time, written as the log always has been.
+type StampFormat struct {
+	UTC     bool // Rather than local time, so that logs of machines in different zones line up
+	ISO8601 bool // Such as 2020-11-20T15:30:00.000Z, with milliseconds and the offset from UTC
+}
+
+// In returns the time in the zone of the format.
+func (format StampFormat) In(t time.Time) time.Time {
+	if format.UTC {
+		return t.UTC()
+	}
+	return t.Local()
+}
+
+// Layout returns the layout for time.Format.
+func (format StampFormat) Layout() string {
+	if format.ISO8601 {
+		return iso8601TimeFormat
+	}
+	return dumpTimeFormat
+}
+
+func (format StampFormat) Format(t time.Time) string {
+	return format.In(t).Format(format.Layout())
+}
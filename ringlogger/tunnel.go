@@ -0,0 +1,131 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ringlogger
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+)
+
+// tunnelLogPath returns where the tunnel keeps a ring of its own lines, so that its history survives however chatty
+// the other tunnels are in the shared log.
+func tunnelLogPath(tunnelName string, create bool) (string, error) {
+	root, err := conf.RootDirectory(create)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(root, "Logs")
+	if create {
+		err = os.Mkdir(dir, os.ModeDir|0700)
+		if err != nil && !os.IsExist(err) {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, tunnelName+".bin"), nil
+}
+
+// InitTunnelLogger makes the global logger write each line to the log of the tunnel as well as to the shared log.
+func InitTunnelLogger(tunnelName string) error {
+	if Global == nil || Global.also != nil {
+		return nil
+	}
+	path, err := tunnelLogPath(tunnelName, true)
+	if err != nil {
+		return err
+	}
+	Global.also, err = NewRinglogger(path, Global.tag)
+	return err
+}
+
+// DeleteTunnelLog removes the log of the tunnel, whose service must have stopped, once the tunnel has been deleted or
+// renamed, so that the logs of tunnels that are no more do not pile up.
+func DeleteTunnelLog(tunnelName string) error {
+	if !conf.TunnelNameIsValid(tunnelName) {
+		return os.ErrNotExist
+	}
+	path, err := tunnelLogPath(tunnelName, false)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// OpenLog maps the shared log, or the log of the tunnel if the name is not empty, for reading.
+func OpenLog(tunnelName string) (*Ringlogger, error) {
+	var path string
+	if len(tunnelName) == 0 {
+		root, err := conf.RootDirectory(false)
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(root, "log.bin")
+	} else {
+		if !conf.TunnelNameIsValid(tunnelName) {
+			return nil, os.ErrNotExist
+		}
+		var err error
+		path, err = tunnelLogPath(tunnelName, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return openReadOnly(path)
+}
+
+// Loggers are leveled loggers, like the package-level ones, which Shared holds.
+type Loggers struct {
+	Error, Warn, Info, Debug, Trace *log.Logger
+
+	log *Ringlogger // The log of the tunnel, or nil
+}
+
+// Shared holds the package-level loggers, which is what a process with only one tunnel logs it through.
+var Shared = &Loggers{Error: Error, Warn: Warn, Info: Info, Debug: Debug, Trace: Trace}
+
+type tunnelLevelWriter struct {
+	level Level
+	log   *Ringlogger
+}
+
+func (w tunnelLevelWriter) Write(p []byte) (int, error) {
+	if Global == nil {
+		return os.Stderr.Write(p)
+	}
+	return Global.writeLevel(w.level, p, w.log)
+}
+
+// NewTunnelLoggers returns the loggers of one of the tunnels of a process that runs several, which mark each line with
+// the name of the tunnel, as SetPrefix would, and write it to the log of the tunnel as well as to the shared log. If
+// the log of the tunnel cannot be opened, the loggers are returned along with the error, and write only to the shared
+// log.
+func NewTunnelLoggers(tunnelName string) (*Loggers, error) {
+	loggers := &Loggers{}
+	path, err := tunnelLogPath(tunnelName, true)
+	if err == nil && Global != nil {
+		loggers.log, err = NewRinglogger(path, Global.tag)
+	}
+	prefix := fmt.Sprintf("[%s] ", tunnelName)
+	for level, logger := range []**log.Logger{&loggers.Error, &loggers.Warn, &loggers.Info, &loggers.Debug, &loggers.Trace} {
+		*logger = log.New(tunnelLevelWriter{Level(level), loggers.log}, prefix, 0)
+	}
+	return loggers, err
+}
+
+// Close closes the log of the tunnel, after which the loggers write only to the shared log.
+func (loggers *Loggers) Close() error {
+	if loggers.log == nil {
+		return nil
+	}
+	return loggers.log.Close()
+}
@@ -24,3 +24,10 @@ func PipePathOfTunnel(tunnelName string) (string, error) {
 	}
 	return `\\.\pipe\ProtectedPrefix\Administrators\WireGuard\` + tunnelName, nil
 }
+
+// The tunnel host is the service that runs, in one process, the tunnels that need no service of their own, when the
+// ConsolidatedTunnelService policy is set. Its pipe is not under that of the tunnels, so that it is not taken for one.
+const (
+	TunnelHostServiceName = "WireGuardTunnelHost"
+	TunnelHostPipePath    = `\\.\pipe\ProtectedPrefix\Administrators\WireGuardTunnelHost`
+)
@@ -0,0 +1,18 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package services
+
+// TunnelServicePrivileges are the only privileges that the service control manager leaves in the token of a tunnel
+// service, which would otherwise have every privilege of Local System. Once it has started, the tunnel service drops
+// all but SeLoadDriverPrivilege itself.
+var TunnelServicePrivileges = []string{
+	"SeChangeNotifyPrivilege", // Which every service is given regardless
+	"SeLoadDriverPrivilege",   // For Wintun to create the adapter and to remove it when shutting down
+	"SeImpersonatePrivilege",  // For Wintun to impersonate Local System while it does
+}
+
+// SeDebugPrivilege is not among them, since Wintun only needs it to open the token of a process of Local System when
+// running as an administrator, which Local System itself can open without it.
@@ -15,6 +15,8 @@ import (
 	"golang.zx2c4.com/wireguard/tun"
 
 	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/etw"
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
 	"golang.zx2c4.com/wireguard/windows/tunnel/firewall"
 	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
 )
@@ -133,6 +135,11 @@ func configureInterface(family winipcfg.AddressFamily, conf *conf.Config, tun *t
 	if err != nil {
 		return err
 	}
+	ipversion := "v4"
+	if family == windows.AF_INET6 {
+		ipversion = "v6"
+	}
+	etw.Eventf(etw.LevelInfo, etw.KeywordRoute, "Set %d %s addresses and %d routes", len(addresses), ipversion, len(deduplicatedRoutes))
 
 	ipif, err := luid.IPInterface(family)
 	if err != nil {
@@ -169,32 +176,39 @@ func configureInterface(family winipcfg.AddressFamily, conf *conf.Config, tun *t
 		return nil
 	}
 	if len(conf.Interface.DNSSearch) > 1 {
-		log.Printf("Warning: %d DNS search domains were specified, but only one is supported, so the first one (%s) was used.", len(conf.Interface.DNSSearch), dnsSearch)
+		ringlogger.Warn.Printf("%d DNS search domains were specified, but only one is supported, so the first one (%s) was used.", len(conf.Interface.DNSSearch), dnsSearch)
 	}
 	err = luid.SetDNSForFamily(family, conf.Interface.DNS)
 	if err != nil {
 		return err
 	}
+	etw.Eventf(etw.LevelInfo, etw.KeywordDNS, "Set %s DNS servers %v and search domain %q", ipversion, conf.Interface.DNS, dnsSearch)
 
 	return nil
 }
 
-func enableFirewall(conf *conf.Config, tun *tun.NativeTun) error {
-	doNotRestrict := true
-	if len(conf.Peers) == 1 {
-	nextallowedip:
-		for _, allowedip := range conf.Peers[0].AllowedIPs {
-			if allowedip.Cidr == 0 {
-				for _, b := range allowedip.IP {
-					if b != 0 {
-						continue nextallowedip
-					}
+// RoutesAllTraffic returns whether the tunnel has a single peer through which all traffic is routed, in which case the
+// firewall restricts what may go around it, which is why such a tunnel needs a process of its own.
+func RoutesAllTraffic(conf *conf.Config) bool {
+	if len(conf.Peers) != 1 {
+		return false
+	}
+nextallowedip:
+	for _, allowedip := range conf.Peers[0].AllowedIPs {
+		if allowedip.Cidr == 0 {
+			for _, b := range allowedip.IP {
+				if b != 0 {
+					continue nextallowedip
 				}
-				doNotRestrict = false
-				break
 			}
+			return true
 		}
 	}
+	return false
+}
+
+func enableFirewall(conf *conf.Config, tun *tun.NativeTun) error {
+	doNotRestrict := !RoutesAllTraffic(conf)
 	log.Println("Enabling firewall rules")
 	return firewall.EnableFirewall(tun.LUID(), doNotRestrict, conf.Interface.DNS)
 }
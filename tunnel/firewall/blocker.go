@@ -47,7 +47,7 @@ func createWfpSession() (uintptr, error) {
 	return sessionHandle, nil
 }
 
-func registerBaseObjects(session uintptr) (*baseObjects, error) {
+func registerBaseObjects(session uintptr, luid uint64) (*baseObjects, error) {
 	bo := &baseObjects{}
 	var err error
 	bo.provider, err = windows.GenerateGUID()
@@ -70,6 +70,10 @@ func registerBaseObjects(session uintptr) (*baseObjects, error) {
 		provider := wtFwpmProvider0{
 			providerKey: bo.provider,
 			displayData: *displayData,
+			providerData: wtFwpByteBlob{
+				size: uint32(unsafe.Sizeof(luid)),
+				data: (*uint8)(unsafe.Pointer(&luid)),
+			},
 		}
 		err = fwpmProviderAdd0(session, &provider, 0)
 		if err != nil {
@@ -112,7 +116,7 @@ func EnableFirewall(luid uint64, doNotRestrict bool, restrictToDNSServers []net.
 	}
 
 	objectInstaller := func(session uintptr) error {
-		baseObjects, err := registerBaseObjects(session)
+		baseObjects, err := registerBaseObjects(session, luid)
 		if err != nil {
 			return wrapErr(err)
 		}
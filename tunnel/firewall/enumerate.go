@@ -0,0 +1,77 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package firewall
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// AppliedFilters returns the display names of the filters currently installed by
+// the WireGuard provider that was registered for the interface with the given LUID.
+func AppliedFilters(luid uint64) ([]string, error) {
+	var session uintptr
+	err := fwpmEngineOpen0(nil, cRPC_C_AUTHN_WINNT, nil, nil, unsafe.Pointer(&session))
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	defer fwpmEngineClose0(session)
+
+	var enumHandle uintptr
+	err = fwpmFilterCreateEnumHandle0(session, 0, &enumHandle)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	defer fwpmFilterDestroyEnumHandle0(session, enumHandle)
+
+	providers := make(map[windows.GUID]bool)
+	isOurs := func(key *windows.GUID) bool {
+		if key == nil {
+			return false
+		}
+		if ours, ok := providers[*key]; ok {
+			return ours
+		}
+		var provider *wtFwpmProvider0
+		ours := false
+		if fwpmProviderGetByKey0(session, key, &provider) == nil {
+			if provider.displayData.name != nil && windows.UTF16PtrToString(provider.displayData.name) == "WireGuard" &&
+				provider.providerData.size == uint32(unsafe.Sizeof(luid)) && provider.providerData.data != nil {
+				ours = *(*uint64)(unsafe.Pointer(provider.providerData.data)) == luid
+			}
+			fwpmFreeMemory0(unsafe.Pointer(&provider))
+		}
+		providers[*key] = ours
+		return ours
+	}
+
+	const batchSize = 128
+	var names []string
+	for {
+		var entries **wtFwpmFilter0
+		var count uint32
+		err = fwpmFilterEnum0(session, enumHandle, batchSize, &entries, &count)
+		if err != nil {
+			return nil, wrapErr(err)
+		}
+		if count == 0 {
+			break
+		}
+		filters := (*[(1 << 28) - 1]*wtFwpmFilter0)(unsafe.Pointer(entries))[:count:count]
+		for _, filter := range filters {
+			if !isOurs(filter.providerKey) || filter.displayData.name == nil {
+				continue
+			}
+			names = append(names, windows.UTF16PtrToString(filter.displayData.name))
+		}
+		fwpmFreeMemory0(unsafe.Pointer(&entries))
+		if count < batchSize {
+			break
+		}
+	}
+	return names, nil
+}
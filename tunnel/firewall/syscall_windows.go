@@ -34,3 +34,15 @@ package firewall
 
 // https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmprovideradd0
 //sys	fwpmProviderAdd0(engineHandle uintptr, provider *wtFwpmProvider0, sd uintptr) (err error) [failretval!=0] = fwpuclnt.FwpmProviderAdd0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmprovidergetbykey0
+//sys	fwpmProviderGetByKey0(engineHandle uintptr, key *windows.GUID, provider **wtFwpmProvider0) (err error) [failretval!=0] = fwpuclnt.FwpmProviderGetByKey0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmfiltercreateenumhandle0
+//sys	fwpmFilterCreateEnumHandle0(engineHandle uintptr, enumTemplate uintptr, enumHandle *uintptr) (err error) [failretval!=0] = fwpuclnt.FwpmFilterCreateEnumHandle0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmfilterenum0
+//sys	fwpmFilterEnum0(engineHandle uintptr, enumHandle uintptr, numEntriesRequested uint32, entries ***wtFwpmFilter0, numEntriesReturned *uint32) (err error) [failretval!=0] = fwpuclnt.FwpmFilterEnum0
+
+// https://docs.microsoft.com/en-us/windows/desktop/api/fwpmu/nf-fwpmu-fwpmfilterdestroyenumhandle0
+//sys	fwpmFilterDestroyEnumHandle0(engineHandle uintptr, enumHandle uintptr) (err error) [failretval!=0] = fwpuclnt.FwpmFilterDestroyEnumHandle0
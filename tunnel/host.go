@@ -0,0 +1,415 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package tunnel
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+
+	"golang.zx2c4.com/wireguard/ipc/winpipe"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/crashdump"
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
+	"golang.zx2c4.com/wireguard/windows/services"
+	"golang.zx2c4.com/wireguard/windows/version"
+)
+
+// The tunnel host runs many tunnels in one process, each with its own Wintun interface, device and named pipe, so that
+// machines with dozens of tunnels do not need as many services. Unlike a tunnel service, it keeps its privileges, and
+// may start processes, since it creates and sets up the interfaces of tunnels started long after it, which takes
+// running netsh.exe for their DNS servers, and it never enables the firewall, which is for the whole process, so the
+// manager gives tunnels that route all traffic their own services still. Only the manager, which runs as Local System,
+// may connect to its pipe.
+const (
+	tunnelHostSDDL         = "O:SYD:P(A;;GA;;;SY)"
+	tunnelHostStopDeadline = time.Second * 30
+)
+
+// HostRequest is what the manager sends over the pipe of the tunnel host. Verb is "start", with the path of the
+// configuration, "stop", with the name of the tunnel, or "watch", after which the host sends a HostEvent whenever a
+// tunnel changes state, starting with the current state of each.
+type HostRequest struct {
+	Verb       string
+	ConfigPath string
+	Tunnel     string
+}
+
+type HostResponse struct {
+	Error   string
+	Running int // The tunnels still starting or running after the request
+}
+
+// HostEvent is a tunnel of the host changing state, with the same exit codes that a tunnel service would have when it
+// stops.
+type HostEvent struct {
+	Tunnel                  string
+	State                   svc.State
+	Win32ExitCode           uint32
+	ServiceSpecificExitCode uint32
+	Running                 int // The tunnels still starting or running after the change
+}
+
+type hostedTunnel struct {
+	state    svc.State
+	stop     chan struct{}
+	stopping bool
+	stopped  chan struct{}
+}
+
+type tunnelHost struct {
+	sync.Mutex
+	tunnels      map[string]*hostedTunnel
+	active       map[string]string // Config paths by tunnel name, of what to start again when the host starts
+	watchers     map[net.Conn]*gob.Encoder
+	shuttingDown bool
+}
+
+func (host *tunnelHost) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (svcSpecificEC bool, exitCode uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+
+	var listener net.Listener
+	var err error
+	serviceError := services.ErrorSuccess
+
+	defer func() {
+		svcSpecificEC, exitCode = services.DetermineErrorCode(err, serviceError)
+		logErr := services.CombineErrors(err, serviceError)
+		if logErr != nil {
+			log.Println(logErr)
+		}
+		changes <- svc.Status{State: svc.StopPending}
+		if listener != nil {
+			listener.Close()
+		}
+		host.stopAll()
+		log.Println("Shutting down")
+	}()
+
+	err = ringlogger.InitGlobalLogger("TUN")
+	if err != nil {
+		serviceError = services.ErrorRingloggerOpen
+		return
+	}
+	defer printPanic()
+
+	if err := crashdump.Install("TUNHOST"); err != nil {
+		ringlogger.Warn.Printf("Unable to install crash handler: %v", err)
+	}
+
+	log.Println("Starting tunnel host", version.UserAgent())
+
+	host.active, err = conf.LoadHostActive()
+	if err != nil {
+		ringlogger.Warn.Printf("Unable to load the tunnels to start: %v", err)
+		host.active = make(map[string]string)
+		err = nil
+	}
+
+	sd, err := windows.SecurityDescriptorFromString(tunnelHostSDDL)
+	if err != nil {
+		serviceError = services.ErrorUAPIListen
+		return
+	}
+	listener, err = winpipe.ListenPipe(services.TunnelHostPipePath, &winpipe.PipeConfig{SecurityDescriptor: sd})
+	if err != nil {
+		serviceError = services.ErrorUAPIListen
+		return
+	}
+
+	// The host is running before it starts any tunnel, so that creating their Wintun interfaces, which starts a
+	// service too, does not deadlock at boot should the service control manager be locked.
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	log.Println("Startup complete")
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go host.serve(conn)
+		}
+	}()
+
+	host.Lock()
+	active := make(map[string]string, len(host.active))
+	for name, configPath := range host.active {
+		active[name] = configPath
+	}
+	host.Unlock()
+	for name, configPath := range active {
+		if err := host.start(configPath); err != nil {
+			log.Printf("[%s] Unable to start in the tunnel host: %v", name, err)
+		}
+	}
+
+	for c := range r {
+		switch c.Cmd {
+		case svc.Stop, svc.Shutdown:
+			return
+		case svc.Interrogate:
+			changes <- c.CurrentStatus
+		default:
+			ringlogger.Error.Printf("Unexpected service control request #%d\n", c)
+		}
+	}
+	return
+}
+
+func (host *tunnelHost) serve(conn net.Conn) {
+	defer printPanic()
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second * 30))
+	var request HostRequest
+	err := gob.NewDecoder(conn).Decode(&request)
+	if err != nil {
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+	encoder := gob.NewEncoder(conn)
+	var response HostResponse
+	switch request.Verb {
+	case "start":
+		err = host.start(request.ConfigPath)
+	case "stop":
+		err = host.stop(request.Tunnel)
+	case "watch":
+		host.watch(conn, encoder)
+		return
+	default:
+		err = fmt.Errorf("Unknown request: %q", request.Verb)
+	}
+	if err != nil {
+		response.Error = err.Error()
+	}
+	host.Lock()
+	response.Running = host.running()
+	host.Unlock()
+	conn.SetWriteDeadline(time.Now().Add(time.Second * 5))
+	encoder.Encode(response)
+}
+
+// watch sends the current state of each tunnel to the watcher, and then, until it disconnects, every change.
+func (host *tunnelHost) watch(conn net.Conn, encoder *gob.Encoder) {
+	host.Lock()
+	host.watchers[conn] = encoder
+	for name, t := range host.tunnels {
+		conn.SetWriteDeadline(time.Now().Add(time.Second * 2))
+		if encoder.Encode(HostEvent{Tunnel: name, State: t.state, Running: host.running()}) != nil {
+			delete(host.watchers, conn)
+			host.Unlock()
+			return
+		}
+	}
+	host.Unlock()
+	var discard [1]byte
+	for {
+		if _, err := conn.Read(discard[:]); err != nil {
+			break
+		}
+	}
+	host.Lock()
+	delete(host.watchers, conn)
+	host.Unlock()
+}
+
+// running returns the number of tunnels still starting or running. The host must be locked.
+func (host *tunnelHost) running() int {
+	running := 0
+	for _, t := range host.tunnels {
+		if !t.stopping {
+			running++
+		}
+	}
+	return running
+}
+
+// notify sends the event to every watcher, dropping those that do not take it in time. The host must be locked.
+func (host *tunnelHost) notify(event HostEvent) {
+	event.Running = host.running()
+	for conn, encoder := range host.watchers {
+		conn.SetWriteDeadline(time.Now().Add(time.Second * 2))
+		if encoder.Encode(event) != nil {
+			conn.Close()
+			delete(host.watchers, conn)
+		}
+	}
+}
+
+func (host *tunnelHost) start(configPath string) error {
+	if !conf.PathIsEncrypted(configPath) {
+		return errors.New("Only tunnels of the configuration store may be hosted")
+	}
+	config, err := conf.LoadFromPath(configPath)
+	if err != nil {
+		return err
+	}
+	config.DeduplicateNetworkEntries()
+	if RoutesAllTraffic(config) {
+		return errors.New("Tunnels that route all traffic need a service of their own")
+	}
+
+	host.Lock()
+	defer host.Unlock()
+	if host.shuttingDown {
+		return errors.New("The tunnel host is shutting down")
+	}
+	if _, found := host.tunnels[config.Name]; found {
+		return errors.New("Tunnel already installed and running")
+	}
+	t := &hostedTunnel{state: svc.StartPending, stop: make(chan struct{}), stopped: make(chan struct{})}
+	host.tunnels[config.Name] = t
+	if host.active[config.Name] != configPath {
+		host.active[config.Name] = configPath
+		host.saveActive()
+	}
+	host.notify(HostEvent{Tunnel: config.Name, State: svc.StartPending})
+	go host.run(config, t)
+	return nil
+}
+
+func (host *tunnelHost) stop(tunnelName string) error {
+	host.Lock()
+	defer host.Unlock()
+	_, wasActive := host.active[tunnelName]
+	if wasActive {
+		delete(host.active, tunnelName)
+		host.saveActive()
+	}
+	t, found := host.tunnels[tunnelName]
+	if !found {
+		if wasActive {
+			return nil
+		}
+		return errors.New("Tunnel is not in the tunnel host")
+	}
+	if !t.stopping {
+		t.stopping = true
+		close(t.stop)
+	}
+	return nil
+}
+
+// stopAll stops every tunnel, when the host itself stops. They are started again when it starts, unless none was
+// running, in which case it was stopped because no tunnel is left for it, and those that stopped on their own are not
+// started again either.
+func (host *tunnelHost) stopAll() {
+	host.Lock()
+	host.shuttingDown = true
+	var stopped []chan struct{}
+	for _, t := range host.tunnels {
+		if !t.stopping {
+			t.stopping = true
+			close(t.stop)
+		}
+		stopped = append(stopped, t.stopped)
+	}
+	if len(stopped) == 0 && len(host.active) > 0 {
+		host.active = make(map[string]string)
+		host.saveActive()
+	}
+	host.Unlock()
+
+	deadline := time.After(tunnelHostStopDeadline)
+	for _, s := range stopped {
+		select {
+		case <-s:
+		case <-deadline:
+			ringlogger.Error.Printf("Failed to stop all tunnels after %v, so exiting anyway", tunnelHostStopDeadline)
+			return
+		}
+	}
+}
+
+func (host *tunnelHost) setState(tunnelName string, t *hostedTunnel, state svc.State) {
+	host.Lock()
+	t.state = state
+	host.notify(HostEvent{Tunnel: tunnelName, State: state})
+	host.Unlock()
+}
+
+// run brings the tunnel up, and down again when it is stopped, or when it fails. Unless the host itself is stopping, it
+// is then no longer started when the host starts, just as the service of a tunnel that stops is deleted.
+func (host *tunnelHost) run(config *conf.Config, t *hostedTunnel) {
+	defer printPanic()
+
+	loggers, err := ringlogger.NewTunnelLoggers(config.Name)
+	if err != nil {
+		loggers.Warn.Printf("Unable to open the log of the tunnel, so only the shared log is kept: %v", err)
+		err = nil
+	}
+	rt := &runningTunnel{config: config, log: loggers}
+	serviceError := services.ErrorSuccess
+
+	defer func() {
+		svcSpecificEC, exitCode := services.DetermineErrorCode(err, serviceError)
+		logErr := services.CombineErrors(err, serviceError)
+		if logErr != nil {
+			loggers.Error.Println(logErr)
+		}
+		host.setState(config.Name, t, svc.StopPending)
+		rt.tearDown(logErr == nil)
+		loggers.Info.Println("Shutting down")
+		loggers.Close()
+
+		event := HostEvent{Tunnel: config.Name, State: svc.Stopped, Win32ExitCode: exitCode}
+		if svcSpecificEC {
+			event.Win32ExitCode = uint32(windows.ERROR_SERVICE_SPECIFIC_ERROR)
+			event.ServiceSpecificExitCode = exitCode
+		}
+		host.Lock()
+		delete(host.tunnels, config.Name)
+		close(t.stopped)
+		if _, wasActive := host.active[config.Name]; wasActive && !host.shuttingDown {
+			delete(host.active, config.Name)
+			host.saveActive()
+		}
+		host.notify(event)
+		host.Unlock()
+	}()
+
+	loggers.Info.Println("Starting in the tunnel host")
+	serviceError, err = rt.bringUp(false, nil)
+	if err != nil {
+		return
+	}
+	host.setState(config.Name, t, svc.Running)
+	loggers.Info.Println("Startup complete")
+
+	select {
+	case <-t.stop:
+	case <-rt.dev.Wait():
+	case <-rt.idle:
+		loggers.Info.Printf("No traffic for %v, deactivating", rt.options.IdleTimeout)
+	case e := <-rt.watcher.errors:
+		serviceError, err = e.serviceError, e.err
+	}
+}
+
+// saveActive writes the tunnels to start when the host starts. The host must be locked.
+func (host *tunnelHost) saveActive() {
+	if err := conf.SaveHostActive(host.active); err != nil {
+		ringlogger.Warn.Printf("Unable to save the tunnels to start: %v", err)
+	}
+}
+
+// RunHost runs the tunnel host service.
+func RunHost() error {
+	return svc.Run(services.TunnelHostServiceName, &tunnelHost{
+		tunnels:  make(map[string]*hostedTunnel),
+		active:   make(map[string]string),
+		watchers: make(map[net.Conn]*gob.Encoder),
+	})
+}
@@ -0,0 +1,87 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package tunnel
+
+import (
+	"encoding/gob"
+	"errors"
+	"net"
+	"time"
+
+	"golang.org/x/sys/windows"
+
+	"golang.zx2c4.com/wireguard/ipc/winpipe"
+
+	"golang.zx2c4.com/wireguard/windows/services"
+)
+
+func dialHost() (net.Conn, error) {
+	localSystem, err := windows.CreateWellKnownSid(windows.WinLocalSystemSid)
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.Second * 5
+	return winpipe.DialPipe(services.TunnelHostPipePath, &timeout, localSystem)
+}
+
+func hostRequest(request HostRequest) (running int, err error) {
+	conn, err := dialHost()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Second * 30))
+	err = gob.NewEncoder(conn).Encode(request)
+	if err != nil {
+		return 0, err
+	}
+	var response HostResponse
+	err = gob.NewDecoder(conn).Decode(&response)
+	if err != nil {
+		return 0, err
+	}
+	if len(response.Error) > 0 {
+		return response.Running, errors.New(response.Error)
+	}
+	return response.Running, nil
+}
+
+// HostStart has the tunnel host start the tunnel of the configuration at the path, which must be in the configuration
+// store. Like starting a tunnel service, it returns before the tunnel is up; WatchHost tells when it is.
+func HostStart(configPath string) error {
+	_, err := hostRequest(HostRequest{Verb: "start", ConfigPath: configPath})
+	return err
+}
+
+// HostStop has the tunnel host stop the tunnel, returning how many other tunnels it is still running, so that it can
+// be stopped too when there are none.
+func HostStop(tunnelName string) (running int, err error) {
+	return hostRequest(HostRequest{Verb: "stop", Tunnel: tunnelName})
+}
+
+// WatchHost calls onEvent with the current state of each tunnel of the host, and then with every change, until the
+// connection to the host is lost, which is when it returns.
+func WatchHost(onEvent func(HostEvent)) error {
+	conn, err := dialHost()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(time.Second * 5))
+	err = gob.NewEncoder(conn).Encode(HostRequest{Verb: "watch"})
+	if err != nil {
+		return err
+	}
+	decoder := gob.NewDecoder(conn)
+	for {
+		var event HostEvent
+		err = decoder.Decode(&event)
+		if err != nil {
+			return err
+		}
+		onEvent(event)
+	}
+}
@@ -0,0 +1,55 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package tunnel
+
+import (
+	"time"
+
+	"golang.zx2c4.com/wireguard/tun"
+
+	"golang.zx2c4.com/wireguard/windows/tunnel/winipcfg"
+)
+
+const idleCheckInterval = time.Minute
+
+// watchIdle returns a channel that is closed once the interface has carried no unicast traffic for the given
+// timeout. Multicast and broadcast chatter from the operating system is deliberately not counted as traffic.
+// Closing stop ends the watch.
+func watchIdle(nativeTun *tun.NativeTun, timeout time.Duration, stop <-chan struct{}) <-chan struct{} {
+	idle := make(chan struct{})
+	luid := winipcfg.LUID(nativeTun.LUID())
+	octets := func() (uint64, bool) {
+		row, err := luid.Interface()
+		if err != nil {
+			return 0, false
+		}
+		return row.InUcastOctets + row.OutUcastOctets, true
+	}
+	go func() {
+		ticker := time.NewTicker(idleCheckInterval)
+		defer ticker.Stop()
+		last, _ := octets()
+		lastActivity := time.Now()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			current, ok := octets()
+			if !ok || current != last {
+				last = current
+				lastActivity = time.Now()
+				continue
+			}
+			if time.Since(lastActivity) >= timeout {
+				close(idle)
+				return
+			}
+		}
+	}()
+	return idle
+}
@@ -40,6 +40,9 @@ type interfaceWatcher struct {
 	changeCallbacks4        []winipcfg.ChangeCallback
 	changeCallbacks6        []winipcfg.ChangeCallback
 	storedEvents            []interfaceWatcherEvent
+
+	configured4, configured6 bool
+	afterConfigure           func() // Called once both families have been configured, or nil
 }
 
 func hasDefaultRoute(family winipcfg.AddressFamily, peers []conf.Peer) bool {
@@ -113,6 +116,16 @@ func (iw *interfaceWatcher) setup(family winipcfg.AddressFamily) {
 		iw.errors <- interfaceWatcherError{services.ErrorSetNetConfig, err}
 		return
 	}
+	if family == windows.AF_INET {
+		iw.configured4 = true
+	} else {
+		iw.configured6 = true
+	}
+	if iw.configured4 && iw.configured6 && iw.afterConfigure != nil {
+		afterConfigure := iw.afterConfigure
+		iw.afterConfigure = nil
+		afterConfigure()
+	}
 }
 
 func watchInterface() (*interfaceWatcher, error) {
@@ -142,11 +155,13 @@ func watchInterface() (*interfaceWatcher, error) {
 	return iw, nil
 }
 
-func (iw *interfaceWatcher) Configure(device *device.Device, conf *conf.Config, tun *tun.NativeTun) {
+// Configure sets up the interface of the device for each family as it appears, and then calls afterConfigure, if it
+// is not nil, once both have been set up, which never happens on a system that has one of them disabled.
+func (iw *interfaceWatcher) Configure(device *device.Device, conf *conf.Config, tun *tun.NativeTun, afterConfigure func()) {
 	iw.setupMutex.Lock()
 	defer iw.setupMutex.Unlock()
 
-	iw.device, iw.conf, iw.tun = device, conf, tun
+	iw.device, iw.conf, iw.tun, iw.afterConfigure = device, conf, tun, afterConfigure
 	for _, event := range iw.storedEvents {
 		if event.luid == winipcfg.LUID(iw.tun.LUID()) {
 			iw.setup(event.family)
@@ -0,0 +1,103 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package tunnel
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"golang.zx2c4.com/wireguard/device"
+
+	"golang.zx2c4.com/wireguard/windows/etw"
+)
+
+const peerWatchInterval = time.Second * 5
+
+type watchedPeer struct {
+	endpoint      string
+	lastHandshake string
+}
+
+// watchPeers emits an ETW event whenever a peer completes a handshake or roams to a new endpoint, which wireguard-go
+// does not announce, by comparing its state from one interval to the next. The device is only asked for its state
+// while a trace has enabled those events, and the first state after that is taken as it is, so that handshakes made
+// while nobody was listening are not reported late. Closing stop ends the watch.
+func watchPeers(dev *device.Device, stop <-chan struct{}) {
+	var peers map[string]*watchedPeer
+	ticker := time.NewTicker(peerWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if !etw.Enabled(etw.LevelInfo, etw.KeywordHandshake|etw.KeywordEndpoint) {
+			peers = nil
+			continue
+		}
+		var buf bytes.Buffer
+		writer := bufio.NewWriter(&buf)
+		if dev.IpcGetOperation(writer) != nil || writer.Flush() != nil {
+			continue
+		}
+		var name string
+		var current *watchedPeer
+		seen := make(map[string]*watchedPeer)
+		finishPeer := func() {
+			if current == nil {
+				return
+			}
+			previous := peers[name]
+			if peers == nil {
+				previous = current
+			} else if previous == nil {
+				previous = &watchedPeer{lastHandshake: "0"}
+			}
+			if current.lastHandshake != previous.lastHandshake && current.lastHandshake != "0" {
+				etw.Eventf(etw.LevelInfo, etw.KeywordHandshake, "Handshake completed with peer %s at %s", name, current.endpoint)
+			}
+			if current.endpoint != previous.endpoint && len(previous.endpoint) > 0 {
+				etw.Eventf(etw.LevelInfo, etw.KeywordEndpoint, "Endpoint of peer %s changed from %s to %s", name, previous.endpoint, current.endpoint)
+			}
+			seen[name] = current
+		}
+		scanner := bufio.NewScanner(&buf)
+		for scanner.Scan() {
+			key, value := scanner.Text(), ""
+			if i := strings.IndexByte(key, '='); i >= 0 {
+				key, value = key[:i], key[i+1:]
+			}
+			switch key {
+			case "public_key":
+				finishPeer()
+				name = value
+				if raw, err := hex.DecodeString(value); err == nil {
+					name = base64.StdEncoding.EncodeToString(raw)
+				}
+				current = &watchedPeer{lastHandshake: "0"}
+			case "endpoint":
+				if current != nil {
+					current.endpoint = value
+				}
+			case "last_handshake_time_sec":
+				if current != nil {
+					current.lastHandshake = value
+				}
+			case "last_handshake_time_nsec":
+				if current != nil && current.lastHandshake != "0" {
+					current.lastHandshake += "." + value
+				}
+			}
+		}
+		finishPeer()
+		peers = seen
+	}
+}
@@ -0,0 +1,165 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package tunnel
+
+import (
+	"bufio"
+	"net"
+	"strings"
+
+	"golang.zx2c4.com/wireguard/device"
+	"golang.zx2c4.com/wireguard/ipc"
+	"golang.zx2c4.com/wireguard/tun"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
+	"golang.zx2c4.com/wireguard/windows/services"
+)
+
+// runningTunnel is a tunnel being brought up or down, either as the only one of a tunnel service, or as one of those
+// of the tunnel host. Whatever of it has been brought up is not nil, so that tearDown undoes just that.
+type runningTunnel struct {
+	config  *conf.Config
+	options *conf.TunnelOptions
+	log     *ringlogger.Loggers
+
+	watcher   *interfaceWatcher
+	nativeTun *tun.NativeTun
+	dev       *device.Device
+	uapi      net.Listener
+
+	stopWatches chan struct{}
+	idle        <-chan struct{}
+
+	afterConfigure func() // Called once the addresses, routes, and DNS servers of the interface are set, or nil
+}
+
+// bringUp brings the tunnel up, as far as running its PostUp script. It enables the firewall only if firewall is true,
+// since only one tunnel of a process may have it, and calls beforeDevice, if it is not nil, right before creating the
+// device, which is when the tunnel service drops its privileges.
+func (rt *runningTunnel) bringUp(firewall bool, beforeDevice func() (services.Error, error)) (services.Error, error) {
+	config := rt.config
+
+	rt.log.Info.Println("Watching network interfaces")
+	var err error
+	rt.watcher, err = watchInterface()
+	if err != nil {
+		return services.ErrorSetNetConfig, err
+	}
+
+	rt.log.Info.Println("Resolving DNS names")
+	uapiConf, err := config.ToUAPI()
+	if err != nil {
+		return services.ErrorDNSLookup, err
+	}
+
+	rt.log.Info.Println("Creating Wintun interface")
+	wintun, err := tun.CreateTUNWithRequestedGUID(config.Name, deterministicGUID(config), 0)
+	if err != nil {
+		return services.ErrorCreateWintun, err
+	}
+	rt.nativeTun = wintun.(*tun.NativeTun)
+	wintunVersion, err := rt.nativeTun.RunningVersion()
+	if err != nil {
+		rt.log.Warn.Printf("Unable to determine Wintun version: %v", err)
+	} else {
+		rt.log.Info.Printf("Using Wintun/%d.%d", (wintunVersion>>16)&0xffff, wintunVersion&0xffff)
+	}
+
+	err = runScriptCommand(config.Interface.PreUp, config.Name)
+	if err != nil {
+		return services.ErrorRunScript, err
+	}
+
+	if firewall {
+		err = enableFirewall(config, rt.nativeTun)
+		if err != nil {
+			return services.ErrorFirewall, err
+		}
+	}
+
+	rt.options, err = conf.LoadTunnelOptions(config.Name)
+	if err != nil {
+		rt.log.Warn.Printf("Unable to load tunnel options: %v", err)
+		rt.options = &conf.TunnelOptions{}
+	}
+
+	if beforeDevice != nil {
+		serviceError, err := beforeDevice()
+		if err != nil {
+			return serviceError, err
+		}
+	}
+
+	rt.log.Info.Println("Creating interface instance")
+	logger := &device.Logger{Debug: rt.log.Debug, Info: rt.log.Info, Error: rt.log.Error}
+	rt.dev = device.NewDevice(wintun, logger)
+
+	rt.log.Info.Println("Setting interface configuration")
+	rt.uapi, err = ipc.UAPIListen(config.Name)
+	if err != nil {
+		return services.ErrorUAPIListen, err
+	}
+	err = rt.dev.IpcSetOperation(bufio.NewReader(strings.NewReader(uapiConf)))
+	if err != nil {
+		return services.ErrorDeviceSetConfig, err
+	}
+
+	rt.log.Info.Println("Bringing peers up")
+	rt.dev.Up()
+
+	rt.watcher.Configure(rt.dev, config, rt.nativeTun, rt.afterConfigure)
+
+	rt.log.Info.Println("Listening for UAPI requests")
+	go func(uapi net.Listener, dev *device.Device) {
+		for {
+			conn, err := uapi.Accept()
+			if err != nil {
+				return
+			}
+			go dev.IpcHandle(conn)
+		}
+	}(rt.uapi, rt.dev)
+
+	err = runScriptCommand(config.Interface.PostUp, config.Name)
+	if err != nil {
+		return services.ErrorRunScript, err
+	}
+
+	rt.stopWatches = make(chan struct{})
+	go watchPeers(rt.dev, rt.stopWatches)
+	if rt.options.IdleTimeout > 0 {
+		rt.log.Info.Printf("Deactivating after %v without traffic", rt.options.IdleTimeout)
+		rt.idle = watchIdle(rt.nativeTun, rt.options.IdleTimeout, rt.stopWatches)
+	}
+	return services.ErrorSuccess, nil
+}
+
+// tearDown undoes what bringUp did, running the PreDown and PostDown scripts only if runScripts is true, which it is
+// not when the tunnel is going down because of an error, and PostDown only if PreDown succeeded.
+func (rt *runningTunnel) tearDown(runScripts bool) {
+	if rt.stopWatches != nil {
+		close(rt.stopWatches)
+		rt.stopWatches = nil
+	}
+	if runScripts && rt.dev != nil {
+		runScripts = runScriptCommand(rt.config.Interface.PreDown, rt.config.Name) == nil
+	}
+	if rt.watcher != nil {
+		rt.watcher.Destroy()
+	}
+	if rt.uapi != nil {
+		rt.uapi.Close()
+	}
+	if rt.dev != nil {
+		rt.dev.Close()
+	} else if rt.nativeTun != nil {
+		rt.nativeTun.Close()
+	}
+	if runScripts && rt.dev != nil {
+		runScriptCommand(rt.config.Interface.PostDown, rt.config.Name)
+	}
+}
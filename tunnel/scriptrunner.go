@@ -16,6 +16,7 @@ import (
 	"golang.org/x/sys/windows"
 
 	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/ringlogger"
 )
 
 func runScriptCommand(command, interfaceName string) error {
@@ -72,6 +73,6 @@ func runScriptCommand(command, interfaceName string) error {
 	if state.ExitCode() == 0 {
 		return nil
 	}
-	log.Printf("Command error exit status: %d", state.ExitCode())
+	ringlogger.Error.Printf("Command error exit status: %d", state.ExitCode())
 	return windows.ERROR_GENERIC_COMMAND_FAILED
 }
@@ -6,11 +6,9 @@
 package tunnel
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
 	"log"
-	"net"
 	"os"
 	"runtime"
 	"runtime/debug"
@@ -19,11 +17,9 @@ import (
 
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/mgr"
-	"golang.zx2c4.com/wireguard/device"
-	"golang.zx2c4.com/wireguard/ipc"
-	"golang.zx2c4.com/wireguard/tun"
 
 	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/crashdump"
 	"golang.zx2c4.com/wireguard/windows/elevate"
 	"golang.zx2c4.com/wireguard/windows/ringlogger"
 	"golang.zx2c4.com/wireguard/windows/services"
@@ -37,11 +33,7 @@ type tunnelService struct {
 func (service *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (svcSpecificEC bool, exitCode uint32) {
 	changes <- svc.Status{State: svc.StartPending}
 
-	var dev *device.Device
-	var uapi net.Listener
-	var watcher *interfaceWatcher
-	var nativeTun *tun.NativeTun
-	var config *conf.Config
+	var rt *runningTunnel
 	var err error
 	serviceError := services.ErrorSuccess
 
@@ -70,7 +62,7 @@ func (service *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest,
 						buf = make([]byte, 2*len(buf))
 					}
 					lines := bytes.Split(buf, []byte{'\n'})
-					log.Println("Failed to shutdown after 30 seconds. Probably dead locked. Printing stack and killing.")
+					ringlogger.Error.Println("Failed to shutdown after 30 seconds. Probably dead locked. Printing stack and killing.")
 					for _, line := range lines {
 						if len(bytes.TrimSpace(line)) > 0 {
 							log.Println(string(line))
@@ -85,20 +77,8 @@ func (service *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest,
 			}
 		}()
 
-		if logErr == nil && dev != nil && config != nil {
-			logErr = runScriptCommand(config.Interface.PreDown, config.Name)
-		}
-		if watcher != nil {
-			watcher.Destroy()
-		}
-		if uapi != nil {
-			uapi.Close()
-		}
-		if dev != nil {
-			dev.Close()
-		}
-		if logErr == nil && dev != nil && config != nil {
-			_ = runScriptCommand(config.Interface.PostDown, config.Name)
+		if rt != nil {
+			rt.tearDown(logErr == nil)
 		}
 		stopIt <- true
 		log.Println("Shutting down")
@@ -109,18 +89,9 @@ func (service *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest,
 		serviceError = services.ErrorRingloggerOpen
 		return
 	}
-	defer func() {
-		if x := recover(); x != nil {
-			for _, line := range append([]string{fmt.Sprint(x)}, strings.Split(string(debug.Stack()), "\n")...) {
-				if len(strings.TrimSpace(line)) > 0 {
-					log.Println(line)
-				}
-			}
-			panic(x)
-		}
-	}()
+	defer printPanic()
 
-	config, err = conf.LoadFromPath(service.Path)
+	config, err := conf.LoadFromPath(service.Path)
 	if err != nil {
 		serviceError = services.ErrorLoadConfiguration
 		return
@@ -133,7 +104,15 @@ func (service *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest,
 	}
 
 	logPrefix := fmt.Sprintf("[%s] ", config.Name)
-	log.SetPrefix(logPrefix)
+	ringlogger.SetPrefix(logPrefix)
+	err = ringlogger.InitTunnelLogger(config.Name)
+	if err != nil {
+		ringlogger.Warn.Printf("Unable to open the log of the tunnel, so only the shared log is kept: %v", err)
+		err = nil
+	}
+	if err := crashdump.Install("TUN-" + config.Name); err != nil {
+		ringlogger.Warn.Printf("Unable to install crash handler: %v", err)
+	}
 
 	log.Println("Starting", version.UserAgent())
 
@@ -150,90 +129,25 @@ func (service *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest,
 		m.Disconnect()
 	}
 
-	log.Println("Watching network interfaces")
-	watcher, err = watchInterface()
-	if err != nil {
-		serviceError = services.ErrorSetNetConfig
-		return
-	}
-
-	log.Println("Resolving DNS names")
-	uapiConf, err := config.ToUAPI()
-	if err != nil {
-		serviceError = services.ErrorDNSLookup
-		return
-	}
-
-	log.Println("Creating Wintun interface")
-	wintun, err := tun.CreateTUNWithRequestedGUID(config.Name, deterministicGUID(config), 0)
-	if err != nil {
-		serviceError = services.ErrorCreateWintun
-		return
-	}
-	nativeTun = wintun.(*tun.NativeTun)
-	wintunVersion, err := nativeTun.RunningVersion()
-	if err != nil {
-		log.Printf("Warning: unable to determine Wintun version: %v", err)
-	} else {
-		log.Printf("Using Wintun/%d.%d", (wintunVersion>>16)&0xffff, wintunVersion&0xffff)
-	}
-
-	err = runScriptCommand(config.Interface.PreUp, config.Name)
-	if err != nil {
-		serviceError = services.ErrorRunScript
-		return
-	}
-
-	err = enableFirewall(config, nativeTun)
-	if err != nil {
-		serviceError = services.ErrorFirewall
-		return
-	}
-
-	log.Println("Dropping privileges")
-	err = elevate.DropAllPrivileges(true)
-	if err != nil {
-		serviceError = services.ErrorDropPrivileges
-		return
-	}
-
-	log.Println("Creating interface instance")
-	logOutput := log.New(ringlogger.Global, logPrefix, 0)
-	logger := &device.Logger{logOutput, logOutput, logOutput}
-	dev = device.NewDevice(wintun, logger)
-
-	log.Println("Setting interface configuration")
-	uapi, err = ipc.UAPIListen(config.Name)
-	if err != nil {
-		serviceError = services.ErrorUAPIListen
-		return
-	}
-	ipcErr := dev.IpcSetOperation(bufio.NewReader(strings.NewReader(uapiConf)))
-	if ipcErr != nil {
-		err = ipcErr
-		serviceError = services.ErrorDeviceSetConfig
-		return
-	}
-
-	log.Println("Bringing peers up")
-	dev.Up()
-
-	watcher.Configure(dev, config, nativeTun)
-
-	log.Println("Listening for UAPI requests")
-	go func() {
-		for {
-			conn, err := uapi.Accept()
-			if err != nil {
-				continue
+	rt = &runningTunnel{config: config, log: ringlogger.Shared}
+	if !conf.AdminBool("DangerousScriptExecution") {
+		// DNS servers are set by running netsh, so child processes are forbidden only once that is done.
+		rt.afterConfigure = func() {
+			// Windows 7 does not nest jobs, so this fails should something else have put the service in one.
+			if err := forbidChildProcesses(); err != nil {
+				ringlogger.Warn.Printf("Unable to forbid child processes: %v", err)
 			}
-			go dev.IpcHandle(conn)
 		}
-	}()
-
-	err = runScriptCommand(config.Interface.PostUp, config.Name)
+	}
+	serviceError, err = rt.bringUp(true, func() (services.Error, error) {
+		log.Println("Dropping privileges")
+		err := elevate.DropAllPrivileges(true)
+		if err != nil {
+			return services.ErrorDropPrivileges, err
+		}
+		return services.ErrorSuccess, nil
+	})
 	if err != nil {
-		serviceError = services.ErrorRunScript
 		return
 	}
 
@@ -249,17 +163,35 @@ func (service *tunnelService) Execute(args []string, r <-chan svc.ChangeRequest,
 			case svc.Interrogate:
 				changes <- c.CurrentStatus
 			default:
-				log.Printf("Unexpected service control request #%d\n", c)
+				ringlogger.Error.Printf("Unexpected service control request #%d\n", c)
 			}
-		case <-dev.Wait():
+		case <-rt.dev.Wait():
 			return
-		case e := <-watcher.errors:
+		case <-rt.idle:
+			log.Printf("No traffic for %v, deactivating", rt.options.IdleTimeout)
+			return
+		case e := <-rt.watcher.errors:
 			serviceError, err = e.serviceError, e.err
 			return
 		}
 	}
 }
 
+// printPanic writes a minidump and logs the panic, before letting it crash the service.
+func printPanic() {
+	if x := recover(); x != nil {
+		if err := crashdump.WriteMinidump(); err != nil {
+			log.Printf("Unable to write minidump: %v", err)
+		}
+		for _, line := range append([]string{fmt.Sprint(x)}, strings.Split(string(debug.Stack()), "\n")...) {
+			if len(strings.TrimSpace(line)) > 0 {
+				log.Println(line)
+			}
+		}
+		panic(x)
+	}
+}
+
 func Run(confPath string) error {
 	name, err := conf.NameFromPath(confPath)
 	if err != nil {
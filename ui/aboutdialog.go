@@ -47,6 +47,7 @@ func runAboutDialog(owner walk.Form) error {
 		showingAboutDialog = nil
 	}()
 	disposables.Add(showingAboutDialog)
+	applyModernWindowStyle(showingAboutDialog.Handle(), false)
 	showingAboutDialog.SetTitle(l18n.Sprintf("About WireGuard"))
 	showingAboutDialog.SetLayout(vbl)
 	if icon, err := loadLogoIcon(32); err == nil {
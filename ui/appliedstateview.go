@@ -0,0 +1,193 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/lxn/walk"
+	"github.com/lxn/win"
+
+	"golang.zx2c4.com/wireguard/windows/l18n"
+	"golang.zx2c4.com/wireguard/windows/manager"
+)
+
+type AppliedStateView struct {
+	*walk.ScrollView
+	group        *walk.GroupBox
+	status       *labelTextLine
+	addresses    *labelTextLine
+	routes       *labelTextLine
+	dns          *labelTextLine
+	dnsSuffix    *labelTextLine
+	nrpt         *labelTextLine
+	firewall     *labelTextLine
+	lines        []widgetsLine
+	tunnel       *manager.Tunnel
+	updateTicker *time.Ticker
+}
+
+func NewAppliedStateView(parent walk.Container) (*AppliedStateView, error) {
+	var err error
+	var disposables walk.Disposables
+	defer disposables.Treat()
+
+	asv := new(AppliedStateView)
+	if asv.ScrollView, err = walk.NewScrollView(parent); err != nil {
+		return nil, err
+	}
+	disposables.Add(asv)
+	vlayout := walk.NewVBoxLayout()
+	vlayout.SetMargins(walk.Margins{5, 0, 5, 0})
+	asv.SetLayout(vlayout)
+	if asv.group, err = newPaddedGroupGrid(asv); err != nil {
+		return nil, err
+	}
+
+	items := []labelTextLineItem{
+		{l18n.Sprintf("Status:"), &asv.status},
+		{l18n.Sprintf("Addresses:"), &asv.addresses},
+		{l18n.Sprintf("Routes:"), &asv.routes},
+		{l18n.Sprintf("DNS servers:"), &asv.dns},
+		{l18n.Sprintf("DNS suffix:"), &asv.dnsSuffix},
+		{l18n.Sprintf("NRPT rules:"), &asv.nrpt},
+		{l18n.Sprintf("Firewall rules:"), &asv.firewall},
+	}
+	if asv.lines, err = createLabelTextLines(items, asv.group, &disposables); err != nil {
+		return nil, err
+	}
+	setAutomationID(asv.status.text, automationAppliedStatus)
+	layoutInGrid(asv, asv.group.Layout().(*walk.GridLayout))
+	walk.NewVSpacer(asv)
+
+	asv.SetTunnel(nil)
+
+	if err := walk.InitWrapperWindow(asv); err != nil {
+		return nil, err
+	}
+	asv.SetDoubleBuffering(true)
+	asv.updateTicker = time.NewTicker(time.Second * 2)
+	go func() {
+		for range asv.updateTicker.C {
+			if !asv.Visible() || !asv.Form().Visible() || win.IsIconic(asv.Form().Handle()) {
+				continue
+			}
+			if asv.tunnel != nil {
+				asv.refresh(asv.tunnel)
+			}
+		}
+	}()
+
+	disposables.Spare()
+
+	return asv, nil
+}
+
+func (asv *AppliedStateView) widgetsLines() []widgetsLine {
+	return asv.lines
+}
+
+func (asv *AppliedStateView) Dispose() {
+	if asv.updateTicker != nil {
+		asv.updateTicker.Stop()
+		asv.updateTicker = nil
+	}
+	asv.ScrollView.Dispose()
+}
+
+func (asv *AppliedStateView) SetTunnel(tunnel *manager.Tunnel) {
+	asv.tunnel = tunnel //XXX: This races with the read in the updateTicker, but it's pointer-sized!
+
+	if tunnel == nil {
+		asv.group.SetVisible(false)
+		return
+	}
+	asv.group.SetTitle(l18n.Sprintf("Applied state: %s", tunnel.Name))
+	asv.group.SetVisible(true)
+	go asv.refresh(tunnel)
+}
+
+func (asv *AppliedStateView) refresh(tunnel *manager.Tunnel) {
+	var appliedState manager.AppliedState
+	state, err := tunnel.State()
+	if err == nil && state == manager.TunnelStarted {
+		appliedState, err = tunnel.AppliedState()
+	}
+	asv.Synchronize(func() {
+		if asv.tunnel == nil || asv.tunnel.Name != tunnel.Name {
+			return
+		}
+		asv.apply(&appliedState, state, err)
+	})
+}
+
+func (asv *AppliedStateView) apply(appliedState *manager.AppliedState, state manager.TunnelState, err error) {
+	asv.SetSuspended(true)
+	defer asv.SetSuspended(false)
+
+	for _, line := range asv.lines {
+		line.(*labelTextLine).hide()
+	}
+	if err != nil {
+		asv.status.show(l18n.Sprintf("unable to query: %v", err))
+		return
+	}
+	if state != manager.TunnelStarted {
+		asv.status.show(textForState(state, false))
+		return
+	}
+
+	if len(appliedState.Addresses) > 0 {
+		addrStrings := make([]string, len(appliedState.Addresses))
+		for i, address := range appliedState.Addresses {
+			addrStrings[i] = address.String()
+		}
+		asv.addresses.show(strings.Join(addrStrings, l18n.EnumerationSeparator()))
+	}
+
+	if len(appliedState.Routes) > 0 {
+		routeStrings := make([]string, len(appliedState.Routes))
+		for i, route := range appliedState.Routes {
+			if route.NextHop == nil || route.NextHop.IsUnspecified() {
+				routeStrings[i] = l18n.Sprintf("%s, metric %d", route.Destination.String(), route.Metric)
+			} else {
+				routeStrings[i] = l18n.Sprintf("%s via %s, metric %d", route.Destination.String(), route.NextHop.String(), route.Metric)
+			}
+		}
+		asv.routes.show(strings.Join(routeStrings, "\r\n"))
+	}
+
+	if len(appliedState.DNS) > 0 {
+		addrStrings := make([]string, len(appliedState.DNS))
+		for i, address := range appliedState.DNS {
+			addrStrings[i] = address.String()
+		}
+		asv.dns.show(strings.Join(addrStrings, l18n.EnumerationSeparator()))
+	}
+
+	if len(appliedState.DNSSuffix) > 0 {
+		asv.dnsSuffix.show(appliedState.DNSSuffix)
+	}
+
+	if len(appliedState.NRPTRules) > 0 {
+		ruleStrings := make([]string, len(appliedState.NRPTRules))
+		for i, rule := range appliedState.NRPTRules {
+			addrStrings := make([]string, len(rule.DNS))
+			for j, address := range rule.DNS {
+				addrStrings[j] = address.String()
+			}
+			ruleStrings[i] = l18n.Sprintf("%s via %s", strings.Join(rule.Namespaces, l18n.EnumerationSeparator()), strings.Join(addrStrings, l18n.EnumerationSeparator()))
+		}
+		asv.nrpt.show(strings.Join(ruleStrings, "\r\n"))
+	}
+
+	if len(appliedState.FirewallFilters) > 0 {
+		asv.firewall.show(strings.Join(appliedState.FirewallFilters, "\r\n"))
+	} else {
+		asv.firewall.show(l18n.Sprintf("none"))
+	}
+}
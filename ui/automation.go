@@ -0,0 +1,67 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ui
+
+import (
+	"github.com/lxn/walk"
+	"github.com/lxn/win"
+)
+
+// automationID is the control identifier of an interactive control, which UI Automation reports as its AutomationId.
+// Walk numbers controls in the order they are created, so without these the identifiers shift whenever a control is
+// added, and acceptance tests written against one release break on the next. The values are part of the interface
+// documented in docs/automation.md, so never renumber or reuse them; only append.
+type automationID int32
+
+// These start well above IDOK, IDCANCEL and the other dialog command identifiers, as well as above anything walk
+// would hand out sequentially.
+const (
+	automationTabs automationID = 0x5700 + iota
+	automationTunnelList
+	automationTunnelToolbar
+	automationFillerButton
+	automationTunnelStatus
+	automationToggleActive
+	automationEditTunnel
+	automationDetailTabs
+	automationLogView
+	automationLogSave
+	automationEditName
+	automationEditPublicKey
+	automationEditConfig
+	automationEditIdleTimeout
+	automationEditFormMode
+	automationEditBlockUntunneled
+	automationEditSave
+	automationEditCancel
+	automationPreferencesSave
+	automationPreferencesCancel
+	automationImportWizardImport
+	automationImportWizardClose
+	automationOnboardingImport
+	automationOnboardingCreate
+	automationOnboardingClose
+	automationAppliedStatus
+	automationEditIdleTimeoutEnabled
+	automationImportWizardSequence
+	automationPreferencesTunnelNotifications
+	automationPreferencesErrorNotifications
+	automationPreferencesUpdateNotifications
+	automationPreferencesConfirmDelete
+	automationPreferencesConfirmDeactivate
+	automationPreferencesConfirmImportOverwrite
+	automationPreferencesCheckForUpdates
+	automationPreferencesMaintenanceWindow
+	automationPreferencesMaintenanceWindowInactive
+	automationPreferencesExitStopsTunnels
+	automationPreferencesRequireReauthentication
+	automationPreferencesLogTimesUTC
+	automationPreferencesLogTimesISO8601
+)
+
+func setAutomationID(w walk.Window, id automationID) {
+	win.SetWindowLong(w.Handle(), win.GWL_ID, int32(id))
+}
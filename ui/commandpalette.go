@@ -0,0 +1,249 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/lxn/walk"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/l18n"
+	"golang.zx2c4.com/wireguard/windows/manager"
+)
+
+type paletteCommand struct {
+	title string
+	run   func()
+}
+
+var showingCommandPalette *walk.Dialog
+
+// fuzzyScore reports whether every rune of pattern appears in text in order, ignoring case, and if so how well it
+// matches. Runs of consecutive runes and matches at the start of words score higher.
+func fuzzyScore(pattern, text string) (int, bool) {
+	patternRunes := []rune(strings.ToLower(pattern))
+	if len(patternRunes) == 0 {
+		return 0, true
+	}
+	score, p := 0, 0
+	consecutive := false
+	previous := ' '
+	for _, r := range strings.ToLower(text) {
+		if p < len(patternRunes) && r == patternRunes[p] {
+			score++
+			if consecutive {
+				score += 2
+			}
+			if !unicode.IsLetter(previous) && !unicode.IsDigit(previous) {
+				score += 3
+			}
+			consecutive = true
+			p++
+		} else {
+			consecutive = false
+		}
+		previous = r
+	}
+	return score, p == len(patternRunes)
+}
+
+func (mtw *ManageTunnelsWindow) paletteCommands() []paletteCommand {
+	tp := mtw.tunnelsPage
+	model := tp.listView.model
+	tunnels := make([]manager.Tunnel, len(model.tunnels))
+	copy(tunnels, model.tunnels)
+	sort.Slice(tunnels, func(i, j int) bool {
+		return conf.TunnelNameIsLess(tunnels[i].Name, tunnels[j].Name)
+	})
+
+	var commands []paletteCommand
+	for i := range tunnels {
+		tunnel := tunnels[i]
+		state := model.lastObservedState[tunnel]
+		if state == manager.TunnelStarted || state == manager.TunnelStarting {
+			commands = append(commands, paletteCommand{l18n.Sprintf("Deactivate ‘%s’", tunnel.Name), func() {
+				go func() {
+					if !confirmDeactivation(mtw, &tunnel) {
+						return
+					}
+					if err := tunnel.Stop(); err != nil {
+						mtw.Synchronize(func() {
+							showErrorCustom(mtw, l18n.Sprintf("Failed to deactivate tunnel"), err.Error())
+						})
+					}
+				}()
+			}})
+		} else {
+			commands = append(commands, paletteCommand{l18n.Sprintf("Activate ‘%s’", tunnel.Name), func() {
+				go func() {
+					if !confirmActivation(mtw, &tunnel) {
+						return
+					}
+					if err := tunnel.Start(); err != nil {
+						mtw.Synchronize(func() {
+							showErrorCustom(mtw, l18n.Sprintf("Failed to activate tunnel"), err.Error())
+						})
+					}
+				}()
+			}})
+		}
+		if IsAdmin {
+			commands = append(commands, paletteCommand{l18n.Sprintf("Edit ‘%s’…", tunnel.Name), func() {
+				mtw.tabs.SetCurrentIndex(0)
+				tp.listView.selectTunnel(tunnel.Name)
+				tp.onEditTunnel()
+			}})
+		}
+	}
+
+	commands = append(commands, paletteCommand{l18n.Sprintf("View log"), func() {
+		mtw.tabs.SetCurrentIndex(1)
+		mtw.logPage.scrollToBottom()
+	}})
+	if IsAdmin {
+		commands = append(commands,
+			paletteCommand{l18n.Sprintf("Import tunnel(s) from file…"), tp.onImport},
+			paletteCommand{l18n.Sprintf("Add empty tunnel…"), tp.onAddTunnel},
+			paletteCommand{l18n.Sprintf("Preferences…"), func() {
+				onPreferences(mtw)
+			}},
+		)
+		if !conf.UpdatesDisabled() {
+			commands = append(commands,
+				paletteCommand{l18n.Sprintf("Check for updates"), func() {
+					go manager.IPCClientCheckForUpdate()
+				}},
+				paletteCommand{l18n.Sprintf("Install update from file…"), mtw.onUpdateFromFile},
+			)
+		}
+	}
+	commands = append(commands, paletteCommand{l18n.Sprintf("About WireGuard…"), func() {
+		onAbout(mtw)
+	}})
+	return commands
+}
+
+func (mtw *ManageTunnelsWindow) onCommandPalette() {
+	if showingCommandPalette != nil {
+		showingCommandPalette.Show()
+		raise(showingCommandPalette.Handle())
+		return
+	}
+	command, err := runCommandPalette(mtw, mtw.paletteCommands())
+	if err != nil {
+		showError(err, mtw)
+		return
+	}
+	if command != nil {
+		command.run()
+	}
+}
+
+func runCommandPalette(owner walk.Form, commands []paletteCommand) (*paletteCommand, error) {
+	var err error
+	var disposables walk.Disposables
+	defer disposables.Treat()
+
+	if showingCommandPalette, err = walk.NewDialog(owner); err != nil {
+		return nil, err
+	}
+	defer func() {
+		showingCommandPalette = nil
+	}()
+	dlg := showingCommandPalette
+	disposables.Add(dlg)
+	applyModernWindowStyle(dlg.Handle(), true)
+	dlg.SetTitle(l18n.Sprintf("Command Palette"))
+	dlg.SetMinMaxSize(walk.Size{400, 300}, walk.Size{0, 0})
+	vlayout := walk.NewVBoxLayout()
+	vlayout.SetMargins(walk.Margins{10, 10, 10, 10})
+	vlayout.SetSpacing(6)
+	dlg.SetLayout(vlayout)
+	if font, err := scaledFont(9); err == nil {
+		dlg.SetFont(font)
+	}
+
+	filterEdit, err := walk.NewLineEdit(dlg)
+	if err != nil {
+		return nil, err
+	}
+	filterEdit.SetCueBanner(l18n.Sprintf("Type to search tunnels and actions"))
+
+	listBox, err := walk.NewListBox(dlg)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*paletteCommand
+	updateMatches := func() {
+		type scoredCommand struct {
+			command *paletteCommand
+			score   int
+		}
+		pattern := strings.TrimSpace(filterEdit.Text())
+		var scored []scoredCommand
+		for i := range commands {
+			if score, ok := fuzzyScore(pattern, commands[i].title); ok {
+				scored = append(scored, scoredCommand{&commands[i], score})
+			}
+		}
+		sort.SliceStable(scored, func(i, j int) bool {
+			return scored[i].score > scored[j].score
+		})
+		matches = make([]*paletteCommand, len(scored))
+		titles := make([]string, len(scored))
+		for i := range scored {
+			matches[i] = scored[i].command
+			titles[i] = scored[i].command.title
+		}
+		listBox.SetModel(titles)
+		if len(titles) > 0 {
+			listBox.SetCurrentIndex(0)
+		}
+	}
+	updateMatches()
+
+	var chosen *paletteCommand
+	choose := func() {
+		i := listBox.CurrentIndex()
+		if i < 0 || i >= len(matches) {
+			return
+		}
+		chosen = matches[i]
+		dlg.Accept()
+	}
+	filterEdit.TextChanged().Attach(updateMatches)
+	filterEdit.KeyDown().Attach(func(key walk.Key) {
+		i := listBox.CurrentIndex()
+		switch key {
+		case walk.KeyReturn:
+			choose()
+		case walk.KeyEscape:
+			dlg.Cancel()
+		case walk.KeyDown:
+			if i+1 < len(matches) {
+				listBox.SetCurrentIndex(i + 1)
+			}
+		case walk.KeyUp:
+			if i > 0 {
+				listBox.SetCurrentIndex(i - 1)
+			}
+		}
+	})
+	listBox.ItemActivated().Attach(choose)
+
+	disposables.Spare()
+
+	dlg.Starting().Attach(func() {
+		filterEdit.SetFocus()
+	})
+	dlg.Run()
+
+	return chosen, nil
+}
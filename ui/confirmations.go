@@ -0,0 +1,71 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ui
+
+import (
+	"time"
+
+	"github.com/lxn/walk"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/l18n"
+	"golang.zx2c4.com/wireguard/windows/manager"
+)
+
+// Peers re-handshake every two minutes while traffic flows, so a handshake newer than this means the tunnel is in use.
+const recentHandshakeAge = time.Minute * 3
+
+func confirmationsForced() bool {
+	return conf.AdminBool("ForceConfirmations")
+}
+
+func confirmDeleteRequired() bool {
+	return currentSettings.ConfirmDelete || confirmationsForced()
+}
+
+func confirmDeactivateRequired() bool {
+	return currentSettings.ConfirmDeactivate || confirmationsForced()
+}
+
+func confirmImportOverwriteRequired() bool {
+	return currentSettings.ConfirmImportOverwrite || confirmationsForced()
+}
+
+// confirmDeactivation asks the user whether to proceed if deactivating the tunnel would interrupt traffic that is
+// flowing through it, when so configured. Like confirmActivation, it must not be called from the UI thread.
+func confirmDeactivation(form walk.Form, tunnel *manager.Tunnel) bool {
+	if !confirmDeactivateRequired() {
+		return true
+	}
+	state, err := tunnel.State()
+	if err != nil || state != manager.TunnelStarted {
+		return true
+	}
+	config, err := tunnel.RuntimeConfig()
+	if err != nil {
+		return true
+	}
+	inUse := false
+	for i := range config.Peers {
+		if handshake := config.Peers[i].LastHandshakeTime; !handshake.IsEmpty() && time.Since(time.Unix(0, int64(handshake))) < recentHandshakeAge {
+			inUse = true
+			break
+		}
+	}
+	if !inUse {
+		return true
+	}
+
+	proceed := make(chan bool)
+	form.Synchronize(func() {
+		var owner walk.Form
+		if form.Visible() {
+			owner = form
+		}
+		proceed <- walk.MsgBox(owner, l18n.Sprintf("Deactivate tunnel ‘%s’", tunnel.Name), l18n.Sprintf("Tunnel ‘%s’ is carrying traffic. Are you sure you would like to deactivate it?", tunnel.Name), walk.MsgBoxYesNo|walk.MsgBoxIconWarning) == walk.DlgCmdYes
+	})
+	return <-proceed
+}
@@ -38,6 +38,14 @@ type labelTextLine struct {
 	text  *walk.TextEdit
 }
 
+type lastErrorLine struct {
+	label     *walk.TextLabel
+	composite *walk.Composite
+	text      *walk.TextEdit
+	logLink   *walk.LinkLabel
+	time      time.Time
+}
+
 type toggleActiveLine struct {
 	composite *walk.Composite
 	button    *walk.PushButton
@@ -45,6 +53,7 @@ type toggleActiveLine struct {
 
 type interfaceView struct {
 	status       *labelStatusLine
+	lastError    *lastErrorLine
 	publicKey    *labelTextLine
 	listenPort   *labelTextLine
 	mtu          *labelTextLine
@@ -133,6 +142,7 @@ func newLabelStatusLine(parent walk.Container) (*labelStatusLine, error) {
 		return nil, err
 	}
 	disposables.Add(lsl.statusLabel)
+	setAutomationID(lsl.statusLabel, automationTunnelStatus)
 	win.SetWindowLong(lsl.statusLabel.Handle(), win.GWL_EXSTYLE, win.GetWindowLong(lsl.statusLabel.Handle(), win.GWL_EXSTYLE)&^win.WS_EX_CLIENTEDGE)
 	lsl.statusLabel.SetReadOnly(true)
 	lsl.statusLabel.SetBackground(walk.NullBrush())
@@ -204,6 +214,79 @@ func newLabelTextLine(fieldName string, parent walk.Container) (*labelTextLine,
 	return lt, nil
 }
 
+func (lel *lastErrorLine) widgets() (walk.Widget, walk.Widget) {
+	return lel.label, lel.composite
+}
+
+func (lel *lastErrorLine) show(tunnelError *manager.TunnelError) {
+	if tunnelError == nil {
+		lel.text.SetText("")
+		lel.label.SetVisible(false)
+		lel.composite.SetVisible(false)
+		return
+	}
+	lel.time = tunnelError.Time
+	s, e := lel.text.TextSelection()
+	lel.text.SetText(l18n.Sprintf("%s (%s)", tunnelError.Message, tunnelError.Time.Format("2006-01-02 15:04:05")))
+	lel.text.SetTextSelection(s, e)
+	lel.label.SetVisible(true)
+	lel.composite.SetVisible(true)
+}
+
+func (lel *lastErrorLine) Dispose() {
+	lel.label.Dispose()
+	lel.composite.Dispose()
+}
+
+func newLastErrorLine(parent walk.Container) (*lastErrorLine, error) {
+	var err error
+	var disposables walk.Disposables
+	defer disposables.Treat()
+
+	lel := new(lastErrorLine)
+
+	if lel.label, err = walk.NewTextLabel(parent); err != nil {
+		return nil, err
+	}
+	disposables.Add(lel.label)
+	lel.label.SetText(l18n.Sprintf("Last error:"))
+	lel.label.SetTextAlignment(walk.AlignHFarVNear)
+	lel.label.SetVisible(false)
+
+	if lel.composite, err = walk.NewComposite(parent); err != nil {
+		return nil, err
+	}
+	disposables.Add(lel.composite)
+	layout := walk.NewVBoxLayout()
+	layout.SetMargins(walk.Margins{})
+	layout.SetAlignment(walk.AlignHNearVNear)
+	layout.SetSpacing(0)
+	lel.composite.SetLayout(layout)
+	lel.composite.SetVisible(false)
+
+	if lel.text, err = walk.NewTextEdit(lel.composite); err != nil {
+		return nil, err
+	}
+	win.SetWindowLong(lel.text.Handle(), win.GWL_EXSTYLE, win.GetWindowLong(lel.text.Handle(), win.GWL_EXSTYLE)&^win.WS_EX_CLIENTEDGE)
+	lel.text.SetCompactHeight(true)
+	lel.text.SetReadOnly(true)
+	lel.text.SetBackground(walk.NullBrush())
+	lel.text.SetTextColor(walk.RGB(0xc4, 0x2b, 0x1c))
+	lel.text.FocusedChanged().Attach(func() {
+		lel.text.SetTextSelection(0, 0)
+	})
+	lel.text.Accessibility().SetRole(walk.AccRoleStatictext)
+
+	if lel.logLink, err = walk.NewLinkLabel(lel.composite); err != nil {
+		return nil, err
+	}
+	lel.logLink.SetText(l18n.Sprintf("<a>View related log lines</a>"))
+
+	disposables.Spare()
+
+	return lel, nil
+}
+
 func (tal *toggleActiveLine) widgets() (walk.Widget, walk.Widget) {
 	return nil, tal.composite
 }
@@ -253,6 +336,7 @@ func newToggleActiveLine(parent walk.Container) (*toggleActiveLine, error) {
 		return nil, err
 	}
 	disposables.Add(tal.button)
+	setAutomationID(tal.button, automationToggleActive)
 	walk.NewHSpacer(tal.composite)
 	tal.update(manager.TunnelStopped)
 
@@ -300,6 +384,11 @@ func newInterfaceView(parent walk.Container) (*interfaceView, error) {
 	}
 	disposables.Add(iv.status)
 
+	if iv.lastError, err = newLastErrorLine(parent); err != nil {
+		return nil, err
+	}
+	disposables.Add(iv.lastError)
+
 	items := []labelTextLineItem{
 		{l18n.Sprintf("Public key:"), &iv.publicKey},
 		{l18n.Sprintf("Listen port:"), &iv.listenPort},
@@ -317,7 +406,7 @@ func newInterfaceView(parent walk.Container) (*interfaceView, error) {
 	}
 	disposables.Add(iv.toggleActive)
 
-	iv.lines = append([]widgetsLine{iv.status}, append(iv.lines, iv.toggleActive)...)
+	iv.lines = append([]widgetsLine{iv.status, iv.lastError}, append(iv.lines, iv.toggleActive)...)
 
 	layoutInGrid(iv, parent.Layout().(*walk.GridLayout))
 
@@ -526,6 +615,9 @@ func NewConfView(parent walk.Container) (*ConfView, error) {
 		return nil, err
 	}
 	cv.interfaze.toggleActive.button.Clicked().Attach(cv.onToggleActiveClicked)
+	cv.interfaze.lastError.logLink.LinkActivated().Attach(func(*walk.LinkLabelLink) {
+		cv.onViewLastErrorLog()
+	})
 	cv.peers = make(map[conf.Key]*peerView)
 	cv.tunnelChangedCB = manager.IPCClientRegisterTunnelChange(cv.onTunnelChanged)
 	cv.SetTunnel(nil)
@@ -582,6 +674,12 @@ func (cv *ConfView) Dispose() {
 func (cv *ConfView) onToggleActiveClicked() {
 	cv.interfaze.toggleActive.button.SetEnabled(false)
 	go func() {
+		if !confirmActivation(cv.Form(), cv.tunnel) || !confirmDeactivation(cv.Form(), cv.tunnel) {
+			cv.Synchronize(func() {
+				cv.interfaze.toggleActive.button.SetEnabled(true)
+			})
+			return
+		}
 		oldState, err := cv.tunnel.Toggle()
 		if err != nil {
 			cv.Synchronize(func() {
@@ -597,6 +695,28 @@ func (cv *ConfView) onToggleActiveClicked() {
 	}()
 }
 
+func (cv *ConfView) onViewLastErrorLog() {
+	mtw, ok := cv.Form().(*ManageTunnelsWindow)
+	if !ok || cv.tunnel == nil {
+		return
+	}
+	mtw.tabs.SetCurrentIndex(1)
+	mtw.logPage.selectTunnelLines(cv.tunnel.Name, cv.interfaze.lastError.time)
+}
+
+// updateLastError fetches the tunnel's last failure from the manager, so it must not be called from the UI thread.
+func (cv *ConfView) updateLastError(tunnel *manager.Tunnel) {
+	tunnelError, err := tunnel.LastError()
+	if err != nil {
+		return
+	}
+	cv.Synchronize(func() {
+		if cv.tunnel != nil && cv.tunnel.Name == tunnel.Name {
+			cv.interfaze.lastError.show(tunnelError)
+		}
+	})
+}
+
 func (cv *ConfView) onTunnelChanged(tunnel *manager.Tunnel, state manager.TunnelState, globalState manager.TunnelState, err error) {
 	cv.Synchronize(func() {
 		cv.interfaze.toggleActive.updateGlobal(globalState)
@@ -616,6 +736,7 @@ func (cv *ConfView) onTunnelChanged(tunnel *manager.Tunnel, state manager.Tunnel
 		cv.Synchronize(func() {
 			cv.setTunnel(tunnel, &config, state)
 		})
+		cv.updateLastError(tunnel)
 	}
 }
 
@@ -635,9 +756,11 @@ func (cv *ConfView) SetTunnel(tunnel *manager.Tunnel) {
 			cv.Synchronize(func() {
 				cv.setTunnel(tunnel, &config, state)
 			})
+			cv.updateLastError(tunnel)
 		}()
 	} else {
 		cv.setTunnel(tunnel, &config, state)
+		cv.interfaze.lastError.show(nil)
 	}
 }
 
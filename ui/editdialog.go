@@ -7,6 +7,7 @@ package ui
 
 import (
 	"strings"
+	"time"
 
 	"github.com/lxn/walk"
 	"github.com/lxn/win"
@@ -23,27 +24,50 @@ type EditDialog struct {
 	nameEdit                        *walk.LineEdit
 	pubkeyEdit                      *walk.LineEdit
 	syntaxEdit                      *syntax.SyntaxEdit
+	peerForm                        *PeerForm
+	formModeButton                  *walk.PushButton
 	blockUntunneledTrafficCB        *walk.CheckBox
+	idleTimeoutCB                   *walk.CheckBox
+	idleTimeoutEdit                 *walk.NumberEdit
 	saveButton                      *walk.PushButton
 	config                          conf.Config
+	options                         conf.TunnelOptions
 	lastPrivateKey                  string
 	blockUntunneledTraficCheckGuard bool
 }
 
-func runEditDialog(owner walk.Form, tunnel *manager.Tunnel) *conf.Config {
-	dlg, err := newEditDialog(owner, tunnel)
+func runEditDialog(owner walk.Form, tunnel *manager.Tunnel) (*conf.Config, *conf.TunnelOptions) {
+	return runEditDialogWithTemplate(owner, tunnel, nil)
+}
+
+// runNewTunnelDialog creates a tunnel that talks to the same peers as template, but with keys and an address of its own.
+func runNewTunnelDialog(owner walk.Form, template *conf.Config) (*conf.Config, *conf.TunnelOptions) {
+	return runEditDialogWithTemplate(owner, nil, template)
+}
+
+func runEditDialogWithTemplate(owner walk.Form, tunnel *manager.Tunnel, template *conf.Config) (*conf.Config, *conf.TunnelOptions) {
+	dlg, err := newEditDialog(owner, tunnel, template)
 	if showError(err, owner) {
-		return nil
+		return nil, nil
 	}
 
 	if dlg.Run() == walk.DlgCmdOK {
-		return &dlg.config
+		return &dlg.config, &dlg.options
 	}
 
-	return nil
+	return nil, nil
+}
+
+// suggestedAddresses returns the next free address of the configured pool, if any.
+func suggestedAddresses() []conf.IPCidr {
+	addresses, err := manager.IPCClientSuggestedAddresses()
+	if err != nil {
+		return nil
+	}
+	return addresses
 }
 
-func newEditDialog(owner walk.Form, tunnel *manager.Tunnel) (*EditDialog, error) {
+func newEditDialog(owner walk.Form, tunnel *manager.Tunnel, template *conf.Config) (*EditDialog, error) {
 	var err error
 	var disposables walk.Disposables
 	defer disposables.Treat()
@@ -61,8 +85,26 @@ func newEditDialog(owner walk.Form, tunnel *manager.Tunnel) (*EditDialog, error)
 		// Creating a new tunnel, create a new private key and use the default template
 		pk, _ := conf.NewPrivateKey()
 		dlg.config = conf.Config{Interface: conf.Interface{PrivateKey: *pk}}
+		if template != nil {
+			dlg.config.Interface.MTU = template.Interface.MTU
+			dlg.config.Interface.DNS = template.Interface.DNS
+			dlg.config.Interface.DNSSearch = template.Interface.DNSSearch
+			dlg.config.Peers = make([]conf.Peer, len(template.Peers))
+			for i := range template.Peers {
+				dlg.config.Peers[i] = conf.Peer{
+					PublicKey:           template.Peers[i].PublicKey,
+					AllowedIPs:          template.Peers[i].AllowedIPs,
+					Endpoint:            template.Peers[i].Endpoint,
+					PersistentKeepalive: template.Peers[i].PersistentKeepalive,
+				}
+			}
+		}
+		dlg.config.Interface.Addresses = suggestedAddresses()
 	} else {
 		dlg.config, _ = tunnel.StoredConfig()
+		if options, err := tunnel.Options(); err == nil {
+			dlg.options = *options
+		}
 	}
 
 	layout := walk.NewGridLayout()
@@ -74,10 +116,14 @@ func newEditDialog(owner walk.Form, tunnel *manager.Tunnel) (*EditDialog, error)
 		return nil, err
 	}
 	disposables.Add(dlg)
+	applyModernWindowStyle(dlg.Handle(), false)
 	dlg.SetIcon(owner.Icon())
 	dlg.SetTitle(title)
 	dlg.SetLayout(layout)
 	dlg.SetMinMaxSize(walk.Size{500, 400}, walk.Size{0, 0})
+	if font, err := scaledFont(9); err == nil {
+		dlg.SetFont(font)
+	}
 	if icon, err := loadSystemIcon("imageres", -114, 32); err == nil {
 		dlg.SetIcon(icon)
 	}
@@ -95,6 +141,7 @@ func newEditDialog(owner walk.Form, tunnel *manager.Tunnel) (*EditDialog, error)
 	}
 	layout.SetRange(dlg.nameEdit, walk.Rectangle{1, 0, 1, 1})
 	dlg.nameEdit.SetText(dlg.config.Name)
+	setAutomationID(dlg.nameEdit, automationEditName)
 
 	pubkeyLabel, err := walk.NewTextLabel(dlg)
 	if err != nil {
@@ -104,24 +151,106 @@ func newEditDialog(owner walk.Form, tunnel *manager.Tunnel) (*EditDialog, error)
 	pubkeyLabel.SetTextAlignment(walk.AlignHFarVCenter)
 	pubkeyLabel.SetText(l18n.Sprintf("&Public key:"))
 
-	if dlg.pubkeyEdit, err = walk.NewLineEdit(dlg); err != nil {
+	pubkeyContainer, err := walk.NewComposite(dlg)
+	if err != nil {
+		return nil, err
+	}
+	layout.SetRange(pubkeyContainer, walk.Rectangle{1, 1, 1, 1})
+	pubkeyContainer.SetLayout(walk.NewHBoxLayout())
+	pubkeyContainer.Layout().SetMargins(walk.Margins{})
+
+	if dlg.pubkeyEdit, err = walk.NewLineEdit(pubkeyContainer); err != nil {
 		return nil, err
 	}
-	layout.SetRange(dlg.pubkeyEdit, walk.Rectangle{1, 1, 1, 1})
 	dlg.pubkeyEdit.SetReadOnly(true)
 	dlg.pubkeyEdit.SetText(l18n.Sprintf("(unknown)"))
 	dlg.pubkeyEdit.Accessibility().SetRole(walk.AccRoleStatictext)
+	setAutomationID(dlg.pubkeyEdit, automationEditPublicKey)
+
+	copyPubkeyButton, err := walk.NewPushButton(pubkeyContainer)
+	if err != nil {
+		return nil, err
+	}
+	copyPubkeyButton.SetText(l18n.Sprintf("&Copy"))
+	copyPubkeyButton.SetToolTipText(l18n.Sprintf("Copy public key to clipboard"))
+	copyPubkeyButton.Clicked().Attach(dlg.onCopyPublicKeyClicked)
+
+	generateKeypairButton, err := walk.NewPushButton(pubkeyContainer)
+	if err != nil {
+		return nil, err
+	}
+	generateKeypairButton.SetText(l18n.Sprintf("New &keypair"))
+	generateKeypairButton.SetToolTipText(l18n.Sprintf("Generate a new private key, replacing the existing one"))
+	generateKeypairButton.Clicked().Attach(dlg.onGenerateKeypair)
+
+	generatePresharedKeyButton, err := walk.NewPushButton(pubkeyContainer)
+	if err != nil {
+		return nil, err
+	}
+	generatePresharedKeyButton.SetText(l18n.Sprintf("Preshared ke&ys"))
+	generatePresharedKeyButton.SetToolTipText(l18n.Sprintf("Generate a preshared key for each peer that does not already have one"))
+	generatePresharedKeyButton.Clicked().Attach(dlg.onGeneratePresharedKey)
 
 	if dlg.syntaxEdit, err = syntax.NewSyntaxEdit(dlg); err != nil {
 		return nil, err
 	}
 	layout.SetRange(dlg.syntaxEdit, walk.Rectangle{0, 2, 2, 1})
+	dlg.syntaxEdit.SetTextScale(currentSettings.TextScale)
+	setAutomationID(dlg.syntaxEdit, automationEditConfig)
+
+	if dlg.peerForm, err = NewPeerForm(dlg); err != nil {
+		return nil, err
+	}
+	layout.SetRange(dlg.peerForm, walk.Rectangle{0, 2, 2, 1})
+	dlg.peerForm.SetVisible(false)
+
+	idleTimeoutContainer, err := walk.NewComposite(dlg)
+	if err != nil {
+		return nil, err
+	}
+	layout.SetRange(idleTimeoutContainer, walk.Rectangle{0, 3, 2, 1})
+	idleTimeoutContainer.SetLayout(walk.NewHBoxLayout())
+	idleTimeoutContainer.Layout().SetMargins(walk.Margins{})
+
+	if dlg.idleTimeoutCB, err = walk.NewCheckBox(idleTimeoutContainer); err != nil {
+		return nil, err
+	}
+	setAutomationID(dlg.idleTimeoutCB, automationEditIdleTimeoutEnabled)
+	dlg.idleTimeoutCB.SetText(l18n.Sprintf("&Deactivate after"))
+	dlg.idleTimeoutCB.SetToolTipText(l18n.Sprintf("The tunnel service deactivates the tunnel once no traffic has flowed through it for this long."))
+	dlg.idleTimeoutCB.SetChecked(dlg.options.IdleTimeout > 0)
+
+	if dlg.idleTimeoutEdit, err = walk.NewNumberEdit(idleTimeoutContainer); err != nil {
+		return nil, err
+	}
+	setAutomationID(dlg.idleTimeoutEdit, automationEditIdleTimeout)
+	dlg.idleTimeoutEdit.SetDecimals(0)
+	dlg.idleTimeoutEdit.SetRange(1, 24*60)
+	dlg.idleTimeoutEdit.SetSpinButtonsVisible(true)
+	dlg.idleTimeoutEdit.SetMinMaxSize(walk.Size{60, 0}, walk.Size{60, 0})
+	if dlg.options.IdleTimeout > 0 {
+		dlg.idleTimeoutEdit.SetValue(float64(dlg.options.IdleTimeout / time.Minute))
+	} else {
+		dlg.idleTimeoutEdit.SetValue(30)
+	}
+	dlg.idleTimeoutEdit.SetEnabled(dlg.idleTimeoutCB.Checked())
+	dlg.idleTimeoutCB.CheckedChanged().Attach(func() {
+		dlg.idleTimeoutEdit.SetEnabled(dlg.idleTimeoutCB.Checked())
+	})
+
+	idleTimeoutLabel, err := walk.NewTextLabel(idleTimeoutContainer)
+	if err != nil {
+		return nil, err
+	}
+	idleTimeoutLabel.SetText(l18n.Sprintf("minutes without traffic"))
+
+	walk.NewHSpacer(idleTimeoutContainer)
 
 	buttonsContainer, err := walk.NewComposite(dlg)
 	if err != nil {
 		return nil, err
 	}
-	layout.SetRange(buttonsContainer, walk.Rectangle{0, 3, 2, 1})
+	layout.SetRange(buttonsContainer, walk.Rectangle{0, 4, 2, 1})
 	buttonsContainer.SetLayout(walk.NewHBoxLayout())
 	buttonsContainer.Layout().SetMargins(walk.Margins{})
 
@@ -130,16 +259,25 @@ func newEditDialog(owner walk.Form, tunnel *manager.Tunnel) (*EditDialog, error)
 	}
 	dlg.blockUntunneledTrafficCB.SetText(l18n.Sprintf("&Block untunneled traffic (kill-switch)"))
 	dlg.blockUntunneledTrafficCB.SetToolTipText(l18n.Sprintf("When a configuration has exactly one peer, and that peer has an allowed IPs containing at least one of 0.0.0.0/0 or ::/0, then the tunnel service engages a firewall ruleset to block all traffic that is neither to nor from the tunnel interface or is to the wrong DNS server, with special exceptions for DHCP and NDP."))
+	setAutomationID(dlg.blockUntunneledTrafficCB, automationEditBlockUntunneled)
 	dlg.blockUntunneledTrafficCB.SetVisible(false)
 	dlg.blockUntunneledTrafficCB.CheckedChanged().Attach(dlg.onBlockUntunneledTrafficCBCheckedChanged)
 
 	walk.NewHSpacer(buttonsContainer)
 
+	if dlg.formModeButton, err = walk.NewPushButton(buttonsContainer); err != nil {
+		return nil, err
+	}
+	dlg.formModeButton.SetText(l18n.Sprintf("Edit peers in &form"))
+	dlg.formModeButton.Clicked().Attach(dlg.onFormModeButtonClicked)
+	setAutomationID(dlg.formModeButton, automationEditFormMode)
+
 	if dlg.saveButton, err = walk.NewPushButton(buttonsContainer); err != nil {
 		return nil, err
 	}
 	dlg.saveButton.SetText(l18n.Sprintf("&Save"))
 	dlg.saveButton.Clicked().Attach(dlg.onSaveButtonClicked)
+	setAutomationID(dlg.saveButton, automationEditSave)
 
 	cancelButton, err := walk.NewPushButton(buttonsContainer)
 	if err != nil {
@@ -147,6 +285,7 @@ func newEditDialog(owner walk.Form, tunnel *manager.Tunnel) (*EditDialog, error)
 	}
 	cancelButton.SetText(l18n.Sprintf("Cancel"))
 	cancelButton.Clicked().Attach(dlg.Cancel)
+	setAutomationID(cancelButton, automationEditCancel)
 
 	dlg.SetCancelButton(cancelButton)
 	dlg.SetDefaultButton(dlg.saveButton)
@@ -292,6 +431,128 @@ func (dlg *EditDialog) onBlockUntunneledTrafficStateChanged(state int) {
 	dlg.blockUntunneledTraficCheckGuard = false
 }
 
+func (dlg *EditDialog) syncPeerFormToText() bool {
+	peers, err := dlg.peerForm.Peers()
+	if err != nil {
+		showWarningCustom(dlg, l18n.Sprintf("Invalid peer"), err.Error())
+		return false
+	}
+	text := dlg.syntaxEdit.Text()
+	cfg, err := conf.FromWgQuick(text, "temporary")
+	if err != nil {
+		showWarningCustom(dlg, l18n.Sprintf("Invalid configuration"), err.Error())
+		return false
+	}
+	cfg.Peers = peers
+	// Only the lines of what was changed in the form are rewritten, so that comments and formatting are kept.
+	rewritten, err := cfg.RewriteWgQuick(text)
+	if err != nil {
+		showWarningCustom(dlg, l18n.Sprintf("Invalid configuration"), err.Error())
+		return false
+	}
+	if rewritten != text {
+		dlg.syntaxEdit.SetText(rewritten)
+	}
+	return true
+}
+
+func (dlg *EditDialog) onFormModeButtonClicked() {
+	if dlg.peerForm.Visible() {
+		if !dlg.syncPeerFormToText() {
+			return
+		}
+		dlg.peerForm.SetVisible(false)
+		dlg.syntaxEdit.SetVisible(true)
+		dlg.formModeButton.SetText(l18n.Sprintf("Edit peers in &form"))
+		dlg.syntaxEdit.SetFocus()
+		return
+	}
+
+	cfg, err := conf.FromWgQuick(dlg.syntaxEdit.Text(), "temporary")
+	if err != nil {
+		showWarningCustom(dlg, l18n.Sprintf("Invalid configuration"), l18n.Sprintf("The configuration must be valid before it can be edited in form mode: %v", err))
+		return
+	}
+	dlg.peerForm.SetPeers(cfg.Peers)
+	dlg.syntaxEdit.SetVisible(false)
+	dlg.peerForm.SetVisible(true)
+	dlg.formModeButton.SetText(l18n.Sprintf("Edit as &text"))
+}
+
+func (dlg *EditDialog) modifyConfig(modify func(cfg *conf.Config) bool) {
+	if dlg.peerForm.Visible() && !dlg.syncPeerFormToText() {
+		return
+	}
+	text := dlg.syntaxEdit.Text()
+	cfg, err := conf.FromWgQuick(text, "temporary")
+	if err != nil {
+		showWarningCustom(dlg, l18n.Sprintf("Invalid configuration"), err.Error())
+		return
+	}
+	if !modify(cfg) {
+		return
+	}
+	rewritten, err := cfg.RewriteWgQuick(text)
+	if err != nil {
+		showWarningCustom(dlg, l18n.Sprintf("Invalid configuration"), err.Error())
+		return
+	}
+	dlg.syntaxEdit.SetText(rewritten)
+	if dlg.peerForm.Visible() {
+		dlg.peerForm.SetPeers(cfg.Peers)
+	}
+}
+
+func (dlg *EditDialog) onCopyPublicKeyClicked() {
+	key, _ := conf.NewPrivateKeyFromString(dlg.lastPrivateKey)
+	if key == nil {
+		showWarningCustom(dlg, l18n.Sprintf("Invalid private key"), l18n.Sprintf("The configuration does not contain a valid private key."))
+		return
+	}
+	walk.Clipboard().SetText(key.Public().String())
+}
+
+func (dlg *EditDialog) onGenerateKeypair() {
+	dlg.modifyConfig(func(cfg *conf.Config) bool {
+		if !cfg.Interface.PrivateKey.IsZero() && walk.DlgCmdNo == walk.MsgBox(dlg,
+			l18n.Sprintf("Generate new keypair"),
+			l18n.Sprintf("Replacing the private key means that every peer must be updated with the new public key. Continue?"),
+			walk.MsgBoxYesNo|walk.MsgBoxIconWarning) {
+			return false
+		}
+		pk, err := conf.NewPrivateKey()
+		if err != nil {
+			showErrorCustom(dlg, l18n.Sprintf("Unable to generate key"), err.Error())
+			return false
+		}
+		cfg.Interface.PrivateKey = *pk
+		return true
+	})
+}
+
+func (dlg *EditDialog) onGeneratePresharedKey() {
+	dlg.modifyConfig(func(cfg *conf.Config) bool {
+		generated := 0
+		for i := range cfg.Peers {
+			if !cfg.Peers[i].PresharedKey.IsZero() {
+				continue
+			}
+			psk, err := conf.NewPresharedKey()
+			if err != nil {
+				showErrorCustom(dlg, l18n.Sprintf("Unable to generate key"), err.Error())
+				return false
+			}
+			cfg.Peers[i].PresharedKey = *psk
+			generated++
+		}
+		if generated == 0 {
+			showWarningCustom(dlg, l18n.Sprintf("No peers without preshared keys"), l18n.Sprintf("Every peer already has a preshared key."))
+			return false
+		}
+		return true
+	})
+}
+
 func (dlg *EditDialog) onSyntaxEditPrivateKeyChanged(privateKey string) {
 	if privateKey == dlg.lastPrivateKey {
 		return
@@ -306,6 +567,10 @@ func (dlg *EditDialog) onSyntaxEditPrivateKeyChanged(privateKey string) {
 }
 
 func (dlg *EditDialog) onSaveButtonClicked() {
+	if dlg.peerForm.Visible() && !dlg.syncPeerFormToText() {
+		return
+	}
+
 	newName := dlg.nameEdit.Text()
 	if newName == "" {
 		showWarningCustom(dlg, l18n.Sprintf("Invalid name"), l18n.Sprintf("A name is required."))
@@ -338,5 +603,10 @@ func (dlg *EditDialog) onSaveButtonClicked() {
 	}
 
 	dlg.config = *cfg
+	if dlg.idleTimeoutCB.Checked() {
+		dlg.options.IdleTimeout = time.Duration(dlg.idleTimeoutEdit.Value()) * time.Minute
+	} else {
+		dlg.options.IdleTimeout = 0
+	}
 	dlg.Accept()
 }
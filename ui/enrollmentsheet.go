@@ -0,0 +1,245 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"html/template"
+	"image/png"
+	"os"
+	"strings"
+
+	"github.com/lxn/walk"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/l18n"
+	"golang.zx2c4.com/wireguard/windows/ui/qrcode"
+)
+
+type enrollmentField struct {
+	Label string
+	Value string
+}
+
+type enrollmentSheet struct {
+	Title   string
+	Warning string
+	QRCode  template.URL
+	Fields  []enrollmentField
+}
+
+var enrollmentSheetTemplate = template.Must(template.New("sheet").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: "Segoe UI", sans-serif; margin: 2em; }
+.warning { border: 2px solid #c00; color: #c00; padding: 0.5em 1em; font-weight: bold; }
+img { width: 8cm; height: 8cm; image-rendering: pixelated; }
+th { text-align: right; padding-right: 1em; vertical-align: top; }
+td { font-family: Consolas, monospace; word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="warning">{{.Warning}}</p>
+<p><img src="{{.QRCode}}" alt=""></p>
+<table>
+{{range .Fields}}<tr><th>{{.Label}}</th><td>{{.Value}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+func enrollmentFields(config *conf.Config) []enrollmentField {
+	joinCidrs := func(cidrs []conf.IPCidr) string {
+		addrs := make([]string, len(cidrs))
+		for i := range cidrs {
+			addrs[i] = cidrs[i].String()
+		}
+		return strings.Join(addrs, ", ")
+	}
+	fields := []enrollmentField{
+		{l18n.Sprintf("Tunnel name:"), config.Name},
+		{l18n.Sprintf("Device public key:"), config.Interface.PrivateKey.Public().String()},
+	}
+	if len(config.Interface.Addresses) > 0 {
+		fields = append(fields, enrollmentField{l18n.Sprintf("Assigned address:"), joinCidrs(config.Interface.Addresses)})
+	}
+	if len(config.Interface.DNS) > 0 {
+		dns := make([]string, len(config.Interface.DNS))
+		for i := range config.Interface.DNS {
+			dns[i] = config.Interface.DNS[i].String()
+		}
+		fields = append(fields, enrollmentField{l18n.Sprintf("DNS servers:"), strings.Join(dns, ", ")})
+	}
+	for i := range config.Peers {
+		peer := &config.Peers[i]
+		fields = append(fields, enrollmentField{l18n.Sprintf("Server public key:"), peer.PublicKey.String()})
+		if !peer.Endpoint.IsEmpty() {
+			fields = append(fields, enrollmentField{l18n.Sprintf("Endpoint:"), peer.Endpoint.String()})
+		}
+		if len(peer.AllowedIPs) > 0 {
+			fields = append(fields, enrollmentField{l18n.Sprintf("Allowed IPs:"), joinCidrs(peer.AllowedIPs)})
+		}
+	}
+	return fields
+}
+
+func enrollmentWarning() string {
+	return l18n.Sprintf("This sheet contains the private key of the tunnel. Anyone who scans or photographs the QR code can impersonate the device it is meant for. Hand it over in person, do not send it by email or chat, and destroy printed copies once the device is enrolled.")
+}
+
+func exportEnrollmentSheet(owner walk.Form, config *conf.Config, code *qrcode.Code) {
+	dlg := walk.FileDialog{
+		Filter: l18n.Sprintf("Web Pages (*.html)|*.html"),
+		Title:  l18n.Sprintf("Export enrollment sheet"),
+	}
+	dlg.FilePath = config.Name + ".html"
+	if ok, _ := dlg.ShowSave(owner); !ok {
+		return
+	}
+	if !strings.HasSuffix(strings.ToLower(dlg.FilePath), ".html") {
+		dlg.FilePath += ".html"
+	}
+
+	var image bytes.Buffer
+	err := png.Encode(&image, code.Image(8))
+	if err != nil {
+		showErrorCustom(owner, l18n.Sprintf("Unable to export enrollment sheet"), err.Error())
+		return
+	}
+	writeFileWithOverwriteHandling(owner, dlg.FilePath, func(file *os.File) error {
+		return enrollmentSheetTemplate.Execute(file, &enrollmentSheet{
+			Title:   l18n.Sprintf("WireGuard enrollment sheet: %s", config.Name),
+			Warning: enrollmentWarning(),
+			QRCode:  template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(image.Bytes())),
+			Fields:  enrollmentFields(config),
+		})
+	})
+}
+
+func runEnrollmentSheetDialog(owner walk.Form, config *conf.Config) error {
+	code, err := qrcode.Encode([]byte(config.ToWgQuick()))
+	if err != nil {
+		return err
+	}
+
+	var disposables walk.Disposables
+	defer disposables.Treat()
+
+	dlg, err := walk.NewDialogWithFixedSize(owner)
+	if err != nil {
+		return err
+	}
+	disposables.Add(dlg)
+	applyModernWindowStyle(dlg.Handle(), false)
+	dlg.SetTitle(l18n.Sprintf("Enrollment sheet: %s", config.Name))
+	if icon, err := loadLogoIcon(32); err == nil {
+		dlg.SetIcon(icon)
+	}
+	vlayout := walk.NewVBoxLayout()
+	vlayout.SetMargins(walk.Margins{10, 10, 10, 10})
+	vlayout.SetSpacing(6)
+	dlg.SetLayout(vlayout)
+	if font, err := scaledFont(9); err == nil {
+		dlg.SetFont(font)
+	}
+
+	warningLabel, err := walk.NewTextLabel(dlg)
+	if err != nil {
+		return err
+	}
+	warningLabel.SetText(enrollmentWarning())
+	warningLabel.SetTextColor(walk.RGB(0xc0, 0x00, 0x00))
+	warningLabel.SetMinMaxSize(walk.Size{600, 0}, walk.Size{600, 0})
+
+	sheetContainer, err := walk.NewComposite(dlg)
+	if err != nil {
+		return err
+	}
+	sheetContainer.SetLayout(walk.NewHBoxLayout())
+	sheetContainer.Layout().SetMargins(walk.Margins{})
+
+	qrView, err := walk.NewImageView(sheetContainer)
+	if err != nil {
+		return err
+	}
+	scale := 280 / (code.Size + 8)
+	if scale < 2 {
+		scale = 2
+	}
+	qrBitmap, err := walk.NewBitmapFromImageForDPI(code.Image(scale), 96)
+	if err != nil {
+		return err
+	}
+	disposables.Add(qrBitmap)
+	qrView.SetImage(qrBitmap)
+	qrView.Accessibility().SetName(l18n.Sprintf("QR code of the configuration"))
+
+	fieldsContainer, err := walk.NewComposite(sheetContainer)
+	if err != nil {
+		return err
+	}
+	fieldsLayout := walk.NewGridLayout()
+	fieldsLayout.SetMargins(walk.Margins{})
+	fieldsLayout.SetColumnStretchFactor(1, 1)
+	fieldsContainer.SetLayout(fieldsLayout)
+	for i, field := range enrollmentFields(config) {
+		label, err := walk.NewTextLabel(fieldsContainer)
+		if err != nil {
+			return err
+		}
+		label.SetText(field.Label)
+		label.SetTextAlignment(walk.AlignHFarVNear)
+		fieldsLayout.SetRange(label, walk.Rectangle{0, i, 1, 1})
+		value, err := walk.NewLineEdit(fieldsContainer)
+		if err != nil {
+			return err
+		}
+		value.SetText(field.Value)
+		value.SetReadOnly(true)
+		value.Accessibility().SetRole(walk.AccRoleStatictext)
+		fieldsLayout.SetRange(value, walk.Rectangle{1, i, 1, 1})
+	}
+	walk.NewVSpacer(fieldsContainer)
+
+	buttonsContainer, err := walk.NewComposite(dlg)
+	if err != nil {
+		return err
+	}
+	buttonsContainer.SetLayout(walk.NewHBoxLayout())
+	buttonsContainer.Layout().SetMargins(walk.Margins{})
+	walk.NewHSpacer(buttonsContainer)
+
+	exportButton, err := walk.NewPushButton(buttonsContainer)
+	if err != nil {
+		return err
+	}
+	exportButton.SetText(l18n.Sprintf("E&xport printable sheet…"))
+	exportButton.Clicked().Attach(func() {
+		exportEnrollmentSheet(dlg, config, code)
+	})
+
+	closeButton, err := walk.NewPushButton(buttonsContainer)
+	if err != nil {
+		return err
+	}
+	closeButton.SetText(l18n.Sprintf("Close"))
+	closeButton.Clicked().Attach(dlg.Cancel)
+
+	dlg.SetCancelButton(closeButton)
+	dlg.SetDefaultButton(closeButton)
+
+	disposables.Spare()
+
+	dlg.Run()
+	qrBitmap.Dispose()
+
+	return nil
+}
@@ -7,7 +7,9 @@ package ui
 
 import (
 	"github.com/lxn/walk"
+	"golang.org/x/sys/windows/registry"
 
+	"golang.zx2c4.com/wireguard/windows/conf"
 	"golang.zx2c4.com/wireguard/windows/l18n"
 	"golang.zx2c4.com/wireguard/windows/manager"
 )
@@ -17,21 +19,45 @@ type widthAndState struct {
 	state manager.TunnelState
 }
 
+type widthAndLogo struct {
+	width int
+	logo  logoVariant
+}
+
+type widthStateAndLogo struct {
+	width int
+	state manager.TunnelState
+	logo  logoVariant
+}
+
+// logoVariant is the resource ID of a rendering of the logo.
+type logoVariant int
+
+const (
+	logoColor           logoVariant = 7
+	logoForLightTaskbar logoVariant = 9
+	logoForDarkTaskbar  logoVariant = 10
+)
+
 type widthAndDllIdx struct {
 	width int
 	idx   int32
 	dll   string
 }
 
-var cachedOverlayIconsForWidthAndState = make(map[widthAndState]walk.Image)
+var cachedOverlayIconsForWidthStateAndLogo = make(map[widthStateAndLogo]walk.Image)
 
 func iconWithOverlayForState(state manager.TunnelState, size int) (icon walk.Image, err error) {
-	icon = cachedOverlayIconsForWidthAndState[widthAndState{size, state}]
+	return iconWithOverlayForStateAndLogo(state, size, logoColor)
+}
+
+func iconWithOverlayForStateAndLogo(state manager.TunnelState, size int, logo logoVariant) (icon walk.Image, err error) {
+	icon = cachedOverlayIconsForWidthStateAndLogo[widthStateAndLogo{size, state, logo}]
 	if icon != nil {
 		return
 	}
 
-	wireguardIcon, err := loadLogoIcon(size)
+	wireguardIcon, err := loadLogoIconVariant(logo, size)
 	if err != nil {
 		return
 	}
@@ -59,7 +85,41 @@ func iconWithOverlayForState(state manager.TunnelState, size int) (icon walk.Ima
 		return nil
 	})
 
-	cachedOverlayIconsForWidthAndState[widthAndState{size, state}] = icon
+	cachedOverlayIconsForWidthStateAndLogo[widthStateAndLogo{size, state, logo}] = icon
+
+	return
+}
+
+var cachedErrorOverlayIconsForWidthAndLogo = make(map[widthAndLogo]walk.Image)
+
+func iconWithErrorOverlay(size int, logo logoVariant) (icon walk.Image, err error) {
+	icon = cachedErrorOverlayIconsForWidthAndLogo[widthAndLogo{size, logo}]
+	if icon != nil {
+		return
+	}
+
+	wireguardIcon, err := loadLogoIconVariant(logo, size)
+	if err != nil {
+		return
+	}
+
+	iconSize := wireguardIcon.Size()
+	w := int(float64(iconSize.Width) * 0.65)
+	h := int(float64(iconSize.Height) * 0.65)
+	overlayBounds := walk.Rectangle{iconSize.Width - w, iconSize.Height - h, w, h}
+	overlayIcon := walk.IconError()
+
+	icon = walk.NewPaintFuncImage(walk.Size{size, size}, func(canvas *walk.Canvas, bounds walk.Rectangle) error {
+		if err := canvas.DrawImageStretched(wireguardIcon, bounds); err != nil {
+			return err
+		}
+		if err := canvas.DrawImageStretched(overlayIcon, overlayBounds); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	cachedErrorOverlayIconsForWidthAndLogo[widthAndLogo{size, logo}] = icon
 
 	return
 }
@@ -121,16 +181,47 @@ func loadSystemIcon(dll string, index int32, size int) (icon *walk.Icon, err err
 	return
 }
 
-var cachedLogoIconsForWidth = make(map[int]*walk.Icon)
+var cachedLogoIconsForWidthAndLogo = make(map[widthAndLogo]*walk.Icon)
 
 func loadLogoIcon(size int) (icon *walk.Icon, err error) {
-	icon = cachedLogoIconsForWidth[size]
+	return loadLogoIconVariant(logoColor, size)
+}
+
+func loadLogoIconVariant(logo logoVariant, size int) (icon *walk.Icon, err error) {
+	icon = cachedLogoIconsForWidthAndLogo[widthAndLogo{size, logo}]
 	if icon != nil {
 		return
 	}
-	icon, err = walk.NewIconFromResourceIdWithSize(7, walk.Size{size, size})
+	icon, err = walk.NewIconFromResourceIdWithSize(int(logo), walk.Size{size, size})
 	if err == nil {
-		cachedLogoIconsForWidth[size] = icon
+		cachedLogoIconsForWidthAndLogo[widthAndLogo{size, logo}] = icon
 	}
 	return
 }
+
+func taskbarUsesLightTheme() bool {
+	key, err := registry.OpenKey(registry.CURRENT_USER, "Software\\Microsoft\\Windows\\CurrentVersion\\Themes\\Personalize", registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	defer key.Close()
+	light, _, err := key.GetIntegerValue("SystemUsesLightTheme")
+	return err == nil && light != 0
+}
+
+// trayLogo picks the rendering of the logo for the notification area. Automatically, the color logo is kept on dark
+// taskbars, where it is plainly visible, and a dark monochrome one is used on light taskbars, where it otherwise is not.
+func trayLogo() logoVariant {
+	switch currentSettings.TrayIconStyle {
+	case conf.TrayIconColor:
+		return logoColor
+	case conf.TrayIconMonochromeDark:
+		return logoForLightTaskbar
+	case conf.TrayIconMonochromeLight:
+		return logoForDarkTaskbar
+	}
+	if taskbarUsesLightTheme() {
+		return logoForLightTaskbar
+	}
+	return logoColor
+}
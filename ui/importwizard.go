@@ -0,0 +1,354 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lxn/walk"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/l18n"
+	"golang.zx2c4.com/wireguard/windows/manager"
+)
+
+type importWizardEntry struct {
+	source       string
+	detectedName string
+	config       *conf.Config
+	parseErr     error
+	name         string
+	result       string
+	importable   bool
+	imported     bool
+}
+
+type importWizardModel struct {
+	walk.TableModelBase
+	entries []importWizardEntry
+}
+
+func (m *importWizardModel) RowCount() int {
+	return len(m.entries)
+}
+
+func (m *importWizardModel) Value(row, col int) interface{} {
+	if row < 0 || row >= len(m.entries) {
+		return ""
+	}
+	entry := &m.entries[row]
+	switch col {
+	case 0:
+		return entry.source
+	case 1:
+		return entry.detectedName
+	case 2:
+		return entry.name
+	case 3:
+		return entry.result
+	}
+	return ""
+}
+
+const (
+	importCollisionSkip = iota
+	importCollisionAppendNumber
+)
+
+type importWizard struct {
+	*walk.Dialog
+	prefixEdit     *walk.LineEdit
+	suffixEdit     *walk.LineEdit
+	sequenceCB     *walk.CheckBox
+	sequenceStart  *walk.NumberEdit
+	collisionCombo *walk.ComboBox
+	tableView      *walk.TableView
+	summaryLabel   *walk.TextLabel
+	importButton   *walk.PushButton
+	closeButton    *walk.PushButton
+	model          *importWizardModel
+	existingNames  map[string]bool
+}
+
+func runImportWizard(owner walk.Form, paths []string) {
+	unparsedConfigs, err := readUnparsedConfigs(paths)
+	if err != nil && len(unparsedConfigs) == 0 {
+		showErrorCustom(owner, l18n.Sprintf("Error"), l18n.Sprintf("Could not import selected configuration: %v", err))
+		return
+	} else if len(unparsedConfigs) == 0 {
+		showErrorCustom(owner, l18n.Sprintf("Error"), l18n.Sprintf("Could not import selected configuration: %v", l18n.Sprintf("no configuration files were found")))
+		return
+	}
+	existingTunnels, err := manager.IPCClientTunnels()
+	if err != nil {
+		showErrorCustom(owner, l18n.Sprintf("Error"), l18n.Sprintf("Could not enumerate existing tunnels: %v", err))
+		return
+	}
+
+	wizard, err := newImportWizard(owner, unparsedConfigs, existingTunnels)
+	if showError(err, owner) {
+		return
+	}
+	wizard.Run()
+}
+
+func newImportWizard(owner walk.Form, unparsedConfigs []unparsedConfig, existingTunnels []manager.Tunnel) (*importWizard, error) {
+	var err error
+	var disposables walk.Disposables
+	defer disposables.Treat()
+
+	wiz := &importWizard{
+		model:         &importWizardModel{entries: make([]importWizardEntry, len(unparsedConfigs))},
+		existingNames: make(map[string]bool, len(existingTunnels)),
+	}
+	for _, tunnel := range existingTunnels {
+		wiz.existingNames[strings.ToLower(tunnel.Name)] = true
+	}
+	for i, unparsed := range unparsedConfigs {
+		entry := &wiz.model.entries[i]
+		entry.source = unparsed.Source
+		entry.detectedName = unparsed.Name
+		entry.config, entry.parseErr = conf.FromWgQuickWithUnknownEncoding(unparsed.Config, "temporary")
+	}
+
+	if wiz.Dialog, err = walk.NewDialog(owner); err != nil {
+		return nil, err
+	}
+	disposables.Add(wiz)
+	applyModernWindowStyle(wiz.Handle(), false)
+	wiz.SetTitle(l18n.Sprintf("Bulk import tunnels"))
+	if owner != nil {
+		wiz.SetIcon(owner.Icon())
+	}
+	wiz.SetMinMaxSize(walk.Size{700, 450}, walk.Size{0, 0})
+	vlayout := walk.NewVBoxLayout()
+	vlayout.SetMargins(walk.Margins{10, 10, 10, 10})
+	vlayout.SetSpacing(6)
+	wiz.SetLayout(vlayout)
+
+	namingGroup, err := walk.NewGroupBox(wiz)
+	if err != nil {
+		return nil, err
+	}
+	namingGroup.SetTitle(l18n.Sprintf("Naming"))
+	namingLayout := walk.NewGridLayout()
+	namingLayout.SetColumnStretchFactor(1, 1)
+	namingLayout.SetColumnStretchFactor(3, 1)
+	namingGroup.SetLayout(namingLayout)
+
+	newLabel := func(text string, row, col int) error {
+		label, err := walk.NewTextLabel(namingGroup)
+		if err != nil {
+			return err
+		}
+		label.SetText(text)
+		label.SetTextAlignment(walk.AlignHFarVCenter)
+		namingLayout.SetRange(label, walk.Rectangle{col, row, 1, 1})
+		return nil
+	}
+
+	if err = newLabel(l18n.Sprintf("&Prefix:"), 0, 0); err != nil {
+		return nil, err
+	}
+	if wiz.prefixEdit, err = walk.NewLineEdit(namingGroup); err != nil {
+		return nil, err
+	}
+	namingLayout.SetRange(wiz.prefixEdit, walk.Rectangle{1, 0, 1, 1})
+
+	if err = newLabel(l18n.Sprintf("&Suffix:"), 0, 2); err != nil {
+		return nil, err
+	}
+	if wiz.suffixEdit, err = walk.NewLineEdit(namingGroup); err != nil {
+		return nil, err
+	}
+	namingLayout.SetRange(wiz.suffixEdit, walk.Rectangle{3, 0, 1, 1})
+
+	if wiz.sequenceCB, err = walk.NewCheckBox(namingGroup); err != nil {
+		return nil, err
+	}
+	setAutomationID(wiz.sequenceCB, automationImportWizardSequence)
+	wiz.sequenceCB.SetText(l18n.Sprintf("Replace names with a &sequence starting at:"))
+	namingLayout.SetRange(wiz.sequenceCB, walk.Rectangle{0, 1, 2, 1})
+	if wiz.sequenceStart, err = walk.NewNumberEdit(namingGroup); err != nil {
+		return nil, err
+	}
+	wiz.sequenceStart.SetDecimals(0)
+	wiz.sequenceStart.SetRange(0, 99999)
+	wiz.sequenceStart.SetValue(1)
+	wiz.sequenceStart.SetEnabled(false)
+	namingLayout.SetRange(wiz.sequenceStart, walk.Rectangle{2, 1, 2, 1})
+
+	if err = newLabel(l18n.Sprintf("On name &collision:"), 2, 0); err != nil {
+		return nil, err
+	}
+	if wiz.collisionCombo, err = walk.NewDropDownBox(namingGroup); err != nil {
+		return nil, err
+	}
+	wiz.collisionCombo.SetModel([]string{
+		importCollisionSkip:         l18n.Sprintf("Skip the tunnel"),
+		importCollisionAppendNumber: l18n.Sprintf("Append a number to the name"),
+	})
+	wiz.collisionCombo.SetCurrentIndex(importCollisionAppendNumber)
+	namingLayout.SetRange(wiz.collisionCombo, walk.Rectangle{1, 2, 3, 1})
+
+	if wiz.tableView, err = walk.NewTableView(wiz); err != nil {
+		return nil, err
+	}
+	wiz.tableView.SetAlternatingRowBG(true)
+	wiz.tableView.SetLastColumnStretched(true)
+	for _, column := range []struct {
+		title string
+		width int
+	}{
+		{l18n.Sprintf("File"), 180},
+		{l18n.Sprintf("Detected name"), 130},
+		{l18n.Sprintf("Import as"), 150},
+		{l18n.Sprintf("Result"), 0},
+	} {
+		col := walk.NewTableViewColumn()
+		col.SetTitle(column.title)
+		if column.width > 0 {
+			col.SetWidth(column.width)
+		}
+		wiz.tableView.Columns().Add(col)
+	}
+	wiz.tableView.SetModel(wiz.model)
+
+	buttonsContainer, err := walk.NewComposite(wiz)
+	if err != nil {
+		return nil, err
+	}
+	buttonsContainer.SetLayout(walk.NewHBoxLayout())
+	buttonsContainer.Layout().SetMargins(walk.Margins{})
+
+	if wiz.summaryLabel, err = walk.NewTextLabel(buttonsContainer); err != nil {
+		return nil, err
+	}
+	walk.NewHSpacer(buttonsContainer)
+
+	if wiz.importButton, err = walk.NewPushButton(buttonsContainer); err != nil {
+		return nil, err
+	}
+	wiz.importButton.SetText(l18n.Sprintf("&Import"))
+	wiz.importButton.Clicked().Attach(wiz.onImport)
+	setAutomationID(wiz.importButton, automationImportWizardImport)
+
+	if wiz.closeButton, err = walk.NewPushButton(buttonsContainer); err != nil {
+		return nil, err
+	}
+	wiz.closeButton.SetText(l18n.Sprintf("Cancel"))
+	wiz.closeButton.Clicked().Attach(wiz.Cancel)
+	setAutomationID(wiz.closeButton, automationImportWizardClose)
+
+	wiz.SetCancelButton(wiz.closeButton)
+	wiz.SetDefaultButton(wiz.importButton)
+
+	wiz.prefixEdit.TextChanged().Attach(wiz.updateNames)
+	wiz.suffixEdit.TextChanged().Attach(wiz.updateNames)
+	wiz.sequenceCB.CheckedChanged().Attach(func() {
+		wiz.sequenceStart.SetEnabled(wiz.sequenceCB.Checked())
+		wiz.updateNames()
+	})
+	wiz.sequenceStart.ValueChanged().Attach(wiz.updateNames)
+	wiz.collisionCombo.CurrentIndexChanged().Attach(wiz.updateNames)
+	wiz.updateNames()
+
+	disposables.Spare()
+
+	return wiz, nil
+}
+
+func (wiz *importWizard) updateNames() {
+	prefix, suffix := wiz.prefixEdit.Text(), wiz.suffixEdit.Text()
+	sequence := int(wiz.sequenceStart.Value())
+	appendNumber := wiz.collisionCombo.CurrentIndex() == importCollisionAppendNumber
+	taken := make(map[string]bool, len(wiz.existingNames)+len(wiz.model.entries))
+	for name := range wiz.existingNames {
+		taken[name] = true
+	}
+
+	importable := 0
+	for i := range wiz.model.entries {
+		entry := &wiz.model.entries[i]
+		entry.importable = false
+		if entry.parseErr != nil {
+			entry.name = ""
+			entry.result = l18n.Sprintf("Invalid configuration: %v", entry.parseErr)
+			continue
+		}
+		base := entry.detectedName
+		if wiz.sequenceCB.Checked() {
+			base = strconv.Itoa(sequence)
+			sequence++
+		}
+		entry.name = prefix + base + suffix
+		if !conf.TunnelNameIsValid(entry.name) {
+			entry.result = l18n.Sprintf("Invalid tunnel name")
+			continue
+		}
+		if taken[strings.ToLower(entry.name)] {
+			if !appendNumber {
+				entry.result = l18n.Sprintf("Skipped: another tunnel already has this name")
+				continue
+			}
+			original := entry.name
+			for n := 2; taken[strings.ToLower(entry.name)]; n++ {
+				entry.name = fmt.Sprintf("%s-%d", original, n)
+			}
+			if !conf.TunnelNameIsValid(entry.name) {
+				entry.result = l18n.Sprintf("Invalid tunnel name")
+				continue
+			}
+			entry.result = l18n.Sprintf("Ready (renamed from ‘%s’ to avoid a collision)", original)
+		} else {
+			entry.result = l18n.Sprintf("Ready")
+		}
+		taken[strings.ToLower(entry.name)] = true
+		entry.importable = true
+		importable++
+	}
+	wiz.model.PublishRowsReset()
+	wiz.summaryLabel.SetText(l18n.SprintfPlural(len(wiz.model.entries), "%d of %d tunnel will be imported", "%d of %d tunnels will be imported", importable, len(wiz.model.entries)))
+	wiz.importButton.SetEnabled(importable > 0)
+}
+
+func (wiz *importWizard) onImport() {
+	for _, widget := range []walk.Widget{wiz.prefixEdit, wiz.suffixEdit, wiz.sequenceCB, wiz.sequenceStart, wiz.collisionCombo, wiz.importButton, wiz.closeButton} {
+		widget.SetEnabled(false)
+	}
+	entries := wiz.model.entries
+	go func() {
+		imported, failed := 0, 0
+		for i := range entries {
+			if !entries[i].importable {
+				continue
+			}
+			i := i
+			config := *entries[i].config
+			config.Name = entries[i].name
+			_, err := manager.IPCClientNewTunnel(&config)
+			wiz.Synchronize(func() {
+				if err != nil {
+					entries[i].result = l18n.Sprintf("Failed: %v", err)
+					failed++
+				} else {
+					entries[i].result = l18n.Sprintf("Imported")
+					entries[i].imported = true
+					imported++
+				}
+				wiz.model.PublishRowChanged(i)
+			})
+		}
+		wiz.Synchronize(func() {
+			wiz.summaryLabel.SetText(l18n.SprintfPlural(imported, "Imported %d tunnel, %d failed, %d skipped", "Imported %d tunnels, %d failed, %d skipped", imported, failed, len(entries)-imported-failed))
+			wiz.closeButton.SetText(l18n.Sprintf("&Close"))
+			wiz.closeButton.SetEnabled(true)
+			wiz.SetDefaultButton(wiz.closeButton)
+		})
+	}()
+}
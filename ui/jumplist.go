@@ -0,0 +1,270 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ui
+
+import (
+	"os"
+	"sort"
+	"syscall"
+	"unsafe"
+
+	"github.com/lxn/walk"
+	"github.com/lxn/win"
+	"golang.org/x/sys/windows"
+
+	"golang.zx2c4.com/wireguard/windows/l18n"
+	"golang.zx2c4.com/wireguard/windows/manager"
+)
+
+const maxJumpListTunnels = 8
+
+type cPROPVARIANT struct {
+	vt       uint16
+	reserved [3]uint16
+	pwszVal  *uint16
+	_        uintptr
+}
+
+type cPROPERTYKEY struct {
+	fmtid windows.GUID
+	pid   uint32
+}
+
+const cVT_LPWSTR = 31
+
+var (
+	clsidDestinationList            = win.CLSID{0x77f10cf0, 0x3db5, 0x4966, [8]byte{0xb5, 0x20, 0xb7, 0xc5, 0x4f, 0xd3, 0x5e, 0xd6}}
+	clsidEnumerableObjectCollection = win.CLSID{0x2d3468c1, 0x36a7, 0x43b6, [8]byte{0xac, 0x24, 0xd3, 0xf0, 0x2f, 0xd9, 0x60, 0x7a}}
+	clsidShellLink                  = win.CLSID{0x00021401, 0x0000, 0x0000, [8]byte{0xc0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}}
+	iidICustomDestinationList       = win.IID{0x6332debf, 0x87b5, 0x4670, [8]byte{0x90, 0xc0, 0x5e, 0x57, 0xb4, 0x08, 0xa4, 0x9e}}
+	iidIObjectArray                 = win.IID{0x92ca9dcd, 0x5622, 0x4bba, [8]byte{0xa8, 0x05, 0x5e, 0x9f, 0x54, 0x1b, 0xd8, 0xc9}}
+	iidIObjectCollection            = win.IID{0x5632b1a4, 0xe38a, 0x400a, [8]byte{0x92, 0x8a, 0xd4, 0xcd, 0x63, 0x23, 0x02, 0x95}}
+	iidIShellLinkW                  = win.IID{0x000214f9, 0x0000, 0x0000, [8]byte{0xc0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}}
+	iidIPropertyStore               = win.IID{0x886d8eeb, 0x8cf2, 0x4446, [8]byte{0x8d, 0x02, 0xcd, 0xba, 0x1d, 0xbd, 0xcf, 0x99}}
+	pkeyTitle                       = cPROPERTYKEY{windows.GUID{0xf29f85e0, 0x4ff9, 0x1068, [8]byte{0xab, 0x91, 0x08, 0x00, 0x2b, 0x27, 0xb3, 0xd9}}, 2}
+)
+
+// These are offsets into the vtables of the respective interfaces, from shobjidl_core.h and propsys.h.
+const (
+	queryInterfaceOffset = 0
+	releaseComOffset     = 2
+
+	destinationListBeginListOffset      = 4
+	destinationListAppendCategoryOffset = 5
+	destinationListCommitListOffset     = 8
+	destinationListAbortListOffset      = 11
+
+	objectCollectionAddObjectOffset = 5
+
+	shellLinkSetDescriptionOffset  = 7
+	shellLinkSetArgumentsOffset    = 11
+	shellLinkSetIconLocationOffset = 17
+	shellLinkSetPathOffset         = 20
+
+	propertyStoreSetValueOffset = 6
+	propertyStoreCommitOffset   = 7
+)
+
+type jumpListItem struct {
+	title     string
+	arguments string
+}
+
+func hresultToError(hr uintptr) error {
+	if int32(hr) < 0 {
+		return syscall.Errno(hr)
+	}
+	return nil
+}
+
+func comRelease(object **[0xffff]uintptr) {
+	syscall.Syscall((*object)[releaseComOffset], 1, uintptr(unsafe.Pointer(object)), 0, 0)
+}
+
+func comCreate(clsid *win.CLSID, iid *win.IID) (object **[0xffff]uintptr, err error) {
+	if hr := win.CoCreateInstance(clsid, nil, win.CLSCTX_INPROC_SERVER, iid, (*unsafe.Pointer)(unsafe.Pointer(&object))); win.FAILED(hr) {
+		return nil, syscall.Errno(hr)
+	}
+	return
+}
+
+func newJumpListLink(executable string, item *jumpListItem) (**[0xffff]uintptr, error) {
+	link, err := comCreate(&clsidShellLink, &iidIShellLinkW)
+	if err != nil {
+		return nil, err
+	}
+	for _, call := range []struct {
+		offset uintptr
+		arg    *uint16
+	}{
+		{shellLinkSetPathOffset, windows.StringToUTF16Ptr(executable)},
+		{shellLinkSetArgumentsOffset, windows.StringToUTF16Ptr(item.arguments)},
+		{shellLinkSetDescriptionOffset, windows.StringToUTF16Ptr(item.title)},
+	} {
+		if hr, _, _ := syscall.Syscall((*link)[call.offset], 2, uintptr(unsafe.Pointer(link)), uintptr(unsafe.Pointer(call.arg)), 0); hresultToError(hr) != nil {
+			comRelease(link)
+			return nil, hresultToError(hr)
+		}
+	}
+	syscall.Syscall((*link)[shellLinkSetIconLocationOffset], 3, uintptr(unsafe.Pointer(link)), uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(executable))), 0)
+
+	var propertyStore **[0xffff]uintptr
+	if hr, _, _ := syscall.Syscall((*link)[queryInterfaceOffset], 3, uintptr(unsafe.Pointer(link)), uintptr(unsafe.Pointer(&iidIPropertyStore)), uintptr(unsafe.Pointer(&propertyStore))); hresultToError(hr) != nil {
+		comRelease(link)
+		return nil, hresultToError(hr)
+	}
+	defer comRelease(propertyStore)
+	title := cPROPVARIANT{vt: cVT_LPWSTR, pwszVal: windows.StringToUTF16Ptr(item.title)}
+	if hr, _, _ := syscall.Syscall((*propertyStore)[propertyStoreSetValueOffset], 3, uintptr(unsafe.Pointer(propertyStore)), uintptr(unsafe.Pointer(&pkeyTitle)), uintptr(unsafe.Pointer(&title))); hresultToError(hr) != nil {
+		comRelease(link)
+		return nil, hresultToError(hr)
+	}
+	if hr, _, _ := syscall.Syscall((*propertyStore)[propertyStoreCommitOffset], 1, uintptr(unsafe.Pointer(propertyStore)), 0, 0); hresultToError(hr) != nil {
+		comRelease(link)
+		return nil, hresultToError(hr)
+	}
+	return link, nil
+}
+
+// commitJumpList must be called from the UI thread, which walk has already initialized for COM.
+func commitJumpList(category string, items []jumpListItem) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	destinationList, err := comCreate(&clsidDestinationList, &iidICustomDestinationList)
+	if err != nil {
+		return err
+	}
+	defer comRelease(destinationList)
+
+	var minSlots uint32
+	var removed **[0xffff]uintptr
+	if hr, _, _ := syscall.Syscall6((*destinationList)[destinationListBeginListOffset], 4, uintptr(unsafe.Pointer(destinationList)), uintptr(unsafe.Pointer(&minSlots)), uintptr(unsafe.Pointer(&iidIObjectArray)), uintptr(unsafe.Pointer(&removed)), 0, 0); hresultToError(hr) != nil {
+		return hresultToError(hr)
+	}
+	comRelease(removed)
+	committed := false
+	defer func() {
+		if !committed {
+			syscall.Syscall((*destinationList)[destinationListAbortListOffset], 1, uintptr(unsafe.Pointer(destinationList)), 0, 0)
+		}
+	}()
+
+	if len(items) > 0 {
+		collection, err := comCreate(&clsidEnumerableObjectCollection, &iidIObjectCollection)
+		if err != nil {
+			return err
+		}
+		defer comRelease(collection)
+		for i := range items {
+			link, err := newJumpListLink(executable, &items[i])
+			if err != nil {
+				return err
+			}
+			hr, _, _ := syscall.Syscall((*collection)[objectCollectionAddObjectOffset], 2, uintptr(unsafe.Pointer(collection)), uintptr(unsafe.Pointer(link)), 0)
+			comRelease(link)
+			if hresultToError(hr) != nil {
+				return hresultToError(hr)
+			}
+		}
+		if hr, _, _ := syscall.Syscall((*destinationList)[destinationListAppendCategoryOffset], 3, uintptr(unsafe.Pointer(destinationList)), uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(category))), uintptr(unsafe.Pointer(collection))); hresultToError(hr) != nil {
+			// This fails when the user has disabled tracking of recent items, in which case there is nothing to show.
+			return hresultToError(hr)
+		}
+	}
+
+	if hr, _, _ := syscall.Syscall((*destinationList)[destinationListCommitListOffset], 1, uintptr(unsafe.Pointer(destinationList)), 0, 0); hresultToError(hr) != nil {
+		return hresultToError(hr)
+	}
+	committed = true
+	return nil
+}
+
+// updateJumpList replaces the taskbar jump list with tasks to activate or deactivate the most recently used tunnels.
+func updateJumpList(mtw *ManageTunnelsWindow) {
+	go func() {
+		tunnels, err := manager.IPCClientTunnels()
+		if err != nil {
+			return
+		}
+		lastUsed, err := manager.IPCClientLastUsed()
+		if err != nil {
+			return
+		}
+		options, err := manager.IPCClientAllTunnelOptions()
+		if err != nil {
+			return
+		}
+		sort.SliceStable(tunnels, func(i, j int) bool {
+			if a, b := options[tunnels[i].Name].Favorite, options[tunnels[j].Name].Favorite; a != b {
+				return a
+			}
+			return lastUsed[tunnels[i].Name].After(lastUsed[tunnels[j].Name])
+		})
+		var items []jumpListItem
+		for i := range tunnels {
+			if len(items) == maxJumpListTunnels || (lastUsed[tunnels[i].Name].IsZero() && !options[tunnels[i].Name].Favorite) {
+				break
+			}
+			state, err := tunnels[i].State()
+			if err != nil {
+				continue
+			}
+			if state == manager.TunnelStarted || state == manager.TunnelStarting {
+				items = append(items, jumpListItem{l18n.Sprintf("Deactivate ‘%s’", tunnels[i].Name), "/deactivatetunnel " + tunnels[i].Name})
+			} else {
+				items = append(items, jumpListItem{l18n.Sprintf("Activate ‘%s’", tunnels[i].Name), "/activatetunnel " + tunnels[i].Name})
+			}
+		}
+		mtw.Synchronize(func() {
+			commitJumpList(l18n.Sprintf("Recent Tunnels"), items)
+		})
+	}()
+}
+
+// onTunnelCommand carries out a command of a jump list task, which the manager passes on from the unelevated process
+// that the task runs, once the user agrees to it, since any other program of the user could have sent it as well.
+func (mtw *ManageTunnelsWindow) onTunnelCommand(tunnel *manager.Tunnel, activate bool) {
+	proceed := make(chan bool)
+	mtw.Synchronize(func() {
+		var owner walk.Form
+		if mtw.Visible() {
+			owner = mtw
+		}
+		var title, text string
+		if activate {
+			title, text = l18n.Sprintf("Activate tunnel"), l18n.Sprintf("Do you want to activate the tunnel ‘%s’?", tunnel.Name)
+		} else {
+			title, text = l18n.Sprintf("Deactivate tunnel"), l18n.Sprintf("Do you want to deactivate the tunnel ‘%s’?", tunnel.Name)
+		}
+		proceed <- walk.MsgBox(owner, title, text, walk.MsgBoxYesNo|walk.MsgBoxIconQuestion|walk.MsgBoxSetForeground) == walk.DlgCmdYes
+	})
+	if !<-proceed {
+		return
+	}
+	var err error
+	if activate {
+		if !confirmActivation(mtw, tunnel) {
+			return
+		}
+		err = tunnel.Start()
+	} else {
+		if !confirmDeactivation(mtw, tunnel) {
+			return
+		}
+		err = tunnel.Stop()
+	}
+	if err != nil {
+		mtw.Synchronize(func() {
+			if activate {
+				showErrorCustom(nil, l18n.Sprintf("Failed to activate tunnel"), err.Error())
+			} else {
+				showErrorCustom(nil, l18n.Sprintf("Failed to deactivate tunnel"), err.Error())
+			}
+		})
+	}
+}
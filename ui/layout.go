@@ -0,0 +1,162 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ui
+
+import (
+	"unsafe"
+
+	"github.com/lxn/win"
+	"golang.org/x/sys/windows/registry"
+)
+
+// The layout is a matter of personal taste rather than of policy, so unlike the settings held by the manager, it is
+// kept per user.
+const layoutRegistryKey = "Software\\WireGuard\\Layout"
+
+type windowLayout struct {
+	bounds    win.RECT // The normal position, in workspace coordinates as used by GetWindowPlacement
+	maximized bool
+	tab       int
+	detailTab int
+	listWidth int // The width of the tunnel list, where the splitter was left, in 1/96" units
+	tunnel    string
+}
+
+func loadWindowLayout() (*windowLayout, error) {
+	key, err := registry.OpenKey(registry.CURRENT_USER, layoutRegistryKey, registry.QUERY_VALUE)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Close()
+	integer := func(name string) int32 {
+		value, _, err := key.GetIntegerValue(name)
+		if err != nil {
+			return 0
+		}
+		return int32(value)
+	}
+	layout := &windowLayout{
+		bounds:    win.RECT{Left: integer("Left"), Top: integer("Top"), Right: integer("Right"), Bottom: integer("Bottom")},
+		maximized: integer("Maximized") != 0,
+		tab:       int(integer("Tab")),
+		detailTab: int(integer("DetailTab")),
+		listWidth: int(integer("ListWidth")),
+	}
+	layout.tunnel, _, _ = key.GetStringValue("Tunnel")
+	return layout, nil
+}
+
+func (layout *windowLayout) save() error {
+	key, _, err := registry.CreateKey(registry.CURRENT_USER, layoutRegistryKey, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+	maximized := int32(0)
+	if layout.maximized {
+		maximized = 1
+	}
+	for _, value := range []struct {
+		name  string
+		value int32
+	}{
+		{"Left", layout.bounds.Left},
+		{"Top", layout.bounds.Top},
+		{"Right", layout.bounds.Right},
+		{"Bottom", layout.bounds.Bottom},
+		{"Maximized", maximized},
+		{"Tab", int32(layout.tab)},
+		{"DetailTab", int32(layout.detailTab)},
+		{"ListWidth", int32(layout.listWidth)},
+	} {
+		err = key.SetDWordValue(value.name, uint32(value.value))
+		if err != nil {
+			return err
+		}
+	}
+	return key.SetStringValue("Tunnel", layout.tunnel)
+}
+
+// loadSortOrder returns the order in which the user last chose to have the tunnels listed, which is saved as soon as
+// it is chosen rather than with the rest of the layout.
+func loadSortOrder() tunnelSortOrder {
+	key, err := registry.OpenKey(registry.CURRENT_USER, layoutRegistryKey, registry.QUERY_VALUE)
+	if err != nil {
+		return sortByName
+	}
+	defer key.Close()
+	value, _, err := key.GetIntegerValue("SortOrder")
+	if err != nil || value > uint64(sortByLastUsed) {
+		return sortByName
+	}
+	return tunnelSortOrder(value)
+}
+
+func saveSortOrder(order tunnelSortOrder) error {
+	key, _, err := registry.CreateKey(registry.CURRENT_USER, layoutRegistryKey, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+	return key.SetDWordValue("SortOrder", uint32(order))
+}
+
+func (mtw *ManageTunnelsWindow) saveLayout() {
+	wp := win.WINDOWPLACEMENT{Length: uint32(unsafe.Sizeof(win.WINDOWPLACEMENT{}))}
+	if !win.GetWindowPlacement(mtw.Handle(), &wp) {
+		return
+	}
+	layout := &windowLayout{
+		bounds:    wp.RcNormalPosition,
+		maximized: win.IsZoomed(mtw.Handle()),
+		tab:       mtw.tabs.CurrentIndex(),
+		detailTab: mtw.tunnelsPage.detailTabs.CurrentIndex(),
+		listWidth: mtw.tunnelsPage.listContainer.Width(),
+	}
+	if layout.tab != 1 {
+		// The update tab comes and goes, so only the log tab is worth returning to.
+		layout.tab = 0
+	}
+	if tunnel := mtw.tunnelsPage.listView.CurrentTunnel(); tunnel != nil {
+		layout.tunnel = tunnel.Name
+	}
+	layout.save()
+}
+
+// restoreLayout applies the layout saved by a previous session, if any, but must be called before the window is
+// first shown.
+func (mtw *ManageTunnelsWindow) restoreLayout() {
+	layout, err := loadWindowLayout()
+	if err != nil {
+		return
+	}
+	if layout.bounds.Right-layout.bounds.Left > 0 && layout.bounds.Bottom-layout.bounds.Top > 0 {
+		previous := win.WINDOWPLACEMENT{Length: uint32(unsafe.Sizeof(win.WINDOWPLACEMENT{}))}
+		win.GetWindowPlacement(mtw.Handle(), &previous)
+		wp := previous
+		wp.ShowCmd = win.SW_HIDE
+		wp.RcNormalPosition = layout.bounds
+		win.SetWindowPlacement(mtw.Handle(), &wp)
+		if win.MonitorFromWindow(mtw.Handle(), win.MONITOR_DEFAULTTONULL) == 0 {
+			// The monitor that the window was last on is gone.
+			win.SetWindowPlacement(mtw.Handle(), &previous)
+		} else {
+			mtw.maximizeOnShow = layout.maximized
+		}
+	}
+	if layout.tab >= 0 && layout.tab < mtw.tabs.Pages().Len() {
+		mtw.tabs.SetCurrentIndex(layout.tab)
+	}
+	if layout.detailTab >= 0 && layout.detailTab < mtw.tunnelsPage.detailTabs.Pages().Len() {
+		mtw.tunnelsPage.detailTabs.SetCurrentIndex(layout.detailTab)
+	}
+	if layout.listWidth > 0 {
+		mtw.tunnelsPage.setListWidth(layout.listWidth)
+	}
+	if len(layout.tunnel) > 0 {
+		mtw.tunnelsPage.listView.selectTunnel(layout.tunnel)
+	}
+}
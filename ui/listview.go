@@ -8,6 +8,7 @@ package ui
 import (
 	"sort"
 	"sync/atomic"
+	"time"
 
 	"github.com/lxn/win"
 
@@ -24,8 +25,30 @@ type ListModel struct {
 
 	tunnels           []manager.Tunnel
 	lastObservedState map[manager.Tunnel]manager.TunnelState
+	lastUsed          map[string]time.Time
+	recent            map[string]bool
+	favorites         map[string]bool
+	sortOrder         tunnelSortOrder
+
+	// The states of tunnels are only fetched once their rows are first painted, in one request for all of the rows
+	// painted since the last one, so that the rows of thousands of tunnels that are never scrolled to cost nothing.
+	pendingStates  map[manager.Tunnel]bool
+	fetchingStates bool
 }
 
+type tunnelSortOrder int
+
+const (
+	sortByName      tunnelSortOrder = iota // Natural order of tunnel names
+	sortRecentFirst                        // A few recently used tunnels, followed by the rest by name
+	sortByLastUsed                         // Most recently used first, never used ones by name at the end
+)
+
+const (
+	maxRecentTunnels = 5
+	recentTunnelAge  = time.Hour * 24 * 30
+)
+
 var cachedListViewIconsForWidthAndState = make(map[widthAndState]*walk.Bitmap)
 
 func (t *ListModel) RowCount() int {
@@ -39,9 +62,49 @@ func (t *ListModel) Value(row, col int) interface{} {
 	return t.tunnels[row].Name
 }
 
+func (t *ListModel) updateRecent() {
+	t.recent = make(map[string]bool, maxRecentTunnels)
+	if t.sortOrder != sortRecentFirst {
+		return
+	}
+	names := make([]string, 0, len(t.tunnels))
+	for i := range t.tunnels {
+		if used, ok := t.lastUsed[t.tunnels[i].Name]; ok && time.Since(used) < recentTunnelAge {
+			names = append(names, t.tunnels[i].Name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return t.lastUsed[names[i]].After(t.lastUsed[names[j]])
+	})
+	if len(names) > maxRecentTunnels {
+		names = names[:maxRecentTunnels]
+	}
+	for _, name := range names {
+		t.recent[name] = true
+	}
+}
+
 func (t *ListModel) Sort(col int, order walk.SortOrder) error {
+	t.updateRecent()
 	sort.SliceStable(t.tunnels, func(i, j int) bool {
-		return conf.TunnelNameIsLess(t.tunnels[i].Name, t.tunnels[j].Name)
+		a, b := t.tunnels[i].Name, t.tunnels[j].Name
+		if t.favorites[a] != t.favorites[b] {
+			return t.favorites[a]
+		}
+		switch t.sortOrder {
+		case sortRecentFirst:
+			if t.recent[a] != t.recent[b] {
+				return t.recent[a]
+			}
+			if t.recent[a] {
+				return t.lastUsed[a].After(t.lastUsed[b])
+			}
+		case sortByLastUsed:
+			if !t.lastUsed[a].Equal(t.lastUsed[b]) {
+				return t.lastUsed[a].After(t.lastUsed[b])
+			}
+		}
+		return conf.TunnelNameIsLess(a, b)
 	})
 
 	return t.SorterBase.Sort(col, order)
@@ -52,6 +115,8 @@ type ListView struct {
 
 	model *ListModel
 
+	sortOrderChanged func(tunnelSortOrder)
+
 	tunnelChangedCB        *manager.TunnelChangeCallback
 	tunnelsChangedCB       *manager.TunnelsChangeCallback
 	tunnelsUpdateSuspended int32
@@ -71,6 +136,10 @@ func NewListView(parent walk.Container) (*ListView, error) {
 
 	model := new(ListModel)
 	model.lastObservedState = make(map[manager.Tunnel]manager.TunnelState)
+	model.lastUsed = make(map[string]time.Time)
+	model.favorites = make(map[string]bool)
+	model.pendingStates = make(map[manager.Tunnel]bool)
+	model.sortOrder = loadSortOrder()
 	tv.SetModel(model)
 	tv.SetLastColumnStretched(true)
 	tv.SetHeaderHidden(true)
@@ -113,7 +182,39 @@ func (tv *ListView) CurrentTunnel() *manager.Tunnel {
 	return &tv.model.tunnels[idx]
 }
 
+// SetSortOrder changes how the tunnels are ordered, keeping the current tunnel selected.
+func (tv *ListView) SetSortOrder(order tunnelSortOrder) {
+	if order != tv.model.sortOrder {
+		tv.model.sortOrder = order
+		tv.resort()
+	}
+	if tv.sortOrderChanged != nil {
+		tv.sortOrderChanged(order)
+	}
+}
+
+func (tv *ListView) IsFavorite(tunnelName string) bool {
+	return tv.model.favorites[tunnelName]
+}
+
+func (tv *ListView) SortOrder() tunnelSortOrder {
+	return tv.model.sortOrder
+}
+
+func (tv *ListView) resort() {
+	var currentName string
+	if current := tv.CurrentTunnel(); current != nil {
+		currentName = current.Name
+	}
+	tv.model.Sort(tv.model.SortedColumn(), tv.model.SortOrder())
+	tv.model.PublishRowsReset()
+	if len(currentName) > 0 {
+		tv.selectTunnel(currentName)
+	}
+}
+
 var dummyBitmap *walk.Bitmap
+var recentTunnelFont *walk.Font
 
 func (tv *ListView) StyleCell(style *walk.CellStyle) {
 	row := style.Row()
@@ -122,16 +223,19 @@ func (tv *ListView) StyleCell(style *walk.CellStyle) {
 	}
 	tunnel := &tv.model.tunnels[row]
 
+	if tv.model.recent[tunnel.Name] {
+		if font := tv.Font(); recentTunnelFont == nil || recentTunnelFont.Family() != font.Family() || recentTunnelFont.PointSize() != font.PointSize() {
+			recentTunnelFont, _ = walk.NewFont(font.Family(), font.PointSize(), walk.FontBold)
+		}
+		style.Font = recentTunnelFont
+	}
+
 	var state manager.TunnelState
 	var ok bool
 	state, ok = tv.model.lastObservedState[tv.model.tunnels[row]]
 	if !ok {
-		var err error
-		state, err = tunnel.State()
-		if err != nil {
-			return
-		}
-		tv.model.lastObservedState[tv.model.tunnels[row]] = state
+		tv.fetchState(*tunnel)
+		return
 	}
 
 	icon, err := iconForState(state, 14)
@@ -181,6 +285,45 @@ func (tv *ListView) StyleCell(style *walk.CellStyle) {
 	}
 }
 
+// fetchState has the state of the tunnel fetched, along with those of any other rows that are painted before the
+// manager answers, and their rows repainted once it does.
+func (tv *ListView) fetchState(tunnel manager.Tunnel) {
+	tv.model.pendingStates[tunnel] = true
+	if tv.model.fetchingStates {
+		return
+	}
+	tv.model.fetchingStates = true
+	go func() {
+		// Yield to the rest of the paint, so that its rows are part of this request.
+		time.Sleep(time.Millisecond * 10)
+		states, err := manager.IPCClientTunnelStates()
+		tv.Synchronize(func() {
+			tv.model.fetchingStates = false
+			pending := tv.model.pendingStates
+			tv.model.pendingStates = make(map[manager.Tunnel]bool)
+			if err != nil {
+				return
+			}
+			for i := range tv.model.tunnels {
+				tunnel := tv.model.tunnels[i]
+				if !pending[tunnel] {
+					continue
+				}
+				if _, ok := tv.model.lastObservedState[tunnel]; ok {
+					continue
+				}
+				// The manager only tracks tunnels that are not stopped.
+				if state, ok := states[tunnel.Name]; ok {
+					tv.model.lastObservedState[tunnel] = state
+				} else {
+					tv.model.lastObservedState[tunnel] = manager.TunnelStopped
+				}
+				tv.model.PublishRowChanged(i)
+			}
+		})
+	}()
+}
+
 func (tv *ListView) onTunnelChange(tunnel *manager.Tunnel, state manager.TunnelState, globalState manager.TunnelState, err error) {
 	tv.Synchronize(func() {
 		idx := -1
@@ -192,7 +335,17 @@ func (tv *ListView) onTunnelChange(tunnel *manager.Tunnel, state manager.TunnelS
 		}
 
 		if idx != -1 {
+			if old, ok := tv.model.lastObservedState[tv.model.tunnels[idx]]; ok && old == state {
+				return
+			}
 			tv.model.lastObservedState[tv.model.tunnels[idx]] = state
+			if state == manager.TunnelStarting {
+				tv.model.lastUsed[tunnel.Name] = time.Now()
+				if tv.model.sortOrder != sortByName {
+					tv.resort()
+					return
+				}
+			}
 			tv.model.PublishRowChanged(idx)
 			return
 		}
@@ -219,25 +372,44 @@ func (tv *ListView) Load(asyncUI bool) {
 	if err != nil {
 		return
 	}
+	lastUsed, _ := manager.IPCClientLastUsed()
+	options, optionsErr := manager.IPCClientAllTunnelOptions()
 	doUI := func() {
+		if lastUsed != nil {
+			tv.model.lastUsed = lastUsed
+		}
+		favoritesChanged := false
+		if optionsErr == nil {
+			favorites := make(map[string]bool, len(options))
+			for name, tunnelOptions := range options {
+				if tunnelOptions.Favorite {
+					favorites[name] = true
+					favoritesChanged = favoritesChanged || !tv.model.favorites[name]
+				}
+			}
+			favoritesChanged = favoritesChanged || len(favorites) != len(tv.model.favorites)
+			tv.model.favorites = favorites
+		}
 		newTunnels := make(map[manager.Tunnel]bool, len(tunnels))
 		oldTunnels := make(map[manager.Tunnel]bool, len(tv.model.tunnels))
 		for _, tunnel := range tunnels {
 			newTunnels[tunnel] = true
 		}
-		for i := len(tv.model.tunnels); i > 0; {
-			i--
-			tunnel := tv.model.tunnels[i]
+		kept := tv.model.tunnels[:0]
+		didRemove := false
+		for _, tunnel := range tv.model.tunnels {
 			oldTunnels[tunnel] = true
-			if !newTunnels[tunnel] {
-				tv.model.tunnels = append(tv.model.tunnels[:i], tv.model.tunnels[i+1:]...)
-				tv.model.PublishRowsRemoved(i, i) // TODO: Do we have to call that everytime or can we pass a range?
+			if newTunnels[tunnel] {
+				kept = append(kept, tunnel)
+			} else {
 				delete(tv.model.lastObservedState, tunnel)
+				didRemove = true
 			}
 		}
+		tv.model.tunnels = kept
 		didAdd := false
 		firstTunnelName := ""
-		for tunnel := range newTunnels {
+		for _, tunnel := range tunnels {
 			if !oldTunnels[tunnel] {
 				if len(firstTunnelName) == 0 || !conf.TunnelNameIsLess(firstTunnelName, tunnel.Name) {
 					firstTunnelName = tunnel.Name
@@ -246,12 +418,19 @@ func (tv *ListView) Load(asyncUI bool) {
 				didAdd = true
 			}
 		}
-		if didAdd {
-			tv.model.PublishRowsReset()
+		if favoritesChanged && !didAdd && !didRemove {
+			tv.resort()
+			return
+		}
+		if didAdd || favoritesChanged {
 			tv.model.Sort(tv.model.SortedColumn(), tv.model.SortOrder())
-			if len(tv.SelectedIndexes()) == 0 {
-				tv.selectTunnel(firstTunnelName)
-			}
+		}
+		if didAdd || didRemove {
+			// A single reset, rather than one notification per row, keeps this cheap with thousands of tunnels.
+			tv.model.PublishRowsReset()
+		}
+		if didAdd && len(tv.SelectedIndexes()) == 0 {
+			tv.selectTunnel(firstTunnelName)
 		}
 	}
 	if asyncUI {
@@ -265,6 +444,7 @@ func (tv *ListView) selectTunnel(tunnelName string) {
 	for i, tunnel := range tv.model.tunnels {
 		if tunnel.Name == tunnelName {
 			tv.SetCurrentIndex(i)
+			tv.EnsureItemVisible(i)
 			break
 		}
 	}
@@ -274,12 +454,12 @@ func (tv *ListView) SelectFirstActiveTunnel() {
 	tunnels := make([]manager.Tunnel, len(tv.model.tunnels))
 	copy(tunnels, tv.model.tunnels)
 	go func() {
+		states, err := manager.IPCClientTunnelStates()
+		if err != nil {
+			return
+		}
 		for _, tunnel := range tunnels {
-			state, err := tunnel.State()
-			if err != nil {
-				continue
-			}
-			if state == manager.TunnelStarting || state == manager.TunnelStarted {
+			if state := states[tunnel.Name]; state == manager.TunnelStarting || state == manager.TunnelStarted {
 				tv.Synchronize(func() {
 					tv.selectTunnel(tunnel.Name)
 				})
@@ -8,11 +8,14 @@ package ui
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/lxn/walk"
 	"golang.zx2c4.com/wireguard/windows/l18n"
+	"golang.zx2c4.com/wireguard/windows/manager"
 	"golang.zx2c4.com/wireguard/windows/ringlogger"
 )
 
@@ -22,8 +25,12 @@ const (
 
 type LogPage struct {
 	*walk.TabPage
-	logView *walk.TableView
-	model   *logModel
+	logView          *walk.TableView
+	stampCol         *walk.TableViewColumn
+	model            *logModel
+	tunnelCombo      *walk.ComboBox
+	tunnelNames      []string // Parallel to the items of tunnelCombo, where the empty name is all tunnels
+	tunnelsChangedCB *manager.TunnelsChangeCallback
 }
 
 func NewLogPage() (*LogPage, error) {
@@ -40,6 +47,10 @@ func NewLogPage() (*LogPage, error) {
 
 	lp.Disposing().Attach(func() {
 		lp.model.quit <- true
+		if lp.tunnelsChangedCB != nil {
+			lp.tunnelsChangedCB.Unregister()
+			lp.tunnelsChangedCB = nil
+		}
 	})
 
 	lp.SetTitle(l18n.Sprintf("Log"))
@@ -48,6 +59,7 @@ func NewLogPage() (*LogPage, error) {
 	if lp.logView, err = walk.NewTableView(lp); err != nil {
 		return nil, err
 	}
+	setAutomationID(lp.logView, automationLogView)
 	lp.logView.SetAlternatingRowBG(true)
 	lp.logView.SetLastColumnStretched(true)
 	lp.logView.SetGridlines(true)
@@ -82,12 +94,12 @@ func NewLogPage() (*LogPage, error) {
 	}
 	lp.logView.SelectedIndexesChanged().Attach(setSelectionStatus)
 
-	stampCol := walk.NewTableViewColumn()
-	stampCol.SetName("Stamp")
-	stampCol.SetTitle(l18n.Sprintf("Time"))
-	stampCol.SetFormat("2006-01-02 15:04:05.000")
-	stampCol.SetWidth(140)
-	lp.logView.Columns().Add(stampCol)
+	lp.stampCol = walk.NewTableViewColumn()
+	lp.stampCol.SetName("Stamp")
+	lp.stampCol.SetTitle(l18n.Sprintf("Time"))
+	lp.stampCol.SetFormat(stampLayout())
+	lp.stampCol.SetWidth(170)
+	lp.logView.Columns().Add(lp.stampCol)
 
 	msgCol := walk.NewTableViewColumn()
 	msgCol.SetName("Line")
@@ -106,6 +118,25 @@ func NewLogPage() (*LogPage, error) {
 	buttonsContainer.SetLayout(walk.NewHBoxLayout())
 	buttonsContainer.Layout().SetMargins(walk.Margins{})
 
+	tunnelLabel, err := walk.NewTextLabel(buttonsContainer)
+	if err != nil {
+		return nil, err
+	}
+	tunnelLabel.SetText(l18n.Sprintf("S&how:"))
+	if lp.tunnelCombo, err = walk.NewDropDownBox(buttonsContainer); err != nil {
+		return nil, err
+	}
+	lp.tunnelCombo.SetToolTipText(l18n.Sprintf("Each tunnel also keeps a log of its own, which the other tunnels cannot crowd out."))
+	lp.refreshTunnels()
+	lp.tunnelCombo.CurrentIndexChanged().Attach(func() {
+		if i := lp.tunnelCombo.CurrentIndex(); i >= 0 && i < len(lp.tunnelNames) {
+			lp.model.show(lp.tunnelNames[i])
+		}
+	})
+	lp.tunnelsChangedCB = manager.IPCClientRegisterTunnelsChange(func() {
+		lp.Synchronize(lp.refreshTunnels)
+	})
+
 	walk.NewHSpacer(buttonsContainer)
 
 	saveButton, err := walk.NewPushButton(buttonsContainer)
@@ -113,6 +144,7 @@ func NewLogPage() (*LogPage, error) {
 		return nil, err
 	}
 	saveButton.SetText(l18n.Sprintf("&Save"))
+	setAutomationID(saveButton, automationLogSave)
 	saveButton.Clicked().Attach(lp.onSave)
 
 	disposables.Spare()
@@ -120,6 +152,33 @@ func NewLogPage() (*LogPage, error) {
 	return lp, nil
 }
 
+func (lp *LogPage) refreshTunnels() {
+	tunnels, err := manager.IPCClientTunnels()
+	if err != nil {
+		return
+	}
+	current := ""
+	if i := lp.tunnelCombo.CurrentIndex(); i >= 0 && i < len(lp.tunnelNames) {
+		current = lp.tunnelNames[i]
+	}
+	names := []string{""}
+	items := []string{l18n.Sprintf("All tunnels")}
+	sort.Slice(tunnels, func(i, j int) bool {
+		return tunnels[i].Name < tunnels[j].Name
+	})
+	selected := 0
+	for _, tunnel := range tunnels {
+		if tunnel.Name == current {
+			selected = len(names)
+		}
+		names = append(names, tunnel.Name)
+		items = append(items, tunnel.Name)
+	}
+	lp.tunnelNames = names
+	lp.tunnelCombo.SetModel(items)
+	lp.tunnelCombo.SetCurrentIndex(selected)
+}
+
 func (lp *LogPage) isAtBottom() bool {
 	return len(lp.model.items) == 0 || lp.logView.ItemVisible(len(lp.model.items)-1)
 }
@@ -128,6 +187,32 @@ func (lp *LogPage) scrollToBottom() {
 	lp.logView.EnsureItemVisible(len(lp.model.items) - 1)
 }
 
+// selectTunnelLines selects the lines logged by the named tunnel shortly before and after the given time, or all of
+// its lines if none were logged then.
+func (lp *LogPage) selectTunnelLines(tunnelName string, around time.Time) {
+	// Lines start with the tag of the service and perhaps of the level, followed by the tunnel name.
+	prefix := fmt.Sprintf("] [%s] ", tunnelName)
+	from, to := around.Add(-time.Minute*2), around.Add(time.Second*10)
+	var all, near []int
+	for i := range lp.model.items {
+		if !strings.Contains(lp.model.items[i].Line, prefix) {
+			continue
+		}
+		all = append(all, i)
+		if stamp := lp.model.items[i].Stamp; !stamp.Before(from) && !stamp.After(to) {
+			near = append(near, i)
+		}
+	}
+	if len(near) == 0 {
+		near = all
+	}
+	if len(near) == 0 {
+		return
+	}
+	lp.logView.SetSelectedIndexes(near)
+	lp.logView.EnsureItemVisible(near[0])
+}
+
 func (lp *LogPage) onCopy() {
 	var logLines strings.Builder
 	selectedItemIndexes := lp.logView.SelectedIndexes()
@@ -136,18 +221,42 @@ func (lp *LogPage) onCopy() {
 	}
 	for i := 0; i < len(selectedItemIndexes); i++ {
 		logItem := lp.model.items[selectedItemIndexes[i]]
-		logLines.WriteString(fmt.Sprintf("%s: %s\r\n", logItem.Stamp.Format("2006-01-02 15:04:05.000"), logItem.Line))
+		logLines.WriteString(fmt.Sprintf("%s: %s\r\n", logItem.Stamp.Format(stampLayout()), logItem.Line))
 	}
 	walk.Clipboard().SetText(logLines.String())
 }
 
+// stampFormat returns how the preferences say that timestamps should be shown and exported.
+func stampFormat() ringlogger.StampFormat {
+	return ringlogger.StampFormat{UTC: currentSettings.LogTimesUTC, ISO8601: currentSettings.LogTimesISO8601}
+}
+
+// stampLayout is like the layout of stampFormat, but with milliseconds rather than microseconds by default, which is
+// as much as fits in the column.
+func stampLayout() string {
+	if format := stampFormat(); format.ISO8601 {
+		return format.Layout()
+	}
+	return "2006-01-02 15:04:05.000"
+}
+
+// applyStampFormat shows the timestamps as the preferences now say.
+func (lp *LogPage) applyStampFormat() {
+	format := stampFormat()
+	lp.stampCol.SetFormat(stampLayout())
+	for i := range lp.model.items {
+		lp.model.items[i].Stamp = format.In(lp.model.items[i].Stamp)
+	}
+	lp.model.PublishRowsReset()
+}
+
 func (lp *LogPage) onSelectAll() {
 	lp.logView.SetSelectedIndexes([]int{-1})
 }
 
 func (lp *LogPage) onSave() {
 	fd := walk.FileDialog{
-		Filter:   l18n.Sprintf("Text Files (*.txt)|*.txt|All Files (*.*)|*.*"),
+		Filter:   l18n.Sprintf("Text Files (*.txt)|*.txt|JSON Lines Files (*.jsonl)|*.jsonl|All Files (*.*)|*.*"),
 		FilePath: fmt.Sprintf("wireguard-log-%s.txt", time.Now().Format("2006-01-02T150405")),
 		Title:    l18n.Sprintf("Export log to file"),
 	}
@@ -160,22 +269,48 @@ func (lp *LogPage) onSave() {
 
 	if fd.FilterIndex == 1 && !strings.HasSuffix(fd.FilePath, ".txt") {
 		fd.FilePath = fd.FilePath + ".txt"
+	} else if fd.FilterIndex == 2 && !strings.HasSuffix(fd.FilePath, ".jsonl") {
+		fd.FilePath = strings.TrimSuffix(fd.FilePath, ".txt") + ".jsonl"
 	}
 
 	writeFileWithOverwriteHandling(form, fd.FilePath, func(file *os.File) error {
-		if _, err := ringlogger.Global.WriteTo(file); err != nil {
-			return fmt.Errorf("exportLog: Ringlogger.WriteTo failed: %w", err)
+		lines, _, err := readLog(lp.model.tunnel, ringlogger.CursorAll)
+		if err != nil {
+			return fmt.Errorf("exportLog: readLog failed: %w", err)
+		}
+		if strings.HasSuffix(fd.FilePath, ".jsonl") {
+			if _, err := ringlogger.WriteLinesJSONTo(file, lines, stampFormat()); err != nil {
+				return fmt.Errorf("exportLog: ringlogger.WriteLinesJSONTo failed: %w", err)
+			}
+			return nil
+		}
+		if _, err := ringlogger.WriteLinesTo(file, lines, stampFormat()); err != nil {
+			return fmt.Errorf("exportLog: ringlogger.WriteLinesTo failed: %w", err)
 		}
 
 		return nil
 	})
 }
 
+// readLog reads the log of the tunnel, or the shared log if tunnel is empty, which is mapped already unless this UI is
+// for another machine.
+func readLog(tunnel string, cursor uint32) ([]ringlogger.FollowLine, uint32, error) {
+	if len(tunnel) == 0 && ringlogger.Global != nil {
+		lines, nextCursor, _ := ringlogger.Global.ReadFromCursor(cursor)
+		return lines, nextCursor, nil
+	}
+	return manager.IPCClientTunnelLog(tunnel, cursor)
+}
+
 type logModel struct {
 	walk.ReflectTableModelBase
-	lp    *LogPage
-	quit  chan bool
-	items []ringlogger.FollowLine
+	lp     *LogPage
+	quit   chan bool
+	items  []ringlogger.FollowLine
+	tunnel string // The tunnel whose log is shown, or empty for the shared log
+
+	wantedLock sync.Mutex
+	wanted     string // What the follower should switch to, as tunnel is only for the UI thread
 }
 
 func newLogModel(lp *LogPage) *logModel {
@@ -183,18 +318,36 @@ func newLogModel(lp *LogPage) *logModel {
 	go func() {
 		ticker := time.NewTicker(time.Second)
 		cursor := ringlogger.CursorAll
+		following := ""
 
 		for {
 			select {
 			case <-ticker.C:
-				var items []ringlogger.FollowLine
-				items, cursor = ringlogger.Global.FollowFromCursor(cursor)
+				mdl.wantedLock.Lock()
+				if mdl.wanted != following {
+					following = mdl.wanted
+					cursor = ringlogger.CursorAll
+				}
+				mdl.wantedLock.Unlock()
+				items, nextCursor, err := readLog(following, cursor)
+				if err != nil {
+					continue
+				}
+				cursor = nextCursor
 				if len(items) == 0 {
 					continue
 				}
+				tunnel := following
 				mdl.lp.Synchronize(func() {
+					if mdl.tunnel != tunnel {
+						return
+					}
 					isAtBottom := mdl.lp.isAtBottom() && len(lp.logView.SelectedIndexes()) <= 1
 
+					format := stampFormat()
+					for i := range items {
+						items[i].Stamp = format.In(items[i].Stamp)
+					}
 					mdl.items = append(mdl.items, items...)
 					if len(mdl.items) > maxLogLinesDisplayed {
 						mdl.items = mdl.items[len(mdl.items)-maxLogLinesDisplayed:]
@@ -216,6 +369,19 @@ func newLogModel(lp *LogPage) *logModel {
 	return mdl
 }
 
+// show switches to the log of the tunnel, or to the shared log if the name is empty, starting from its oldest line.
+func (mdl *logModel) show(tunnelName string) {
+	if tunnelName == mdl.tunnel {
+		return
+	}
+	mdl.tunnel = tunnelName
+	mdl.items = nil
+	mdl.PublishRowsReset()
+	mdl.wantedLock.Lock()
+	mdl.wanted = tunnelName
+	mdl.wantedLock.Unlock()
+}
+
 func (mdl *logModel) Items() interface{} {
 	return mdl.items
 }
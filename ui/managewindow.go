@@ -26,18 +26,32 @@ type ManageTunnelsWindow struct {
 	updatePage  *UpdatePage
 
 	tunnelChangedCB *manager.TunnelChangeCallback
+
+	themeChangedPublisher walk.EventPublisher
+	maximizeOnShow        bool
+	erroredTunnels        map[string]bool // Tunnels whose last change failed, which the taskbar button warns about
 }
 
 const (
 	manageWindowWindowClass = "WireGuard UI - Manage Tunnels"
 	raiseMsg                = win.WM_USER + 0x3510
 	aboutWireGuardCmd       = 0x37
+	preferencesCmd          = 0x38
 )
 
 var taskbarButtonCreatedMsg uint32
 
 var initedManageTunnels sync.Once
 
+// scaledFont returns the UI font at the given point size, adjusted by the text scale preference.
+func scaledFont(pointSize int) (*walk.Font, error) {
+	scale := currentSettings.TextScale
+	if scale <= 0 {
+		scale = 100
+	}
+	return walk.NewFont("Segoe UI", (pointSize*scale+50)/100, 0)
+}
+
 func NewManageTunnelsWindow() (*ManageTunnelsWindow, error) {
 	initedManageTunnels.Do(func() {
 		walk.AppendToWalkInit(func() {
@@ -50,12 +64,12 @@ func NewManageTunnelsWindow() (*ManageTunnelsWindow, error) {
 	var disposables walk.Disposables
 	defer disposables.Treat()
 
-	font, err := walk.NewFont("Segoe UI", 9, 0)
+	font, err := scaledFont(9)
 	if err != nil {
 		return nil, err
 	}
 
-	mtw := new(ManageTunnelsWindow)
+	mtw := &ManageTunnelsWindow{erroredTunnels: make(map[string]bool)}
 	mtw.SetName("WireGuard")
 
 	err = walk.InitWindow(mtw, nil, manageWindowWindowClass, win.WS_OVERLAPPEDWINDOW, win.WS_EX_CONTROLPARENT)
@@ -65,6 +79,7 @@ func NewManageTunnelsWindow() (*ManageTunnelsWindow, error) {
 	disposables.Add(mtw)
 	win.ChangeWindowMessageFilterEx(mtw.Handle(), raiseMsg, win.MSGFLT_ALLOW, nil)
 	mtw.SetPersistent(true)
+	applyModernWindowStyle(mtw.Handle(), false)
 
 	if icon, err := loadLogoIcon(32); err == nil {
 		mtw.SetIcon(icon)
@@ -77,6 +92,10 @@ func NewManageTunnelsWindow() (*ManageTunnelsWindow, error) {
 	vlayout.SetMargins(walk.Margins{5, 5, 5, 5})
 	mtw.SetLayout(vlayout)
 	mtw.Closing().Attach(func(canceled *bool, reason walk.CloseReason) {
+		mtw.saveLayout()
+		if len(RemoteMachine) > 0 {
+			return
+		}
 		// "Close to tray" instead of exiting application
 		*canceled = true
 		if !noTrayAvailable {
@@ -87,6 +106,12 @@ func NewManageTunnelsWindow() (*ManageTunnelsWindow, error) {
 	})
 	mtw.VisibleChanged().Attach(func() {
 		if mtw.Visible() {
+			if mtw.maximizeOnShow {
+				mtw.maximizeOnShow = false
+				win.ShowWindow(mtw.Handle(), win.SW_MAXIMIZE)
+			}
+			// Once laid out at its restored width, the list may be resized again.
+			mtw.Synchronize(mtw.tunnelsPage.releaseListWidth)
 			mtw.tunnelsPage.updateConfView()
 			win.SetForegroundWindow(mtw.Handle())
 			win.BringWindowToTop(mtw.Handle())
@@ -97,6 +122,7 @@ func NewManageTunnelsWindow() (*ManageTunnelsWindow, error) {
 	if mtw.tabs, err = walk.NewTabWidget(mtw); err != nil {
 		return nil, err
 	}
+	setAutomationID(mtw.tabs, automationTabs)
 
 	if mtw.tunnelsPage, err = NewTunnelsPage(); err != nil {
 		return nil, err
@@ -109,6 +135,11 @@ func NewManageTunnelsWindow() (*ManageTunnelsWindow, error) {
 	}
 	mtw.tabs.Pages().Add(mtw.logPage.TabPage)
 
+	commandPaletteAction := walk.NewAction()
+	commandPaletteAction.SetShortcut(walk.Shortcut{walk.ModControl | walk.ModShift, walk.KeyP})
+	commandPaletteAction.Triggered().Attach(mtw.onCommandPalette)
+	mtw.ShortcutActions().Add(commandPaletteAction)
+
 	mtw.tunnelChangedCB = manager.IPCClientRegisterTunnelChange(mtw.onTunnelChange)
 	globalState, _ := manager.IPCClientGlobalState()
 	mtw.onTunnelChange(nil, manager.TunnelUnknown, globalState, nil)
@@ -122,7 +153,18 @@ func NewManageTunnelsWindow() (*ManageTunnelsWindow, error) {
 			DwTypeData: windows.StringToUTF16Ptr(l18n.Sprintf("&About WireGuard…")),
 			WID:        uint32(aboutWireGuardCmd),
 		})
-		win.InsertMenuItem(systemMenu, 1, true, &win.MENUITEMINFO{
+		separatorPosition := uint32(1)
+		if IsAdmin {
+			win.InsertMenuItem(systemMenu, 1, true, &win.MENUITEMINFO{
+				CbSize:     uint32(unsafe.Sizeof(win.MENUITEMINFO{})),
+				FMask:      win.MIIM_ID | win.MIIM_STRING | win.MIIM_FTYPE,
+				FType:      win.MIIM_STRING,
+				DwTypeData: windows.StringToUTF16Ptr(l18n.Sprintf("&Preferences…")),
+				WID:        uint32(preferencesCmd),
+			})
+			separatorPosition++
+		}
+		win.InsertMenuItem(systemMenu, separatorPosition, true, &win.MENUITEMINFO{
 			CbSize: uint32(unsafe.Sizeof(win.MENUITEMINFO{})),
 			FMask:  win.MIIM_TYPE,
 			FType:  win.MFT_SEPARATOR,
@@ -134,6 +176,19 @@ func NewManageTunnelsWindow() (*ManageTunnelsWindow, error) {
 	return mtw, nil
 }
 
+// ThemeChanged is published when the user switches between light and dark themes.
+func (mtw *ManageTunnelsWindow) ThemeChanged() *walk.Event {
+	return mtw.themeChangedPublisher.Event()
+}
+
+func (mtw *ManageTunnelsWindow) applyTextScale() {
+	font, err := scaledFont(9)
+	if err != nil {
+		return
+	}
+	mtw.SetFont(font)
+}
+
 func (mtw *ManageTunnelsWindow) Dispose() {
 	if mtw.tunnelChangedCB != nil {
 		mtw.tunnelChangedCB.Unregister()
@@ -153,6 +208,10 @@ func (mtw *ManageTunnelsWindow) updateProgressIndicator(globalState manager.Tunn
 	default:
 		pi.SetState(walk.PINoProgress)
 	}
+	if len(mtw.erroredTunnels) > 0 {
+		pi.SetOverlayIcon(walk.IconWarning(), l18n.Sprintf("Tunnel error"))
+		return
+	}
 	if icon, err := iconForState(globalState, 16); err == nil {
 		if globalState == manager.TunnelStopped {
 			icon = nil
@@ -161,8 +220,42 @@ func (mtw *ManageTunnelsWindow) updateProgressIndicator(globalState manager.Tunn
 	}
 }
 
+// pruneErroredTunnels forgets failures of tunnels that have since been deleted. It must not be called from the UI thread.
+func (mtw *ManageTunnelsWindow) pruneErroredTunnels() {
+	tunnels, err := manager.IPCClientTunnels()
+	if err != nil {
+		return
+	}
+	globalState, err := manager.IPCClientGlobalState()
+	if err != nil {
+		return
+	}
+	mtw.Synchronize(func() {
+		if len(mtw.erroredTunnels) == 0 {
+			return
+		}
+		existing := make(map[string]bool, len(tunnels))
+		for i := range tunnels {
+			existing[tunnels[i].Name] = true
+		}
+		for name := range mtw.erroredTunnels {
+			if !existing[name] {
+				delete(mtw.erroredTunnels, name)
+			}
+		}
+		mtw.updateProgressIndicator(globalState)
+	})
+}
+
 func (mtw *ManageTunnelsWindow) onTunnelChange(tunnel *manager.Tunnel, state manager.TunnelState, globalState manager.TunnelState, err error) {
 	mtw.Synchronize(func() {
+		if tunnel != nil {
+			if err != nil {
+				mtw.erroredTunnels[tunnel.Name] = true
+			} else {
+				delete(mtw.erroredTunnels, tunnel.Name)
+			}
+		}
 		mtw.updateProgressIndicator(globalState)
 
 		if err != nil && mtw.Visible() {
@@ -201,6 +294,13 @@ func (mtw *ManageTunnelsWindow) WndProc(hwnd win.HWND, msg uint32, wParam, lPara
 		if wParam == aboutWireGuardCmd {
 			onAbout(mtw)
 			return 0
+		} else if wParam == preferencesCmd {
+			onPreferences(mtw)
+			return 0
+		}
+	case win.WM_SETTINGCHANGE:
+		if lParam != 0 && windows.UTF16PtrToString(*(**uint16)(unsafe.Pointer(&lParam))) == "ImmersiveColorSet" {
+			mtw.themeChangedPublisher.Publish()
 		}
 	case raiseMsg:
 		if mtw.tunnelsPage == nil || mtw.tabs == nil {
@@ -0,0 +1,250 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ui
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lxn/walk"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/l18n"
+	"golang.zx2c4.com/wireguard/windows/manager"
+)
+
+const (
+	onboardingRegistryKey   = "Software\\WireGuard"
+	onboardingRegistryValue = "OnboardingComplete"
+	maxFoundConfigs         = 12
+)
+
+func onboardingComplete() bool {
+	key, err := registry.OpenKey(registry.CURRENT_USER, onboardingRegistryKey, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	defer key.Close()
+	value, _, err := key.GetIntegerValue(onboardingRegistryValue)
+	return err == nil && value != 0
+}
+
+func setOnboardingComplete() {
+	key, _, err := registry.CreateKey(registry.CURRENT_USER, onboardingRegistryKey, registry.SET_VALUE)
+	if err != nil {
+		return
+	}
+	defer key.Close()
+	key.SetDWordValue(onboardingRegistryValue, 1)
+}
+
+// onboardingNeeded reports whether this is the first launch for this user on a machine without any tunnels.
+func onboardingNeeded() bool {
+	if onboardingComplete() {
+		return false
+	}
+	tunnels, err := manager.IPCClientTunnels()
+	if err != nil {
+		return false
+	}
+	if len(tunnels) > 0 {
+		setOnboardingComplete()
+		return false
+	}
+	return true
+}
+
+// findExistingConfigs looks for wg-quick files in the places where users tend to leave them, returning only those that
+// would import cleanly.
+func findExistingConfigs() []string {
+	var dirs []string
+	for _, folder := range []*windows.KNOWNFOLDERID{windows.FOLDERID_Downloads, windows.FOLDERID_Desktop, windows.FOLDERID_Documents} {
+		if dir, err := windows.KnownFolderPath(folder, windows.KF_FLAG_DEFAULT); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	if profile, err := windows.KnownFolderPath(windows.FOLDERID_Profile, windows.KF_FLAG_DEFAULT); err == nil {
+		dirs = append(dirs, filepath.Join(profile, "wireguard"), filepath.Join(profile, ".wireguard"))
+	}
+
+	var found []string
+	for _, dir := range dirs {
+		paths, _ := filepath.Glob(filepath.Join(dir, "*.conf"))
+		for _, path := range paths {
+			if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() || info.Size() > 1024*64 {
+				continue
+			}
+			name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			if !conf.TunnelNameIsValid(name) {
+				continue
+			}
+			bytes, err := ioutil.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			if _, err = conf.FromWgQuickWithUnknownEncoding(string(bytes), name); err != nil {
+				continue
+			}
+			found = append(found, path)
+			if len(found) == maxFoundConfigs {
+				return found
+			}
+		}
+	}
+	return found
+}
+
+func (mtw *ManageTunnelsWindow) maybeRunOnboarding() {
+	go func() {
+		if !onboardingNeeded() {
+			return
+		}
+		var found []string
+		if IsAdmin {
+			found = findExistingConfigs()
+		}
+		mtw.Synchronize(func() {
+			raise(mtw.Handle())
+			showError(runOnboardingDialog(mtw, found), mtw)
+			setOnboardingComplete()
+		})
+	}()
+}
+
+func runOnboardingDialog(mtw *ManageTunnelsWindow, found []string) error {
+	var err error
+	var disposables walk.Disposables
+	defer disposables.Treat()
+
+	dlg, err := walk.NewDialogWithFixedSize(mtw)
+	if err != nil {
+		return err
+	}
+	disposables.Add(dlg)
+	applyModernWindowStyle(dlg.Handle(), false)
+	dlg.SetTitle(l18n.Sprintf("Welcome to WireGuard"))
+	if icon, err := loadLogoIcon(32); err == nil {
+		dlg.SetIcon(icon)
+	}
+	vlayout := walk.NewVBoxLayout()
+	vlayout.SetMargins(walk.Margins{10, 10, 10, 10})
+	vlayout.SetSpacing(6)
+	dlg.SetLayout(vlayout)
+	if font, err := scaledFont(9); err == nil {
+		dlg.SetFont(font)
+	}
+
+	trayLabel, err := walk.NewTextLabel(dlg)
+	if err != nil {
+		return err
+	}
+	trayLabel.SetText(l18n.Sprintf("WireGuard lives in the notification area of the taskbar. Closing this window does not deactivate any tunnels: click the WireGuard icon there to open it again, or right-click it to activate and deactivate tunnels or to exit."))
+	trayLabel.SetMinMaxSize(walk.Size{400, 0}, walk.Size{400, 0})
+
+	var next func()
+
+	var importCBs []*walk.CheckBox
+	if len(found) > 0 {
+		group, err := walk.NewGroupBox(dlg)
+		if err != nil {
+			return err
+		}
+		group.SetTitle(l18n.Sprintf("Import existing tunnels"))
+		group.SetLayout(walk.NewVBoxLayout())
+		foundLabel, err := walk.NewTextLabel(group)
+		if err != nil {
+			return err
+		}
+		foundLabel.SetText(l18n.Sprintf("These configuration files were found on this computer:"))
+		for _, path := range found {
+			cb, err := walk.NewCheckBox(group)
+			if err != nil {
+				return err
+			}
+			cb.SetText(strings.ReplaceAll(path, "&", "&&"))
+			cb.SetChecked(true)
+			importCBs = append(importCBs, cb)
+		}
+		importButton, err := walk.NewPushButton(group)
+		if err != nil {
+			return err
+		}
+		importButton.SetText(l18n.Sprintf("&Import selected"))
+		setAutomationID(importButton, automationOnboardingImport)
+		importButton.Clicked().Attach(func() {
+			var paths []string
+			for i, cb := range importCBs {
+				if cb.Checked() {
+					paths = append(paths, found[i])
+				}
+			}
+			if len(paths) == 0 {
+				return
+			}
+			next = func() {
+				mtw.tunnelsPage.importFiles(paths)
+			}
+			dlg.Accept()
+		})
+	}
+
+	if IsAdmin {
+		group, err := walk.NewGroupBox(dlg)
+		if err != nil {
+			return err
+		}
+		group.SetTitle(l18n.Sprintf("Create your first tunnel"))
+		group.SetLayout(walk.NewVBoxLayout())
+		createLabel, err := walk.NewTextLabel(group)
+		if err != nil {
+			return err
+		}
+		createLabel.SetText(l18n.Sprintf("A new tunnel comes with a freshly generated key pair. Give its public key to the administrator of your peer, then fill in the [Peer] section with the public key, endpoint, and allowed IPs they give you in return."))
+		createLabel.SetMinMaxSize(walk.Size{380, 0}, walk.Size{380, 0})
+		createButton, err := walk.NewPushButton(group)
+		if err != nil {
+			return err
+		}
+		createButton.SetText(l18n.Sprintf("&Create tunnel…"))
+		setAutomationID(createButton, automationOnboardingCreate)
+		createButton.Clicked().Attach(func() {
+			next = mtw.tunnelsPage.onAddTunnel
+			dlg.Accept()
+		})
+	}
+
+	buttonsContainer, err := walk.NewComposite(dlg)
+	if err != nil {
+		return err
+	}
+	buttonsContainer.SetLayout(walk.NewHBoxLayout())
+	buttonsContainer.Layout().SetMargins(walk.Margins{})
+	walk.NewHSpacer(buttonsContainer)
+
+	closeButton, err := walk.NewPushButton(buttonsContainer)
+	if err != nil {
+		return err
+	}
+	closeButton.SetText(l18n.Sprintf("Close"))
+	setAutomationID(closeButton, automationOnboardingClose)
+	closeButton.Clicked().Attach(dlg.Cancel)
+
+	dlg.SetCancelButton(closeButton)
+	dlg.SetDefaultButton(closeButton)
+
+	disposables.Spare()
+
+	dlg.Run()
+
+	if next != nil {
+		next()
+	}
+	return nil
+}
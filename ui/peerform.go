@@ -0,0 +1,340 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ui
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/lxn/walk"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/l18n"
+)
+
+var invalidFieldColor = walk.RGB(0xd0, 0x00, 0x00)
+
+type peerFormView struct {
+	group           *walk.GroupBox
+	publicKey       *walk.LineEdit
+	endpoint        *walk.LineEdit
+	allowedIPs      *walk.ListBox
+	allowedIPEdit   *walk.LineEdit
+	addAllowedIP    *walk.PushButton
+	removeAllowedIP *walk.PushButton
+	keepalive       *walk.NumberEdit
+	removePeer      *walk.PushButton
+	allowedIPList   []string
+	peer            conf.Peer
+}
+
+type PeerForm struct {
+	*walk.ScrollView
+	peers   []*peerFormView
+	addPeer *walk.PushButton
+}
+
+func NewPeerForm(parent walk.Container) (*PeerForm, error) {
+	var err error
+	var disposables walk.Disposables
+	defer disposables.Treat()
+
+	pf := new(PeerForm)
+	if pf.ScrollView, err = walk.NewScrollView(parent); err != nil {
+		return nil, err
+	}
+	disposables.Add(pf)
+	vlayout := walk.NewVBoxLayout()
+	vlayout.SetMargins(walk.Margins{0, 0, 5, 0})
+	pf.SetLayout(vlayout)
+
+	toolbarContainer, err := walk.NewComposite(pf)
+	if err != nil {
+		return nil, err
+	}
+	toolbarContainer.SetLayout(walk.NewHBoxLayout())
+	toolbarContainer.Layout().SetMargins(walk.Margins{})
+	if pf.addPeer, err = walk.NewPushButton(toolbarContainer); err != nil {
+		return nil, err
+	}
+	pf.addPeer.SetText(l18n.Sprintf("&Add peer"))
+	pf.addPeer.Clicked().Attach(func() {
+		pf.appendPeer(&conf.Peer{})
+	})
+	walk.NewHSpacer(toolbarContainer)
+
+	disposables.Spare()
+
+	return pf, nil
+}
+
+func validateLineEdit(le *walk.LineEdit, valid func(string) bool) {
+	if valid(strings.TrimSpace(le.Text())) {
+		le.SetTextColor(walk.Color(0))
+	} else {
+		le.SetTextColor(invalidFieldColor)
+	}
+}
+
+func newPeerFormView(parent walk.Container, peer *conf.Peer) (*peerFormView, error) {
+	var err error
+	var disposables walk.Disposables
+	defer disposables.Treat()
+
+	pfv := &peerFormView{peer: *peer}
+
+	if pfv.group, err = walk.NewGroupBox(parent); err != nil {
+		return nil, err
+	}
+	disposables.Add(pfv.group)
+	pfv.group.SetTitle(l18n.Sprintf("Peer"))
+	layout := walk.NewGridLayout()
+	layout.SetMargins(walk.Margins{10, 5, 10, 5})
+	layout.SetSpacing(6)
+	layout.SetColumnStretchFactor(1, 3)
+	pfv.group.SetLayout(layout)
+
+	newLabel := func(text string, row int) error {
+		label, err := walk.NewTextLabel(pfv.group)
+		if err != nil {
+			return err
+		}
+		label.SetText(text)
+		label.SetTextAlignment(walk.AlignHFarVCenter)
+		layout.SetRange(label, walk.Rectangle{0, row, 1, 1})
+		return nil
+	}
+
+	if err = newLabel(l18n.Sprintf("Public key:"), 0); err != nil {
+		return nil, err
+	}
+	if pfv.publicKey, err = walk.NewLineEdit(pfv.group); err != nil {
+		return nil, err
+	}
+	layout.SetRange(pfv.publicKey, walk.Rectangle{1, 0, 1, 1})
+	if !peer.PublicKey.IsZero() {
+		pfv.publicKey.SetText(peer.PublicKey.String())
+	}
+	pfv.publicKey.TextChanged().Attach(func() {
+		validateLineEdit(pfv.publicKey, func(s string) bool {
+			_, err := conf.NewPrivateKeyFromString(s)
+			return err == nil
+		})
+	})
+
+	if err = newLabel(l18n.Sprintf("Endpoint:"), 1); err != nil {
+		return nil, err
+	}
+	if pfv.endpoint, err = walk.NewLineEdit(pfv.group); err != nil {
+		return nil, err
+	}
+	layout.SetRange(pfv.endpoint, walk.Rectangle{1, 1, 1, 1})
+	pfv.endpoint.SetCueBanner(l18n.Sprintf("host:port"))
+	if !peer.Endpoint.IsEmpty() {
+		pfv.endpoint.SetText(peer.Endpoint.String())
+	}
+	pfv.endpoint.TextChanged().Attach(func() {
+		validateLineEdit(pfv.endpoint, func(s string) bool {
+			if len(s) == 0 {
+				return true
+			}
+			_, err := conf.NewEndpointFromString(s)
+			return err == nil
+		})
+	})
+
+	if err = newLabel(l18n.Sprintf("Allowed IPs:"), 2); err != nil {
+		return nil, err
+	}
+	allowedIPsContainer, err := walk.NewComposite(pfv.group)
+	if err != nil {
+		return nil, err
+	}
+	layout.SetRange(allowedIPsContainer, walk.Rectangle{1, 2, 1, 1})
+	allowedIPsLayout := walk.NewGridLayout()
+	allowedIPsLayout.SetMargins(walk.Margins{})
+	allowedIPsLayout.SetSpacing(6)
+	allowedIPsContainer.SetLayout(allowedIPsLayout)
+	if pfv.allowedIPEdit, err = walk.NewLineEdit(allowedIPsContainer); err != nil {
+		return nil, err
+	}
+	allowedIPsLayout.SetRange(pfv.allowedIPEdit, walk.Rectangle{0, 0, 1, 1})
+	pfv.allowedIPEdit.SetCueBanner(l18n.Sprintf("address/prefix"))
+	pfv.allowedIPEdit.TextChanged().Attach(func() {
+		validateLineEdit(pfv.allowedIPEdit, func(s string) bool {
+			if len(s) == 0 {
+				return true
+			}
+			_, err := conf.NewIPCidrFromString(s)
+			return err == nil
+		})
+	})
+	if pfv.addAllowedIP, err = walk.NewPushButton(allowedIPsContainer); err != nil {
+		return nil, err
+	}
+	allowedIPsLayout.SetRange(pfv.addAllowedIP, walk.Rectangle{1, 0, 1, 1})
+	pfv.addAllowedIP.SetText(l18n.Sprintf("A&dd"))
+	pfv.addAllowedIP.Clicked().Attach(pfv.onAddAllowedIP)
+	if pfv.allowedIPs, err = walk.NewListBox(allowedIPsContainer); err != nil {
+		return nil, err
+	}
+	allowedIPsLayout.SetRange(pfv.allowedIPs, walk.Rectangle{0, 1, 1, 1})
+	pfv.allowedIPs.SetMinMaxSize(walk.Size{0, 60}, walk.Size{0, 100})
+	if pfv.removeAllowedIP, err = walk.NewPushButton(allowedIPsContainer); err != nil {
+		return nil, err
+	}
+	allowedIPsLayout.SetRange(pfv.removeAllowedIP, walk.Rectangle{1, 1, 1, 1})
+	pfv.removeAllowedIP.SetText(l18n.Sprintf("&Remove"))
+	pfv.removeAllowedIP.Clicked().Attach(pfv.onRemoveAllowedIP)
+	pfv.allowedIPList = make([]string, 0, len(peer.AllowedIPs))
+	for _, allowedIP := range peer.AllowedIPs {
+		pfv.allowedIPList = append(pfv.allowedIPList, allowedIP.String())
+	}
+	pfv.allowedIPs.SetModel(pfv.allowedIPList)
+
+	if err = newLabel(l18n.Sprintf("Persistent keepalive:"), 3); err != nil {
+		return nil, err
+	}
+	if pfv.keepalive, err = walk.NewNumberEdit(pfv.group); err != nil {
+		return nil, err
+	}
+	layout.SetRange(pfv.keepalive, walk.Rectangle{1, 3, 1, 1})
+	pfv.keepalive.SetDecimals(0)
+	pfv.keepalive.SetRange(0, 65535)
+	pfv.keepalive.SetSuffix(l18n.Sprintf(" seconds"))
+	pfv.keepalive.SetValue(float64(peer.PersistentKeepalive))
+
+	removeContainer, err := walk.NewComposite(pfv.group)
+	if err != nil {
+		return nil, err
+	}
+	layout.SetRange(removeContainer, walk.Rectangle{0, 4, 2, 1})
+	removeContainer.SetLayout(walk.NewHBoxLayout())
+	removeContainer.Layout().SetMargins(walk.Margins{})
+	walk.NewHSpacer(removeContainer)
+	if pfv.removePeer, err = walk.NewPushButton(removeContainer); err != nil {
+		return nil, err
+	}
+	pfv.removePeer.SetText(l18n.Sprintf("Remove &peer"))
+
+	disposables.Spare()
+
+	return pfv, nil
+}
+
+func (pfv *peerFormView) onAddAllowedIP() {
+	text := strings.TrimSpace(pfv.allowedIPEdit.Text())
+	if len(text) == 0 {
+		return
+	}
+	var added bool
+	for _, entry := range strings.Split(text, ",") {
+		ipcidr, err := conf.NewIPCidrFromString(strings.TrimSpace(entry))
+		if err != nil {
+			showWarningCustom(pfv.group.Form(), l18n.Sprintf("Invalid allowed IP"), err.Error())
+			return
+		}
+		pfv.allowedIPList = append(pfv.allowedIPList, ipcidr.String())
+		added = true
+	}
+	if added {
+		pfv.allowedIPs.SetModel(pfv.allowedIPList)
+		pfv.allowedIPEdit.SetText("")
+	}
+}
+
+func (pfv *peerFormView) onRemoveAllowedIP() {
+	index := pfv.allowedIPs.CurrentIndex()
+	if index < 0 || index >= len(pfv.allowedIPList) {
+		return
+	}
+	pfv.allowedIPList = append(pfv.allowedIPList[:index], pfv.allowedIPList[index+1:]...)
+	pfv.allowedIPs.SetModel(pfv.allowedIPList)
+}
+
+func (pfv *peerFormView) toPeer() (*conf.Peer, error) {
+	peer := pfv.peer
+
+	publicKey := strings.TrimSpace(pfv.publicKey.Text())
+	if len(publicKey) == 0 {
+		return nil, errors.New(l18n.Sprintf("All peers must have public keys"))
+	}
+	key, err := conf.NewPrivateKeyFromString(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	peer.PublicKey = *key
+
+	peer.Endpoint = conf.Endpoint{}
+	if endpoint := strings.TrimSpace(pfv.endpoint.Text()); len(endpoint) > 0 {
+		e, err := conf.NewEndpointFromString(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		peer.Endpoint = *e
+	}
+
+	peer.AllowedIPs = make([]conf.IPCidr, 0, len(pfv.allowedIPList))
+	for _, allowedIP := range pfv.allowedIPList {
+		ipcidr, err := conf.NewIPCidrFromString(allowedIP)
+		if err != nil {
+			return nil, err
+		}
+		peer.AllowedIPs = append(peer.AllowedIPs, *ipcidr)
+	}
+
+	peer.PersistentKeepalive = uint16(pfv.keepalive.Value())
+	return &peer, nil
+}
+
+func (pf *PeerForm) appendPeer(peer *conf.Peer) {
+	pfv, err := newPeerFormView(pf, peer)
+	if err != nil {
+		showError(err, pf.Form())
+		return
+	}
+	pfv.removePeer.Clicked().Attach(func() {
+		pf.removePeerView(pfv)
+	})
+	pf.peers = append(pf.peers, pfv)
+}
+
+func (pf *PeerForm) removePeerView(pfv *peerFormView) {
+	for i := range pf.peers {
+		if pf.peers[i] == pfv {
+			pf.peers = append(pf.peers[:i], pf.peers[i+1:]...)
+			break
+		}
+	}
+	pfv.group.SetVisible(false)
+	pfv.group.Parent().Children().Remove(pfv.group)
+	pfv.group.Dispose()
+}
+
+func (pf *PeerForm) SetPeers(peers []conf.Peer) {
+	pf.SetSuspended(true)
+	defer pf.SetSuspended(false)
+
+	for len(pf.peers) > 0 {
+		pf.removePeerView(pf.peers[0])
+	}
+	for i := range peers {
+		pf.appendPeer(&peers[i])
+	}
+}
+
+func (pf *PeerForm) Peers() ([]conf.Peer, error) {
+	peers := make([]conf.Peer, 0, len(pf.peers))
+	for _, pfv := range pf.peers {
+		peer, err := pfv.toPeer()
+		if err != nil {
+			pfv.group.SetFocus()
+			return nil, err
+		}
+		peers = append(peers, *peer)
+	}
+	return peers, nil
+}
@@ -0,0 +1,487 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/lxn/walk"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/l18n"
+	"golang.zx2c4.com/wireguard/windows/manager"
+	"golang.zx2c4.com/wireguard/windows/updater"
+)
+
+var currentSettings = conf.DefaultSettings()
+var showingPreferencesDialog *walk.Dialog
+
+// The maintenance window is chosen in steps of this many minutes.
+const maintenanceWindowStep = 30
+
+func onPreferences(owner walk.Form) {
+	showError(runPreferencesDialog(owner), owner)
+}
+
+func runPreferencesDialog(owner walk.Form) error {
+	if showingPreferencesDialog != nil {
+		showingPreferencesDialog.Show()
+		raise(showingPreferencesDialog.Handle())
+		return nil
+	}
+
+	var err error
+	var disposables walk.Disposables
+	defer disposables.Treat()
+
+	if showingPreferencesDialog, err = walk.NewDialogWithFixedSize(owner); err != nil {
+		return err
+	}
+	defer func() {
+		showingPreferencesDialog = nil
+	}()
+	dlg := showingPreferencesDialog
+	disposables.Add(dlg)
+	applyModernWindowStyle(dlg.Handle(), false)
+	dlg.SetTitle(l18n.Sprintf("WireGuard Preferences"))
+	if icon, err := loadLogoIcon(32); err == nil {
+		dlg.SetIcon(icon)
+	}
+	vlayout := walk.NewVBoxLayout()
+	vlayout.SetMargins(walk.Margins{10, 10, 10, 10})
+	vlayout.SetSpacing(6)
+	dlg.SetLayout(vlayout)
+
+	settings := *currentSettings
+
+	group, err := walk.NewGroupBox(dlg)
+	if err != nil {
+		return err
+	}
+	group.SetTitle(l18n.Sprintf("Notifications"))
+	group.SetLayout(walk.NewVBoxLayout())
+	tunnelNotificationsCB, err := walk.NewCheckBox(group)
+	if err != nil {
+		return err
+	}
+	setAutomationID(tunnelNotificationsCB, automationPreferencesTunnelNotifications)
+	tunnelNotificationsCB.SetText(l18n.Sprintf("Notify when a tunnel is &activated or deactivated"))
+	tunnelNotificationsCB.SetChecked(settings.TunnelNotifications)
+	errorNotificationsCB, err := walk.NewCheckBox(group)
+	if err != nil {
+		return err
+	}
+	setAutomationID(errorNotificationsCB, automationPreferencesErrorNotifications)
+	errorNotificationsCB.SetText(l18n.Sprintf("Notify when a tunnel &error occurs"))
+	errorNotificationsCB.SetChecked(settings.ErrorNotifications)
+	updateNotificationsCB, err := walk.NewCheckBox(group)
+	if err != nil {
+		return err
+	}
+	setAutomationID(updateNotificationsCB, automationPreferencesUpdateNotifications)
+	updateNotificationsCB.SetText(l18n.Sprintf("Notify when an &update is available"))
+	updateNotificationsCB.SetChecked(settings.UpdateNotifications)
+
+	group, err = walk.NewGroupBox(dlg)
+	if err != nil {
+		return err
+	}
+	group.SetTitle(l18n.Sprintf("Confirmations"))
+	group.SetLayout(walk.NewVBoxLayout())
+	confirmDeleteCB, err := walk.NewCheckBox(group)
+	if err != nil {
+		return err
+	}
+	setAutomationID(confirmDeleteCB, automationPreferencesConfirmDelete)
+	confirmDeleteCB.SetText(l18n.Sprintf("Confirm before d&eleting tunnels"))
+	confirmDeleteCB.SetChecked(confirmDeleteRequired())
+	confirmDeactivateCB, err := walk.NewCheckBox(group)
+	if err != nil {
+		return err
+	}
+	setAutomationID(confirmDeactivateCB, automationPreferencesConfirmDeactivate)
+	confirmDeactivateCB.SetText(l18n.Sprintf("Confirm before deactivating a tunnel that is carrying &traffic"))
+	confirmDeactivateCB.SetChecked(confirmDeactivateRequired())
+	confirmImportOverwriteCB, err := walk.NewCheckBox(group)
+	if err != nil {
+		return err
+	}
+	setAutomationID(confirmImportOverwriteCB, automationPreferencesConfirmImportOverwrite)
+	confirmImportOverwriteCB.SetText(l18n.Sprintf("Confirm before an import &replaces an existing tunnel"))
+	confirmImportOverwriteCB.SetChecked(confirmImportOverwriteRequired())
+	if confirmationsForced() {
+		confirmDeleteCB.SetEnabled(false)
+		confirmDeactivateCB.SetEnabled(false)
+		confirmImportOverwriteCB.SetEnabled(false)
+	}
+
+	group, err = walk.NewGroupBox(dlg)
+	if err != nil {
+		return err
+	}
+	group.SetTitle(l18n.Sprintf("Behavior"))
+	group.SetLayout(walk.NewVBoxLayout())
+	checkForUpdatesContainer, err := walk.NewComposite(group)
+	if err != nil {
+		return err
+	}
+	checkForUpdatesContainer.SetLayout(walk.NewHBoxLayout())
+	checkForUpdatesContainer.Layout().SetMargins(walk.Margins{})
+	checkForUpdatesCB, err := walk.NewCheckBox(checkForUpdatesContainer)
+	if err != nil {
+		return err
+	}
+	setAutomationID(checkForUpdatesCB, automationPreferencesCheckForUpdates)
+	checkForUpdatesCB.SetText(l18n.Sprintf("&Check for updates automatically every"))
+	checkForUpdatesCB.SetChecked(settings.CheckForUpdates)
+	updateCheckIntervalEdit, err := walk.NewNumberEdit(checkForUpdatesContainer)
+	if err != nil {
+		return err
+	}
+	updateCheckIntervalEdit.SetDecimals(0)
+	updateCheckIntervalEdit.SetRange(1, conf.MaxUpdateCheckInterval)
+	updateCheckIntervalEdit.SetSpinButtonsVisible(true)
+	updateCheckIntervalEdit.SetMinMaxSize(walk.Size{60, 0}, walk.Size{60, 0})
+	updateCheckIntervalEdit.SetValue(float64(settings.EffectiveUpdateCheckInterval() / time.Hour))
+	updateCheckIntervalLabel, err := walk.NewTextLabel(checkForUpdatesContainer)
+	if err != nil {
+		return err
+	}
+	updateCheckIntervalLabel.SetText(l18n.Sprintf("hours"))
+	walk.NewHSpacer(checkForUpdatesContainer)
+	_, updateCheckIntervalPinned := conf.AdminInteger("UpdateCheckInterval")
+	updatesDisabled := conf.UpdatesDisabled()
+	if updatesDisabled {
+		checkForUpdatesCB.SetChecked(false)
+		checkForUpdatesCB.SetEnabled(false)
+		checkForUpdatesCB.SetToolTipText(l18n.Sprintf("Updates have been disabled by your administrator."))
+	}
+	updateCheckIntervalEdit.SetEnabled(!updatesDisabled && !updateCheckIntervalPinned && checkForUpdatesCB.Checked())
+	checkForUpdatesCB.CheckedChanged().Attach(func() {
+		updateCheckIntervalEdit.SetEnabled(!updateCheckIntervalPinned && checkForUpdatesCB.Checked())
+	})
+
+	updateChannelContainer, err := walk.NewComposite(group)
+	if err != nil {
+		return err
+	}
+	updateChannelContainer.SetLayout(walk.NewHBoxLayout())
+	updateChannelContainer.Layout().SetMargins(walk.Margins{})
+	updateChannelLabel, err := walk.NewTextLabel(updateChannelContainer)
+	if err != nil {
+		return err
+	}
+	updateChannelLabel.SetText(l18n.Sprintf("U&pdate channel:"))
+	updateChannelCombo, err := walk.NewDropDownBox(updateChannelContainer)
+	if err != nil {
+		return err
+	}
+	updateChannelCombo.SetModel([]string{
+		l18n.Sprintf("Stable releases"),
+		l18n.Sprintf("Beta releases"),
+	})
+	updateChannelCombo.SetToolTipText(l18n.Sprintf("Beta releases are pre-release builds for testing. They may be less reliable than stable releases."))
+	updateChannelCombo.SetCurrentIndex(0)
+	for i, channel := range updater.Channels {
+		if channel == settings.EffectiveUpdateChannel() {
+			updateChannelCombo.SetCurrentIndex(i)
+		}
+	}
+	_, updateChannelPinned := conf.AdminString("UpdateChannel")
+	updateChannelCombo.SetEnabled(!updateChannelPinned && !updatesDisabled)
+	walk.NewHSpacer(updateChannelContainer)
+
+	maintenanceWindowContainer, err := walk.NewComposite(group)
+	if err != nil {
+		return err
+	}
+	maintenanceWindowContainer.SetLayout(walk.NewHBoxLayout())
+	maintenanceWindowContainer.Layout().SetMargins(walk.Margins{})
+	maintenanceWindowCB, err := walk.NewCheckBox(maintenanceWindowContainer)
+	if err != nil {
+		return err
+	}
+	setAutomationID(maintenanceWindowCB, automationPreferencesMaintenanceWindow)
+	maintenanceWindowCB.SetText(l18n.Sprintf("&Install updates automatically between"))
+	maintenanceWindowCB.SetToolTipText(l18n.Sprintf("Installing an update briefly interrupts all tunnels, so choose a time when nobody is likely to be using them."))
+	maintenanceWindowCB.SetChecked(settings.MaintenanceWindow.Enabled)
+	var maintenanceWindowTimes []string
+	for minute := 0; minute < 24*60; minute += maintenanceWindowStep {
+		maintenanceWindowTimes = append(maintenanceWindowTimes, time.Date(2000, 1, 1, 0, minute, 0, 0, time.Local).Format("15:04"))
+	}
+	maintenanceWindowStartCombo, err := walk.NewDropDownBox(maintenanceWindowContainer)
+	if err != nil {
+		return err
+	}
+	maintenanceWindowStartCombo.SetModel(maintenanceWindowTimes)
+	maintenanceWindowStartCombo.SetCurrentIndex(settings.MaintenanceWindow.Start / maintenanceWindowStep % len(maintenanceWindowTimes))
+	maintenanceWindowAndLabel, err := walk.NewTextLabel(maintenanceWindowContainer)
+	if err != nil {
+		return err
+	}
+	maintenanceWindowAndLabel.SetText(l18n.Sprintf("and"))
+	maintenanceWindowEndCombo, err := walk.NewDropDownBox(maintenanceWindowContainer)
+	if err != nil {
+		return err
+	}
+	maintenanceWindowEndCombo.SetModel(maintenanceWindowTimes)
+	maintenanceWindowEndCombo.SetCurrentIndex(settings.MaintenanceWindow.End / maintenanceWindowStep % len(maintenanceWindowTimes))
+	walk.NewHSpacer(maintenanceWindowContainer)
+	maintenanceWindowInactiveCB, err := walk.NewCheckBox(group)
+	if err != nil {
+		return err
+	}
+	setAutomationID(maintenanceWindowInactiveCB, automationPreferencesMaintenanceWindowInactive)
+	maintenanceWindowInactiveCB.SetText(l18n.Sprintf("Only install updates automatically when &no tunnel is active"))
+	maintenanceWindowInactiveCB.SetChecked(settings.MaintenanceWindow.OnlyWhenInactive)
+	if updatesDisabled || conf.UpdatesNotifyOnly() {
+		maintenanceWindowCB.SetChecked(false)
+		maintenanceWindowCB.SetEnabled(false)
+	}
+	updateMaintenanceWindowEnabled := func() {
+		enabled := maintenanceWindowCB.Checked()
+		maintenanceWindowStartCombo.SetEnabled(enabled)
+		maintenanceWindowEndCombo.SetEnabled(enabled)
+		maintenanceWindowInactiveCB.SetEnabled(enabled)
+	}
+	maintenanceWindowCB.CheckedChanged().Attach(updateMaintenanceWindowEnabled)
+	updateMaintenanceWindowEnabled()
+
+	exitStopsTunnelsCB, err := walk.NewCheckBox(group)
+	if err != nil {
+		return err
+	}
+	setAutomationID(exitStopsTunnelsCB, automationPreferencesExitStopsTunnels)
+	exitStopsTunnelsCB.SetText(l18n.Sprintf("&Deactivate all tunnels when exiting WireGuard"))
+	exitStopsTunnelsCB.SetChecked(settings.ExitStopsTunnels)
+	requireReauthenticationCB, err := walk.NewCheckBox(group)
+	if err != nil {
+		return err
+	}
+	setAutomationID(requireReauthenticationCB, automationPreferencesRequireReauthentication)
+	requireReauthenticationCB.SetText(l18n.Sprintf("&Require credentials before revealing, exporting, or deleting tunnels"))
+	requireReauthenticationCB.SetChecked(reauthenticationRequired())
+	requireReauthenticationCB.SetEnabled(!conf.AdminBool("RequireReauthentication"))
+
+	trayIconContainer, err := walk.NewComposite(group)
+	if err != nil {
+		return err
+	}
+	trayIconContainer.SetLayout(walk.NewHBoxLayout())
+	trayIconContainer.Layout().SetMargins(walk.Margins{})
+	trayIconLabel, err := walk.NewTextLabel(trayIconContainer)
+	if err != nil {
+		return err
+	}
+	trayIconLabel.SetText(l18n.Sprintf("&Tray icon:"))
+	trayIconCombo, err := walk.NewDropDownBox(trayIconContainer)
+	if err != nil {
+		return err
+	}
+	trayIconCombo.SetModel([]string{
+		conf.TrayIconAutomatic:       l18n.Sprintf("Match taskbar theme"),
+		conf.TrayIconColor:           l18n.Sprintf("Color"),
+		conf.TrayIconMonochromeDark:  l18n.Sprintf("Monochrome dark"),
+		conf.TrayIconMonochromeLight: l18n.Sprintf("Monochrome light"),
+	})
+	trayIconCombo.SetCurrentIndex(int(settings.TrayIconStyle))
+	walk.NewHSpacer(trayIconContainer)
+
+	languageContainer, err := walk.NewComposite(group)
+	if err != nil {
+		return err
+	}
+	languageContainer.SetLayout(walk.NewHBoxLayout())
+	languageContainer.Layout().SetMargins(walk.Margins{})
+	languageLabel, err := walk.NewTextLabel(languageContainer)
+	if err != nil {
+		return err
+	}
+	languageLabel.SetText(l18n.Sprintf("&Language:"))
+	languageCombo, err := walk.NewDropDownBox(languageContainer)
+	if err != nil {
+		return err
+	}
+	languages := []string{""}
+	languageNames := []string{l18n.Sprintf("Windows default")}
+	languageIndex := 0
+	for _, tag := range l18n.SupportedLanguages() {
+		if tag.String() == settings.Language {
+			languageIndex = len(languages)
+		}
+		languages = append(languages, tag.String())
+		languageNames = append(languageNames, l18n.LanguageDisplayName(tag))
+	}
+	languageCombo.SetModel(languageNames)
+	languageCombo.SetCurrentIndex(languageIndex)
+	walk.NewHSpacer(languageContainer)
+
+	textScaleContainer, err := walk.NewComposite(group)
+	if err != nil {
+		return err
+	}
+	textScaleContainer.SetLayout(walk.NewHBoxLayout())
+	textScaleContainer.Layout().SetMargins(walk.Margins{})
+	textScaleLabel, err := walk.NewTextLabel(textScaleContainer)
+	if err != nil {
+		return err
+	}
+	textScaleLabel.SetText(l18n.Sprintf("Te&xt size:"))
+	textScaleCombo, err := walk.NewDropDownBox(textScaleContainer)
+	if err != nil {
+		return err
+	}
+	textScales := []int{90, 100, 125, 150, 175, 200}
+	textScaleNames := make([]string, len(textScales))
+	textScaleIndex := 1
+	for i, scale := range textScales {
+		textScaleNames[i] = l18n.Sprintf("%d%%", scale)
+		if scale == settings.TextScale {
+			textScaleIndex = i
+		}
+	}
+	textScaleCombo.SetModel(textScaleNames)
+	textScaleCombo.SetCurrentIndex(textScaleIndex)
+	walk.NewHSpacer(textScaleContainer)
+
+	logTimesUTCCB, err := walk.NewCheckBox(group)
+	if err != nil {
+		return err
+	}
+	setAutomationID(logTimesUTCCB, automationPreferencesLogTimesUTC)
+	logTimesUTCCB.SetText(l18n.Sprintf("Sh&ow log times in UTC rather than local time"))
+	logTimesUTCCB.SetChecked(settings.LogTimesUTC)
+	logTimesISO8601CB, err := walk.NewCheckBox(group)
+	if err != nil {
+		return err
+	}
+	setAutomationID(logTimesISO8601CB, automationPreferencesLogTimesISO8601)
+	logTimesISO8601CB.SetText(l18n.Sprintf("Show log times in the ISO 8601 &format"))
+	logTimesISO8601CB.SetToolTipText(l18n.Sprintf("Such as 2020-11-20T15:30:00.000Z, which log collectors and spreadsheets read without ambiguity."))
+	logTimesISO8601CB.SetChecked(settings.LogTimesISO8601)
+
+	addressPoolContainer, err := walk.NewComposite(group)
+	if err != nil {
+		return err
+	}
+	addressPoolContainer.SetLayout(walk.NewHBoxLayout())
+	addressPoolContainer.Layout().SetMargins(walk.Margins{})
+	addressPoolLabel, err := walk.NewTextLabel(addressPoolContainer)
+	if err != nil {
+		return err
+	}
+	addressPoolLabel.SetText(l18n.Sprintf("Address &pool for new tunnels:"))
+	addressPoolEdit, err := walk.NewLineEdit(addressPoolContainer)
+	if err != nil {
+		return err
+	}
+	addressPoolEdit.SetCueBanner(l18n.Sprintf("e.g. 10.8.0.0/24"))
+	addressPoolEdit.SetToolTipText(l18n.Sprintf("New tunnels are offered the next address of this subnet that no stored tunnel uses. The first address is left for the server."))
+	addressPoolEdit.SetText(settings.AddressPool)
+
+	buttonsContainer, err := walk.NewComposite(dlg)
+	if err != nil {
+		return err
+	}
+	buttonsContainer.SetLayout(walk.NewHBoxLayout())
+	buttonsContainer.Layout().SetMargins(walk.Margins{})
+	walk.NewHSpacer(buttonsContainer)
+
+	saveButton, err := walk.NewPushButton(buttonsContainer)
+	if err != nil {
+		return err
+	}
+	saveButton.SetText(l18n.Sprintf("&Save"))
+	setAutomationID(saveButton, automationPreferencesSave)
+	saveButton.Clicked().Attach(func() {
+		settings.AddressPool = ""
+		if text := strings.TrimSpace(addressPoolEdit.Text()); len(text) > 0 {
+			pool, err := conf.ParseAddressPool(text)
+			if err != nil {
+				showWarningCustom(dlg, l18n.Sprintf("Invalid address pool"), err.Error())
+				return
+			}
+			settings.AddressPool = pool.String()
+		}
+		settings.TunnelNotifications = tunnelNotificationsCB.Checked()
+		settings.ErrorNotifications = errorNotificationsCB.Checked()
+		settings.UpdateNotifications = updateNotificationsCB.Checked()
+		if !updatesDisabled {
+			settings.CheckForUpdates = checkForUpdatesCB.Checked()
+		}
+		if !updateCheckIntervalPinned {
+			settings.UpdateCheckInterval = int(updateCheckIntervalEdit.Value())
+		}
+		if !updateChannelPinned {
+			if i := updateChannelCombo.CurrentIndex(); i > 0 && i < len(updater.Channels) {
+				settings.UpdateChannel = updater.Channels[i]
+			} else {
+				settings.UpdateChannel = ""
+			}
+		}
+		settings.ExitStopsTunnels = exitStopsTunnelsCB.Checked()
+		if maintenanceWindowCB.Enabled() {
+			settings.MaintenanceWindow.Enabled = maintenanceWindowCB.Checked()
+		}
+		settings.MaintenanceWindow.Start = maintenanceWindowStartCombo.CurrentIndex() * maintenanceWindowStep
+		settings.MaintenanceWindow.End = maintenanceWindowEndCombo.CurrentIndex() * maintenanceWindowStep
+		settings.MaintenanceWindow.OnlyWhenInactive = maintenanceWindowInactiveCB.Checked()
+		if settings.RequireReauthentication && !requireReauthenticationCB.Checked() &&
+			!reauthenticate(dlg, l18n.Sprintf("Enter your credentials to stop requiring them for sensitive actions.")) {
+			return
+		}
+		if !confirmationsForced() {
+			settings.ConfirmDelete = confirmDeleteCB.Checked()
+			settings.ConfirmDeactivate = confirmDeactivateCB.Checked()
+			settings.ConfirmImportOverwrite = confirmImportOverwriteCB.Checked()
+		}
+		settings.TrayIconStyle = conf.TrayIconStyle(trayIconCombo.CurrentIndex())
+		if requireReauthenticationCB.Enabled() {
+			settings.RequireReauthentication = requireReauthenticationCB.Checked()
+		}
+		if i := textScaleCombo.CurrentIndex(); i >= 0 {
+			settings.TextScale = textScales[i]
+		}
+		if i := languageCombo.CurrentIndex(); i >= 0 {
+			settings.Language = languages[i]
+		}
+		settings.LogTimesUTC = logTimesUTCCB.Checked()
+		settings.LogTimesISO8601 = logTimesISO8601CB.Checked()
+		err := manager.IPCClientSetSettings(&settings)
+		if err != nil {
+			showErrorCustom(dlg, l18n.Sprintf("Unable to save preferences"), err.Error())
+			return
+		}
+		languageChanged := settings.Language != currentSettings.Language
+		currentSettings = &settings
+		dlg.Accept()
+		if languageChanged {
+			l18n.SetLanguageOverride(settings.Language)
+			if walk.MsgBox(owner, l18n.Sprintf("Restart WireGuard"), l18n.Sprintf("The new language will be used once the WireGuard interface restarts. Do you want to restart it now? Active tunnels are not affected."), walk.MsgBoxYesNo|walk.MsgBoxIconQuestion) == walk.DlgCmdYes {
+				// The manager relaunches the interface shortly after it exits.
+				walk.App().Exit(0)
+			}
+		}
+	})
+
+	cancelButton, err := walk.NewPushButton(buttonsContainer)
+	if err != nil {
+		return err
+	}
+	cancelButton.SetText(l18n.Sprintf("Cancel"))
+	setAutomationID(cancelButton, automationPreferencesCancel)
+	cancelButton.Clicked().Attach(dlg.Cancel)
+
+	dlg.SetCancelButton(cancelButton)
+	dlg.SetDefaultButton(saveButton)
+
+	disposables.Spare()
+
+	dlg.Run()
+
+	return nil
+}
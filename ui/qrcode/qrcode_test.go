@@ -0,0 +1,88 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package qrcode
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// The codewords of "HELLO WORLD" in alphanumeric mode at 1-M, from the worked example that accompanies the
+	// specification.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	expected := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	ecc := reedSolomonRemainder(data, reedSolomonDivisor(10))
+	if !bytes.Equal(ecc, expected) {
+		t.Errorf("Reed-Solomon codewords are %v although %v are expected", ecc, expected)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	for version := minVersion; version <= maxVersion; version++ {
+		if rawDataModules(version)%8 > 7 || dataCodewords(version) <= 0 {
+			t.Errorf("Version %d has an impossible capacity", version)
+		}
+	}
+	if dataCodewords(1) != 16 || dataCodewords(40) != 2334 {
+		t.Errorf("Capacities of versions 1 and 40 are %d and %d", dataCodewords(1), dataCodewords(40))
+	}
+	if _, err := Encode(make([]byte, 2332)); err != ErrTooLong {
+		t.Error("Oversized data should not encode")
+	}
+	code, err := Encode([]byte("[Interface]\nPrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code.Size != 5*4+17 {
+		t.Errorf("Symbol size is %d", code.Size)
+	}
+}
+
+func TestGoldenMatrix(t *testing.T) {
+	// Version 2-M with mask 2, as made by an independent encoder written from the specification.
+	expected := []string{
+		"#######...##..#.#.#######",
+		"#.....#..##...###.#.....#",
+		"#.###.#.##.#..#.#.#.###.#",
+		"#.###.#.#...####..#.###.#",
+		"#.###.#.####....#.#.###.#",
+		"#.....#.#..##..##.#.....#",
+		"#######.#.#.#.#.#.#######",
+		"........##..#.###........",
+		"#.#####...#.####..#####..",
+		"#..#...####.#.#.##.#...#.",
+		"...#..######.#.#.#####.##",
+		".....#...##.#....#.#....#",
+		".##...#..#..#########.###",
+		"###.#.......#...##.#.#.#.",
+		"#..##.#####...########.##",
+		"#..#.#...#.#...#.#.##...#",
+		"#.#####..#####..#####.#..",
+		"........#..###.##...##...",
+		"#######......##.#.#.#.###",
+		"#.....#.#.#.....#...##...",
+		"#.###.#.#############.#..",
+		"#.###.#.#..#....#.#.#####",
+		"#.###.#.###..#.......##.#",
+		"#.....#..#.#..#.##.###..#",
+		"#######.#######....######",
+	}
+	code, err := Encode([]byte("https://www.wireguard.com/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code.Size != len(expected) {
+		t.Fatalf("Symbol size is %d although %d is expected", code.Size, len(expected))
+	}
+	for y, row := range expected {
+		for x := range row {
+			if code.Black(x, y) != (row[x] == '#') {
+				t.Errorf("Module at column %d of row %d differs", x, y)
+			}
+		}
+	}
+}
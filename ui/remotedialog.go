@@ -0,0 +1,133 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ui
+
+import (
+	"os"
+	"strings"
+
+	"github.com/lxn/walk"
+
+	"golang.zx2c4.com/wireguard/windows/l18n"
+)
+
+// onManageRemote asks for the name of a machine, and opens a window for its tunnels in a process of its own, which
+// connects to the manager of that machine as the user, so that it needs nothing of this one.
+func onManageRemote(owner walk.Form) {
+	machine, ok := runRemoteDialog(owner)
+	if !ok {
+		return
+	}
+	path, err := os.Executable()
+	if err != nil {
+		showError(err, owner)
+		return
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		showError(err, owner)
+		return
+	}
+	defer devNull.Close()
+	proc, err := os.StartProcess(path, []string{path, "/remoteui", machine}, &os.ProcAttr{Files: []*os.File{devNull, devNull, devNull}})
+	if err != nil {
+		showErrorCustom(owner, l18n.Sprintf("Unable to manage remote machine"), err.Error())
+		return
+	}
+	proc.Release()
+}
+
+func runRemoteDialog(owner walk.Form) (machine string, ok bool) {
+	var disposables walk.Disposables
+	defer disposables.Treat()
+
+	dlg, err := walk.NewDialogWithFixedSize(owner)
+	if err != nil {
+		showError(err, owner)
+		return "", false
+	}
+	disposables.Add(dlg)
+	applyModernWindowStyle(dlg.Handle(), false)
+	dlg.SetTitle(l18n.Sprintf("Manage Remote Machine"))
+	if icon, err := loadLogoIcon(32); err == nil {
+		dlg.SetIcon(icon)
+	}
+	layout := walk.NewGridLayout()
+	layout.SetSpacing(6)
+	layout.SetMargins(walk.Margins{10, 10, 10, 10})
+	dlg.SetLayout(layout)
+
+	explanationLbl, err := walk.NewTextLabel(dlg)
+	if err != nil {
+		showError(err, dlg)
+		return "", false
+	}
+	explanationLbl.SetText(l18n.Sprintf("The tunnels of another machine may be managed from here if it allows remote management, and if you are one of its administrators."))
+	layout.SetRange(explanationLbl, walk.Rectangle{0, 0, 2, 1})
+
+	machineLbl, err := walk.NewTextLabel(dlg)
+	if err != nil {
+		showError(err, dlg)
+		return "", false
+	}
+	machineLbl.SetText(l18n.Sprintf("&Machine:"))
+	machineLbl.SetTextAlignment(walk.AlignHFarVCenter)
+	layout.SetRange(machineLbl, walk.Rectangle{0, 1, 1, 1})
+
+	machineEdit, err := walk.NewLineEdit(dlg)
+	if err != nil {
+		showError(err, dlg)
+		return "", false
+	}
+	machineEdit.SetMinMaxSize(walk.Size{250, 0}, walk.Size{0, 0})
+	layout.SetRange(machineEdit, walk.Rectangle{1, 1, 1, 1})
+
+	buttonsContainer, err := walk.NewComposite(dlg)
+	if err != nil {
+		showError(err, dlg)
+		return "", false
+	}
+	layout.SetRange(buttonsContainer, walk.Rectangle{0, 2, 2, 1})
+	buttonsLayout := walk.NewHBoxLayout()
+	buttonsLayout.SetMargins(walk.Margins{})
+	buttonsContainer.SetLayout(buttonsLayout)
+	walk.NewHSpacer(buttonsContainer)
+
+	connectButton, err := walk.NewPushButton(buttonsContainer)
+	if err != nil {
+		showError(err, dlg)
+		return "", false
+	}
+	connectButton.SetText(l18n.Sprintf("&Connect"))
+	connectButton.SetEnabled(false)
+	machineEdit.TextChanged().Attach(func() {
+		connectButton.SetEnabled(len(strings.TrimSpace(machineEdit.Text())) > 0)
+	})
+	connectButton.Clicked().Attach(func() {
+		machine = strings.TrimLeft(strings.TrimSpace(machineEdit.Text()), `\`)
+		if len(machine) == 0 || strings.ContainsAny(machine, `\/`) {
+			showErrorCustom(dlg, l18n.Sprintf("Invalid machine name"), l18n.Sprintf("Enter the name or address of a machine, such as ‘server’ or ‘server.example.com’."))
+			return
+		}
+		dlg.Accept()
+	})
+
+	cancelButton, err := walk.NewPushButton(buttonsContainer)
+	if err != nil {
+		showError(err, dlg)
+		return "", false
+	}
+	cancelButton.SetText(l18n.Sprintf("Cancel"))
+	cancelButton.Clicked().Attach(dlg.Cancel)
+
+	dlg.SetDefaultButton(connectButton)
+	dlg.SetCancelButton(cancelButton)
+
+	if dlg.Run() != walk.DlgCmdOK {
+		return "", false
+	}
+	return machine, true
+}
@@ -0,0 +1,64 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ui
+
+import (
+	"strings"
+
+	"github.com/lxn/walk"
+
+	"golang.zx2c4.com/wireguard/windows/l18n"
+	"golang.zx2c4.com/wireguard/windows/manager"
+)
+
+const maxListedRouteConflicts = 15
+
+// confirmActivation asks the user whether to proceed if activating the tunnel would clash with the addresses of
+// other adapters or active tunnels. It must not be called from the UI thread, since it blocks on the user's answer.
+func confirmActivation(form walk.Form, tunnel *manager.Tunnel) bool {
+	state, err := tunnel.State()
+	if err != nil || state != manager.TunnelStopped {
+		return true
+	}
+	conflicts, err := tunnel.RouteConflicts()
+	if err != nil || len(conflicts) == 0 {
+		return true
+	}
+
+	lines := make([]string, 0, maxListedRouteConflicts+1)
+	for i := range conflicts {
+		if i == maxListedRouteConflicts {
+			lines = append(lines, l18n.Sprintf("…and %d more", len(conflicts)-maxListedRouteConflicts))
+			break
+		}
+		lines = append(lines, textForRouteConflict(&conflicts[i]))
+	}
+	message := l18n.Sprintf("Activating tunnel ‘%s’ may break connectivity, because of the following conflicts:\n\n%s\n\nDo you want to activate it anyway?", tunnel.Name, strings.Join(lines, "\n"))
+
+	proceed := make(chan bool)
+	form.Synchronize(func() {
+		var owner walk.Form
+		if form.Visible() {
+			owner = form
+		}
+		proceed <- walk.MsgBox(owner, l18n.Sprintf("Route conflicts"), message, walk.MsgBoxYesNo|walk.MsgBoxIconWarning) == walk.DlgCmdYes
+	})
+	return <-proceed
+}
+
+func textForRouteConflict(conflict *manager.RouteConflict) string {
+	prefix, conflicting := conflict.Prefix.String(), conflict.Conflicting.String()
+	switch {
+	case conflict.Kind == manager.AddressConflict && conflict.IsTunnel:
+		return l18n.Sprintf("• Address %s overlaps %s of tunnel ‘%s’", prefix, conflicting, conflict.Owner)
+	case conflict.Kind == manager.AddressConflict:
+		return l18n.Sprintf("• Address %s overlaps %s of adapter ‘%s’", prefix, conflicting, conflict.Owner)
+	case conflict.IsTunnel:
+		return l18n.Sprintf("• Allowed IP %s overlaps %s of tunnel ‘%s’", prefix, conflicting, conflict.Owner)
+	default:
+		return l18n.Sprintf("• Allowed IP %s overlaps %s of adapter ‘%s’", prefix, conflicting, conflict.Owner)
+	}
+}
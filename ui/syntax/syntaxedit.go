@@ -24,6 +24,7 @@ type SyntaxEdit struct {
 	idoc                            *win.ITextDocument
 	lastBlockState                  BlockState
 	yheight                         int
+	textScale                       int
 	highlightGuard                  uint32
 	textChangedPublisher            walk.EventPublisher
 	privateKeyPublisher             walk.StringEventPublisher
@@ -231,7 +232,7 @@ func (se *SyntaxEdit) highlightText() error {
 		},
 	}
 	if se.yheight != 0 {
-		format.YHeight = 20 * 10
+		format.YHeight = int32(20 * 10 * se.textScale / 100)
 	}
 	win.SendMessage(hWnd, win.EM_SETCHARFORMAT, win.SCF_ALL, uintptr(unsafe.Pointer(&format)))
 	bgColor := win.COLORREF(win.GetSysColor(win.COLOR_WINDOW))
@@ -479,9 +480,19 @@ func NewSyntaxEdit(parent walk.Container) (*SyntaxEdit, error) {
 			return se.SetText("")
 		},
 		se.textChangedPublisher.Event()))
+	se.textScale = 100
 	return se, nil
 }
 
+// SetTextScale sets the size of the text as a percentage of the default, independently of the DPI.
+func (se *SyntaxEdit) SetTextScale(percent int) {
+	if percent <= 0 || percent == se.textScale {
+		return
+	}
+	se.textScale = percent
+	se.highlightText()
+}
+
 func (se *SyntaxEdit) ApplyDPI(dpi int) {
 	hWnd := se.Handle()
 	hdc := win.GetDC(hWnd)
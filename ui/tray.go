@@ -6,8 +6,10 @@
 package ui
 
 import (
+	"reflect"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"golang.zx2c4.com/wireguard/windows/conf"
@@ -26,12 +28,27 @@ type Tray struct {
 	// Current known tunnels by name
 	tunnels                  map[string]*walk.Action
 	tunnelsAreInBreakoutMenu bool
+	favorites                map[string]bool
 
 	mtw *ManageTunnelsWindow
 
 	tunnelChangedCB  *manager.TunnelChangeCallback
 	tunnelsChangedCB *manager.TunnelsChangeCallback
 
+	globalState  manager.TunnelState
+	sampleState  int32 // The global state, for the sampler of transfer rates, which runs on its own goroutine
+	errored      bool
+	activeNames  []string
+	rxRate       conf.Bytes
+	txRate       conf.Bytes
+	updateTicker *time.Ticker
+	stopSampling chan struct{}
+
+	quietUntil   time.Time
+	quietTimer   *time.Timer
+	quietAction  *walk.Action
+	quietOffItem *walk.Action
+
 	clicked func()
 }
 
@@ -56,7 +73,7 @@ func (tray *Tray) setup() error {
 
 	tray.SetToolTip(l18n.Sprintf("WireGuard: Deactivated"))
 	tray.SetVisible(true)
-	if icon, err := loadLogoIcon(16); err == nil {
+	if icon, err := loadLogoIconVariant(trayLogo(), 16); err == nil {
 		tray.SetIcon(icon)
 	}
 
@@ -83,7 +100,9 @@ func (tray *Tray) setup() error {
 		{separator: true},
 		{label: l18n.Sprintf("&Manage tunnels…"), handler: tray.onManageTunnels, enabled: true, defawlt: true},
 		{label: l18n.Sprintf("&Import tunnel(s) from file…"), handler: tray.onImport, enabled: true, hidden: !IsAdmin},
+		{label: l18n.Sprintf("Manage &remote machine…"), handler: tray.onManageRemote, enabled: true, hidden: !IsAdmin},
 		{separator: true},
+		{label: l18n.Sprintf("&Preferences…"), handler: tray.onPreferences, enabled: true, hidden: !IsAdmin},
 		{label: l18n.Sprintf("&About WireGuard…"), handler: tray.onAbout, enabled: true},
 		{label: l18n.Sprintf("E&xit"), handler: onQuit, enabled: true, hidden: !IsAdmin},
 	} {
@@ -103,16 +122,121 @@ func (tray *Tray) setup() error {
 
 		tray.ContextMenu().Actions().Add(action)
 	}
+	if err := tray.addQuietMenu(); err != nil {
+		return err
+	}
 	tray.tunnelChangedCB = manager.IPCClientRegisterTunnelChange(tray.onTunnelChange)
 	tray.tunnelsChangedCB = manager.IPCClientRegisterTunnelsChange(tray.onTunnelsChange)
 	tray.onTunnelsChange()
 	globalState, _ := manager.IPCClientGlobalState()
 	tray.updateGlobalState(globalState)
 
+	tray.updateTicker = time.NewTicker(time.Second * 2)
+	tray.stopSampling = make(chan struct{})
+	go tray.sampleTransferRates(tray.updateTicker, tray.stopSampling)
+
 	return nil
 }
 
+// sampleTransferRates periodically sums the transfer counters of all active tunnels, in order to show rates in the
+// tooltip, until stop is closed.
+func (tray *Tray) sampleTransferRates(ticker *time.Ticker, stop chan struct{}) {
+	var lastRx, lastTx conf.Bytes
+	var lastSample time.Time
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if manager.TunnelState(atomic.LoadInt32(&tray.sampleState)) != manager.TunnelStarted {
+			lastSample = time.Time{}
+			continue
+		}
+		states, err := manager.IPCClientTunnelStates()
+		if err != nil {
+			continue
+		}
+		transfers, err := manager.IPCClientTunnelTransfers()
+		if err != nil {
+			continue
+		}
+		var names []string
+		for name, state := range states {
+			if state == manager.TunnelStarted {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		var rx, tx conf.Bytes
+		for _, transfer := range transfers {
+			rx += transfer.Rx
+			tx += transfer.Tx
+		}
+		now := time.Now()
+		var rxRate, txRate conf.Bytes
+		if !lastSample.IsZero() && rx >= lastRx && tx >= lastTx {
+			seconds := now.Sub(lastSample).Seconds()
+			rxRate = conf.Bytes(float64(rx-lastRx) / seconds)
+			txRate = conf.Bytes(float64(tx-lastTx) / seconds)
+		}
+		lastRx, lastTx, lastSample = rx, tx, now
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		tray.mtw.Synchronize(func() {
+			tray.activeNames, tray.rxRate, tray.txRate = names, rxRate, txRate
+			tray.updateToolTip()
+		})
+	}
+}
+
+func (tray *Tray) updateToolTip() {
+	var toolTip string
+	if tray.errored {
+		toolTip = l18n.Sprintf("WireGuard: %s", l18n.Sprintf("Error"))
+	} else {
+		toolTip = l18n.Sprintf("WireGuard: %s", textForState(tray.globalState, true))
+	}
+	if tray.globalState == manager.TunnelStarted && len(tray.activeNames) > 0 {
+		toolTip += "\n" + strings.Join(tray.activeNames, l18n.EnumerationSeparator())
+		toolTip += "\n" + l18n.Sprintf("↓ %s/s ↑ %s/s", tray.rxRate.String(), tray.txRate.String())
+	}
+	// The notification area truncates tooltips at 127 characters, but does so without regard for surrogate pairs.
+	if runes := []rune(toolTip); len(runes) > 120 {
+		toolTip = string(runes[:119]) + "…"
+	}
+	tray.SetToolTip(toolTip)
+}
+
+func (tray *Tray) updateIcon() {
+	var icon walk.Image
+	var err error
+	if tray.errored {
+		icon, err = iconWithErrorOverlay(16, trayLogo())
+	} else {
+		icon, err = iconWithOverlayForStateAndLogo(tray.globalState, 16, trayLogo())
+	}
+	if err == nil {
+		tray.SetIcon(icon)
+	}
+}
+
 func (tray *Tray) Dispose() error {
+	if tray.quietTimer != nil {
+		tray.quietTimer.Stop()
+		tray.quietTimer = nil
+	}
+	if tray.updateTicker != nil {
+		tray.updateTicker.Stop()
+		tray.updateTicker = nil
+	}
+	if tray.stopSampling != nil {
+		close(tray.stopSampling)
+		tray.stopSampling = nil
+	}
 	if tray.tunnelChangedCB != nil {
 		tray.tunnelChangedCB.Unregister()
 		tray.tunnelChangedCB = nil
@@ -129,7 +253,24 @@ func (tray *Tray) onTunnelsChange() {
 	if err != nil {
 		return
 	}
+	options, err := manager.IPCClientAllTunnelOptions()
+	if err != nil {
+		options = nil
+	}
 	tray.mtw.Synchronize(func() {
+		favorites := make(map[string]bool, len(options))
+		for name, tunnelOptions := range options {
+			if tunnelOptions.Favorite {
+				favorites[name] = true
+			}
+		}
+		if !reflect.DeepEqual(favorites, tray.favorites) {
+			// Rather than shuffling actions around, rebuild the tunnel entries in their new order.
+			for trayTunnel := range tray.tunnels {
+				tray.removeTunnelAction(trayTunnel)
+			}
+			tray.favorites = favorites
+		}
 		tunnelSet := make(map[string]bool, len(tunnels))
 		for _, tunnel := range tunnels {
 			tunnelSet[tunnel.Name] = true
@@ -151,6 +292,9 @@ func (tray *Tray) sortedTunnels() []string {
 		names = append(names, name)
 	}
 	sort.SliceStable(names, func(i, j int) bool {
+		if tray.favorites[names[i]] != tray.favorites[names[j]] {
+			return tray.favorites[names[i]]
+		}
 		return conf.TunnelNameIsLess(names[i], names[j])
 	})
 	return names
@@ -165,6 +309,18 @@ func (tray *Tray) addTunnelAction(tunnel *manager.Tunnel) {
 	tunnelAction.Triggered().Attach(func() {
 		tunnelAction.SetChecked(!tunnelAction.Checked())
 		go func() {
+			if !confirmActivation(tray.mtw, &tclosure) {
+				tray.mtw.Synchronize(func() {
+					tunnelAction.SetChecked(false)
+				})
+				return
+			}
+			if !confirmDeactivation(tray.mtw, &tclosure) {
+				tray.mtw.Synchronize(func() {
+					tunnelAction.SetChecked(true)
+				})
+				return
+			}
 			oldState, err := tclosure.Toggle()
 			if err != nil {
 				tray.mtw.Synchronize(func() {
@@ -254,6 +410,11 @@ func (tray *Tray) rebalanceTunnelsMenu() {
 
 func (tray *Tray) onTunnelChange(tunnel *manager.Tunnel, state manager.TunnelState, globalState manager.TunnelState, err error) {
 	tray.mtw.Synchronize(func() {
+		if err != nil {
+			tray.errored = true
+		} else if state == manager.TunnelStarting || state == manager.TunnelStarted {
+			tray.errored = false
+		}
 		tray.updateGlobalState(globalState)
 		if err == nil {
 			tunnelAction := tray.tunnels[tunnel.Name]
@@ -261,19 +422,19 @@ func (tray *Tray) onTunnelChange(tunnel *manager.Tunnel, state manager.TunnelSta
 				wasChecked := tunnelAction.Checked()
 				switch state {
 				case manager.TunnelStarted:
-					if !wasChecked {
+					if !wasChecked && currentSettings.TunnelNotifications && !tray.quiet() {
 						icon, _ := iconWithOverlayForState(state, 128)
 						tray.ShowCustom(l18n.Sprintf("WireGuard Activated"), l18n.Sprintf("The %s tunnel has been activated.", tunnel.Name), icon)
 					}
 
 				case manager.TunnelStopped:
-					if wasChecked {
+					if wasChecked && currentSettings.TunnelNotifications && !tray.quiet() {
 						icon, _ := loadSystemIcon("imageres", -31, 128) // TODO: this icon isn't very good...
 						tray.ShowCustom(l18n.Sprintf("WireGuard Deactivated"), l18n.Sprintf("The %s tunnel has been deactivated.", tunnel.Name), icon)
 					}
 				}
 			}
-		} else if !tray.mtw.Visible() {
+		} else if !tray.mtw.Visible() && currentSettings.ErrorNotifications && !tray.quiet() {
 			tray.ShowError(l18n.Sprintf("WireGuard Tunnel Error"), err.Error())
 		}
 		tray.setTunnelState(tunnel, state)
@@ -281,14 +442,14 @@ func (tray *Tray) onTunnelChange(tunnel *manager.Tunnel, state manager.TunnelSta
 }
 
 func (tray *Tray) updateGlobalState(globalState manager.TunnelState) {
-	if icon, err := iconWithOverlayForState(globalState, 16); err == nil {
-		tray.SetIcon(icon)
-	}
+	tray.globalState = globalState
+	atomic.StoreInt32(&tray.sampleState, int32(globalState))
+	tray.updateIcon()
+	tray.updateToolTip()
 
 	actions := tray.ContextMenu().Actions()
 	statusAction := actions.At(0)
 
-	tray.SetToolTip(l18n.Sprintf("WireGuard: %s", textForState(globalState, true)))
 	stateText := textForState(globalState, false)
 	stateIcon, err := iconForState(globalState, 16)
 	if err == nil {
@@ -355,9 +516,12 @@ func (tray *Tray) UpdateFound() {
 	}
 	action.Triggered().Attach(showUpdateTab)
 	tray.clicked = showUpdateTab
-	tray.ContextMenu().Actions().Insert(tray.ContextMenu().Actions().Len()-2, action)
+	tray.ContextMenu().Actions().Insert(tray.ContextMenu().Actions().Len()-3, action)
 
 	showUpdateBalloon := func() {
+		if !currentSettings.UpdateNotifications || tray.quiet() {
+			return
+		}
 		icon, _ := loadSystemIcon("imageres", 1, 128)
 		tray.ShowCustom(l18n.Sprintf("WireGuard Update Available"), l18n.Sprintf("An update to WireGuard is now available. You are advised to update as soon as possible."), icon)
 	}
@@ -372,7 +536,87 @@ func (tray *Tray) UpdateFound() {
 	}
 }
 
+// addQuietMenu adds the do not disturb submenu right after the import item, ahead of the preferences.
+func (tray *Tray) addQuietMenu() error {
+	menu, err := walk.NewMenu()
+	if err != nil {
+		return err
+	}
+	for _, item := range []struct {
+		label    string
+		duration time.Duration
+	}{
+		{l18n.Sprintf("For &1 hour"), time.Hour},
+		{l18n.Sprintf("For &4 hours"), time.Hour * 4},
+		{l18n.Sprintf("&Until turned off"), 0},
+	} {
+		duration := item.duration
+		action := walk.NewAction()
+		action.SetText(item.label)
+		action.Triggered().Attach(func() {
+			if duration == 0 {
+				tray.setQuietUntil(time.Unix(1<<62, 0))
+			} else {
+				tray.setQuietUntil(time.Now().Add(duration))
+			}
+		})
+		menu.Actions().Add(action)
+	}
+	menu.Actions().Add(walk.NewSeparatorAction())
+	tray.quietOffItem = walk.NewAction()
+	tray.quietOffItem.SetText(l18n.Sprintf("&Turn off"))
+	tray.quietOffItem.Triggered().Attach(func() {
+		tray.setQuietUntil(time.Time{})
+	})
+	menu.Actions().Add(tray.quietOffItem)
+
+	tray.quietAction = walk.NewMenuAction(menu)
+	actions := tray.ContextMenu().Actions()
+	err = actions.Insert(actions.Len()-4, tray.quietAction)
+	if err != nil {
+		return err
+	}
+	tray.setQuietUntil(time.Time{})
+	return nil
+}
+
+// quiet returns whether balloons are currently suppressed. The events themselves are still recorded in the log by
+// the manager and tunnel services.
+func (tray *Tray) quiet() bool {
+	return !tray.quietUntil.IsZero() && time.Now().Before(tray.quietUntil)
+}
+
+func (tray *Tray) setQuietUntil(until time.Time) {
+	if tray.quietTimer != nil {
+		tray.quietTimer.Stop()
+		tray.quietTimer = nil
+	}
+	tray.quietUntil = until
+	switch {
+	case until.IsZero():
+		tray.quietAction.SetText(l18n.Sprintf("&Do not disturb"))
+	case until.Year() > 9999:
+		tray.quietAction.SetText(l18n.Sprintf("&Do not disturb (on)"))
+	default:
+		tray.quietAction.SetText(l18n.Sprintf("&Do not disturb (until %s)", until.Format("15:04")))
+		tray.quietTimer = time.AfterFunc(time.Until(until), func() {
+			tray.mtw.Synchronize(func() {
+				if !tray.quiet() {
+					tray.setQuietUntil(time.Time{})
+				}
+			})
+		})
+	}
+	tray.quietAction.SetChecked(!until.IsZero())
+	tray.quietOffItem.SetEnabled(!until.IsZero())
+}
+
 func (tray *Tray) onManageTunnels() {
+	if tray.errored {
+		tray.errored = false
+		tray.updateIcon()
+		tray.updateToolTip()
+	}
 	tray.mtw.tunnelsPage.listView.SelectFirstActiveTunnel()
 	tray.mtw.tabs.SetCurrentIndex(0)
 	raise(tray.mtw.Handle())
@@ -386,6 +630,22 @@ func (tray *Tray) onAbout() {
 	}
 }
 
+func (tray *Tray) onPreferences() {
+	if tray.mtw.Visible() {
+		onPreferences(tray.mtw)
+	} else {
+		onPreferences(nil)
+	}
+}
+
+func (tray *Tray) onManageRemote() {
+	if tray.mtw.Visible() {
+		onManageRemote(tray.mtw)
+	} else {
+		onManageRemote(nil)
+	}
+}
+
 func (tray *Tray) onImport() {
 	raise(tray.mtw.Handle())
 	tray.mtw.tunnelsPage.onImport()
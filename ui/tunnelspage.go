@@ -26,9 +26,12 @@ type TunnelsPage struct {
 	*walk.TabPage
 
 	listView      *ListView
-	listContainer walk.Container
+	listContainer *walk.Composite
+	listWidth     int // The width of the list to keep until the page is first laid out, or zero
 	listToolbar   *walk.ToolBar
+	detailTabs    *walk.TabWidget
 	confView      *ConfView
+	appliedView   *AppliedStateView
 	fillerButton  *walk.PushButton
 	fillerHandler func()
 
@@ -50,7 +53,11 @@ func NewTunnelsPage() (*TunnelsPage, error) {
 	tp.SetTitle(l18n.Sprintf("Tunnels"))
 	tp.SetLayout(walk.NewHBoxLayout())
 
-	tp.listContainer, _ = walk.NewComposite(tp)
+	splitter, err := walk.NewHSplitter(tp)
+	if err != nil {
+		return nil, err
+	}
+	tp.listContainer, _ = walk.NewComposite(splitter)
 	vlayout := walk.NewVBoxLayout()
 	vlayout.SetMargins(walk.Margins{})
 	vlayout.SetSpacing(0)
@@ -59,22 +66,34 @@ func NewTunnelsPage() (*TunnelsPage, error) {
 	if tp.listView, err = NewListView(tp.listContainer); err != nil {
 		return nil, err
 	}
+	setAutomationID(tp.listView, automationTunnelList)
+
+	// The list keeps its width when the window is resized, and the details take what is left.
+	splitter.SetFixed(tp.listContainer, true)
+	detailsContainer, err := walk.NewComposite(splitter)
+	if err != nil {
+		return nil, err
+	}
+	hlayout := walk.NewHBoxLayout()
+	hlayout.SetMargins(walk.Margins{})
+	detailsContainer.SetLayout(hlayout)
 
-	if tp.currentTunnelContainer, err = walk.NewComposite(tp); err != nil {
+	if tp.currentTunnelContainer, err = walk.NewComposite(detailsContainer); err != nil {
 		return nil, err
 	}
 	vlayout = walk.NewVBoxLayout()
 	vlayout.SetMargins(walk.Margins{})
 	tp.currentTunnelContainer.SetLayout(vlayout)
 
-	if tp.fillerContainer, err = walk.NewComposite(tp); err != nil {
+	if tp.fillerContainer, err = walk.NewComposite(detailsContainer); err != nil {
 		return nil, err
 	}
 	tp.fillerContainer.SetVisible(false)
-	hlayout := walk.NewHBoxLayout()
+	hlayout = walk.NewHBoxLayout()
 	hlayout.SetMargins(walk.Margins{})
 	tp.fillerContainer.SetLayout(hlayout)
 	tp.fillerButton, _ = walk.NewPushButton(tp.fillerContainer)
+	setAutomationID(tp.fillerButton, automationFillerButton)
 	tp.fillerButton.SetMinMaxSize(walk.Size{200, 0}, walk.Size{200, 0})
 	tp.fillerButton.SetVisible(IsAdmin)
 	tp.fillerButton.Clicked().Attach(func() {
@@ -83,7 +102,30 @@ func NewTunnelsPage() (*TunnelsPage, error) {
 		}
 	})
 
-	if tp.confView, err = NewConfView(tp.currentTunnelContainer); err != nil {
+	if tp.detailTabs, err = walk.NewTabWidget(tp.currentTunnelContainer); err != nil {
+		return nil, err
+	}
+	setAutomationID(tp.detailTabs, automationDetailTabs)
+	configPage, err := walk.NewTabPage()
+	if err != nil {
+		return nil, err
+	}
+	configPage.SetTitle(l18n.Sprintf("Configuration"))
+	configPage.SetLayout(walk.NewVBoxLayout())
+	configPage.Layout().SetMargins(walk.Margins{})
+	tp.detailTabs.Pages().Add(configPage)
+	if tp.confView, err = NewConfView(configPage); err != nil {
+		return nil, err
+	}
+	appliedPage, err := walk.NewTabPage()
+	if err != nil {
+		return nil, err
+	}
+	appliedPage.SetTitle(l18n.Sprintf("Applied State"))
+	appliedPage.SetLayout(walk.NewVBoxLayout())
+	appliedPage.Layout().SetMargins(walk.Margins{})
+	tp.detailTabs.Pages().Add(appliedPage)
+	if tp.appliedView, err = NewAppliedStateView(appliedPage); err != nil {
 		return nil, err
 	}
 
@@ -105,6 +147,7 @@ func NewTunnelsPage() (*TunnelsPage, error) {
 		editTunnel.SetEnabled(tp.listView.CurrentIndex() > -1)
 	})
 	editTunnel.SetText(l18n.Sprintf("&Edit"))
+	setAutomationID(editTunnel, automationEditTunnel)
 	editTunnel.Clicked().Attach(tp.onEditTunnel)
 	editTunnel.SetVisible(IsAdmin)
 
@@ -140,6 +183,7 @@ func (tp *TunnelsPage) CreateToolbar() error {
 	if tp.listToolbar, err = walk.NewToolBarWithOrientationAndButtonStyle(toolBarContainer, walk.Horizontal, walk.ToolBarButtonImageBeforeText); err != nil {
 		return err
 	}
+	setAutomationID(tp.listToolbar, automationTunnelToolbar)
 
 	addMenu, err := walk.NewMenu()
 	if err != nil {
@@ -154,6 +198,10 @@ func (tp *TunnelsPage) CreateToolbar() error {
 	importAction.SetDefault(true)
 	importAction.Triggered().Attach(tp.onImport)
 	addMenu.Actions().Add(importAction)
+	bulkImportAction := walk.NewAction()
+	bulkImportAction.SetText(l18n.Sprintf("&Bulk import tunnels…"))
+	bulkImportAction.Triggered().Attach(tp.onBulkImport)
+	addMenu.Actions().Add(bulkImportAction)
 	addAction := walk.NewAction()
 	addAction.SetText(l18n.Sprintf("Add &empty tunnel…"))
 	addActionIcon, _ := loadSystemIcon("imageres", -2, 16)
@@ -187,12 +235,8 @@ func (tp *TunnelsPage) CreateToolbar() error {
 	exportAction.Triggered().Attach(tp.onExportTunnels)
 	tp.listToolbar.Actions().Add(exportAction)
 
-	fixContainerWidthToToolbarWidth := func() {
-		toolbarWidth := tp.listToolbar.SizeHint().Width
-		tp.listContainer.SetMinMaxSizePixels(walk.Size{toolbarWidth, 0}, walk.Size{toolbarWidth, 0})
-	}
-	fixContainerWidthToToolbarWidth()
-	tp.listToolbar.SizeChanged().Attach(fixContainerWidthToToolbarWidth)
+	tp.fitListWidthToToolbar()
+	tp.listToolbar.SizeChanged().Attach(tp.fitListWidthToToolbar)
 
 	contextMenu, err := walk.NewMenu()
 	if err != nil {
@@ -212,6 +256,11 @@ func (tp *TunnelsPage) CreateToolbar() error {
 	importAction2.SetVisible(IsAdmin)
 	contextMenu.Actions().Add(importAction2)
 	tp.ShortcutActions().Add(importAction2)
+	bulkImportAction2 := walk.NewAction()
+	bulkImportAction2.SetText(l18n.Sprintf("&Bulk import tunnels…"))
+	bulkImportAction2.Triggered().Attach(tp.onBulkImport)
+	bulkImportAction2.SetVisible(IsAdmin)
+	contextMenu.Actions().Add(bulkImportAction2)
 	addAction2 := walk.NewAction()
 	addAction2.SetText(l18n.Sprintf("Add &empty tunnel…"))
 	addAction2.SetShortcut(walk.Shortcut{walk.ModControl, walk.KeyN})
@@ -232,6 +281,37 @@ func (tp *TunnelsPage) CreateToolbar() error {
 	editAction.Triggered().Attach(tp.onEditTunnel)
 	contextMenu.Actions().Add(editAction)
 	tp.ShortcutActions().Add(editAction)
+	favoriteAction := walk.NewAction()
+	favoriteAction.SetText(l18n.Sprintf("&Pin as favorite"))
+	favoriteAction.SetCheckable(true)
+	favoriteAction.SetVisible(IsAdmin)
+	favoriteAction.Triggered().Attach(tp.onToggleFavorite)
+	contextMenu.Actions().Add(favoriteAction)
+	addLikeAction := walk.NewAction()
+	addLikeAction.SetText(l18n.Sprintf("Add tunnel &like selected…"))
+	addLikeAction.SetVisible(IsAdmin)
+	addLikeAction.Triggered().Attach(tp.onAddTunnelLikeSelected)
+	contextMenu.Actions().Add(addLikeAction)
+	enrollmentSheetAction := walk.NewAction()
+	enrollmentSheetAction.SetText(l18n.Sprintf("Show e&nrollment sheet…"))
+	enrollmentSheetAction.SetVisible(IsAdmin)
+	enrollmentSheetAction.Triggered().Attach(tp.onEnrollmentSheet)
+	contextMenu.Actions().Add(enrollmentSheetAction)
+	copyRedactedAction := walk.NewAction()
+	copyRedactedAction.SetText(l18n.Sprintf("&Copy configuration for sharing"))
+	copyRedactedAction.SetVisible(IsAdmin)
+	copyRedactedAction.Triggered().Attach(func() { tp.onCopyRedacted(false) })
+	contextMenu.Actions().Add(copyRedactedAction)
+	copyMaskedAction := walk.NewAction()
+	copyMaskedAction.SetText(l18n.Sprintf("Copy configuration for sharing, &hiding endpoints"))
+	copyMaskedAction.SetVisible(IsAdmin)
+	copyMaskedAction.Triggered().Attach(func() { tp.onCopyRedacted(true) })
+	contextMenu.Actions().Add(copyMaskedAction)
+	selfTestAction := walk.NewAction()
+	selfTestAction.SetText(l18n.Sprintf("Run connectivity &self-test"))
+	selfTestAction.SetVisible(IsAdmin)
+	selfTestAction.Triggered().Attach(tp.onSelfTest)
+	contextMenu.Actions().Add(selfTestAction)
 	deleteAction2 := walk.NewAction()
 	deleteAction2.SetText(l18n.Sprintf("&Remove selected tunnel(s)"))
 	deleteAction2.SetShortcut(walk.Shortcut{0, walk.KeyDelete})
@@ -246,6 +326,43 @@ func (tp *TunnelsPage) CreateToolbar() error {
 	selectAllAction.Triggered().Attach(tp.onSelectAll)
 	contextMenu.Actions().Add(selectAllAction)
 	tp.listView.ShortcutActions().Add(selectAllAction)
+	contextMenu.Actions().Add(walk.NewSeparatorAction())
+	sortMenu, err := walk.NewMenu()
+	if err != nil {
+		return err
+	}
+	sortAction, err := contextMenu.Actions().AddMenu(sortMenu)
+	if err != nil {
+		return err
+	}
+	sortAction.SetText(l18n.Sprintf("S&ort by"))
+	sortOrders := []struct {
+		order tunnelSortOrder
+		text  string
+	}{
+		{sortByName, l18n.Sprintf("&Name")},
+		{sortRecentFirst, l18n.Sprintf("Name, with &recent tunnels first")},
+		{sortByLastUsed, l18n.Sprintf("&Last used")},
+	}
+	sortOrderActions := make([]*walk.Action, len(sortOrders))
+	for i := range sortOrders {
+		order := sortOrders[i].order
+		action := walk.NewAction()
+		action.SetText(sortOrders[i].text)
+		action.SetCheckable(true)
+		action.SetChecked(order == tp.listView.SortOrder())
+		action.Triggered().Attach(func() {
+			tp.listView.SetSortOrder(order)
+			saveSortOrder(order)
+		})
+		sortMenu.Actions().Add(action)
+		sortOrderActions[i] = action
+	}
+	tp.listView.sortOrderChanged = func(order tunnelSortOrder) {
+		for i := range sortOrders {
+			sortOrderActions[i].SetChecked(sortOrders[i].order == order)
+		}
+	}
 	tp.listView.SetContextMenu(contextMenu)
 
 	setSelectionOrientedOptions := func() {
@@ -256,6 +373,14 @@ func (tp *TunnelsPage) CreateToolbar() error {
 		toggleAction.SetEnabled(selected == 1)
 		selectAllAction.SetEnabled(selected < all)
 		editAction.SetEnabled(selected == 1)
+		favoriteAction.SetEnabled(selected == 1)
+		if tunnel := tp.listView.CurrentTunnel(); tunnel != nil && selected == 1 {
+			favoriteAction.SetChecked(tp.listView.IsFavorite(tunnel.Name))
+		}
+		addLikeAction.SetEnabled(selected == 1)
+		enrollmentSheetAction.SetEnabled(selected == 1)
+		copyRedactedAction.SetEnabled(selected == 1)
+		copyMaskedAction.SetEnabled(selected == 1)
 	}
 	tp.listView.SelectedIndexesChanged().Attach(setSelectionOrientedOptions)
 	setSelectionOrientedOptions()
@@ -275,64 +400,68 @@ func (tp *TunnelsPage) CreateToolbar() error {
 
 func (tp *TunnelsPage) updateConfView() {
 	tp.confView.SetTunnel(tp.listView.CurrentTunnel())
+	tp.appliedView.SetTunnel(tp.listView.CurrentTunnel())
 }
 
-func (tp *TunnelsPage) importFiles(paths []string) {
-	go func() {
-		syncedMsgBox := func(title string, message string, flags walk.MsgBoxStyle) {
-			tp.Synchronize(func() {
-				walk.MsgBox(tp.Form(), title, message, flags)
-			})
-		}
-		type unparsedConfig struct {
-			Name   string
-			Config string
-		}
+type unparsedConfig struct {
+	Name   string
+	Config string
+	Source string
+}
 
-		var (
-			unparsedConfigs []unparsedConfig
-			lastErr         error
-		)
+// readUnparsedConfigs reads every .conf file in paths, as well as every .conf file inside of each .zip file in paths.
+// If some could not be read, the last encountered error is returned along with those that could.
+func readUnparsedConfigs(paths []string) (unparsedConfigs []unparsedConfig, lastErr error) {
+	for _, path := range paths {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".conf":
+			textConfig, err := ioutil.ReadFile(path)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			unparsedConfigs = append(unparsedConfigs, unparsedConfig{Name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), Config: string(textConfig), Source: filepath.Base(path)})
+		case ".zip":
+			// 1 .conf + 1 error .zip edge case?
+			r, err := zip.OpenReader(path)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+
+			for _, f := range r.File {
+				if strings.ToLower(filepath.Ext(f.Name)) != ".conf" {
+					continue
+				}
 
-		for _, path := range paths {
-			switch strings.ToLower(filepath.Ext(path)) {
-			case ".conf":
-				textConfig, err := ioutil.ReadFile(path)
+				rc, err := f.Open()
 				if err != nil {
 					lastErr = err
 					continue
 				}
-				unparsedConfigs = append(unparsedConfigs, unparsedConfig{Name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), Config: string(textConfig)})
-			case ".zip":
-				// 1 .conf + 1 error .zip edge case?
-				r, err := zip.OpenReader(path)
+				textConfig, err := ioutil.ReadAll(rc)
+				rc.Close()
 				if err != nil {
 					lastErr = err
 					continue
 				}
+				unparsedConfigs = append(unparsedConfigs, unparsedConfig{Name: strings.TrimSuffix(filepath.Base(f.Name), filepath.Ext(f.Name)), Config: string(textConfig), Source: filepath.Base(path) + ": " + f.Name})
+			}
 
-				for _, f := range r.File {
-					if strings.ToLower(filepath.Ext(f.Name)) != ".conf" {
-						continue
-					}
-
-					rc, err := f.Open()
-					if err != nil {
-						lastErr = err
-						continue
-					}
-					textConfig, err := ioutil.ReadAll(rc)
-					rc.Close()
-					if err != nil {
-						lastErr = err
-						continue
-					}
-					unparsedConfigs = append(unparsedConfigs, unparsedConfig{Name: strings.TrimSuffix(filepath.Base(f.Name), filepath.Ext(f.Name)), Config: string(textConfig)})
-				}
+			r.Close()
+		}
+	}
+	return
+}
 
-				r.Close()
-			}
+func (tp *TunnelsPage) importFiles(paths []string) {
+	go func() {
+		syncedMsgBox := func(title string, message string, flags walk.MsgBoxStyle) {
+			tp.Synchronize(func() {
+				walk.MsgBox(tp.Form(), title, message, flags)
+			})
 		}
+		unparsedConfigs, lastErr := readUnparsedConfigs(paths)
 
 		if lastErr != nil || unparsedConfigs == nil {
 			if lastErr == nil {
@@ -352,24 +481,28 @@ func (tp *TunnelsPage) importFiles(paths []string) {
 			syncedMsgBox(l18n.Sprintf("Error"), l18n.Sprintf("Could not enumerate existing tunnels: %v", lastErr), walk.MsgBoxIconWarning)
 			return
 		}
-		existingLowerTunnels := make(map[string]bool, len(existingTunnelList))
+		existingLowerTunnels := make(map[string]manager.Tunnel, len(existingTunnelList))
 		for _, tunnel := range existingTunnelList {
-			existingLowerTunnels[strings.ToLower(tunnel.Name)] = true
+			existingLowerTunnels[strings.ToLower(tunnel.Name)] = tunnel
 		}
 
 		configCount := 0
 		tp.listView.SetSuspendTunnelsUpdate(true)
 		for _, unparsedConfig := range unparsedConfigs {
-			if existingLowerTunnels[strings.ToLower(unparsedConfig.Name)] {
-				lastErr = errors.New(l18n.Sprintf("Another tunnel already exists with the name ‘%s’", unparsedConfig.Name))
-				continue
-			}
 			config, err := conf.FromWgQuickWithUnknownEncoding(unparsedConfig.Config, unparsedConfig.Name)
 			if err != nil {
 				lastErr = err
 				continue
 			}
-			_, err = manager.IPCClientNewTunnel(config)
+			if existing, ok := existingLowerTunnels[strings.ToLower(unparsedConfig.Name)]; ok {
+				if !tp.confirmImportOverwrite(existing.Name) {
+					lastErr = errors.New(l18n.Sprintf("Another tunnel already exists with the name ‘%s’", unparsedConfig.Name))
+					continue
+				}
+				err = replaceTunnel(&existing, config)
+			} else {
+				_, err = manager.IPCClientNewTunnel(config)
+			}
 			if err != nil {
 				lastErr = err
 				continue
@@ -385,13 +518,55 @@ func (tp *TunnelsPage) importFiles(paths []string) {
 		case n == 1 && m == n:
 			// nothing
 		case m == n:
-			syncedMsgBox(l18n.Sprintf("Imported tunnels"), l18n.Sprintf("Imported %d tunnels", m), walk.MsgBoxIconInformation)
+			syncedMsgBox(l18n.Sprintf("Imported tunnels"), l18n.SprintfPlural(m, "Imported %d tunnel", "Imported %d tunnels", m), walk.MsgBoxIconInformation)
 		case m != n:
-			syncedMsgBox(l18n.Sprintf("Imported tunnels"), l18n.Sprintf("Imported %d of %d tunnels", m, n), walk.MsgBoxIconWarning)
+			syncedMsgBox(l18n.Sprintf("Imported tunnels"), l18n.SprintfPlural(n, "Imported %d of %d tunnel", "Imported %d of %d tunnels", m, n), walk.MsgBoxIconWarning)
 		}
 	}()
 }
 
+// confirmImportOverwrite asks whether an imported configuration may replace the existing tunnel of the same name,
+// when so configured. It must not be called from the UI thread.
+func (tp *TunnelsPage) confirmImportOverwrite(tunnelName string) bool {
+	if !confirmImportOverwriteRequired() {
+		return true
+	}
+	proceed := make(chan bool)
+	tp.Synchronize(func() {
+		proceed <- walk.MsgBox(tp.Form(), l18n.Sprintf("Replace tunnel ‘%s’", tunnelName), l18n.Sprintf("A tunnel named ‘%s’ already exists. Would you like to replace it with the imported configuration? You cannot undo this action.", tunnelName), walk.MsgBoxYesNo|walk.MsgBoxIconWarning) == walk.DlgCmdYes
+	})
+	return <-proceed
+}
+
+// replaceTunnel swaps the configuration of an existing tunnel, reactivating it afterwards if it was active.
+func replaceTunnel(tunnel *manager.Tunnel, config *conf.Config) error {
+	priorState, err := tunnel.State()
+	if err != nil {
+		return err
+	}
+	options, err := tunnel.Options()
+	if err != nil {
+		return err
+	}
+	err = tunnel.Delete()
+	if err != nil {
+		return err
+	}
+	tunnel.WaitForStop()
+	newTunnel, err := manager.IPCClientNewTunnel(config)
+	if err != nil {
+		return err
+	}
+	err = newTunnel.SetOptions(options)
+	if err != nil {
+		return err
+	}
+	if priorState == manager.TunnelStarting || priorState == manager.TunnelStarted {
+		return newTunnel.Start()
+	}
+	return nil
+}
+
 func (tp *TunnelsPage) exportTunnels(filePath string) {
 	writeFileWithOverwriteHandling(tp.Form(), filePath, func(file *os.File) error {
 		writer := zip.NewWriter(file)
@@ -416,12 +591,14 @@ func (tp *TunnelsPage) exportTunnels(filePath string) {
 	})
 }
 
-func (tp *TunnelsPage) addTunnel(config *conf.Config) {
-	_, err := manager.IPCClientNewTunnel(config)
+func (tp *TunnelsPage) addTunnel(config *conf.Config, options *conf.TunnelOptions) {
+	tunnel, err := manager.IPCClientNewTunnel(config)
+	if err == nil {
+		err = tunnel.SetOptions(options)
+	}
 	if err != nil {
 		showErrorCustom(tp.Form(), l18n.Sprintf("Unable to create tunnel"), err.Error())
 	}
-
 }
 
 // Handlers
@@ -432,7 +609,11 @@ func (tp *TunnelsPage) onTunnelsViewItemActivated() {
 		if err != nil || (globalState != manager.TunnelStarted && globalState != manager.TunnelStopped) {
 			return
 		}
-		oldState, err := tp.listView.CurrentTunnel().Toggle()
+		tunnel := tp.listView.CurrentTunnel()
+		if tunnel == nil || !confirmActivation(tp.Form(), tunnel) || !confirmDeactivation(tp.Form(), tunnel) {
+			return
+		}
+		oldState, err := tunnel.Toggle()
 		if err != nil {
 			tp.Synchronize(func() {
 				if oldState == manager.TunnelUnknown {
@@ -454,12 +635,24 @@ func (tp *TunnelsPage) onEditTunnel() {
 		return
 	}
 
-	if config := runEditDialog(tp.Form(), tunnel); config != nil {
+	if !reauthenticate(tp.Form(), l18n.Sprintf("Enter your credentials to view and edit the private key of tunnel ‘%s’.", tunnel.Name)) {
+		return
+	}
+
+	if config, options := runEditDialog(tp.Form(), tunnel); config != nil {
 		go func() {
 			priorState, err := tunnel.State()
-			tunnel.Delete()
+			if config.Name == tunnel.Name {
+				// Deleting the tunnel would delete its log, which is kept when only its configuration changes.
+				tunnel.Stop()
+			} else {
+				tunnel.Delete()
+			}
 			tunnel.WaitForStop()
 			tunnel, err2 := manager.IPCClientNewTunnel(config)
+			if err2 == nil {
+				err2 = tunnel.SetOptions(options)
+			}
 			if err == nil && err2 == nil && (priorState == manager.TunnelStarting || priorState == manager.TunnelStarted) {
 				tunnel.Start()
 			}
@@ -467,10 +660,110 @@ func (tp *TunnelsPage) onEditTunnel() {
 	}
 }
 
+func (tp *TunnelsPage) onToggleFavorite() {
+	tunnel := tp.listView.CurrentTunnel()
+	if tunnel == nil {
+		return
+	}
+	favorite := !tp.listView.IsFavorite(tunnel.Name)
+	tunnelCopy := *tunnel
+	go func() {
+		options, err := tunnelCopy.Options()
+		if err == nil {
+			options.Favorite = favorite
+			err = tunnelCopy.SetOptions(options)
+		}
+		if err != nil {
+			tp.Synchronize(func() {
+				showErrorCustom(tp.Form(), l18n.Sprintf("Unable to pin tunnel"), err.Error())
+			})
+		}
+	}()
+}
+
+func (tp *TunnelsPage) onAddTunnelLikeSelected() {
+	tunnel := tp.listView.CurrentTunnel()
+	if tunnel == nil {
+		return
+	}
+
+	template, err := tunnel.StoredConfig()
+	if err != nil {
+		showErrorCustom(tp.Form(), l18n.Sprintf("Unable to load tunnel"), err.Error())
+		return
+	}
+	if config, options := runNewTunnelDialog(tp.Form(), &template); config != nil {
+		tp.addTunnel(config, options)
+	}
+}
+
+func (tp *TunnelsPage) onEnrollmentSheet() {
+	tunnel := tp.listView.CurrentTunnel()
+	if tunnel == nil {
+		return
+	}
+
+	if !reauthenticate(tp.Form(), l18n.Sprintf("Enter your credentials to reveal the private key of tunnel ‘%s’ in an enrollment sheet.", tunnel.Name)) {
+		return
+	}
+
+	config, err := tunnel.StoredConfig()
+	if err != nil {
+		showErrorCustom(tp.Form(), l18n.Sprintf("Unable to load tunnel"), err.Error())
+		return
+	}
+	showError(runEnrollmentSheetDialog(tp.Form(), &config), tp.Form())
+}
+
+// onCopyRedacted copies the configuration of the selected tunnel, without its private and preshared keys, and, if
+// maskEndpoints is true, without the hosts of its endpoints, for pasting into bug reports.
+func (tp *TunnelsPage) onCopyRedacted(maskEndpoints bool) {
+	tunnel := tp.listView.CurrentTunnel()
+	if tunnel == nil {
+		return
+	}
+
+	config, err := tunnel.StoredConfig()
+	if err != nil {
+		showErrorCustom(tp.Form(), l18n.Sprintf("Unable to load tunnel"), err.Error())
+		return
+	}
+	showError(walk.Clipboard().SetText(config.ExportRedacted(maskEndpoints)), tp.Form())
+}
+
+// onSelfTest has the manager run the self-test, checking the peers of the selected tunnel too if it is active, and
+// shows the report, which is also copied to the clipboard, so that it can be pasted into a request for help.
+func (tp *TunnelsPage) onSelfTest() {
+	tunnelName := ""
+	if tunnel := tp.listView.CurrentTunnel(); tunnel != nil && len(tp.listView.SelectedIndexes()) == 1 {
+		if state, err := tunnel.State(); err == nil && state == manager.TunnelStarted {
+			tunnelName = tunnel.Name
+		}
+	}
+	form := tp.Form()
+	go func() {
+		report, err := manager.IPCClientSelfTest(tunnelName)
+		form.Synchronize(func() {
+			if err != nil {
+				showErrorCustom(form, l18n.Sprintf("Unable to run self-test"), err.Error())
+				return
+			}
+			var text strings.Builder
+			report.WriteText(&text)
+			walk.Clipboard().SetText(text.String())
+			title, icon := l18n.Sprintf("Self-test passed"), walk.MsgBoxIconInformation
+			if !report.Passed() {
+				title, icon = l18n.Sprintf("Self-test failed"), walk.MsgBoxIconWarning
+			}
+			walk.MsgBox(form, title, l18n.Sprintf("%s\nThe report has been copied to the clipboard.", text.String()), icon)
+		})
+	}()
+}
+
 func (tp *TunnelsPage) onAddTunnel() {
-	if config := runEditDialog(tp.Form(), nil); config != nil {
+	if config, options := runEditDialog(tp.Form(), nil); config != nil {
 		// Save new
-		tp.addTunnel(config)
+		tp.addTunnel(config, options)
 	}
 }
 
@@ -483,20 +776,23 @@ func (tp *TunnelsPage) onDelete() {
 	var title, question string
 	if len(indices) > 1 {
 		tunnelCount := len(indices)
-		title = l18n.Sprintf("Delete %d tunnels", tunnelCount)
-		question = l18n.Sprintf("Are you sure you would like to delete %d tunnels?", tunnelCount)
+		title = l18n.SprintfPlural(tunnelCount, "Delete %d tunnel", "Delete %d tunnels", tunnelCount)
+		question = l18n.SprintfPlural(tunnelCount, "Are you sure you would like to delete %d tunnel?", "Are you sure you would like to delete %d tunnels?", tunnelCount)
 	} else {
 		tunnelName := tp.listView.model.tunnels[indices[0]].Name
 		title = l18n.Sprintf("Delete tunnel ‘%s’", tunnelName)
 		question = l18n.Sprintf("Are you sure you would like to delete tunnel ‘%s’?", tunnelName)
 	}
-	if walk.DlgCmdNo == walk.MsgBox(
+	if confirmDeleteRequired() && walk.DlgCmdNo == walk.MsgBox(
 		tp.Form(),
 		title,
 		l18n.Sprintf("%s You cannot undo this action.", question),
 		walk.MsgBoxYesNo|walk.MsgBoxIconWarning) {
 		return
 	}
+	if !reauthenticate(tp.Form(), l18n.Sprintf("Enter your credentials to delete tunnels.")) {
+		return
+	}
 
 	selectTunnelAfter := ""
 	if len(indices) < len(tp.listView.model.tunnels) {
@@ -535,7 +831,7 @@ func (tp *TunnelsPage) onDelete() {
 				if len(errors) == 1 {
 					showErrorCustom(tp.Form(), l18n.Sprintf("Unable to delete tunnel"), l18n.Sprintf("A tunnel was unable to be removed: %s", errors[0].Error()))
 				} else {
-					showErrorCustom(tp.Form(), l18n.Sprintf("Unable to delete tunnels"), l18n.Sprintf("%d tunnels were unable to be removed.", len(errors)))
+					showErrorCustom(tp.Form(), l18n.Sprintf("Unable to delete tunnels"), l18n.SprintfPlural(len(errors), "%d tunnel was unable to be removed.", "%d tunnels were unable to be removed.", len(errors)))
 				}
 			})
 		}
@@ -559,7 +855,24 @@ func (tp *TunnelsPage) onImport() {
 	tp.importFiles(dlg.FilePaths)
 }
 
+func (tp *TunnelsPage) onBulkImport() {
+	dlg := walk.FileDialog{
+		Filter: l18n.Sprintf("Configuration Files (*.zip, *.conf)|*.zip;*.conf|All Files (*.*)|*.*"),
+		Title:  l18n.Sprintf("Bulk import tunnels from files"),
+	}
+
+	if ok, _ := dlg.ShowOpenMultiple(tp.Form()); !ok {
+		return
+	}
+
+	runImportWizard(tp.Form(), dlg.FilePaths)
+}
+
 func (tp *TunnelsPage) onExportTunnels() {
+	if !reauthenticate(tp.Form(), l18n.Sprintf("Enter your credentials to export tunnels.")) {
+		return
+	}
+
 	dlg := walk.FileDialog{
 		Filter: l18n.Sprintf("Configuration ZIP Files (*.zip)|*.zip"),
 		Title:  l18n.Sprintf("Export tunnels to zip"),
@@ -576,6 +889,32 @@ func (tp *TunnelsPage) onExportTunnels() {
 	tp.exportTunnels(dlg.FilePath)
 }
 
+// fitListWidthToToolbar keeps the list at least as wide as its toolbar, while letting the splitter widen it.
+func (tp *TunnelsPage) fitListWidthToToolbar() {
+	if tp.listWidth > 0 {
+		return
+	}
+	toolbarWidth := tp.listToolbar.SizeHint().Width
+	tp.listContainer.SetMinMaxSizePixels(walk.Size{toolbarWidth, 0}, walk.Size{})
+}
+
+// setListWidth has the list laid out at the width, which is in 1/96" units, until releaseListWidth is called.
+func (tp *TunnelsPage) setListWidth(width int) {
+	if walk.IntFrom96DPI(width, tp.DPI()) < tp.listToolbar.SizeHint().Width {
+		return
+	}
+	tp.listWidth = width
+	tp.listContainer.SetMinMaxSize(walk.Size{width, 0}, walk.Size{width, 0})
+}
+
+func (tp *TunnelsPage) releaseListWidth() {
+	if tp.listWidth == 0 {
+		return
+	}
+	tp.listWidth = 0
+	tp.fitListWidthToToolbar()
+}
+
 func (tp *TunnelsPage) swapFiller(enabled bool) bool {
 	if tp.fillerContainer.Visible() == enabled {
 		return enabled
@@ -601,7 +940,7 @@ func (tp *TunnelsPage) onSelectedTunnelsChanged() {
 	indices := tp.listView.SelectedIndexes()
 	tunnelCount := len(indices)
 	if tp.swapFiller(tunnelCount > 1) {
-		tp.fillerButton.SetText(l18n.Sprintf("Delete %d tunnels", tunnelCount))
+		tp.fillerButton.SetText(l18n.SprintfPlural(tunnelCount, "Delete %d tunnel", "Delete %d tunnels", tunnelCount))
 		tp.fillerHandler = tp.onDelete
 	}
 }
@@ -15,6 +15,8 @@ import (
 	"github.com/lxn/win"
 	"golang.org/x/sys/windows"
 
+	"golang.zx2c4.com/wireguard/windows/conf"
+	"golang.zx2c4.com/wireguard/windows/elevate"
 	"golang.zx2c4.com/wireguard/windows/l18n"
 	"golang.zx2c4.com/wireguard/windows/manager"
 	"golang.zx2c4.com/wireguard/windows/version"
@@ -25,6 +27,9 @@ var shouldQuitManagerWhenExiting = false
 var startTime = time.Now()
 var IsAdmin = false // A global, because this really is global for the process
 
+// RemoteMachine is the machine whose manager this UI is connected to, if not this one.
+var RemoteMachine = ""
+
 func RunUI() {
 	runtime.LockOSThread()
 	windows.SetProcessPriorityBoost(windows.CurrentProcess(), false)
@@ -41,6 +46,11 @@ func RunUI() {
 		tray *Tray
 	)
 
+	if settings, err := manager.IPCClientSettings(); err == nil {
+		currentSettings = &settings
+	}
+	l18n.SetLanguageOverride(currentSettings.Language)
+
 	for mtw == nil {
 		mtw, err = NewManageTunnelsWindow()
 		if err != nil {
@@ -48,7 +58,14 @@ func RunUI() {
 		}
 	}
 
-	for tray == nil {
+	mtw.restoreLayout()
+
+	if len(RemoteMachine) > 0 {
+		// The tray and the jump list are for the tunnels of this machine, so a window for another one has neither.
+		mtw.SetTitle(l18n.Sprintf("WireGuard on %s", RemoteMachine))
+		noTrayAvailable = true
+	}
+	for tray == nil && len(RemoteMachine) == 0 {
 		tray, err = NewTray(mtw)
 		if err != nil {
 			if version.OsIsCore() {
@@ -59,6 +76,40 @@ func RunUI() {
 		}
 	}
 
+	manager.IPCClientRegisterSettingsChange(func(settings *conf.Settings) {
+		mtw.Synchronize(func() {
+			currentSettings = settings
+			mtw.applyTextScale()
+			mtw.logPage.applyStampFormat()
+			if tray != nil {
+				tray.updateIcon()
+			}
+		})
+	})
+	mtw.ThemeChanged().Attach(func() {
+		if tray != nil {
+			tray.updateIcon()
+		}
+	})
+
+	if len(RemoteMachine) == 0 {
+		updateJumpList(mtw)
+		manager.IPCClientRegisterTunnelCommand(func(tunnel *manager.Tunnel, activate bool) {
+			go mtw.onTunnelCommand(tunnel, activate)
+		})
+		manager.IPCClientRegisterTunnelChange(func(tunnel *manager.Tunnel, state manager.TunnelState, globalState manager.TunnelState, err error) {
+			if state == manager.TunnelStarted || state == manager.TunnelStopped {
+				updateJumpList(mtw)
+			}
+		})
+	}
+	manager.IPCClientRegisterTunnelsChange(func() {
+		if len(RemoteMachine) == 0 {
+			updateJumpList(mtw)
+		}
+		mtw.pruneErroredTunnels()
+	})
+
 	manager.IPCClientRegisterManagerStopping(func() {
 		mtw.Synchronize(func() {
 			walk.App().Exit(0)
@@ -81,26 +132,35 @@ func RunUI() {
 			}
 		})
 	}
-	manager.IPCClientRegisterUpdateFound(onUpdateNotification)
-	go func() {
-		updateState, err := manager.IPCClientUpdateState()
-		if err == nil {
-			onUpdateNotification(updateState)
-		}
-	}()
+	if len(RemoteMachine) == 0 {
+		// Another machine cannot be updated from here, so there is no point in saying that it could be.
+		manager.IPCClientRegisterUpdateFound(onUpdateNotification)
+		go func() {
+			updateState, err := manager.IPCClientUpdateState()
+			if err == nil {
+				onUpdateNotification(updateState)
+			}
+		}()
+	}
 
-	if tray == nil {
+	if len(RemoteMachine) > 0 {
+		mtw.Show()
+	} else if tray == nil {
 		win.ShowWindow(mtw.Handle(), win.SW_MINIMIZE)
 	}
 
+	if len(RemoteMachine) == 0 {
+		mtw.maybeRunOnboarding()
+	}
+
 	mtw.Run()
 	if tray != nil {
 		tray.Dispose()
 	}
 	mtw.Dispose()
 
-	if shouldQuitManagerWhenExiting {
-		_, err := manager.IPCClientQuit(true)
+	if shouldQuitManagerWhenExiting && len(RemoteMachine) == 0 {
+		_, err := manager.IPCClientQuit(currentSettings.ExitStopsTunnels)
 		if err != nil {
 			showErrorCustom(nil, l18n.Sprintf("Error Exiting WireGuard"), l18n.Sprintf("Unable to exit service due to: %v. You may want to stop WireGuard from the service manager.", err))
 		}
@@ -112,6 +172,27 @@ func onQuit() {
 	walk.App().Exit(0)
 }
 
+func reauthenticationRequired() bool {
+	return currentSettings.RequireReauthentication || conf.AdminBool("RequireReauthentication")
+}
+
+// reauthenticate returns true if the sensitive action described by reason may proceed, prompting for credentials first if so configured.
+func reauthenticate(owner walk.Form, reason string) bool {
+	if !reauthenticationRequired() {
+		return true
+	}
+	var hwnd win.HWND
+	if owner != nil {
+		hwnd = owner.Handle()
+	}
+	ok, err := elevate.Reauthenticate(uintptr(hwnd), l18n.Sprintf("WireGuard"), reason)
+	if err != nil {
+		showErrorCustom(owner, l18n.Sprintf("Unable to verify credentials"), err.Error())
+		return false
+	}
+	return ok
+}
+
 func showError(err error, owner walk.Form) bool {
 	if err == nil {
 		return false
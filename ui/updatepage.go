@@ -6,7 +6,13 @@
 package ui
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
 	"github.com/lxn/walk"
+	"github.com/lxn/win"
 
 	"golang.zx2c4.com/wireguard/windows/l18n"
 	"golang.zx2c4.com/wireguard/windows/manager"
@@ -15,6 +21,8 @@ import (
 
 type UpdatePage struct {
 	*walk.TabPage
+
+	updateFromFile func(path string)
 }
 
 func NewUpdatePage() (*UpdatePage, error) {
@@ -50,6 +58,21 @@ func NewUpdatePage() (*UpdatePage, error) {
 	status.SetText(l18n.Sprintf("Status: Waiting for user"))
 	status.SetMinMaxSize(walk.Size{1, 0}, walk.Size{0, 0})
 
+	releaseNotesLabel, err := walk.NewTextLabel(up)
+	if err != nil {
+		return nil, err
+	}
+	releaseNotesLabel.SetText(l18n.Sprintf("What’s new:"))
+	releaseNotesLabel.SetVisible(false)
+
+	releaseNotesEdit, err := walk.NewTextEditWithStyle(up, win.WS_VSCROLL)
+	if err != nil {
+		return nil, err
+	}
+	releaseNotesEdit.SetReadOnly(true)
+	releaseNotesEdit.SetMinMaxSize(walk.Size{1, 120}, walk.Size{0, 0})
+	releaseNotesEdit.SetVisible(false)
+
 	bar, err := walk.NewProgressBar(up)
 	if err != nil {
 		return nil, err
@@ -66,6 +89,19 @@ func NewUpdatePage() (*UpdatePage, error) {
 
 	walk.NewVSpacer(up)
 
+	go func() {
+		notes, err := manager.IPCClientReleaseNotes()
+		if err != nil || len(notes.Text) == 0 {
+			return
+		}
+		up.Synchronize(func() {
+			releaseNotesLabel.SetText(l18n.Sprintf("What’s new in version %s:", notes.Version))
+			releaseNotesEdit.SetText(strings.ReplaceAll(strings.ReplaceAll(notes.Text, "\r\n", "\n"), "\n", "\r\n"))
+			releaseNotesLabel.SetVisible(true)
+			releaseNotesEdit.SetVisible(true)
+		})
+	}()
+
 	switchToUpdatingState := func() {
 		if !bar.Visible() {
 			up.SetSuspended(true)
@@ -100,6 +136,15 @@ func NewUpdatePage() (*UpdatePage, error) {
 		}
 	})
 
+	up.updateFromFile = func(path string) {
+		switchToUpdatingState()
+		err := manager.IPCClientUpdateFromFile(path)
+		if err != nil {
+			switchToReadyState()
+			status.SetText(l18n.Sprintf("Error: %v. Please try again.", err))
+		}
+	}
+
 	manager.IPCClientRegisterUpdateProgress(func(dp updater.DownloadProgress) {
 		up.Synchronize(func() {
 			switchToUpdatingState()
@@ -111,7 +156,11 @@ func NewUpdatePage() (*UpdatePage, error) {
 			}
 			if len(dp.Activity) > 0 {
 				stateText := dp.Activity
-				status.SetText(l18n.Sprintf("Status: %s", stateText))
+				if dp.Phase == updater.UpdateDownloading && dp.Remaining > 0 {
+					status.SetText(l18n.Sprintf("Status: %s, about %v remaining", stateText, dp.Remaining.Round(time.Second)))
+				} else {
+					status.SetText(l18n.Sprintf("Status: %s", stateText))
+				}
 			}
 			if dp.BytesTotal > 0 {
 				bar.SetMarqueeMode(false)
@@ -134,3 +183,28 @@ func NewUpdatePage() (*UpdatePage, error) {
 
 	return up, nil
 }
+
+func (mtw *ManageTunnelsWindow) onUpdateFromFile() {
+	dlg := walk.FileDialog{
+		Filter: l18n.Sprintf("Windows Installer Packages (*.msi)|*.msi"),
+		Title:  l18n.Sprintf("Install update from file"),
+	}
+	if ok, _ := dlg.ShowOpen(mtw); !ok {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dlg.FilePath), updater.OfflineFileListName)); err != nil {
+		showErrorCustom(mtw, l18n.Sprintf("Unable to install update"), l18n.Sprintf("The signed file list ‘%s’ must be in the same folder as the update, so that the update can be verified.", updater.OfflineFileListName))
+		return
+	}
+	if mtw.updatePage == nil {
+		updatePage, err := NewUpdatePage()
+		if err != nil {
+			showError(err, mtw)
+			return
+		}
+		mtw.updatePage = updatePage
+		mtw.tabs.Pages().Add(updatePage.TabPage)
+	}
+	mtw.tabs.SetCurrentIndex(mtw.tabs.Pages().Index(mtw.updatePage.TabPage))
+	mtw.updatePage.updateFromFile(dlg.FilePath)
+}
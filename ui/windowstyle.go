@@ -0,0 +1,53 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package ui
+
+import (
+	"unsafe"
+
+	"github.com/lxn/win"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	dwmwaWindowCornerPreference = 33
+	dwmwaSystemBackdropType     = 38
+
+	dwmwcpRound = 2
+
+	dwmsbtMainWindow      = 2 // Mica
+	dwmsbtTransientWindow = 3 // Acrylic
+
+	firstWindows11Build        = 22000
+	firstSystemBackdropOSBuild = 22621
+)
+
+var procDwmSetWindowAttribute = windows.NewLazySystemDLL("dwmapi.dll").NewProc("DwmSetWindowAttribute")
+
+func dwmSetWindowAttribute(hwnd win.HWND, attribute uint32, value uint32) {
+	procDwmSetWindowAttribute.Call(uintptr(hwnd), uintptr(attribute), uintptr(unsafe.Pointer(&value)), unsafe.Sizeof(value))
+}
+
+// applyModernWindowStyle gives the window rounded corners and a Mica title bar, or an acrylic one if it is transient,
+// on versions of Windows that draw them. Older versions keep their usual frame.
+func applyModernWindowStyle(hwnd win.HWND, transient bool) {
+	if procDwmSetWindowAttribute.Find() != nil {
+		return
+	}
+	build := windows.RtlGetVersion().BuildNumber
+	if build < firstWindows11Build {
+		return
+	}
+	dwmSetWindowAttribute(hwnd, dwmwaWindowCornerPreference, dwmwcpRound)
+	if build < firstSystemBackdropOSBuild {
+		return
+	}
+	if transient {
+		dwmSetWindowAttribute(hwnd, dwmwaSystemBackdropType, dwmsbtTransientWindow)
+	} else {
+		dwmSetWindowAttribute(hwnd, dwmwaSystemBackdropType, dwmsbtMainWindow)
+	}
+}
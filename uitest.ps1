@@ -0,0 +1,194 @@
+<#
+ SPDX-License-Identifier: MIT
+
+ Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+
+ Drives the real WireGuard UI through UI Automation without any human input: imports a configuration, checks the
+ check boxes of the edit dialog, exercises the tunnel list menu and the tray menu, activates the tunnel, and verifies
+ that it carries traffic. The controls it uses are listed in docs\automation.md. It neither synthesizes keystrokes or
+ mouse clicks nor needs the foreground, so it runs unattended from a scheduled task or CI agent, even with the screen
+ locked or the session disconnected, as long as it runs elevated in a session with a desktop.
+
+   .\uitest.ps1 -Config C:\acceptance\office.conf -Probe 10.0.0.1
+#>
+
+param (
+	[Parameter(Mandatory = $true)][string]$Config,
+	[string]$Probe,
+	[string]$WireGuard = "$env:ProgramFiles\WireGuard\wireguard.exe",
+	[int]$Timeout = 60,
+	[switch]$Deactivate,
+	# Menu items have no stable identifiers, so they are found by name, which differs with the UI language.
+	[string]$ImportMenuItem = "Import tunnel(s) from file",
+	[string]$SelectAllMenuItem = "Select all"
+)
+
+$ErrorActionPreference = "Stop"
+Add-Type -AssemblyName UIAutomationClient, UIAutomationTypes
+Add-Type -Namespace WireGuardTest -Name Native -MemberDefinition @"
+[DllImport("user32.dll", CharSet = CharSet.Unicode)] public static extern IntPtr FindWindow(string className, string windowName);
+[DllImport("user32.dll")] public static extern bool PostMessage(IntPtr hwnd, uint msg, IntPtr wParam, IntPtr lParam);
+"@
+
+$Ids = @{
+	TunnelList = 22273
+	TunnelStatus = 22276
+	ToggleActive = 22277
+	EditTunnel = 22278
+	EditBlockUntunneled = 22287
+	EditCancel = 22289
+	OnboardingClose = 22296
+	AppliedStatus = 22297
+	EditIdleTimeoutEnabled = 22298
+}
+# The tray icon reports clicks to the main window with this message, which the harness posts to open its menu.
+$TrayMessage = 0x8000
+$WM_LBUTTONDOWN = 0x0201
+$WM_RBUTTONUP = 0x0205
+$WM_CONTEXTMENU = 0x007B
+
+$Tunnel = [IO.Path]::GetFileNameWithoutExtension($Config)
+$Root = [Windows.Automation.AutomationElement]::RootElement
+$Scope = [Windows.Automation.TreeScope]
+$Property = [Windows.Automation.AutomationElement]
+
+function Wait-For([scriptblock]$Condition, [string]$What) {
+	$deadline = (Get-Date).AddSeconds($Timeout)
+	while ((Get-Date) -lt $deadline) {
+		$result = & $Condition
+		if ($result) { return $result }
+		Start-Sleep -Milliseconds 250
+	}
+	throw "Timed out waiting for $What"
+}
+
+function Find-ById($Parent, [string]$Id) {
+	$Parent.FindFirst($Scope::Descendants, (New-Object Windows.Automation.PropertyCondition ($Property::AutomationIdProperty), $Id))
+}
+
+function Find-Window([int]$ProcessId, [string]$ClassName) {
+	$Root.FindFirst($Scope::Descendants, (New-Object Windows.Automation.AndCondition @(
+		(New-Object Windows.Automation.PropertyCondition ($Property::ProcessIdProperty), $ProcessId),
+		(New-Object Windows.Automation.PropertyCondition ($Property::ClassNameProperty), $ClassName)
+	)))
+}
+
+function Find-MenuItem([int]$ProcessId, [string]$Name) {
+	$menu = Wait-For { Find-Window $ProcessId "#32768" } "a menu to open"
+	$items = $menu.FindAll($Scope::Descendants, (New-Object Windows.Automation.PropertyCondition ($Property::ControlTypeProperty), [Windows.Automation.ControlType]::MenuItem))
+	foreach ($item in $items) {
+		if ($item.Current.Name.StartsWith($Name)) { return $item }
+	}
+	throw "The menu has no item named $Name"
+}
+
+function Invoke-Element($Element) {
+	$Element.GetCurrentPattern([Windows.Automation.InvokePattern]::Pattern).Invoke()
+}
+
+function Get-Value($Element) {
+	$Element.GetCurrentPattern([Windows.Automation.ValuePattern]::Pattern).Current.Value
+}
+
+function Get-Toggle($Element) {
+	$Element.GetCurrentPattern([Windows.Automation.TogglePattern]::Pattern).Current.ToggleState
+}
+
+# Confirmations, such as of replacing a tunnel of the same name, are message boxes, which are answered with Yes.
+function Confirm-IfAsked([int]$ProcessId) {
+	$deadline = (Get-Date).AddSeconds(2)
+	while ((Get-Date) -lt $deadline) {
+		$box = Find-Window $ProcessId "#32770"
+		if ($box) {
+			$yes = Find-ById $box "6"
+			if ($yes) { Invoke-Element $yes }
+			return
+		}
+		Start-Sleep -Milliseconds 250
+	}
+}
+
+function Find-Tunnel($List) {
+	Wait-For {
+		$List.FindFirst($Scope::Descendants, (New-Object Windows.Automation.AndCondition @(
+			(New-Object Windows.Automation.PropertyCondition ($Property::ControlTypeProperty), [Windows.Automation.ControlType]::ListItem),
+			(New-Object Windows.Automation.PropertyCondition ($Property::NameProperty), $Tunnel)
+		)))
+	} "tunnel $Tunnel in the list"
+}
+
+Write-Host "Starting the UI"
+& $WireGuard
+# The window starts hidden in the tray, and is found by its class even so.
+$handle = Wait-For {
+	$hwnd = [WireGuardTest.Native]::FindWindow("WireGuard UI - Manage Tunnels", $null)
+	if ($hwnd -ne [IntPtr]::Zero) { $hwnd }
+} "the main window"
+
+# A machine without tunnels shows the welcome dialog first.
+Start-Sleep -Seconds 1
+$onboardingClose = Find-ById $Root $Ids.OnboardingClose
+if ($onboardingClose) {
+	Invoke-Element $onboardingClose
+}
+
+Write-Host "Showing the main window from the tray"
+[WireGuardTest.Native]::PostMessage($handle, $TrayMessage, [IntPtr]::Zero, [IntPtr]$WM_LBUTTONDOWN) | Out-Null
+$window = Wait-For {
+	$element = [Windows.Automation.AutomationElement]::FromHandle($handle)
+	if (-not $element.Current.IsOffscreen) { $element }
+} "the main window to show"
+$processId = $window.Current.ProcessId
+
+Write-Host "Importing $Config from the tray menu"
+[WireGuardTest.Native]::PostMessage($handle, $TrayMessage, [IntPtr]::Zero, [IntPtr]$WM_RBUTTONUP) | Out-Null
+Invoke-Element (Find-MenuItem $processId $ImportMenuItem)
+$fileDialog = Wait-For { Find-Window $processId "#32770" } "the file dialog"
+$fileName = Wait-For { Find-ById $fileDialog "1148" } "the file name box"
+$fileName.GetCurrentPattern([Windows.Automation.ValuePattern]::Pattern).SetValue((Resolve-Path $Config).Path)
+Invoke-Element (Find-ById $fileDialog "1")
+Confirm-IfAsked $processId
+
+$list = Find-ById $window $Ids.TunnelList
+Write-Host "Selecting all tunnels from the list menu"
+$listHandle = [IntPtr]$list.Current.NativeWindowHandle
+[WireGuardTest.Native]::PostMessage($listHandle, $WM_CONTEXTMENU, $listHandle, [IntPtr](-1)) | Out-Null
+Invoke-Element (Find-MenuItem $processId $SelectAllMenuItem)
+$item = Find-Tunnel $list
+$selection = $item.GetCurrentPattern([Windows.Automation.SelectionItemPattern]::Pattern)
+Wait-For { $selection.Current.IsSelected } "tunnel $Tunnel to be selected" | Out-Null
+$selection.Select()
+
+Write-Host "Checking the options of $Tunnel"
+Invoke-Element (Find-ById $window $Ids.EditTunnel)
+$editCancel = Wait-For { Find-ById $Root $Ids.EditCancel } "the edit dialog"
+$blockUntunneled = Find-ById $Root $Ids.EditBlockUntunneled
+if ($blockUntunneled) {
+	Write-Host "Kill-switch: $(Get-Toggle $blockUntunneled)"
+}
+Write-Host "Deactivate after idle time: $(Get-Toggle (Find-ById $Root $Ids.EditIdleTimeoutEnabled))"
+Invoke-Element $editCancel
+
+Write-Host "Activating $Tunnel"
+Invoke-Element (Find-ById $window $Ids.ToggleActive)
+Wait-For { (Get-NetAdapter -Name $Tunnel -ErrorAction SilentlyContinue).Status -eq "Up" } "tunnel $Tunnel to come up" | Out-Null
+Write-Host "Tunnel $Tunnel is up, the UI reports: $(Get-Value (Find-ById $window $Ids.TunnelStatus))"
+$appliedStatus = Find-ById $window $Ids.AppliedStatus
+if ($appliedStatus) {
+	Write-Host "The Applied State tab reports: $(Get-Value $appliedStatus)"
+}
+
+if ($Probe) {
+	Write-Host "Probing $Probe"
+	Wait-For { Test-Connection -ComputerName $Probe -Count 1 -Quiet } "$Probe to answer through the tunnel" | Out-Null
+}
+
+if ($Deactivate) {
+	Write-Host "Deactivating $Tunnel from the tray menu"
+	[WireGuardTest.Native]::PostMessage($handle, $TrayMessage, [IntPtr]::Zero, [IntPtr]$WM_RBUTTONUP) | Out-Null
+	Invoke-Element (Find-MenuItem $processId $Tunnel)
+	Confirm-IfAsked $processId
+	Wait-For { -not (Get-NetAdapter -Name $Tunnel -ErrorAction SilentlyContinue) } "tunnel $Tunnel to go down" | Out-Null
+}
+
+Write-Host "PASS"
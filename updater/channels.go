@@ -0,0 +1,32 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package updater
+
+import "fmt"
+
+// Each channel has its own signed file list. Stable releases live at the top of the download directory, where they
+// always have, so that older clients keep finding them, and the others live in a directory of their own.
+const (
+	StableChannel = "stable"
+	BetaChannel   = "beta"
+)
+
+var Channels = []string{StableChannel, BetaChannel}
+
+// channelURL returns the directory of the update server that holds the file list and packages of the channel.
+func channelURL(channel string) (string, error) {
+	server, err := updateServer()
+	if err != nil {
+		return "", err
+	}
+	switch channel {
+	case "", StableChannel:
+		return server, nil
+	case BetaChannel:
+		return server + "beta/", nil
+	}
+	return "", fmt.Errorf("Unknown update channel %q", channel)
+}
@@ -5,10 +5,19 @@
 
 package updater
 
+// A file list is trusted if any one of these has signed it. When rotating keys, add the successor here a release
+// ahead of signing with it, and remove the predecessor only once no supported release relies on it alone.
+var releasePublicKeysBase64 = []string{
+	"RWRNqGKtBXftKTKPpBPGDMe8jHLnFQ0EdRy8Wg0apV6vTDFLAODD83G4",
+}
+
 const (
-	releasePublicKeyBase64 = "RWRNqGKtBXftKTKPpBPGDMe8jHLnFQ0EdRy8Wg0apV6vTDFLAODD83G4"
-	latestVersionURL       = "https://download.wireguard.com/windows-client/latest.sig"
-	msiURL                 = "https://download.wireguard.com/windows-client/%s"
-	msiArchPrefix          = "wireguard-%s-"
-	msiSuffix              = ".msi"
+	defaultUpdateServer = "https://download.wireguard.com/windows-client/"
+	latestVersionURL    = "%slatest.sig"
+	msiURL              = "%s%s"
+	releaseNotesName    = "release-notes-%s.txt"
+	msiArchPrefix       = "wireguard-%s-"
+	msiSuffix           = ".msi"
+	mspFromInfix        = "-from-"
+	mspSuffix           = ".msp"
 )
@@ -11,19 +11,37 @@ import (
 	"fmt"
 	"hash"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
 	"golang.org/x/crypto/blake2b"
 
+	"golang.zx2c4.com/wireguard/windows/conf"
 	"golang.zx2c4.com/wireguard/windows/elevate"
+	"golang.zx2c4.com/wireguard/windows/updater/winhttp"
 	"golang.zx2c4.com/wireguard/windows/version"
 )
 
+// UpdatePhase says which step of an update is underway, so that the UI need not guess from the activity text.
+type UpdatePhase int
+
+const (
+	UpdatePreparing UpdatePhase = iota
+	UpdateChecking
+	UpdateDownloading
+	UpdateVerifying
+	UpdateInstalling
+)
+
 type DownloadProgress struct {
 	Activity        string
+	Phase           UpdatePhase
 	BytesDownloaded uint64
 	BytesTotal      uint64
+	Remaining       time.Duration // Estimated time until the download completes, or 0 if unknown
 	Error           error
 	Complete        bool
 }
@@ -32,139 +50,312 @@ type progressHashWatcher struct {
 	dp        *DownloadProgress
 	c         chan DownloadProgress
 	hashState hash.Hash
+
+	// The rate is measured from when the current connection started, since a resumed download begins partway.
+	started        time.Time
+	startedAtBytes uint64
+}
+
+func (pm *progressHashWatcher) restart() {
+	pm.started = time.Now()
+	pm.startedAtBytes = pm.dp.BytesDownloaded
 }
 
 func (pm *progressHashWatcher) Write(p []byte) (int, error) {
 	bytes := len(p)
 	pm.dp.BytesDownloaded += uint64(bytes)
+	pm.dp.Remaining = 0
+	if elapsed := time.Since(pm.started); elapsed > time.Second && pm.dp.BytesTotal > pm.dp.BytesDownloaded {
+		rate := float64(pm.dp.BytesDownloaded-pm.startedAtBytes) / elapsed.Seconds()
+		if rate > 0 {
+			pm.dp.Remaining = time.Duration(float64(pm.dp.BytesTotal-pm.dp.BytesDownloaded) / rate * float64(time.Second))
+		}
+	}
 	pm.c <- *pm.dp
 	pm.hashState.Write(p)
 	return bytes, nil
 }
 
 type UpdateFound struct {
+	channel string
+	name    string
+	version string
+	hash    [blake2b.Size256]byte
+	patch   *patchFound // A smaller patch from our version to this one, if the file list has one
+
+	releaseNotesHash *[blake2b.Size256]byte // The hash of the release notes, if the file list has them
+}
+
+type patchFound struct {
 	name string
 	hash [blake2b.Size256]byte
 }
 
-func CheckForUpdate() (*UpdateFound, error) {
+func (update *UpdateFound) Version() string {
+	return update.version
+}
+
+// httpResponse owns the WinHTTP session of its request, since each is used for only one.
+type httpResponse struct {
+	*winhttp.Response
+	session *winhttp.Session
+}
+
+func (response *httpResponse) Close() error {
+	response.Response.Close()
+	return response.session.Close()
+}
+
+// httpGet fetches the URL through WinHTTP rather than net/http, since the latter knows nothing of the system proxy,
+// and on many enterprise networks there is no other way out.
+func httpGet(url string) (*httpResponse, error) {
+	return httpGetFrom(url, 0)
+}
+
+// httpGetFrom is like httpGet, but asks for the content starting at offset.
+func httpGetFrom(url string, offset int64) (*httpResponse, error) {
+	session, err := winhttp.NewSession(version.UserAgent())
+	if err != nil {
+		return nil, err
+	}
+	response, err := session.GetFrom(url, offset)
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	return &httpResponse{response, session}, nil
+}
+
+// CheckForUpdate looks for a release newer than this one in the file list of the given channel, where the empty
+// string means the stable channel.
+func CheckForUpdate(channel string) (*UpdateFound, error) {
 	if !version.IsRunningOfficialVersion() {
 		return nil, errors.New("Build is not official, so updates are disabled")
 	}
-	request, err := http.NewRequest(http.MethodGet, latestVersionURL, nil)
+	dir, err := channelURL(channel)
 	if err != nil {
 		return nil, err
 	}
-	request.Header.Add("User-Agent", version.UserAgent())
-	response, err := http.DefaultClient.Do(request)
+	response, err := httpGet(fmt.Sprintf(latestVersionURL, dir))
 	if err != nil {
 		return nil, err
 	}
-	defer response.Body.Close()
-	var fileList [1024 * 512] /* 512 KiB */ byte
-	bytesRead, err := response.Body.Read(fileList[:])
-	if err != nil && (err != io.EOF || bytesRead == 0) {
+	defer response.Close()
+	fileList, err := ioutil.ReadAll(io.LimitReader(response, 1024*512 /* 512 KiB */))
+	if err != nil {
 		return nil, err
 	}
-	files, err := readFileList(fileList[:bytesRead])
+	files, err := readFileList(fileList)
 	if err != nil {
 		return nil, err
 	}
-	return findCandidate(files)
+	update, err := findCandidate(files)
+	if update != nil {
+		update.channel = channel
+	}
+	return update, err
 }
 
-var updateInProgress = uint32(0)
-
-func DownloadVerifyAndExecute(userToken uintptr) (progress chan DownloadProgress) {
-	progress = make(chan DownloadProgress, 128)
-	progress <- DownloadProgress{Activity: "Initializing"}
-
-	if !atomic.CompareAndSwapUint32(&updateInProgress, 0, 1) {
-		progress <- DownloadProgress{Error: errors.New("An update is already in progress")}
-		return
+// FetchReleaseNotes downloads the plain text release notes of the update, which are only returned if their hash is
+// the one in the signed file list, so that whoever is in the path cannot have users read what they please.
+func FetchReleaseNotes(update *UpdateFound) (string, error) {
+	if update.releaseNotesHash == nil {
+		return "", errors.New("Release notes are not in the file list")
 	}
+	dir, err := channelURL(update.channel)
+	if err != nil {
+		return "", err
+	}
+	response, err := httpGet(fmt.Sprintf(msiURL, dir, fmt.Sprintf(releaseNotesName, update.version)))
+	if err != nil {
+		return "", err
+	}
+	defer response.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Release notes are unavailable: %d %s", response.StatusCode, http.StatusText(response.StatusCode))
+	}
+	notes, err := ioutil.ReadAll(io.LimitReader(response, 1024*64 /* 64 KiB */))
+	if err != nil {
+		return "", err
+	}
+	hash := blake2b.Sum256(notes)
+	if !hmac.Equal(hash[:], update.releaseNotesHash[:]) {
+		return "", errors.New("The release notes hash does not match")
+	}
+	if !utf8.Valid(notes) {
+		return "", errors.New("Release notes are not valid UTF-8")
+	}
+	return string(notes), nil
+}
 
-	doIt := func() {
-		defer atomic.StoreUint32(&updateInProgress, 0)
-
-		progress <- DownloadProgress{Activity: "Checking for update"}
-		update, err := CheckForUpdate()
+// downloadVerifyAndRun fetches the named file from the channel directory and hands it to copyVerifyAndRun. When the
+// connection drops partway, it asks for the rest rather than starting over.
+func downloadVerifyAndRun(progress chan DownloadProgress, dir, name string, hash [blake2b.Size256]byte, run func(*tempFile) error) error {
+	url := fmt.Sprintf(msiURL, dir, name)
+	return copyVerifyAndRun(progress, fmt.Sprintf("Downloading %s", name), func(offset int64) (io.ReadCloser, int64, error) {
+		response, err := httpGetFrom(url, offset)
 		if err != nil {
-			progress <- DownloadProgress{Error: err}
-			return
+			return nil, 0, err
 		}
-		if update == nil {
-			progress <- DownloadProgress{Error: errors.New("No update was found")}
-			return
+		expected := http.StatusOK
+		if offset > 0 {
+			expected = http.StatusPartialContent
 		}
-
-		progress <- DownloadProgress{Activity: "Creating temporary file"}
-		file, err := msiTempFile()
-		if err != nil {
-			progress <- DownloadProgress{Error: err}
-			return
+		if response.StatusCode != expected {
+			response.Close()
+			return nil, 0, fmt.Errorf("Download failed: %d %s", response.StatusCode, http.StatusText(response.StatusCode))
 		}
-		progress <- DownloadProgress{Activity: fmt.Sprintf("Msi destination is %#q", file.Name())}
-		defer func() {
-			if file != nil {
-				file.Delete()
-			}
-		}()
+		return response, response.ContentLength, nil
+	}, hash, run)
+}
+
+// maxResumes is how many times copyVerifyAndRun picks an interrupted copy back up before giving up on it.
+const maxResumes = 5
 
-		dp := DownloadProgress{Activity: "Downloading update"}
+// copyVerifyAndRun copies the update from the source into a file that only the system can write, checks it against
+// the hash in the signed file list and its authenticode signature, and hands it to run, which is msiexec in one guise
+// or another. The open function returns the source starting at the given offset, along with the length of what
+// remains, or -1 if unknown. Since the hash is computed as the bytes arrive, resuming needs neither rereading what
+// was already copied nor trusting it any more than the rest.
+func copyVerifyAndRun(progress chan DownloadProgress, activity string, open func(offset int64) (io.ReadCloser, int64, error), hash [blake2b.Size256]byte, run func(*tempFile) error) error {
+	progress <- DownloadProgress{Activity: "Creating temporary file", Phase: UpdateDownloading}
+	file, err := msiTempFile()
+	if err != nil {
+		return err
+	}
+	progress <- DownloadProgress{Activity: fmt.Sprintf("Msi destination is %#q", file.Name()), Phase: UpdateDownloading}
+	defer file.Delete()
+
+	hasher, err := blake2b.New256(nil)
+	if err != nil {
+		return err
+	}
+	const limit = 1024 * 1024 * 100 /* 100 MiB */
+	dp := DownloadProgress{Activity: activity, Phase: UpdateDownloading}
+	pm := &progressHashWatcher{dp: &dp, c: progress, hashState: hasher}
+	var copied int64
+	for resumes := 0; ; resumes++ {
 		progress <- dp
-		request, err := http.NewRequest(http.MethodGet, fmt.Sprintf(msiURL, update.name), nil)
-		if err != nil {
-			progress <- DownloadProgress{Error: err}
-			return
+		var source io.ReadCloser
+		var length int64
+		source, length, err = open(copied)
+		if err == nil {
+			if length >= 0 {
+				dp.BytesTotal = uint64(copied + length)
+				progress <- dp
+			}
+			pm.restart()
+			var n int64
+			n, err = io.Copy(file, io.TeeReader(io.LimitReader(source, limit-copied), pm))
+			copied += n
+			source.Close()
+			if err == nil {
+				break
+			}
+		}
+		if resumes == maxResumes {
+			return err
 		}
-		request.Header.Add("User-Agent", version.UserAgent())
-		request.Header.Set("Accept-Encoding", "identity")
-		response, err := http.DefaultClient.Do(request)
+		delay := time.Second * time.Duration(1<<resumes)
+		progress <- DownloadProgress{Activity: fmt.Sprintf("Interrupted, so resuming in %v: %v", delay, err), Phase: UpdateDownloading, BytesDownloaded: dp.BytesDownloaded, BytesTotal: dp.BytesTotal}
+		time.Sleep(delay)
+	}
+	if dp.BytesTotal > 0 && uint64(copied) != dp.BytesTotal {
+		return fmt.Errorf("The downloaded update is %d bytes rather than %d", copied, dp.BytesTotal)
+	}
+	if !hmac.Equal(hasher.Sum(nil), hash[:]) {
+		return errors.New("The downloaded update has the wrong hash")
+	}
+
+	progress <- DownloadProgress{Activity: "Verifying authenticode signature", Phase: UpdateVerifying}
+	if !version.VerifyAuthenticode(file.ExclusivePath()) {
+		return errors.New("The downloaded update does not have an authentic authenticode signature")
+	}
+
+	progress <- DownloadProgress{Activity: "Installing update", Phase: UpdateInstalling}
+	return run(file)
+}
+
+// Software inventory tools generally key on the product code and version of the last package installed with /i, and
+// some cannot make sense of a product updated with a patch, so admins may insist on full packages.
+func fullMsiOnly() bool {
+	return conf.AdminBool("UpdateWithFullMsi")
+}
+
+// msiArgs returns the extra arguments for installing a full package. Environments that insist on those also tend to
+// schedule their own reboots.
+func msiArgs() []string {
+	if fullMsiOnly() {
+		return []string{"/norestart"}
+	}
+	return nil
+}
+
+var updateInProgress = uint32(0)
+
+func DownloadVerifyAndExecute(userToken uintptr, channel string) (progress chan DownloadProgress) {
+	return startUpdate(userToken, func(progress chan DownloadProgress) {
+		progress <- DownloadProgress{Activity: "Checking for update", Phase: UpdateChecking}
+		update, err := CheckForUpdate(channel)
 		if err != nil {
 			progress <- DownloadProgress{Error: err}
 			return
 		}
-		defer response.Body.Close()
-		if response.ContentLength >= 0 {
-			dp.BytesTotal = uint64(response.ContentLength)
-			progress <- dp
-		}
-		hasher, err := blake2b.New256(nil)
-		if err != nil {
-			progress <- DownloadProgress{Error: err}
+		if update == nil {
+			progress <- DownloadProgress{Error: errors.New("No update was found")}
 			return
 		}
-		pm := &progressHashWatcher{&dp, progress, hasher}
-		_, err = io.Copy(file, io.TeeReader(io.LimitReader(response.Body, 1024*1024*100 /* 100 MiB */), pm))
+		dir, err := channelURL(update.channel)
 		if err != nil {
 			progress <- DownloadProgress{Error: err}
 			return
 		}
-		if !hmac.Equal(hasher.Sum(nil), update.hash[:]) {
-			progress <- DownloadProgress{Error: errors.New("The downloaded update has the wrong hash")}
-			return
-		}
 
-		progress <- DownloadProgress{Activity: "Verifying authenticode signature"}
-		if !version.VerifyAuthenticode(file.ExclusivePath()) {
-			progress <- DownloadProgress{Error: errors.New("The downloaded update does not have an authentic authenticode signature")}
-			return
+		if update.patch != nil && !fullMsiOnly() {
+			err = downloadVerifyAndRun(progress, dir, update.patch.name, update.patch.hash, func(file *tempFile) error {
+				return runMsp(file, userToken)
+			})
+			if err == nil {
+				progress <- DownloadProgress{Complete: true}
+				return
+			}
+			// A patch only applies to an unmodified installation of exactly our version, so anything from a
+			// repaired or partially updated installation to a proxy mangling the download is worth retrying with
+			// the full installer.
+			progress <- DownloadProgress{Activity: fmt.Sprintf("Patch failed, so falling back to the full installer: %v", err)}
 		}
 
-		progress <- DownloadProgress{Activity: "Installing update"}
-		err = runMsi(file, userToken)
+		err = downloadVerifyAndRun(progress, dir, update.name, update.hash, func(file *tempFile) error {
+			return runMsi(file, userToken, msiArgs()...)
+		})
 		if err != nil {
 			progress <- DownloadProgress{Error: err}
 			return
 		}
 
 		progress <- DownloadProgress{Complete: true}
+	})
+}
+
+// startUpdate runs doIt in the background, as the system if there is no user token, unless another update is
+// already in progress.
+func startUpdate(userToken uintptr, doIt func(progress chan DownloadProgress)) (progress chan DownloadProgress) {
+	progress = make(chan DownloadProgress, 128)
+	progress <- DownloadProgress{Activity: "Initializing"}
+
+	if !atomic.CompareAndSwapUint32(&updateInProgress, 0, 1) {
+		progress <- DownloadProgress{Error: errors.New("An update is already in progress")}
+		return
+	}
+
+	run := func() {
+		defer atomic.StoreUint32(&updateInProgress, 0)
+		doIt(progress)
 	}
 	if userToken == 0 {
 		go func() {
 			err := elevate.DoAsSystem(func() error {
-				doIt()
+				run()
 				return nil
 			})
 			if err != nil {
@@ -172,7 +363,7 @@ func DownloadVerifyAndExecute(userToken uintptr) (progress chan DownloadProgress
 			}
 		}()
 	} else {
-		go doIt()
+		go run()
 	}
 
 	return progress
@@ -18,6 +18,10 @@ func runMsi(msiPath string, userToken uintptr, env []string) error {
 	return exec.Command("qarma", "--info", "--text", fmt.Sprintf("It seems to be working! Were we on Windows, ‘%s’ would be executed.", msiPath)).Run()
 }
 
+func runMsp(mspPath string, userToken uintptr) error {
+	return exec.Command("qarma", "--info", "--text", fmt.Sprintf("It seems to be working! Were we on Windows, ‘%s’ would be applied.", mspPath)).Run()
+}
+
 func msiTempFile() (*os.File, error) {
 	return ioutil.TempFile(os.TempDir(), "")
 }
@@ -47,7 +47,15 @@ func (t *tempFile) Delete() error {
 	return err
 }
 
-func runMsi(msi *tempFile, userToken uintptr) error {
+func runMsi(msi *tempFile, userToken uintptr, args ...string) error {
+	return runMsiexec(msi, "/i", userToken, args)
+}
+
+func runMsp(msp *tempFile, userToken uintptr) error {
+	return runMsiexec(msp, "/update", userToken, nil)
+}
+
+func runMsiexec(file *tempFile, action string, userToken uintptr, args []string) error {
 	system32, err := windows.GetSystemDirectory()
 	if err != nil {
 		return err
@@ -57,7 +65,7 @@ func runMsi(msi *tempFile, userToken uintptr) error {
 		return err
 	}
 	defer devNull.Close()
-	msiPath := msi.ExclusivePath()
+	msiPath := file.ExclusivePath()
 	attr := &os.ProcAttr{
 		Sys: &syscall.SysProcAttr{
 			Token: syscall.Token(userToken),
@@ -66,7 +74,7 @@ func runMsi(msi *tempFile, userToken uintptr) error {
 		Dir:   filepath.Dir(msiPath),
 	}
 	msiexec := filepath.Join(system32, "msiexec.exe")
-	proc, err := os.StartProcess(msiexec, []string{msiexec, "/qb!-", "/i", filepath.Base(msiPath)}, attr)
+	proc, err := os.StartProcess(msiexec, append([]string{msiexec, "/qb!-", action, filepath.Base(msiPath)}, args...), attr)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,82 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// OfflineFileListName is the name of the signed file list that must sit next to an update installed from a local
+// file, which is the name it has on the download server.
+const OfflineFileListName = "latest.sig"
+
+// VerifyAndExecuteFile installs the update at packagePath, which air-gapped networks copy over from the download
+// server together with the signed file list, after checking it exactly as a downloaded update is checked.
+func VerifyAndExecuteFile(userToken uintptr, packagePath string) (progress chan DownloadProgress) {
+	return startUpdate(userToken, func(progress chan DownloadProgress) {
+		progress <- DownloadProgress{Activity: "Reading signed file list", Phase: UpdateChecking}
+		listFile, err := os.Open(filepath.Join(filepath.Dir(packagePath), OfflineFileListName))
+		if err != nil {
+			progress <- DownloadProgress{Error: err}
+			return
+		}
+		list, err := ioutil.ReadAll(io.LimitReader(listFile, 1024*512 /* 512 KiB */))
+		listFile.Close()
+		if err != nil {
+			progress <- DownloadProgress{Error: err}
+			return
+		}
+		files, err := readFileList(list)
+		if err != nil {
+			progress <- DownloadProgress{Error: err}
+			return
+		}
+		name := filepath.Base(packagePath)
+		hash, ok := files[name]
+		if !ok {
+			progress <- DownloadProgress{Error: fmt.Errorf("%s is not in the signed file list", name)}
+			return
+		}
+		update, err := findCandidate(fileList{name: hash})
+		if err != nil {
+			progress <- DownloadProgress{Error: err}
+			return
+		}
+		if update == nil {
+			progress <- DownloadProgress{Error: errors.New("The file is not a newer version for this architecture")}
+			return
+		}
+
+		err = copyVerifyAndRun(progress, fmt.Sprintf("Copying %s", name), func(offset int64) (io.ReadCloser, int64, error) {
+			file, err := os.Open(packagePath)
+			if err != nil {
+				return nil, 0, err
+			}
+			info, err := file.Stat()
+			if err == nil {
+				_, err = file.Seek(offset, io.SeekStart)
+			}
+			if err != nil {
+				file.Close()
+				return nil, 0, err
+			}
+			return file, info.Size() - offset, nil
+		}, update.hash, func(file *tempFile) error {
+			return runMsi(file, userToken, msiArgs()...)
+		})
+		if err != nil {
+			progress <- DownloadProgress{Error: err}
+			return
+		}
+
+		progress <- DownloadProgress{Complete: true}
+	})
+}
@@ -0,0 +1,57 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.zx2c4.com/wireguard/windows/conf"
+)
+
+// updateServer returns the base URL of the download directory, which organizations may point at an internal mirror,
+// laid out like the official one, so as to decide for themselves when each release reaches their machines.
+func updateServer() (string, error) {
+	server, ok := conf.AdminString("UpdateServer")
+	if !ok || len(server) == 0 {
+		return defaultUpdateServer, nil
+	}
+	u, err := url.Parse(server)
+	if err != nil {
+		return "", fmt.Errorf("Invalid update server policy: %v", err)
+	}
+	if u.Scheme != "https" || len(u.Host) == 0 || len(u.RawQuery) > 0 || len(u.Fragment) > 0 {
+		return "", fmt.Errorf("Invalid update server policy: %q is not an https URL of a directory", server)
+	}
+	if !strings.HasSuffix(server, "/") {
+		server += "/"
+	}
+	return server, nil
+}
+
+// trustedReleaseKeys returns the keys that may sign the file list. A mirror that publishes the official list as is
+// needs no more than the built in keys, but one that curates its own list re-signs it, and pins its key by policy in
+// place of ours. Either way, the packages themselves must still carry our authenticode signature.
+func trustedReleaseKeys() ([]*releaseKey, error) {
+	encodedKeys := releasePublicKeysBase64
+	if pinned, ok := conf.AdminString("UpdateServerPublicKey"); ok {
+		encodedKeys = strings.Fields(pinned)
+		if len(encodedKeys) == 0 {
+			return nil, errors.New("Invalid update server public key policy: no keys")
+		}
+	}
+	keys := make([]*releaseKey, 0, len(encodedKeys))
+	for _, encoded := range encodedKeys {
+		key, err := parseReleaseKey(encoded)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
@@ -7,7 +7,10 @@ package updater
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha512"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
@@ -18,18 +21,86 @@ import (
 
 /*
  * Generate with:
- *   $ b2sum -l 256 *.msi > list
+ *   $ b2sum -l 256 *.msi release-notes-*.txt > list
  *   $ signify -S -e -s release.sec -m list
  *   $ upload ./list.sec
+ *
+ * To sign with additional keys, such as the successor of the current key during a rotation, append a field of
+ * the form "sig=<base64 signature>" to the untrusted comment for each, where the signature has the same layout as
+ * a signify one: a two byte algorithm, the eight byte key number, and the signature proper, over the same content.
+ * Clients that predate this ignore the comment, and so keep verifying the signify signature on the second line.
  */
 
+const (
+	signatureAlgorithmEd25519   = "Ed" // As made by signify
+	signatureAlgorithmECDSAP384 = "E3" // ECDSA over P-384 of the SHA-384 of the content, with an ASN.1 signature
+)
+
+const signatureKeyNumberSize = 8
+
+type releaseKey struct {
+	algorithm string
+	keyNumber [signatureKeyNumberSize]byte
+	ed25519   ed25519.PublicKey
+	ecdsa     *ecdsa.PublicKey
+}
+
+func parseReleaseKey(encoded string) (*releaseKey, error) {
+	keyBytes, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(keyBytes) < 2+signatureKeyNumberSize {
+		return nil, errors.New("Invalid public key")
+	}
+	key := &releaseKey{algorithm: string(keyBytes[:2])}
+	copy(key.keyNumber[:], keyBytes[2:])
+	keyBytes = keyBytes[2+signatureKeyNumberSize:]
+	switch key.algorithm {
+	case signatureAlgorithmEd25519:
+		if len(keyBytes) != ed25519.PublicKeySize {
+			return nil, errors.New("Invalid public key")
+		}
+		key.ed25519 = ed25519.PublicKey(keyBytes)
+	case signatureAlgorithmECDSAP384:
+		x, y := elliptic.Unmarshal(elliptic.P384(), keyBytes)
+		if x == nil {
+			return nil, errors.New("Invalid public key")
+		}
+		key.ecdsa = &ecdsa.PublicKey{Curve: elliptic.P384(), X: x, Y: y}
+	default:
+		return nil, errors.New("Invalid public key")
+	}
+	return key, nil
+}
+
+// verify reports whether the signature, in signify layout, was made over the content by this key. Signatures by
+// other keys, or with algorithms this client has never heard of, are simply not ours.
+func (key *releaseKey) verify(content, signature []byte) bool {
+	if len(signature) < 2+signatureKeyNumberSize || string(signature[:2]) != key.algorithm || !bytes.Equal(signature[2:2+signatureKeyNumberSize], key.keyNumber[:]) {
+		return false
+	}
+	signature = signature[2+signatureKeyNumberSize:]
+	switch key.algorithm {
+	case signatureAlgorithmEd25519:
+		return len(signature) == ed25519.SignatureSize && ed25519.Verify(key.ed25519, content, signature)
+	case signatureAlgorithmECDSAP384:
+		digest := sha512.Sum384(content)
+		return ecdsa.VerifyASN1(key.ecdsa, digest[:], signature)
+	}
+	return false
+}
+
 type fileList map[string][blake2b.Size256]byte
 
 func readFileList(input []byte) (fileList, error) {
-	publicKeyBytes, err := base64.StdEncoding.DecodeString(releasePublicKeyBase64)
-	if err != nil || len(publicKeyBytes) != ed25519.PublicKeySize+10 || publicKeyBytes[0] != 'E' || publicKeyBytes[1] != 'd' {
-		return nil, errors.New("Invalid public key")
+	keys, err := trustedReleaseKeys()
+	if err != nil {
+		return nil, err
 	}
+	return verifyFileList(input, keys)
+}
+
+// verifyFileList accepts the file list if any of its signatures was made by any of the keys, so that a list signed
+// by both the outgoing and the incoming key satisfies clients that know only one of them.
+func verifyFileList(input []byte, keys []*releaseKey) (fileList, error) {
 	lines := bytes.SplitN(input, []byte{'\n'}, 3)
 	if len(lines) != 3 {
 		return nil, errors.New("Signature input has too few lines")
@@ -37,15 +108,26 @@ func readFileList(input []byte) (fileList, error) {
 	if !bytes.HasPrefix(lines[0], []byte("untrusted comment: ")) {
 		return nil, errors.New("Signature input is missing untrusted comment")
 	}
-	signatureBytes, err := base64.StdEncoding.DecodeString(string(lines[1]))
-	if err != nil {
-		return nil, errors.New("Signature input is not valid base64")
+	encodedSignatures := []string{string(lines[1])}
+	for _, field := range strings.Fields(string(lines[0])) {
+		if strings.HasPrefix(field, "sig=") {
+			encodedSignatures = append(encodedSignatures, strings.TrimPrefix(field, "sig="))
+		}
 	}
-	if len(signatureBytes) != ed25519.SignatureSize+10 || !bytes.Equal(signatureBytes[:10], publicKeyBytes[:10]) {
-		return nil, errors.New("Signature input bytes are incorrect length, type, or keyid")
+	verified := false
+	for _, encoded := range encodedSignatures {
+		signature, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+		for _, key := range keys {
+			if key.verify(lines[2], signature) {
+				verified = true
+			}
+		}
 	}
-	if !ed25519.Verify(publicKeyBytes[10:], lines[2], signatureBytes[10:]) {
-		return nil, errors.New("Signature is invalid")
+	if !verified {
+		return nil, errors.New("Signature is invalid or made by an unknown key")
 	}
 	fileLines := strings.Split(string(lines[2]), "\n")
 	fileHashes := make(map[string][blake2b.Size256]byte, len(fileLines))
@@ -0,0 +1,91 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package updater
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+const testFileList = "0000000000000000000000000000000000000000000000000000000000000000  wireguard-amd64-0.9.9.msi\n"
+
+func testEd25519Key(t *testing.T, keyNumber byte) (*releaseKey, func([]byte) string) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := &releaseKey{algorithm: signatureAlgorithmEd25519, ed25519: public}
+	key.keyNumber[0] = keyNumber
+	return key, func(content []byte) string {
+		signature := append([]byte(signatureAlgorithmEd25519), key.keyNumber[:]...)
+		return base64.StdEncoding.EncodeToString(append(signature, ed25519.Sign(private, content)...))
+	}
+}
+
+func testECDSAKey(t *testing.T, keyNumber byte) (*releaseKey, func([]byte) string) {
+	private, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := append([]byte(signatureAlgorithmECDSAP384), keyNumber, 0, 0, 0, 0, 0, 0, 0)
+	encoded = append(encoded, elliptic.Marshal(elliptic.P384(), private.X, private.Y)...)
+	key, err := parseReleaseKey(base64.StdEncoding.EncodeToString(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, func(content []byte) string {
+		digest := sha512.Sum384(content)
+		signed, err := ecdsa.SignASN1(rand.Reader, private, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature := append([]byte(signatureAlgorithmECDSAP384), key.keyNumber[:]...)
+		return base64.StdEncoding.EncodeToString(append(signature, signed...))
+	}
+}
+
+func TestVerifyFileList(t *testing.T) {
+	oldKey, oldSign := testEd25519Key(t, 1)
+	newKey, newSign := testECDSAKey(t, 2)
+	_, strangerSign := testEd25519Key(t, 3)
+	content := []byte(testFileList)
+	dualSigned := []byte("untrusted comment: verify with release.pub sig=" + newSign(content) + "\n" + oldSign(content) + "\n" + testFileList)
+
+	for _, test := range []struct {
+		name  string
+		input []byte
+		keys  []*releaseKey
+		valid bool
+	}{
+		{"signify", []byte("untrusted comment: verify with release.pub\n" + oldSign(content) + "\n" + testFileList), []*releaseKey{oldKey}, true},
+		{"dual signed, old client", dualSigned, []*releaseKey{oldKey}, true},
+		{"dual signed, new client", dualSigned, []*releaseKey{newKey}, true},
+		{"dual signed, both keys", dualSigned, []*releaseKey{oldKey, newKey}, true},
+		{"unknown key", []byte("untrusted comment: verify with release.pub\n" + strangerSign(content) + "\n" + testFileList), []*releaseKey{oldKey, newKey}, false},
+		{"tampered", []byte(strings.Replace(string(dualSigned), "0.9.9", "0.9.8", 1)), []*releaseKey{oldKey, newKey}, false},
+	} {
+		files, err := verifyFileList(test.input, test.keys)
+		if test.valid && (err != nil || len(files) != 1) {
+			t.Errorf("%s: expected valid file list, got %v", test.name, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%s: expected invalid file list", test.name)
+		}
+	}
+}
+
+func TestReleaseKeys(t *testing.T) {
+	for _, encoded := range releasePublicKeysBase64 {
+		if _, err := parseReleaseKey(encoded); err != nil {
+			t.Errorf("%s: %v", encoded, err)
+		}
+	}
+}
@@ -10,7 +10,7 @@ import (
 )
 
 func TestUpdate(t *testing.T) {
-	update, err := CheckForUpdate()
+	update, err := CheckForUpdate(StableChannel)
 	if err != nil {
 		t.Error(err)
 		return
@@ -20,7 +20,7 @@ func TestUpdate(t *testing.T) {
 		return
 	}
 	t.Log("Found update")
-	progress := DownloadVerifyAndExecute(0, nil)
+	progress := DownloadVerifyAndExecute(0, StableChannel)
 	for {
 		dp := <-progress
 		if dp.Error != nil {
@@ -56,6 +56,7 @@ func versionNewerThanUs(candidate string) (bool, error) {
 func findCandidate(candidates fileList) (*UpdateFound, error) {
 	prefix := fmt.Sprintf(msiArchPrefix, version.NativeArch())
 	suffix := msiSuffix
+	ourVersion := version.Number
 	for name, hash := range candidates {
 		if strings.HasPrefix(name, prefix) && strings.HasSuffix(name, suffix) {
 			version := strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix)
@@ -67,7 +68,15 @@ func findCandidate(candidates fileList) (*UpdateFound, error) {
 				return nil, err
 			}
 			if newer {
-				return &UpdateFound{name, hash}, nil
+				update := &UpdateFound{name: name, version: version, hash: hash}
+				patchName := prefix + version + mspFromInfix + ourVersion + mspSuffix
+				if patchHash, ok := candidates[patchName]; ok {
+					update.patch = &patchFound{patchName, patchHash}
+				}
+				if notesHash, ok := candidates[fmt.Sprintf(releaseNotesName, version)]; ok {
+					update.releaseNotesHash = &notesHash
+				}
+				return update, nil
 			}
 		}
 	}
@@ -0,0 +1,40 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+package winhttp
+
+type _HINTERNET uintptr
+
+const (
+	_WINHTTP_ACCESS_TYPE_DEFAULT_PROXY   = 0
+	_WINHTTP_ACCESS_TYPE_AUTOMATIC_PROXY = 4
+
+	_WINHTTP_FLAG_SECURE = 0x00800000
+
+	_WINHTTP_OPTION_SECURE_PROTOCOLS     = 84
+	_WINHTTP_FLAG_SECURE_PROTOCOL_TLS1_2 = 0x00000800
+	_WINHTTP_FLAG_SECURE_PROTOCOL_TLS1_3 = 0x00002000
+
+	_WINHTTP_QUERY_STATUS_CODE    = 19
+	_WINHTTP_QUERY_CONTENT_LENGTH = 5
+	_WINHTTP_QUERY_FLAG_NUMBER    = 0x20000000
+
+	_WINHTTP_AUTH_TARGET_PROXY = 1
+
+	_WINHTTP_AUTH_SCHEME_NTLM      = 0x00000002
+	_WINHTTP_AUTH_SCHEME_NEGOTIATE = 0x00000010
+)
+
+//sys	winHttpOpen(userAgent *uint16, accessType uint32, proxy *uint16, proxyBypass *uint16, flags uint32) (sessionHandle _HINTERNET, err error) = winhttp.WinHttpOpen
+//sys	winHttpCloseHandle(handle _HINTERNET) (err error) = winhttp.WinHttpCloseHandle
+//sys	winHttpConnect(sessionHandle _HINTERNET, serverName *uint16, serverPort uint16, reserved uint32) (connectHandle _HINTERNET, err error) = winhttp.WinHttpConnect
+//sys	winHttpOpenRequest(connectHandle _HINTERNET, verb *uint16, objectName *uint16, version *uint16, referrer *uint16, acceptTypes **uint16, flags uint32) (requestHandle _HINTERNET, err error) = winhttp.WinHttpOpenRequest
+//sys	winHttpSendRequest(requestHandle _HINTERNET, headers *uint16, headersLength uint32, optional *byte, optionalLength uint32, totalLength uint32, context uintptr) (err error) = winhttp.WinHttpSendRequest
+//sys	winHttpReceiveResponse(requestHandle _HINTERNET, reserved uintptr) (err error) = winhttp.WinHttpReceiveResponse
+//sys	winHttpQueryHeaders(requestHandle _HINTERNET, infoLevel uint32, name *uint16, buffer unsafe.Pointer, bufferLen *uint32, index *uint32) (err error) = winhttp.WinHttpQueryHeaders
+//sys	winHttpReadData(requestHandle _HINTERNET, buffer *byte, bufferSize uint32, bytesRead *uint32) (err error) = winhttp.WinHttpReadData
+//sys	winHttpQueryAuthSchemes(requestHandle _HINTERNET, supportedSchemes *uint32, firstScheme *uint32, authTarget *uint32) (err error) = winhttp.WinHttpQueryAuthSchemes
+//sys	winHttpSetCredentials(requestHandle _HINTERNET, authTargets uint32, authScheme uint32, userName *uint16, password *uint16, params uintptr) (err error) = winhttp.WinHttpSetCredentials
+//sys	winHttpSetOption(handle _HINTERNET, option uint32, buffer unsafe.Pointer, bufferLen uint32) (err error) = winhttp.WinHttpSetOption
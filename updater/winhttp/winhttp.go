@@ -0,0 +1,217 @@
+/* SPDX-License-Identifier: MIT
+ *
+ * Copyright (C) 2019-2020 WireGuard LLC. All Rights Reserved.
+ */
+
+// Package winhttp fetches files with WinHTTP rather than net/http, so that requests use the same proxy as the rest
+// of the system, from the WinHTTP settings, WPAD, or a PAC file, and can authenticate to it with the credentials of
+// the account that they run as.
+package winhttp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+type Session struct {
+	handle _HINTERNET
+}
+
+type Response struct {
+	StatusCode    int
+	ContentLength int64 // Or -1 if the server did not say
+
+	connect _HINTERNET
+	request _HINTERNET
+}
+
+func NewSession(userAgent string) (*Session, error) {
+	userAgent16, err := windows.UTF16PtrFromString(userAgent)
+	if err != nil {
+		return nil, err
+	}
+	// Automatic proxy selection, which also considers per-user settings and fails over between proxies, is only
+	// available starting with Windows 8.1, so older systems make do with the WinHTTP default proxy.
+	handle, err := winHttpOpen(userAgent16, _WINHTTP_ACCESS_TYPE_AUTOMATIC_PROXY, nil, nil, 0)
+	if err == windows.ERROR_INVALID_PARAMETER {
+		handle, err = winHttpOpen(userAgent16, _WINHTTP_ACCESS_TYPE_DEFAULT_PROXY, nil, nil, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	session := &Session{handle}
+	protocols := uint32(_WINHTTP_FLAG_SECURE_PROTOCOL_TLS1_2 | _WINHTTP_FLAG_SECURE_PROTOCOL_TLS1_3)
+	if winHttpSetOption(handle, _WINHTTP_OPTION_SECURE_PROTOCOLS, unsafe.Pointer(&protocols), uint32(unsafe.Sizeof(protocols))) != nil {
+		protocols = _WINHTTP_FLAG_SECURE_PROTOCOL_TLS1_2
+		winHttpSetOption(handle, _WINHTTP_OPTION_SECURE_PROTOCOLS, unsafe.Pointer(&protocols), uint32(unsafe.Sizeof(protocols)))
+	}
+	// The autologon policy is left at its default, so that the credentials of the current account are only handed to
+	// what WinHTTP considers the intranet, rather than to any server or proxy that asks for them.
+	return session, nil
+}
+
+func (session *Session) Close() error {
+	return winHttpCloseHandle(session.handle)
+}
+
+// Get sends a GET request for the URL, answering a proxy's demand for authentication with the credentials of the
+// current account. The caller must close the response even when the status code is not one of success.
+func (session *Session) Get(rawURL string) (*Response, error) {
+	return session.get(rawURL, "")
+}
+
+// GetFrom is like Get, but asks for the content starting at offset, such as to resume an interrupted download. A
+// server that honors this answers with 206 and a ContentLength of what remains, while one that does not answers with
+// 200 and the whole content, so the caller must check which it got.
+func (session *Session) GetFrom(rawURL string, offset int64) (*Response, error) {
+	if offset <= 0 {
+		return session.get(rawURL, "")
+	}
+	return session.get(rawURL, fmt.Sprintf("Range: bytes=%d-", offset))
+}
+
+func (session *Session) get(rawURL string, headers string) (*Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	var port uint16
+	var flags uint32
+	switch u.Scheme {
+	case "https":
+		port = 443
+		flags = _WINHTTP_FLAG_SECURE
+	case "http":
+		port = 80
+	default:
+		return nil, fmt.Errorf("Unsupported URL scheme %q", u.Scheme)
+	}
+	if len(u.Port()) > 0 {
+		p, err := strconv.ParseUint(u.Port(), 10, 16)
+		if err != nil {
+			return nil, err
+		}
+		port = uint16(p)
+	}
+	host16, err := windows.UTF16PtrFromString(u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	object16, err := windows.UTF16PtrFromString(u.RequestURI())
+	if err != nil {
+		return nil, err
+	}
+	get16, _ := windows.UTF16PtrFromString("GET")
+	var headers16 []uint16
+	if len(headers) > 0 {
+		headers16, err = windows.UTF16FromString(headers)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response := &Response{ContentLength: -1}
+	response.connect, err = winHttpConnect(session.handle, host16, port, 0)
+	if err != nil {
+		return nil, err
+	}
+	response.request, err = winHttpOpenRequest(response.connect, get16, object16, nil, nil, nil, flags)
+	if err != nil {
+		response.Close()
+		return nil, err
+	}
+	for authenticated := false; ; authenticated = true {
+		if len(headers16) > 1 {
+			err = winHttpSendRequest(response.request, &headers16[0], uint32(len(headers16)-1), nil, 0, 0, 0)
+		} else {
+			err = winHttpSendRequest(response.request, nil, 0, nil, 0, 0, 0)
+		}
+		if err == nil {
+			err = winHttpReceiveResponse(response.request, 0)
+		}
+		if err == nil {
+			response.StatusCode, err = response.queryNumber(_WINHTTP_QUERY_STATUS_CODE)
+		}
+		if err != nil {
+			response.Close()
+			return nil, err
+		}
+		if response.StatusCode != 407 || authenticated {
+			break
+		}
+		err = response.authenticateToProxy()
+		if err != nil {
+			response.Close()
+			return nil, err
+		}
+	}
+	if length, err := response.queryNumber(_WINHTTP_QUERY_CONTENT_LENGTH); err == nil {
+		response.ContentLength = int64(length)
+	}
+	return response, nil
+}
+
+// authenticateToProxy picks the strongest scheme that the proxy offers for which the credentials of the current
+// account can be used, since nothing asks the user for others.
+func (response *Response) authenticateToProxy() error {
+	var supported, first, target uint32
+	err := winHttpQueryAuthSchemes(response.request, &supported, &first, &target)
+	if err != nil {
+		return err
+	}
+	if target != _WINHTTP_AUTH_TARGET_PROXY {
+		return errors.New("Server demanded proxy authentication for a target other than the proxy")
+	}
+	for _, scheme := range []uint32{_WINHTTP_AUTH_SCHEME_NEGOTIATE, _WINHTTP_AUTH_SCHEME_NTLM} {
+		if supported&scheme != 0 {
+			return winHttpSetCredentials(response.request, _WINHTTP_AUTH_TARGET_PROXY, scheme, nil, nil, 0)
+		}
+	}
+	return errors.New("Proxy requires authentication, but does not accept Windows credentials")
+}
+
+func (response *Response) queryNumber(infoLevel uint32) (int, error) {
+	var value uint32
+	size := uint32(unsafe.Sizeof(value))
+	err := winHttpQueryHeaders(response.request, infoLevel|_WINHTTP_QUERY_FLAG_NUMBER, nil, unsafe.Pointer(&value), &size, nil)
+	if err != nil {
+		return 0, err
+	}
+	return int(value), nil
+}
+
+func (response *Response) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if len(p) > 1<<30 {
+		p = p[:1<<30]
+	}
+	var bytesRead uint32
+	err := winHttpReadData(response.request, &p[0], uint32(len(p)), &bytesRead)
+	if err != nil {
+		return 0, err
+	}
+	if bytesRead == 0 {
+		return 0, io.EOF
+	}
+	return int(bytesRead), nil
+}
+
+func (response *Response) Close() error {
+	if response.request != 0 {
+		winHttpCloseHandle(response.request)
+		response.request = 0
+	}
+	if response.connect != 0 {
+		winHttpCloseHandle(response.connect)
+		response.connect = 0
+	}
+	return nil
+}
@@ -58,12 +58,12 @@ var messageKeyToIndex = map[string]int{
 	"%.2f\u00a0KiB":                         18,
 	"%.2f\u00a0MiB":                         19,
 	"%.2f\u00a0TiB":                         21,
-	"%d day(s)":                             12,
-	"%d hour(s)":                            13,
-	"%d minute(s)":                          14,
-	"%d second(s)":                          15,
+	"%d days":                               12,
+	"%d hours":                              13,
+	"%d minutes":                            14,
+	"%d seconds":                            15,
 	"%d tunnels were unable to be removed.": 154,
-	"%d year(s)":                            11,
+	"%d years":                              11,
 	"%d\u00a0B":                             17,
 	"%s\n\nPlease consult the log for more information.": 108,
 	"%s (out of date)":                        109,
@@ -87,7 +87,7 @@ var messageKeyToIndex = map[string]int{
 	"&Save":                                   81,
 	"&Save to file…":                          101,
 	"&Toggle":                                 134,
-	"&Tunnels":                                181,
+	"&Tunnels":                                176,
 	"(no argument): elevate and install manager service": 1,
 	"(unknown)":                             79,
 	"A name is required.":                   85,
@@ -98,96 +98,95 @@ var messageKeyToIndex = map[string]int{
 	"Add &empty tunnel…":                    130,
 	"Add Tunnel":                            131,
 	"Addresses:":                            60,
-	"Addresses: %s":                         182,
+	"Addresses: %s":                         175,
 	"Addresses: None":                       114,
 	"All peers must have public keys":       43,
 	"Allowed IPs:":                          63,
 	"An Update is Available!":               125,
 	"An interface must have a private key":  41,
-	"An update to WireGuard is available. It is highly advisable to update without delay.":               162,
-	"An update to WireGuard is now available. You are advised to update as soon as possible.":            127,
-	"Another tunnel already exists with the name ‘%s’":                                                   140,
-	"Another tunnel already exists with the name ‘%s’.":                                                  89,
-	"App version: %s\nGo backend version: %s\nGo version: %s-%s\nOperating system: %s\nArchitecture: %s": 173,
-	"Are you sure you would like to delete %d tunnels?":                                                  147,
-	"Are you sure you would like to delete tunnel ‘%s’?":                                                 149,
-	"Brackets must contain an IPv6 address":                                                              27,
-	"Cancel":                                                                                             82,
-	"Close":                                                                                              52,
-	"Command Line Options":                                                                               3,
-	"Config key is missing an equals separator":                                                          37,
-	"Configuration Files (*.zip, *.conf)|*.zip;*.conf|All Files (*.*)|*.*":                               155,
-	"Configuration ZIP Files (*.zip)|*.zip":                                                              157,
-	"Could not enumerate existing tunnels: %v":                                                           139,
-	"Could not import selected configuration: %v":                                                        138,
-	"Create new tunnel":                                                                                  75,
-	"DNS servers:":                                                                                       61,
-	"Deactivating":                                                                                       96,
-	"Delete %d tunnels":                                                                                  146,
-	"Delete tunnel ‘%s’":                                                                                 148,
-	"E&xit":                                                                                              117,
-	"Edit &selected tunnel…":                                                                             136,
-	"Edit tunnel":                                                                                        76,
-	"Endpoint:":                                                                                          64,
-	"Error":                                                                                              0,
-	"Error Exiting WireGuard":                                                                            160,
-	"Error in getting configuration":                                                                     44,
-	"Error: %v. Please try again.":                                                                       166,
-	"Export all tunnels to &zip…":                                                                        135,
-	"Export all tunnels to zip":                                                                          133,
-	"Export log to file":                                                                                 105,
-	"Export tunnels to zip":                                                                              158,
-	"Failed to activate tunnel":                                                                          71,
-	"Failed to deactivate tunnel":                                                                        72,
-	"Failed to determine tunnel state":                                                                   70,
-	"File ‘%s’ already exists.\n\nDo you want to overwrite it?":                                          92,
-	"Import tunnel(s) from file":                                                                         156,
-	"Imported %d of %d tunnels":                                                                          144,
-	"Imported %d tunnels":                                                                                143,
-	"Imported tunnels":                                                                                   142,
-	"Inactive":                                                                                           95,
-	"Interface: %s":                                                                                      73,
-	"Invalid IP address":                                                                                 23,
-	"Invalid MTU":                                                                                        28,
-	"Invalid endpoint host":                                                                              26,
-	"Invalid key for [Interface] section":                                                                39,
-	"Invalid key for [Peer] section":                                                                     40,
-	"Invalid key for interface section":                                                                  45,
-	"Invalid key for peer section":                                                                       47,
-	"Invalid key: %v":                                                                                    31,
-	"Invalid name":                                                                                       84,
-	"Invalid network prefix length":                                                                      24,
-	"Invalid persistent keepalive":                                                                       30,
-	"Invalid port":                                                                                       29,
-	"Key must have a value":                                                                              38,
-	"Keys must decode to exactly 32 bytes":                                                               32,
-	"Latest handshake:":                                                                                  66,
-	"Line must occur in a section":                                                                       36,
-	"Listen port:":                                                                                       58,
-	"Log":                                                                                                98,
-	"Log message":                                                                                        103,
-	"MTU:":                                                                                               59,
-	"Missing port from endpoint":                                                                         25,
-	"Now":                                                                                                9,
-	"Number must be a number between 0 and 2^64-1: %v":                                                   33,
+	"An update to WireGuard is available. It is highly advisable to update without delay.":            162,
+	"An update to WireGuard is now available. You are advised to update as soon as possible.":         127,
+	"Another tunnel already exists with the name ‘%s’":                                                140,
+	"Another tunnel already exists with the name ‘%s’.":                                               89,
+	"App version: %s\nGo backend version: %s\nGo version: %s\nOperating system: %s\nArchitecture: %s": 173,
+	"Are you sure you would like to delete %d tunnels?":                                               147,
+	"Are you sure you would like to delete tunnel ‘%s’?":                                              149,
+	"Brackets must contain an IPv6 address":                                                           27,
+	"Cancel":                                                                                          82,
+	"Close":                                                                                           52,
+	"Command Line Options":                                                                            3,
+	"Config key is missing an equals separator":                                                       37,
+	"Configuration Files (*.zip, *.conf)|*.zip;*.conf|All Files (*.*)|*.*":                            155,
+	"Configuration ZIP Files (*.zip)|*.zip":                                                           157,
+	"Could not enumerate existing tunnels: %v":                                                        139,
+	"Could not import selected configuration: %v":                                                     138,
+	"Create new tunnel":                                                                               75,
+	"DNS servers:":                                                                                    61,
+	"Deactivating":                                                                                    96,
+	"Delete %d tunnels":                                                                               146,
+	"Delete tunnel ‘%s’":                                                                              148,
+	"E&xit":                                                                                           117,
+	"Edit &selected tunnel…":                                                                          136,
+	"Edit tunnel":                                                                                     76,
+	"Endpoint:":                                                                                       64,
+	"Error":                                                                                           0,
+	"Error Exiting WireGuard":                                                                         160,
+	"Error in getting configuration":                                                                  44,
+	"Error: %v. Please try again.":                                                                    166,
+	"Export all tunnels to &zip…":                                                                     135,
+	"Export all tunnels to zip":                                                                       133,
+	"Export log to file":                                                                              105,
+	"Export tunnels to zip":                                                                           158,
+	"Failed to activate tunnel":                                                                       71,
+	"Failed to deactivate tunnel":                                                                     72,
+	"Failed to determine tunnel state":                                                                70,
+	"File ‘%s’ already exists.\n\nDo you want to overwrite it?":                                       92,
+	"Import tunnel(s) from file":                                                                      156,
+	"Imported %d of %d tunnels":                                                                       144,
+	"Imported %d tunnels":                                                                             143,
+	"Imported tunnels":                                                                                142,
+	"Inactive":                                                                                        95,
+	"Interface: %s":                                                                                   73,
+	"Invalid IP address":                                                                              23,
+	"Invalid MTU":                                                                                     28,
+	"Invalid endpoint host":                                                                           26,
+	"Invalid key for [Interface] section":                                                             39,
+	"Invalid key for [Peer] section":                                                                  40,
+	"Invalid key for interface section":                                                               45,
+	"Invalid key for peer section":                                                                    47,
+	"Invalid key: %v":                                                                                 31,
+	"Invalid name":                                                                                    84,
+	"Invalid network prefix length":                                                                   24,
+	"Invalid persistent keepalive":                                                                    30,
+	"Invalid port":                                                                                    29,
+	"Key must have a value":                                                                           38,
+	"Keys must decode to exactly 32 bytes":                                                            32,
+	"Latest handshake:":                                                                               66,
+	"Line must occur in a section":                                                                    36,
+	"Listen port:":                                                                                    58,
+	"Log":                                                                                             98,
+	"Log message":                                                                                     103,
+	"MTU:":                                                                                            59,
+	"Missing port from endpoint":                                                                      25,
+	"Now":                                                                                             9,
+	"Number must be a number between 0 and 2^64-1: %v":                                                33,
 	"Peer":                                74,
 	"Persistent keepalive:":               65,
 	"Preshared key:":                      62,
 	"Protocol version must be 1":          46,
 	"Public key:":                         57,
 	"Remove selected tunnel(s)":           132,
-	"Scripts:":                            174,
 	"Select &all":                         100,
 	"Status:":                             54,
-	"Status: %s":                          124,
+	"Status: %s":                          120,
 	"Status: Complete!":                   167,
 	"Status: Unknown":                     113,
 	"Status: Waiting for updater service": 165,
 	"Status: Waiting for user":            163,
 	"System clock wound backward!":        10,
 	"Text Files (*.txt)|*.txt|All Files (*.*)|*.*": 104,
-	"The %s tunnel has been activated.":            119,
-	"The %s tunnel has been deactivated.":          121,
+	"The %s tunnel has been activated.":            122,
+	"The %s tunnel has been deactivated.":          124,
 	"Time":                                         102,
 	"Transfer:":                                    67,
 	"Tunnel Error":                                 107,
@@ -209,38 +208,34 @@ var messageKeyToIndex = map[string]int{
 	"Unknown state":    97,
 	"Update Now":       164,
 	"Usage: %s [\n%s]": 2,
-	"When a configuration has exactly one peer, and that peer has an allowed IPs containing at least one of 0.0.0.0/0 or ::/0, then the tunnel service engages a firewall ruleset to block all traffic that is neither to nor from the tunnel interface or is to the wrong DNS server, with special exceptions for DHCP and NDP.": 180,
-	"WireGuard Activated":        118,
-	"WireGuard Deactivated":      120,
+	"When a configuration has exactly one peer, and that peer has an allowed IPs containing at least one of 0.0.0.0/0 or ::/0, then the tunnel service engages a firewall ruleset to block all traffic that is neither to nor from the tunnel interface, with special exceptions for DHCP and NDP.": 174,
+	"WireGuard Activated":        121,
+	"WireGuard Deactivated":      123,
 	"WireGuard Detection Error":  110,
-	"WireGuard Tunnel Error":     122,
+	"WireGuard Tunnel Error":     118,
 	"WireGuard Update Available": 126,
 	"WireGuard is running, but the UI is only accessible from desktops of the Builtin %s group.": 7,
 	"WireGuard logo image": 51,
 	"WireGuard may only be used by users who are a member of the Builtin %s group.": 6,
 	"WireGuard system tray icon did not appear after 30 seconds.":                   8,
-	"WireGuard: %s":          123,
+	"WireGuard: %s":          119,
 	"WireGuard: Deactivated": 112,
 	"Writing file failed":    91,
-	"You must use the native version of WireGuard on this computer.": 172,
+	"You must use the 64-bit version of WireGuard on this computer.": 172,
+	"You must use the native version of WireGuard on this computer.": 178,
 	"[EnumerationSeparator]": 48,
 	"[UnitSeparator]":        49,
 	"[none specified]":       42,
-	"disabled, per policy":   179,
 	"enabled":                68,
 	"http2: Framer %p: failed to decode just-written frame": 168,
 	"http2: Framer %p: read %v":                             170,
 	"http2: Framer %p: wrote %v":                            169,
 	"http2: decoded hpack field %+v":                        171,
-	"no configuration files were found":                     183,
-	"post-down":                                             178,
-	"post-up":                                               176,
-	"pre-down":                                              177,
-	"pre-up":                                                175,
+	"no configuration files were found":                     177,
 	"♥ &Donate!":                                            53,
 }
 
-var deIndex = []uint32{ // 185 elements
+var deIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x00000007, 0x00000059, 0x00000074,
 	0x0000008b, 0x000000e1, 0x00000115, 0x0000016c,
@@ -274,9 +269,9 @@ var deIndex = []uint32{ // 185 elements
 	0x00000b7f, 0x00000bb0, 0x00000bce, 0x00000be2,
 	0x00000bf0, 0x00000c31, 0x00000c42, 0x00000c5d,
 	0x00000ca5, 0x00000cbc, 0x00000cce, 0x00000cde,
-	0x00000cf3, 0x00000d14, 0x00000d1d, 0x00000d31,
-	0x00000d53, 0x00000d69, 0x00000d8d, 0x00000da5,
-	0x00000db6, 0x00000dc4, 0x00000de8, 0x00000e0c,
+	0x00000cf3, 0x00000d14, 0x00000d1d, 0x00000d35,
+	0x00000d46, 0x00000d54, 0x00000d68, 0x00000d8a,
+	0x00000da0, 0x00000dc4, 0x00000de8, 0x00000e0c,
 	// Entry 80 - 9F
 	0x00000e7f, 0x00000e86, 0x00000e92, 0x00000eb6,
 	0x00000ec9, 0x00000ee7, 0x00000f11, 0x00000f1d,
@@ -292,9 +287,7 @@ var deIndex = []uint32{ // 185 elements
 	0x00001594, 0x000015e0, 0x00001607, 0x0000162a,
 	0x0000164d, 0x0000164d, 0x0000164d, 0x0000164d,
 	0x0000164d, 0x0000164d, 0x0000164d, 0x0000164d,
-	0x0000164d, 0x0000164d, 0x0000164d, 0x0000164d,
-	0x0000164d,
-} // Size: 764 bytes
+} // Size: 744 bytes
 
 const deData string = "" + // Size: 5709 bytes
 	"\x02Fehler\x02(kein Argument): Als Administrator ausführen und den Manag" +
@@ -350,10 +343,10 @@ const deData string = "" + // Size: 5709 bytes
 	"[1]s (veraltet)\x02WireGuard Erkennungsfehler\x02Warten auf das Erschein" +
 	"en des WireGuard Fensters nicht möglich: %[1]v \x02WireGuard: Deaktivier" +
 	"t\x02Status: Unbekannt\x02Adressen: Keine\x02Tunnel &verwalten…\x02Tunne" +
-	"l aus Datei &importieren…\x02&Beenden\x02WireGuard aktiviert\x02Der Tunn" +
-	"el %[1]s wurde aktiviert.\x02WireGuard deaktiviert\x02Der Tunnel %[1]s w" +
-	"urde deaktiviert.\x02WireGuard Tunnel Fehler\x02WireGuard: %[1]s\x02Stat" +
-	"us: %[1]s\x02Eine Aktualisierung ist verfügbar!\x02WireGuard Aktualisier" +
+	"l aus Datei &importieren…\x02&Beenden\x02WireGuard Tunnel Fehler\x02Wire" +
+	"Guard: %[1]s\x02Status: %[1]s\x02WireGuard aktiviert\x02Der Tunnel %[1]s" +
+	" wurde aktiviert.\x02WireGuard deaktiviert\x02Der Tunnel %[1]s wurde dea" +
+	"ktiviert.\x02Eine Aktualisierung ist verfügbar!\x02WireGuard Aktualisier" +
 	"ung verfügbar\x02Eine Aktualisierung für WireGuard ist jetzt verfügbar. " +
 	"Es wird empfohlen diese schnellstmöglich durchzuführen.\x02Tunnel\x02&Be" +
 	"arbeiten\x02Einen &leeren Tunnel hinzufügen…\x02Tunnel hinzufügen\x02Mar" +
@@ -388,7 +381,7 @@ const deData string = "" + // Size: 5709 bytes
 	" Frames\x02http2: Framer %[1]p: %[2]v geschrieben\x02http2: Framer %[1]p" +
 	": %[2]v gelesen\x02http2: hpack Feld %+[1]v dekodiert"
 
-var enIndex = []uint32{ // 185 elements
+var enIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x00000006, 0x00000039, 0x0000004f,
 	0x00000064, 0x000000aa, 0x000000d6, 0x00000127,
@@ -422,9 +415,9 @@ var enIndex = []uint32{ // 185 elements
 	0x00000935, 0x00000962, 0x00000975, 0x00000989,
 	0x00000996, 0x000009ca, 0x000009de, 0x000009f8,
 	0x00000a2d, 0x00000a44, 0x00000a54, 0x00000a64,
-	0x00000a77, 0x00000a96, 0x00000a9c, 0x00000ab0,
-	0x00000ad5, 0x00000aeb, 0x00000b12, 0x00000b29,
-	0x00000b3a, 0x00000b48, 0x00000b60, 0x00000b7b,
+	0x00000a77, 0x00000a96, 0x00000a9c, 0x00000ab3,
+	0x00000ac4, 0x00000ad2, 0x00000ae6, 0x00000b0b,
+	0x00000b21, 0x00000b48, 0x00000b60, 0x00000b7b,
 	// Entry 80 - 9F
 	0x00000bd3, 0x00000bdb, 0x00000be1, 0x00000bf6,
 	0x00000c01, 0x00000c1b, 0x00000c35, 0x00000c3d,
@@ -438,13 +431,11 @@ var enIndex = []uint32{ // 185 elements
 	0x0000107b, 0x00001093, 0x000010f2, 0x00001147,
 	0x00001160, 0x0000116b, 0x0000118f, 0x000011af,
 	0x000011c1, 0x000011fa, 0x0000121b, 0x0000123b,
-	0x0000125d, 0x0000129c, 0x0000130d, 0x00001316,
-	0x0000131d, 0x00001325, 0x0000132e, 0x00001338,
-	0x0000134d, 0x00001489, 0x00001492, 0x000014a3,
-	0x000014c5,
-} // Size: 764 bytes
+	0x0000125d, 0x0000129c, 0x00001307, 0x00001425,
+	0x00001436, 0x00001436, 0x00001436, 0x00001436,
+} // Size: 744 bytes
 
-const enData string = "" + // Size: 5317 bytes
+const enData string = "" + // Size: 5174 bytes
 	"\x02Error\x02(no argument): elevate and install manager service\x02Usage" +
 	": %[1]s [\x0a%[2]s]\x02Command Line Options\x02Unable to determine wheth" +
 	"er the process is running under WOW64: %[1]v\x02Unable to open current p" +
@@ -490,16 +481,16 @@ const enData string = "" + // Size: 5317 bytes
 	"]s (out of date)\x02WireGuard Detection Error\x02Unable to wait for Wire" +
 	"Guard window to appear: %[1]v\x02WireGuard: Deactivated\x02Status: Unkno" +
 	"wn\x02Addresses: None\x02&Manage tunnels…\x02&Import tunnel(s) from file" +
-	"…\x02E&xit\x02WireGuard Activated\x02The %[1]s tunnel has been activat" +
-	"ed.\x02WireGuard Deactivated\x02The %[1]s tunnel has been deactivated." +
-	"\x02WireGuard Tunnel Error\x02WireGuard: %[1]s\x02Status: %[1]s\x02An Up" +
-	"date is Available!\x02WireGuard Update Available\x02An update to WireGua" +
-	"rd is now available. You are advised to update as soon as possible.\x02T" +
-	"unnels\x02&Edit\x02Add &empty tunnel…\x02Add Tunnel\x02Remove selected t" +
-	"unnel(s)\x02Export all tunnels to zip\x02&Toggle\x02Export all tunnels t" +
-	"o &zip…\x02Edit &selected tunnel…\x02&Remove selected tunnel(s)\x02Could" +
-	" not import selected configuration: %[1]v\x02Could not enumerate existin" +
-	"g tunnels: %[1]v\x02Another tunnel already exists with the name ‘%[1]s’" +
+	"…\x02E&xit\x02WireGuard Tunnel Error\x02WireGuard: %[1]s\x02Status: %[" +
+	"1]s\x02WireGuard Activated\x02The %[1]s tunnel has been activated.\x02Wi" +
+	"reGuard Deactivated\x02The %[1]s tunnel has been deactivated.\x02An Upda" +
+	"te is Available!\x02WireGuard Update Available\x02An update to WireGuard" +
+	" is now available. You are advised to update as soon as possible.\x02Tun" +
+	"nels\x02&Edit\x02Add &empty tunnel…\x02Add Tunnel\x02Remove selected tun" +
+	"nel(s)\x02Export all tunnels to zip\x02&Toggle\x02Export all tunnels to " +
+	"&zip…\x02Edit &selected tunnel…\x02&Remove selected tunnel(s)\x02Could n" +
+	"ot import selected configuration: %[1]v\x02Could not enumerate existing " +
+	"tunnels: %[1]v\x02Another tunnel already exists with the name ‘%[1]s’" +
 	"\x02Unable to import configuration: %[1]v\x02Imported tunnels\x14\x01" +
 	"\x81\x01\x00\x02\x16\x02Imported %[1]d tunnel\x00\x17\x02Imported %[1]d " +
 	"tunnels\x14\x02\x80\x01\x02\x1f\x02Imported %[1]d of %[2]d tunnel\x00 " +
@@ -522,17 +513,15 @@ const enData string = "" + // Size: 5317 bytes
 	"y again.\x02Status: Complete!\x02http2: Framer %[1]p: failed to decode j" +
 	"ust-written frame\x02http2: Framer %[1]p: wrote %[2]v\x02http2: Framer %" +
 	"[1]p: read %[2]v\x02http2: decoded hpack field %+[1]v\x02You must use th" +
-	"e native version of WireGuard on this computer.\x02App version: %[1]s" +
-	"\x0aGo backend version: %[2]s\x0aGo version: %[3]s-%[4]s\x0aOperating sy" +
-	"stem: %[5]s\x0aArchitecture: %[6]s\x02Scripts:\x02pre-up\x02post-up\x02p" +
-	"re-down\x02post-down\x02disabled, per policy\x02When a configuration has" +
-	" exactly one peer, and that peer has an allowed IPs containing at least " +
-	"one of 0.0.0.0/0 or ::/0, then the tunnel service engages a firewall rul" +
-	"eset to block all traffic that is neither to nor from the tunnel interfa" +
-	"ce or is to the wrong DNS server, with special exceptions for DHCP and N" +
-	"DP.\x02&Tunnels\x02Addresses: %[1]s\x02no configuration files were found"
+	"e 64-bit version of WireGuard on this computer.\x02App version: %[1]s" +
+	"\x0aGo backend version: %[2]s\x0aGo version: %[3]s\x0aOperating system: " +
+	"%[4]s\x0aArchitecture: %[5]s\x02When a configuration has exactly one pee" +
+	"r, and that peer has an allowed IPs containing at least one of 0.0.0.0/0" +
+	" or ::/0, then the tunnel service engages a firewall ruleset to block al" +
+	"l traffic that is neither to nor from the tunnel interface, with special" +
+	" exceptions for DHCP and NDP.\x02Addresses: %[1]s"
 
-var es_ESIndex = []uint32{ // 185 elements
+var es_ESIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x00000006, 0x00000044, 0x00000058,
 	0x00000058, 0x00000058, 0x00000058, 0x00000058,
@@ -584,15 +573,13 @@ var es_ESIndex = []uint32{ // 185 elements
 	0x00000058, 0x00000058, 0x00000058, 0x00000058,
 	0x00000058, 0x00000058, 0x00000058, 0x00000058,
 	0x00000058, 0x00000058, 0x00000058, 0x00000058,
-	0x00000058, 0x00000058, 0x00000058, 0x00000058,
-	0x00000058,
-} // Size: 764 bytes
+} // Size: 744 bytes
 
 const es_ESData string = "" + // Size: 88 bytes
 	"\x02Error\x02(sin argumento): eleva e instala el servicio de administrad" +
 	"or\x02Uso: %[1]s [\x0a%[2]s]"
 
-var faIndex = []uint32{ // 185 elements
+var faIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x00000007, 0x00000007, 0x00000007,
 	0x0000002b, 0x0000002b, 0x0000002b, 0x0000002b,
@@ -626,9 +613,9 @@ var faIndex = []uint32{ // 185 elements
 	0x000005dd, 0x000005dd, 0x00000619, 0x00000634,
 	0x00000648, 0x00000648, 0x00000648, 0x00000648,
 	0x00000648, 0x00000648, 0x00000665, 0x0000067f,
-	0x000006a0, 0x000006a0, 0x000006a0, 0x000006ba,
-	0x000006dc, 0x000006fa, 0x000006fa, 0x00000716,
-	0x00000716, 0x00000728, 0x0000075d, 0x00000796,
+	0x000006a0, 0x000006a0, 0x000006a0, 0x000006bc,
+	0x000006bc, 0x000006ce, 0x000006e8, 0x0000070a,
+	0x00000728, 0x00000728, 0x0000075d, 0x00000796,
 	// Entry 80 - 9F
 	0x00000796, 0x000007a6, 0x000007b4, 0x000007e2,
 	0x000007f8, 0x0000081d, 0x00000852, 0x00000852,
@@ -644,9 +631,7 @@ var faIndex = []uint32{ // 185 elements
 	0x00000bf6, 0x00000bf6, 0x00000bf6, 0x00000bf6,
 	0x00000bf6, 0x00000bf6, 0x00000bf6, 0x00000bf6,
 	0x00000bf6, 0x00000bf6, 0x00000bf6, 0x00000bf6,
-	0x00000bf6, 0x00000bf6, 0x00000bf6, 0x00000bf6,
-	0x00000bf6,
-} // Size: 764 bytes
+} // Size: 744 bytes
 
 const faData string = "" + // Size: 3062 bytes
 	"\x02خطا\x02گزینه\u200cهای خط فرمان\x02هم اکنون\x14\x01\x81\x01\x00\x02" +
@@ -669,14 +654,14 @@ const faData string = "" + // Size: 3062 bytes
 	"\x02وضعیت ناشناخته\x02گزارش وقایع\x02&روگرفت\x02&ذخیره در پرونده…\x02زما" +
 	"ن\x02پیام گزارش رویداد\x02برون\u200cبرد گزارش رویداد به پرونده\x02&دربا" +
 	"ره WireGuard…\x02خطالی تونل\x02وضعیت: ناشناخته\x02نشانی\u200cها: هیچ" +
-	"\x02&مدیریت تونل\u200cها…\x02WireGuard فعال\u200cشد\x02تونل %[1]s فعال" +
-	"\u200cشده.\x02WireGuard غیرفعال شد\x02خطای تونل WireGuard\x02وضعیت: %[1]" +
-	"s\x02یک به\u200cروزرسانی در دسترس است!\x02به\u200cروزرسانی WireGuard در " +
-	"دسترس است\x02تونل\u200cها\x02&ویرایش\x02افزودن &خالی\u200cکردن تونل…" +
-	"\x02افزودن تونل\x02حذف تونل(ها) انتخابی\x02برون\u200cبری همه تونل\u200cه" +
-	"ا به زیپ\x02برون\u200cبری همه تونل\u200cها به &زیپ…\x02تونل\u200cهای وا" +
-	"رد شده\x14\x01\x81\x01\x00\x02\x1d\x02%[1]d تونل وارد شد\x00\x1d\x02%[1" +
-	"]d تونل وارد شد\x14\x02\x80\x01\x02(\x02%[1]d از %[2]d تونل وارد شد\x00(" +
+	"\x02&مدیریت تونل\u200cها…\x02خطای تونل WireGuard\x02وضعیت: %[1]s\x02Wire" +
+	"Guard فعال\u200cشد\x02تونل %[1]s فعال\u200cشده.\x02WireGuard غیرفعال شد" +
+	"\x02یک به\u200cروزرسانی در دسترس است!\x02به\u200cروزرسانی WireGuard در د" +
+	"سترس است\x02تونل\u200cها\x02&ویرایش\x02افزودن &خالی\u200cکردن تونل…\x02" +
+	"افزودن تونل\x02حذف تونل(ها) انتخابی\x02برون\u200cبری همه تونل\u200cها ب" +
+	"ه زیپ\x02برون\u200cبری همه تونل\u200cها به &زیپ…\x02تونل\u200cهای وارد " +
+	"شده\x14\x01\x81\x01\x00\x02\x1d\x02%[1]d تونل وارد شد\x00\x1d\x02%[1]d " +
+	"تونل وارد شد\x14\x02\x80\x01\x02(\x02%[1]d از %[2]d تونل وارد شد\x00(" +
 	"\x02%[1]d از %[2]d تونل وارد شد\x02نمی\u200cتوان تونل ایجاد کرد\x14\x01" +
 	"\x81\x01\x00\x02\x16\x02حذف %[1]d تونل\x00\x16\x02حذف %[1]d تونل\x02حذف " +
 	"تونل ‘%[1]s’\x02حذف تونل\u200c امکان\u200cپذیر نیست\x02نمی\u200cتوان تو" +
@@ -686,7 +671,7 @@ const faData string = "" + // Size: 3062 bytes
 	" به\u200cروز رسانی کن\x02وضعیت: درانتظار برای سرویس به\u200cروزرسانی\x02" +
 	"خطا: %[1]v. لطفا دوباره تلاش کنید.\x02وضعیت: کامل شد!"
 
-var frIndex = []uint32{ // 185 elements
+var frIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x00000007, 0x00000046, 0x00000063,
 	0x00000083, 0x000000cb, 0x00000104, 0x00000166,
@@ -720,9 +705,9 @@ var frIndex = []uint32{ // 185 elements
 	0x00000be7, 0x00000c20, 0x00000c44, 0x00000c5c,
 	0x00000c6d, 0x00000cb7, 0x00000cc9, 0x00000ceb,
 	0x00000d2d, 0x00000d44, 0x00000d54, 0x00000d66,
-	0x00000d7e, 0x00000db0, 0x00000db9, 0x00000dcb,
-	0x00000de9, 0x00000dff, 0x00000e21, 0x00000e3c,
-	0x00000e4e, 0x00000e5c, 0x00000e75, 0x00000e9b,
+	0x00000d7e, 0x00000db0, 0x00000db9, 0x00000dd4,
+	0x00000de6, 0x00000df4, 0x00000e06, 0x00000e24,
+	0x00000e3a, 0x00000e5c, 0x00000e75, 0x00000e9b,
 	// Entry 80 - 9F
 	0x00000f10, 0x00000f18, 0x00000f22, 0x00000f3d,
 	0x00000f4f, 0x00000f7a, 0x00000f9d, 0x00000fa7,
@@ -738,9 +723,7 @@ var frIndex = []uint32{ // 185 elements
 	0x0000165a, 0x0000169f, 0x000016c4, 0x000016e6,
 	0x0000170a, 0x0000170a, 0x0000170a, 0x0000170a,
 	0x0000170a, 0x0000170a, 0x0000170a, 0x0000170a,
-	0x0000170a, 0x0000170a, 0x0000170a, 0x0000170a,
-	0x0000170a,
-} // Size: 764 bytes
+} // Size: 744 bytes
 
 const frData string = "" + // Size: 5898 bytes
 	"\x02Erreur\x02(sans argument) : élever et installer service du gestionna" +
@@ -797,10 +780,10 @@ const frData string = "" + // Size: 5898 bytes
 	"ît.\x02%[1]s (obsolète)\x02Erreur de détection du WireGuard\x02Impossib" +
 	"le d’attendre l'affichage du fenêtre WireGuard : %[1]v\x02WireGuard: Dés" +
 	"activé\x02État : Inconnu\x02Adresses : Aucune\x02&Gestion des tunnels…" +
-	"\x02&Importer le(s) tunnel(s) à partir du fichier…\x02Q&uitter\x02WireGu" +
-	"ard activé\x02Tunnel %[1]s a été activé.\x02WireGuard désactivé\x02Tunne" +
-	"l %[1]s a été désactivé.\x02Erreur du tunnel WireGuard\x02WireGuard : %[" +
-	"1]s\x02État : %[1]s\x02Mise à jour disponible!\x02WireGuard mise à jour " +
+	"\x02&Importer le(s) tunnel(s) à partir du fichier…\x02Q&uitter\x02Erreur" +
+	" du tunnel WireGuard\x02WireGuard : %[1]s\x02État : %[1]s\x02WireGuard a" +
+	"ctivé\x02Tunnel %[1]s a été activé.\x02WireGuard désactivé\x02Tunnel %[1" +
+	"]s a été désactivé.\x02Mise à jour disponible!\x02WireGuard mise à jour " +
 	"est disponible\x02Une mise à jour du WireGuard est disponible. Il est co" +
 	"nseillé de mettre votre WireGuard à jour dès que possible.\x02Tunnels" +
 	"\x02&Modifier\x02Ajouter un &tunnel vide…\x02Ajouter le tunnel\x02Suppri" +
@@ -836,7 +819,7 @@ const frData string = "" + // Size: 5898 bytes
 	"\x02http2: Trameur %[1]p : a lu %[2]v\x02http2 : champ hpack %+[1]v déco" +
 	"dé"
 
-var idIndex = []uint32{ // 185 elements
+var idIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x0000000a, 0x00000047, 0x00000062,
 	0x00000074, 0x000000bf, 0x000000f0, 0x00000148,
@@ -870,9 +853,9 @@ var idIndex = []uint32{ // 185 elements
 	0x0000064b, 0x0000067b, 0x00000693, 0x000006a9,
 	0x000006b5, 0x000006eb, 0x000006fe, 0x00000715,
 	0x0000074a, 0x00000763, 0x0000077b, 0x0000078a,
-	0x0000079d, 0x000007b8, 0x000007c0, 0x000007c0,
-	0x000007c0, 0x000007c0, 0x000007c0, 0x000007d6,
+	0x0000079d, 0x000007b8, 0x000007c0, 0x000007d6,
 	0x000007e7, 0x000007f5, 0x000007f5, 0x000007f5,
+	0x000007f5, 0x000007f5, 0x000007f5, 0x000007f5,
 	// Entry 80 - 9F
 	0x000007f5, 0x000007f5, 0x000007f5, 0x000007f5,
 	0x000007f5, 0x000007f5, 0x000007f5, 0x000007f5,
@@ -888,9 +871,7 @@ var idIndex = []uint32{ // 185 elements
 	0x0000087a, 0x0000087a, 0x0000087a, 0x0000087a,
 	0x0000087a, 0x0000087a, 0x0000087a, 0x0000087a,
 	0x0000087a, 0x0000087a, 0x0000087a, 0x0000087a,
-	0x0000087a, 0x0000087a, 0x0000087a, 0x0000087a,
-	0x0000087a,
-} // Size: 764 bytes
+} // Size: 744 bytes
 
 const idData string = "" + // Size: 2170 bytes
 	"\x02Kesalahan\x02(tidak ada argumen): naikkan akses dan instal servis ma" +
@@ -930,7 +911,7 @@ const idData string = "" + // Size: 2170 bytes
 	"&Hapus tunnel terpilih\x02Tidak dapat mengimpor konfigurasi yang dipilih" +
 	": %[1]v"
 
-var itIndex = []uint32{ // 185 elements
+var itIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x00000007, 0x00000044, 0x0000005d,
 	0x00000075, 0x000000bd, 0x000000f6, 0x0000014d,
@@ -964,9 +945,9 @@ var itIndex = []uint32{ // 185 elements
 	0x00000ae1, 0x00000b14, 0x00000b28, 0x00000b46,
 	0x00000b58, 0x00000b8b, 0x00000b9c, 0x00000bbf,
 	0x00000c04, 0x00000c1b, 0x00000c2e, 0x00000c41,
-	0x00000c57, 0x00000c72, 0x00000c78, 0x00000c8b,
-	0x00000cae, 0x00000cc4, 0x00000cea, 0x00000d05,
-	0x00000d16, 0x00000d23, 0x00000d44, 0x00000d6b,
+	0x00000c57, 0x00000c72, 0x00000c78, 0x00000c93,
+	0x00000ca4, 0x00000cb1, 0x00000cc4, 0x00000ce7,
+	0x00000cfd, 0x00000d23, 0x00000d44, 0x00000d6b,
 	// Entry 80 - 9F
 	0x00000dca, 0x00000dd1, 0x00000ddb, 0x00000df2,
 	0x00000e02, 0x00000e1d, 0x00000e3b, 0x00000e44,
@@ -982,9 +963,7 @@ var itIndex = []uint32{ // 185 elements
 	0x00001465, 0x000014ab, 0x000014d1, 0x000014f5,
 	0x0000151c, 0x0000151c, 0x0000151c, 0x0000151c,
 	0x0000151c, 0x0000151c, 0x0000151c, 0x0000151c,
-	0x0000151c, 0x0000151c, 0x0000151c, 0x0000151c,
-	0x0000151c,
-} // Size: 764 bytes
+} // Size: 744 bytes
 
 const itData string = "" + // Size: 5404 bytes
 	"\x02Errore\x02(nessun argomento): eleva e installa il servizio di gestio" +
@@ -1038,10 +1017,10 @@ const itData string = "" + // Size: 5404 bytes
 	"]s (obsoleto)\x02Errore di rilevamento di WireGuard\x02Impossibile atten" +
 	"dere la comparsa della finestra di WireGuard: %[1]v\x02WireGuard: disatt" +
 	"ivato\x02Stato: sconosciuto\x02Indirizzi: nessuno\x02&Gestisci i tunnel…" +
-	"\x02&Importa tunnel da file…\x02E&sci\x02WireGuard attivato\x02Il tunnel" +
-	" %[1]s è stato attivato.\x02WireGuard disattivato\x02Il tunnel %[1]s è s" +
-	"tato disattivato.\x02Errore tunnel di WireGuard\x02WireGuard: %[1]s\x02S" +
-	"tato: %[1]s\x02Un aggiornamento è disponibile!\x02Aggiornamento di WireG" +
+	"\x02&Importa tunnel da file…\x02E&sci\x02Errore tunnel di WireGuard\x02W" +
+	"ireGuard: %[1]s\x02Stato: %[1]s\x02WireGuard attivato\x02Il tunnel %[1]s" +
+	" è stato attivato.\x02WireGuard disattivato\x02Il tunnel %[1]s è stato d" +
+	"isattivato.\x02Un aggiornamento è disponibile!\x02Aggiornamento di WireG" +
 	"uard disponibile\x02Un aggiornamento di WireGuard è disponibile. Ti cons" +
 	"igliamo di aggiornare il prima possibile.\x02Tunnel\x02&Modifica\x02Aggi" +
 	"ungi tunn&el vuoto\x02Aggiungi tunnel\x02Rimuovi tunnel selezionati\x02E" +
@@ -1075,7 +1054,7 @@ const itData string = "" + // Size: 5404 bytes
 	"p2: Framer %[1]p: ha letto %[2]v\x02http2: campo hpack %+[1]v decodifica" +
 	"to"
 
-var jaIndex = []uint32{ // 185 elements
+var jaIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x0000000a, 0x0000005b, 0x00000075,
 	0x0000009a, 0x000000e6, 0x00000124, 0x0000017b,
@@ -1109,9 +1088,9 @@ var jaIndex = []uint32{ // 185 elements
 	0x00000cac, 0x00000cf6, 0x00000d21, 0x00000d3e,
 	0x00000d54, 0x00000d89, 0x00000d9e, 0x00000db8,
 	0x00000dfa, 0x00000e15, 0x00000e24, 0x00000e39,
-	0x00000e56, 0x00000e8e, 0x00000e99, 0x00000eb3,
-	0x00000ee5, 0x00000eff, 0x00000f31, 0x00000f51,
-	0x00000f62, 0x00000f70, 0x00000f8f, 0x00000fb8,
+	0x00000e56, 0x00000e8e, 0x00000e99, 0x00000eb9,
+	0x00000eca, 0x00000ed8, 0x00000ef2, 0x00000f24,
+	0x00000f3e, 0x00000f70, 0x00000f8f, 0x00000fb8,
 	// Entry 80 - 9F
 	0x00001020, 0x0000102d, 0x00001038, 0x0000105b,
 	0x00001071, 0x00001093, 0x000010c7, 0x000010d8,
@@ -1127,9 +1106,7 @@ var jaIndex = []uint32{ // 185 elements
 	0x000017a2, 0x000017e8, 0x0000181c, 0x00001850,
 	0x00001890, 0x00001890, 0x00001890, 0x00001890,
 	0x00001890, 0x00001890, 0x00001890, 0x00001890,
-	0x00001890, 0x00001890, 0x00001890, 0x00001890,
-	0x00001890,
-} // Size: 764 bytes
+} // Size: 744 bytes
 
 const jaData string = "" + // Size: 6288 bytes
 	"\x02エラー\x02(引数なし): 管理者権限でmanagerサービスをインストールする\x02使い方: %[1]s [\x0a%[2]s]" +
@@ -1163,29 +1140,29 @@ const jaData string = "" + // Size: 6288 bytes
 	"…(&A)\x02トンネルエラー\x02%[1]s\x0a\x0a詳細はログを参照してください。\x02%[1]s (更新あり)\x02Wi" +
 	"reGuard 検出エラー\x02WireGuard ウィンドウが表示できませんでした: %[1]v\x02WireGuard: 無効化済み" +
 	"\x02状態: 不明\x02アドレス: なし\x02トンネルの管理…(&M)\x02トンネルをファイルからインポート…(&I)\x02終了(&X" +
-	")\x02WireGuard 有効化済み\x02トンネル %[1]s は有効になりました。\x02WireGuard 無効化済み\x02トンネル" +
-	" %[1]s は無効になりました。\x02WireGuard トンネルエラー\x02WireGuard: %[1]s\x02状態: %[1]s" +
-	"\x02更新が利用できます！\x02WireGuard の更新が利用可能です\x02WireGuard の更新が利用可能になりました。できるだけ" +
-	"早く更新してください。\x02トンネル\x02編集(&E)\x02空のトンネルを追加…(&E)\x02トンネルの追加\x02選択したトンネル" +
-	"の削除\x02すべてのトンネルをzipにエクスポート\x02切り替え(&T)\x02すべてのトンネルをzipにエクスポート…(&Z)\x02" +
-	"選択したトンネルの編集…(&S)\x02選択したトンネルの削除(&R)\x02選択したファイルからインポートできませんでした: %[1]v" +
-	"\x02既存のトンネルを表示できませんでした: %[1]v\x02‘%[1]s’ という名前の別のトンネルがすでに存在します\x02設定をインポ" +
-	"ートできませんでした: %[1]v\x02トンネルのインポート結果\x14\x01\x81\x01\x00\x001\x02%[1]d トン" +
-	"ネルをインポートしました\x14\x02\x80\x01\x00>\x02%[2]d 中の %[1]d トンネルをインポートしました\x02" +
-	"トンネルを作成できません\x14\x01\x81\x01\x00\x00\x1c\x02%[1]d トンネルを削除\x14\x01\x81" +
-	"\x01\x00\x005\x02本当に %[1]d トンネルを削除しますか？\x02トンネル ‘%[1]s’ を削除\x02本当にトンネル ‘" +
-	"%[1]s’ を削除しますか？\x02%[1]s この操作はもとに戻せません。\x02トンネルを削除できません\x02トンネルを削除できませんで" +
-	"した: %[1]s\x02トンネルを削除できません\x14\x01\x81\x01\x00\x004\x02%[1]d トンネルを削除できま" +
-	"せんでした\x02設定ファイル (*.zip, *.conf)|*.zip;*.conf|すべてのファイル (*.*)|*.*\x02ファイ" +
-	"ルからトンネルをインポート\x02ZIP形式設定ファイル (*.zip)|*.zip\x02トンネルをZIPにエクスポート\x02%[1]s" +
-	" (未署名のビルド、更新の提供なし)\x02WireGuard 終了エラー\x02%[1]v のためサービスを終了できませんでした。サービスマネ" +
-	"ージャから WireGuard を停止できます。\x02WireGuard の更新が利用可能です。速やかに更新することを強く推奨します。" +
-	"\x02状態: ユーザーからの応答待ち\x02今すぐ更新\x02状態: アップデータサービスを待機中\x02エラー: %[1]v。再度実行してく" +
-	"ださい。\x02状態: 完了！\x02http2: Framer %[1]p: just-writtenフレームのデコードに失敗\x02ht" +
-	"tp2: Framer %[1]p: %[2]v を書き込みました\x02http2: Framer %[1]p: %[2]v を読み込みました" +
-	"\x02http2: hpack フィールド %+[1]v をデコードしました"
+	")\x02WireGuard トンネルエラー\x02WireGuard: %[1]s\x02状態: %[1]s\x02WireGuard 有効化" +
+	"済み\x02トンネル %[1]s は有効になりました。\x02WireGuard 無効化済み\x02トンネル %[1]s は無効になりました" +
+	"。\x02更新が利用できます！\x02WireGuard の更新が利用可能です\x02WireGuard の更新が利用可能になりました。でき" +
+	"るだけ早く更新してください。\x02トンネル\x02編集(&E)\x02空のトンネルを追加…(&E)\x02トンネルの追加\x02選択したト" +
+	"ンネルの削除\x02すべてのトンネルをzipにエクスポート\x02切り替え(&T)\x02すべてのトンネルをzipにエクスポート…(&Z)" +
+	"\x02選択したトンネルの編集…(&S)\x02選択したトンネルの削除(&R)\x02選択したファイルからインポートできませんでした: %[1]" +
+	"v\x02既存のトンネルを表示できませんでした: %[1]v\x02‘%[1]s’ という名前の別のトンネルがすでに存在します\x02設定をイン" +
+	"ポートできませんでした: %[1]v\x02トンネルのインポート結果\x14\x01\x81\x01\x00\x001\x02%[1]d ト" +
+	"ンネルをインポートしました\x14\x02\x80\x01\x00>\x02%[2]d 中の %[1]d トンネルをインポートしました" +
+	"\x02トンネルを作成できません\x14\x01\x81\x01\x00\x00\x1c\x02%[1]d トンネルを削除\x14\x01" +
+	"\x81\x01\x00\x005\x02本当に %[1]d トンネルを削除しますか？\x02トンネル ‘%[1]s’ を削除\x02本当にトン" +
+	"ネル ‘%[1]s’ を削除しますか？\x02%[1]s この操作はもとに戻せません。\x02トンネルを削除できません\x02トンネルを削除" +
+	"できませんでした: %[1]s\x02トンネルを削除できません\x14\x01\x81\x01\x00\x004\x02%[1]d トンネル" +
+	"を削除できませんでした\x02設定ファイル (*.zip, *.conf)|*.zip;*.conf|すべてのファイル (*.*)|*.*" +
+	"\x02ファイルからトンネルをインポート\x02ZIP形式設定ファイル (*.zip)|*.zip\x02トンネルをZIPにエクスポート\x02" +
+	"%[1]s (未署名のビルド、更新の提供なし)\x02WireGuard 終了エラー\x02%[1]v のためサービスを終了できませんでした。サ" +
+	"ービスマネージャから WireGuard を停止できます。\x02WireGuard の更新が利用可能です。速やかに更新することを強く推奨し" +
+	"ます。\x02状態: ユーザーからの応答待ち\x02今すぐ更新\x02状態: アップデータサービスを待機中\x02エラー: %[1]v。再度" +
+	"実行してください。\x02状態: 完了！\x02http2: Framer %[1]p: just-writtenフレームのデコードに失敗" +
+	"\x02http2: Framer %[1]p: %[2]v を書き込みました\x02http2: Framer %[1]p: %[2]v を読" +
+	"み込みました\x02http2: hpack フィールド %+[1]v をデコードしました"
 
-var plIndex = []uint32{ // 185 elements
+var plIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x00000007, 0x0000004f, 0x00000066,
 	0x0000007d, 0x000000cb, 0x00000102, 0x0000016b,
@@ -1219,9 +1196,9 @@ var plIndex = []uint32{ // 185 elements
 	0x00000bb0, 0x00000be7, 0x00000c03, 0x00000c1e,
 	0x00000c2c, 0x00000c6c, 0x00000c80, 0x00000c9a,
 	0x00000cd8, 0x00000cf1, 0x00000d02, 0x00000d0f,
-	0x00000d27, 0x00000d4b, 0x00000d55, 0x00000d67,
-	0x00000d87, 0x00000d9f, 0x00000dc2, 0x00000dda,
-	0x00000deb, 0x00000df9, 0x00000e16, 0x00000e3c,
+	0x00000d27, 0x00000d4b, 0x00000d55, 0x00000d6d,
+	0x00000d7e, 0x00000d8c, 0x00000d9e, 0x00000dbe,
+	0x00000dd6, 0x00000df9, 0x00000e16, 0x00000e3c,
 	// Entry 80 - 9F
 	0x00000e93, 0x00000e9a, 0x00000ea2, 0x00000eb8,
 	0x00000ec4, 0x00000ee1, 0x00000f0c, 0x00000f18,
@@ -1236,10 +1213,8 @@ var plIndex = []uint32{ // 185 elements
 	0x0000160e, 0x0000161f, 0x00001647, 0x00001669,
 	0x0000167d, 0x000016c3, 0x000016e4, 0x00001706,
 	0x00001739, 0x00001739, 0x00001739, 0x00001739,
-	0x00001739, 0x00001739, 0x00001739, 0x00001739,
-	0x00001739, 0x00001739, 0x00001741, 0x00001741,
-	0x0000175f,
-} // Size: 764 bytes
+	0x00001739, 0x00001741, 0x0000175f, 0x0000175f,
+} // Size: 744 bytes
 
 const plData string = "" + // Size: 5983 bytes
 	"\x02Błąd\x02(brak argumentu): Podnieś uprawnienia i zainstaluj usługę me" +
@@ -1296,9 +1271,9 @@ const plData string = "" + // Size: 5983 bytes
 	" detekcji WireGuard\x02Nie można poczekać na pojawienie się okna WireGua" +
 	"rd: %[1]v\x02WireGuard: Dezaktywowany\x02Status: Nieznany\x02Adresy: Bra" +
 	"k\x02&Zarządzaj tunelami…\x02&Importuj tunel (tunele) z pliku…\x02W&yjśc" +
-	"ie\x02WireGuard Aktywny\x02Tunel %[1]s został aktywowany.\x02WireGuard D" +
-	"ezaktywowany\x02Tunel %[1]s został dezaktywowany.\x02Błąd tunelu WireGua" +
-	"rd\x02WireGuard: %[1]s\x02Status: %[1]s\x02Dostępna nowa aktualizacja!" +
+	"ie\x02Błąd tunelu WireGuard\x02WireGuard: %[1]s\x02Status: %[1]s\x02Wire" +
+	"Guard Aktywny\x02Tunel %[1]s został aktywowany.\x02WireGuard Dezaktywowa" +
+	"ny\x02Tunel %[1]s został dezaktywowany.\x02Dostępna nowa aktualizacja!" +
 	"\x02Aktualizacja WireGuard jest dostępna\x02Aktualizacja WireGuard jest " +
 	"już dostępna. Zaleca się jak najszybszą aktualizację.\x02Tunele\x02&Edyt" +
 	"uj\x02Dodaj &pusty tunel…\x02Dodaj Tunel\x02Usuń wybrany tunel (tunele)" +
@@ -1337,7 +1312,7 @@ const plData string = "" + // Size: 5983 bytes
 	"2]v\x02http2: zdekodwanie hpack nie powiodło się %+[1]v\x02&Tunele\x02br" +
 	"ak plików konfiguracyjnych"
 
-var roIndex = []uint32{ // 185 elements
+var roIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x00000007, 0x0000005d, 0x00000077,
 	0x00000092, 0x000000d1, 0x00000105, 0x00000169,
@@ -1371,9 +1346,9 @@ var roIndex = []uint32{ // 185 elements
 	0x00000bd1, 0x00000c08, 0x00000c25, 0x00000c3a,
 	0x00000c4a, 0x00000c82, 0x00000c97, 0x00000cb5,
 	0x00000cf3, 0x00000d09, 0x00000d1d, 0x00000d2d,
-	0x00000d45, 0x00000d6a, 0x00000d73, 0x00000d85,
-	0x00000da3, 0x00000db8, 0x00000dd9, 0x00000df3,
-	0x00000e04, 0x00000e11, 0x00000e32, 0x00000e5c,
+	0x00000d45, 0x00000d6a, 0x00000d73, 0x00000d8d,
+	0x00000d9e, 0x00000dab, 0x00000dbd, 0x00000ddb,
+	0x00000df0, 0x00000e11, 0x00000e32, 0x00000e5c,
 	// Entry 80 - 9F
 	0x00000ed2, 0x00000edb, 0x00000edb, 0x00000ef3,
 	0x00000f03, 0x00000f24, 0x00000f46, 0x00000f50,
@@ -1387,11 +1362,9 @@ var roIndex = []uint32{ // 185 elements
 	0x000014bb, 0x000014dc, 0x0000153d, 0x0000159a,
 	0x000015bc, 0x000015cd, 0x000015fb, 0x0000161e,
 	0x00001632, 0x00001674, 0x00001697, 0x000016ba,
-	0x000016e0, 0x00001729, 0x00001729, 0x00001729,
-	0x00001729, 0x00001729, 0x00001729, 0x00001729,
-	0x00001729, 0x00001729, 0x00001733, 0x00001733,
-	0x0000175e,
-} // Size: 764 bytes
+	0x000016e0, 0x000016e0, 0x000016e0, 0x000016e0,
+	0x000016e0, 0x000016ea, 0x00001715, 0x0000175e,
+} // Size: 744 bytes
 
 const roData string = "" + // Size: 5982 bytes
 	"\x02Eroare\x02(fără argument): obținere drept administrativ și instalare" +
@@ -1448,9 +1421,9 @@ const roData string = "" + // Size: 5982 bytes
 	"]s (neactualizat)\x02Eroare de detectare WireGuard\x02Nu se poate aștept" +
 	"a ca fereastra WireGuard să apară: %[1]v\x02WireGuard: dezactivat\x02Sta" +
 	"re: necunoscută\x02Adrese: niciuna\x02&Gestionare tuneluri…\x02&Importar" +
-	"e tunel(uri) din fișier…\x02Ie&șire\x02WireGuard activat\x02Tunelul %[1]" +
-	"s a fost activat.\x02WireGuard dezactivat\x02Tunelul %[1]s a fost dezact" +
-	"ivat.\x02Eroare de tunel WireGuard\x02WireGuard: %[1]s\x02Stare: %[1]s" +
+	"e tunel(uri) din fișier…\x02Ie&șire\x02Eroare de tunel WireGuard\x02Wire" +
+	"Guard: %[1]s\x02Stare: %[1]s\x02WireGuard activat\x02Tunelul %[1]s a fos" +
+	"t activat.\x02WireGuard dezactivat\x02Tunelul %[1]s a fost dezactivat." +
 	"\x02Este disponibilă o actualizare!\x02Actualizare disponibilă pentru Wi" +
 	"reGuard\x02O actualizare pentru WireGuard este acum disponibilă. Se reco" +
 	"mandă efectuarea actualizării cât mai rapid posibil.\x02Tuneluri\x02Adău" +
@@ -1485,11 +1458,11 @@ const roData string = "" + // Size: 5982 bytes
 	"l de actualizare\x02Eroare: %[1]v. Încearcă din nou.\x02Stare: finalizat" +
 	"ă!\x02http2: Framer %[1]p: decodificarea cadrului recent scris a eșuat" +
 	"\x02http2: Framer %[1]p: a scris %[2]v\x02http2: Framer %[1]p: a citit %" +
-	"[2]v\x02http2: câmp hpack decodificat %+[1]v\x02Trebuie să utilizezi ver" +
-	"siunea nativă a WireGuard pe acest calculator.\x02&Tuneluri\x02nu au fos" +
-	"t găsite fișiere de configurare"
+	"[2]v\x02http2: câmp hpack decodificat %+[1]v\x02&Tuneluri\x02nu au fost " +
+	"găsite fișiere de configurare\x02Trebuie să utilizezi versiunea nativă a" +
+	" WireGuard pe acest calculator."
 
-var ruIndex = []uint32{ // 185 elements
+var ruIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x0000000d, 0x000000a9, 0x000000d4,
 	0x00000107, 0x00000166, 0x000001bd, 0x00000244,
@@ -1523,9 +1496,9 @@ var ruIndex = []uint32{ // 185 elements
 	0x0000111f, 0x00001167, 0x00001191, 0x000011a2,
 	0x000011be, 0x00001239, 0x00001250, 0x0000127e,
 	0x000012d2, 0x000012f8, 0x0000131b, 0x00001330,
-	0x0000135c, 0x0000138e, 0x0000139a, 0x000013b3,
-	0x000013dc, 0x000013f7, 0x0000141e, 0x00001444,
-	0x00001455, 0x00001469, 0x00001490, 0x000014c0,
+	0x0000135c, 0x0000138e, 0x0000139a, 0x000013c0,
+	0x000013d1, 0x000013e5, 0x000013fe, 0x00001427,
+	0x00001442, 0x00001469, 0x00001490, 0x000014c0,
 	// Entry 80 - 9F
 	0x0000154b, 0x0000155a, 0x00001576, 0x000015a7,
 	0x000015c7, 0x000015f8, 0x00001633, 0x0000164b,
@@ -1540,10 +1513,8 @@ var ruIndex = []uint32{ // 185 elements
 	0x00002175, 0x00002193, 0x000021b6, 0x000021ef,
 	0x00002211, 0x00002285, 0x000022af, 0x000022dd,
 	0x00002311, 0x00002311, 0x00002311, 0x00002311,
-	0x00002311, 0x00002311, 0x00002311, 0x00002311,
-	0x00002311, 0x00002311, 0x00002311, 0x00002311,
-	0x00002352,
-} // Size: 764 bytes
+	0x00002311, 0x00002311, 0x00002352, 0x00002352,
+} // Size: 744 bytes
 
 const ruData string = "" + // Size: 9042 bytes
 	"\x02Ошибка\x02(нет аргумента): получить права администратора и установит" +
@@ -1596,17 +1567,17 @@ const ruData string = "" + // Size: 9042 bytes
 	"ел)\x02Ошибка обнаружения WireGuard\x02Не удалось дождаться появления о" +
 	"кна WireGuard: %[1]v\x02WireGuard: Деактивирован\x02Статус: Неизвестен" +
 	"\x02Адреса: нет\x02&Управление туннелями…\x02&Импорт туннелей из файла…" +
-	"\x02Вы&ход\x02WireGuard Включен\x02Туннель %[1]s подключен.\x02WireGuard" +
-	" Выключен\x02Туннель %[1]s отключен.\x02Ошибка туннеля WireGuard\x02Wire" +
-	"Guard: %[1]s\x02Статус: %[1]s\x02Доступно обновление!\x02Доступно обновл" +
-	"ение WireGuard\x02Доступно обновление для WireGuard. Рекомендуется обно" +
-	"вить его как можно скорее.\x02Туннели\x02&Редактировать\x02Добавить &пу" +
-	"стой туннель…\x02Добавить туннель\x02Удалить выбранные туннели\x02Экспо" +
-	"рт всех туннелей в zip-архив\x02&Переключить\x02Экспорт всех туннелей в" +
-	" &zip-архив…\x02Редактировать &выбранный туннель…\x02&Удалить выбранные " +
-	"туннели\x02Невозможно импортировать конфигурацию: %[1]v\x02Не удалось п" +
-	"еречислить существующие туннели: %[1]v\x02Туннель с именем ’%[1]s’ уже " +
-	"существует\x02Невозможно импортировать конфигурацию: %[1]v\x02Импортиро" +
+	"\x02Вы&ход\x02Ошибка туннеля WireGuard\x02WireGuard: %[1]s\x02Статус: %[" +
+	"1]s\x02WireGuard Включен\x02Туннель %[1]s подключен.\x02WireGuard Выключ" +
+	"ен\x02Туннель %[1]s отключен.\x02Доступно обновление!\x02Доступно обнов" +
+	"ление WireGuard\x02Доступно обновление для WireGuard. Рекомендуется обн" +
+	"овить его как можно скорее.\x02Туннели\x02&Редактировать\x02Добавить &п" +
+	"устой туннель…\x02Добавить туннель\x02Удалить выбранные туннели\x02Эксп" +
+	"орт всех туннелей в zip-архив\x02&Переключить\x02Экспорт всех туннелей " +
+	"в &zip-архив…\x02Редактировать &выбранный туннель…\x02&Удалить выбранны" +
+	"е туннели\x02Невозможно импортировать конфигурацию: %[1]v\x02Не удалось" +
+	" перечислить существующие туннели: %[1]v\x02Туннель с именем ’%[1]s’ уже" +
+	" существует\x02Невозможно импортировать конфигурацию: %[1]v\x02Импортиро" +
 	"ванные туннели\x14\x01\x81\x01\x00\x041\x02Импортированы туннели: %[1]d" +
 	"\x051\x02Импортированы туннели: %[1]d\x024\x02Импортированный %[1]d тунн" +
 	"ель\x001\x02Импортированы туннели: %[1]d\x14\x02\x80\x01\x04<\x02Импорт" +
@@ -1637,7 +1608,7 @@ const ruData string = "" + // Size: 9042 bytes
 	"v\x02http2: декодирован hpack поле %+[1]v\x02файлы конфигурации не были " +
 	"найдены"
 
-var skIndex = []uint32{ // 185 elements
+var skIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x00000006, 0x0000005e, 0x00000078,
 	0x00000097, 0x000000d1, 0x00000109, 0x0000014b,
@@ -1672,7 +1643,7 @@ var skIndex = []uint32{ // 185 elements
 	0x00000ae3, 0x00000ae3, 0x00000ae3, 0x00000ae3,
 	0x00000ae3, 0x00000ae3, 0x00000ae3, 0x00000ae3,
 	0x00000af9, 0x00000b1d, 0x00000b28, 0x00000b28,
-	0x00000b28, 0x00000b28, 0x00000b28, 0x00000b28,
+	0x00000b39, 0x00000b39, 0x00000b39, 0x00000b39,
 	0x00000b39, 0x00000b39, 0x00000b39, 0x00000b39,
 	// Entry 80 - 9F
 	0x00000b39, 0x00000b39, 0x00000b39, 0x00000b39,
@@ -1689,9 +1660,7 @@ var skIndex = []uint32{ // 185 elements
 	0x00000b39, 0x00000b39, 0x00000b39, 0x00000b39,
 	0x00000b39, 0x00000b39, 0x00000b39, 0x00000b39,
 	0x00000b39, 0x00000b39, 0x00000b39, 0x00000b39,
-	0x00000b39, 0x00000b39, 0x00000b39, 0x00000b39,
-	0x00000b39,
-} // Size: 764 bytes
+} // Size: 744 bytes
 
 const skData string = "" + // Size: 2873 bytes
 	"\x02Chyba\x02(bez argumentu): získať administrátorské práva a nainštalov" +
@@ -1740,7 +1709,7 @@ const skData string = "" + // Size: 2873 bytes
 	"avovať tunely…\x02&Importovať tunel(y) zo súboru…\x02U&končiť\x02WireGua" +
 	"rd: %[1]s"
 
-var slIndex = []uint32{ // 185 elements
+var slIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x00000007, 0x00000058, 0x00000070,
 	0x00000089, 0x000000c1, 0x000000f8, 0x0000014a,
@@ -1774,9 +1743,9 @@ var slIndex = []uint32{ // 185 elements
 	0x00000a99, 0x00000ad1, 0x00000aeb, 0x00000afe,
 	0x00000b0c, 0x00000b3b, 0x00000b51, 0x00000b6e,
 	0x00000ba9, 0x00000bc0, 0x00000bcf, 0x00000bdd,
-	0x00000bf4, 0x00000c13, 0x00000c1a, 0x00000c2e,
-	0x00000c4c, 0x00000c62, 0x00000c82, 0x00000c9a,
-	0x00000cab, 0x00000cb9, 0x00000cd2, 0x00000cf5,
+	0x00000bf4, 0x00000c13, 0x00000c1a, 0x00000c32,
+	0x00000c43, 0x00000c51, 0x00000c65, 0x00000c83,
+	0x00000c99, 0x00000cb9, 0x00000cd2, 0x00000cf5,
 	// Entry 80 - 9F
 	0x00000d3a, 0x00000d41, 0x00000d48, 0x00000d61,
 	0x00000d6d, 0x00000d85, 0x00000d9d, 0x00000da7,
@@ -1792,9 +1761,7 @@ var slIndex = []uint32{ // 185 elements
 	0x000014eb, 0x00001533, 0x00001557, 0x0000157b,
 	0x000015a0, 0x000015a0, 0x000015a0, 0x000015a0,
 	0x000015a0, 0x000015a0, 0x000015a0, 0x000015a0,
-	0x000015a0, 0x000015a0, 0x000015a0, 0x000015a0,
-	0x000015a0,
-} // Size: 764 bytes
+} // Size: 744 bytes
 
 const slData string = "" + // Size: 5536 bytes
 	"\x02Napaka\x02(brez argumenta): povzdigni na skrbniške pravice in namest" +
@@ -1848,9 +1815,9 @@ const slData string = "" + // Size: 5536 bytes
 	"\x02Napaka zaznavanja WireGuarda\x02Čakanje, da se pojavi WireGuardovo o" +
 	"kno, ni možno: %[1]v\x02WireGuard: Deaktiviran\x02Status: Neznan\x02Nasl" +
 	"ovi: Brez\x02&Upravljaj tunele\u00a0…\x02&Uvozi tunel(e) iz datoteke…" +
-	"\x02I&zhod\x02WireGuard aktiviran\x02Tunel %[1]s je bil aktiviran.\x02Wi" +
-	"reGuard deaktiviran\x02Tunel %[1]s je bil deaktiviran.\x02Napaka tunela " +
-	"WireGuard\x02WireGuard: %[1]s\x02Status: %[1]s\x02Na voljo je posodobite" +
+	"\x02I&zhod\x02Napaka tunela WireGuard\x02WireGuard: %[1]s\x02Status: %[1" +
+	"]s\x02WireGuard aktiviran\x02Tunel %[1]s je bil aktiviran.\x02WireGuard " +
+	"deaktiviran\x02Tunel %[1]s je bil deaktiviran.\x02Na voljo je posodobite" +
 	"v!\x02Posodobitev WireGuarda je na voljo\x02Posodobitev WireGuarda je na" +
 	" voljo. Svetujemo posodobitev čim prej.\x02Tuneli\x02&Uredi\x02Dodaj &pr" +
 	"azen tunel\u00a0…\x02Dodaj tunel\x02Odstrani izbrane tunele\x02Izvozi vs" +
@@ -1888,7 +1855,7 @@ const slData string = "" + // Size: 5536 bytes
 	"\x02http2: Framer %[1]p: zapisano %[2]v\x02http2: Framer %[1]p: prebrano" +
 	" %[2]v\x02http2: dekodirano polje hpack %+[1]v"
 
-var ukIndex = []uint32{ // 185 elements
+var ukIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x0000000f, 0x0000000f, 0x0000000f,
 	0x00000042, 0x0000009c, 0x000000f5, 0x00000191,
@@ -1940,9 +1907,7 @@ var ukIndex = []uint32{ // 185 elements
 	0x00000817, 0x00000817, 0x00000817, 0x00000817,
 	0x00000817, 0x00000817, 0x00000817, 0x00000817,
 	0x00000817, 0x00000817, 0x00000817, 0x00000817,
-	0x00000817, 0x00000817, 0x00000817, 0x00000817,
-	0x00000817,
-} // Size: 764 bytes
+} // Size: 744 bytes
 
 const ukData string = "" + // Size: 2071 bytes
 	"\x02Помилка\x02Параметри командного рядка\x02Неможливо визначити, чи пра" +
@@ -1965,7 +1930,7 @@ const ukData string = "" + // Size: 2071 bytes
 	"Зображення логотипу WireGuard\x02Закрити\x02♥ &Пожертвувати!\x02Статус:" +
 	"\x02&Деактивувати\x02&Активувати\x02Відкритий ключ:"
 
-var viIndex = []uint32{ // 185 elements
+var viIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x00000006, 0x00000006, 0x00000006,
 	0x00000006, 0x00000006, 0x00000006, 0x00000006,
@@ -2017,9 +1982,7 @@ var viIndex = []uint32{ // 185 elements
 	0x000002ad, 0x000002ad, 0x000002ad, 0x000002ad,
 	0x000002ad, 0x000002ad, 0x000002ad, 0x000002ad,
 	0x000002ad, 0x000002ad, 0x000002ad, 0x000002ad,
-	0x000002ad, 0x000002ad, 0x000002ad, 0x000002ad,
-	0x000002ad,
-} // Size: 764 bytes
+} // Size: 744 bytes
 
 const viData string = "" + // Size: 685 bytes
 	"\x02Lỗi\x02Vừa xong\x14\x01\x81\x01\x00\x00\x0b\x02%[1]d năm\x14\x01\x81" +
@@ -2034,7 +1997,7 @@ const viData string = "" + // Size: 685 bytes
 	"1]s' không hợp lệ.\x02Không thể liệt kê các VPN\x02VPN đã tồn tại\x02Đã " +
 	"tồn tại VPN với tên ‘%[1]s’."
 
-var zh_CNIndex = []uint32{ // 185 elements
+var zh_CNIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x00000007, 0x00000030, 0x00000047,
 	0x00000057, 0x0000008b, 0x000000b1, 0x000000f6,
@@ -2068,9 +2031,9 @@ var zh_CNIndex = []uint32{ // 185 elements
 	0x00000857, 0x00000889, 0x00000896, 0x000008af,
 	0x000008bc, 0x000008e2, 0x000008f4, 0x0000090b,
 	0x00000936, 0x0000094b, 0x0000095a, 0x00000966,
-	0x0000097b, 0x00000999, 0x000009a5, 0x000009b9,
-	0x000009d7, 0x000009eb, 0x00000a0f, 0x00000a26,
-	0x00000a37, 0x00000a45, 0x00000a55, 0x00000a66,
+	0x0000097b, 0x00000999, 0x000009a5, 0x000009bc,
+	0x000009cd, 0x000009db, 0x000009ef, 0x00000a0d,
+	0x00000a21, 0x00000a45, 0x00000a55, 0x00000a66,
 	// Entry 80 - 9F
 	0x00000aa8, 0x00000aaf, 0x00000abb, 0x00000ad3,
 	0x00000ae0, 0x00000af3, 0x00000b16, 0x00000b2e,
@@ -2086,9 +2049,7 @@ var zh_CNIndex = []uint32{ // 185 elements
 	0x00000f7e, 0x00000fb2, 0x00000fda, 0x00001002,
 	0x00001027, 0x00001027, 0x00001027, 0x00001027,
 	0x00001027, 0x00001027, 0x00001027, 0x00001027,
-	0x00001027, 0x00001027, 0x00001027, 0x00001027,
-	0x00001027,
-} // Size: 764 bytes
+} // Size: 744 bytes
 
 const zh_CNData string = "" + // Size: 4135 bytes
 	"\x02错误\x02(无参数): 提升并安装管理服务\x02用法: %[1]s [\x0a%[2]s]\x02命令行选项\x02无法确定该进程是" +
@@ -2116,8 +2077,8 @@ const zh_CNData string = "" + // Size: 4135 bytes
 	"txt)|*.txt|所有文件 (*.*)|*.*\x02导出日志\x02关于 WireGuard… (&A)\x02隧道错误\x02%[1]s" +
 	"\x0a\x0a更多信息请查看日志。\x02%[1]s (已过时)\x02WireGuard 检测错误\x02无法等待 WireGuard 窗口" +
 	"出现: %[1]v\x02WireGuard: 已断开\x02状态: 未知\x02地址: 无\x02管理隧道… (&M)\x02从文件导入隧" +
-	"道… (&I)\x02退出 (&E)\x02WireGuard 已连接\x02隧道「%[1]s」已连接。\x02WireGuard 已断开" +
-	"\x02隧道「%[1]s」已断开连接。\x02WireGuard 隧道错误\x02WireGuard: %[1]s\x02状态: %[1]s" +
+	"道… (&I)\x02退出 (&E)\x02WireGuard 隧道错误\x02WireGuard: %[1]s\x02状态: %[1]s" +
+	"\x02WireGuard 已连接\x02隧道「%[1]s」已连接。\x02WireGuard 已断开\x02隧道「%[1]s」已断开连接。" +
 	"\x02发现更新！\x02WireGuard 更新\x02新的 WireGuard 版本发布了。强烈建议您现在安装。\x02隧道\x02编辑 (" +
 	"&E)\x02新建空隧道… (&E)\x02新建隧道\x02删除所选隧道\x02导出所有隧道 (ZIP 压缩包)\x02切换连接状态 (&T)" +
 	"\x02导出所有隧道 (ZIP 压缩包)… (&Z)\x02编辑所选隧道… (&E)\x02删除所选隧道 (&R)\x02无法导入配置: %[1" +
@@ -2134,7 +2095,7 @@ const zh_CNData string = "" + // Size: 4135 bytes
 	"码刚写入的帧失败\x02http2: 成帧器 %[1]p: 写入了 %[2]v\x02http2: 成帧器 %[1]p: 读取了 %[2]v" +
 	"\x02http2: 解码的 hpack 字段 %+[1]v"
 
-var zh_TWIndex = []uint32{ // 185 elements
+var zh_TWIndex = []uint32{ // 180 elements
 	// Entry 0 - 1F
 	0x00000000, 0x00000007, 0x00000037, 0x00000056,
 	0x00000066, 0x000000a4, 0x000000d5, 0x00000118,
@@ -2168,9 +2129,9 @@ var zh_TWIndex = []uint32{ // 185 elements
 	0x000008ce, 0x000008fd, 0x0000090d, 0x00000923,
 	0x00000930, 0x0000095f, 0x00000974, 0x0000098c,
 	0x000009b9, 0x000009cf, 0x000009df, 0x000009ec,
-	0x000009fe, 0x00000a16, 0x00000a22, 0x00000a36,
-	0x00000a51, 0x00000a6b, 0x00000a8f, 0x00000aa6,
-	0x00000ab8, 0x00000ac7, 0x00000ace, 0x00000adf,
+	0x000009fe, 0x00000a16, 0x00000a22, 0x00000a39,
+	0x00000a4b, 0x00000a5a, 0x00000a6e, 0x00000a89,
+	0x00000aa3, 0x00000ac7, 0x00000ace, 0x00000adf,
 	// Entry 80 - 9F
 	0x00000b38, 0x00000b3f, 0x00000b4b, 0x00000b63,
 	0x00000b70, 0x00000b83, 0x00000ba6, 0x00000bbe,
@@ -2186,9 +2147,7 @@ var zh_TWIndex = []uint32{ // 185 elements
 	0x0000103c, 0x00001075, 0x00001096, 0x000010b6,
 	0x000010d8, 0x000010d8, 0x000010d8, 0x000010d8,
 	0x000010d8, 0x000010d8, 0x000010d8, 0x000010d8,
-	0x000010d8, 0x000010d8, 0x000010d8, 0x000010d8,
-	0x000010d8,
-} // Size: 764 bytes
+} // Size: 744 bytes
 
 const zh_TWData string = "" + // Size: 4312 bytes
 	"\x02錯誤\x02(無參數)：提升權限並安裝管理服務\x02使用方法： %[1]s [\x0a%[2]s]\x02命令列選項\x02無法確定該" +
@@ -2217,23 +2176,23 @@ const zh_TWData string = "" + // Size: 4312 bytes
 	"檔案 (*.*)|*.*\x02匯出日誌…\x02關於 WireGuard (&A)\x02隧道錯誤\x02%[1]s\x0a\x0a如需更" +
 	"多資訊，請查看日誌。\x02%[1]s（已過時）\x02偵測 WireGuard 錯誤\x02無法等待 WireGuard 視窗開啓： %[" +
 	"1]v\x02WireGuard - 未連線\x02[狀態] 未知\x02[位址] 無\x02管理隧道 (&M)\x02從檔案匯入… (&I)" +
-	"\x02離開 (&X)\x02WireGuard 已連線\x02已連線至隧道 - %[1]s\x02WireGuard 已中斷連線\x02已中斷" +
-	"與隧道的連線 - %[1]s\x02WireGuard 隧道錯誤\x02WireGuard - %[1]s\x02[狀態] %[1]s" +
-	"\x02更新\x02WireGuard 更新\x02更新的 WireGuard 已經為您準備好了。\x0a強烈建議您立即更新 WireGuard" +
-	"。\x02隧道\x02編輯 (&E)\x02新增隧道精靈 (&E)\x02新增隧道\x02刪除選取隧道\x02匯出所有隧道（ZIP 格式）" +
-	"\x02切換連線狀態 (&T)\x02匯出所有隧道至 &ZIP 壓縮檔\x02編輯選取隧道 (&S)\x02刪除已選取隧道 (&R)\x02無法" +
-	"匯入設定： %[1]v\x02無法列出隧道： %[1]v\x02已有另一個同名的隧道「%[1]s」\x02無法匯入設定： %[1]v\x02" +
-	"已匯入隧道\x14\x01\x81\x01\x00\x00\x1a\x02已匯入 %[1]d 個隧道\x14\x02\x80\x01\x00" +
-	"-\x02已匯入 %[1]d 個隧道（共 %[2]d 個）\x02無法建立隧道\x14\x01\x81\x01\x00\x00\x17\x02刪" +
-	"除 %[1]d 個隧道\x14\x01\x81\x01\x00\x00)\x02您確定要刪除 %[1]d 個隧道嗎？\x02刪除隧道 - %" +
-	"[1]s\x02您確定要刪除隧道「%[1]s」嗎？\x02%[1]s\x0a\x0a您將無法復原此操作。\x02無法刪除隧道\x02無法刪除隧道" +
-	"： %[1]s\x02無法刪除隧道\x14\x01\x81\x01\x00\x00\x1d\x02無法刪除 %[1]d 個隧道\x02隧道設" +
-	"定檔 (*.zip, *.conf)|*.zip;*.conf|所有檔案 (*.*)|*.*\x02從檔案中匯入隧道…\x02隧道設定檔 (" +
-	"*.zip)|*.zip\x02匯出隧道設定至…\x02%[1]s（未簽署發行版本，無法自動更新）\x02離開 WireGuard 失敗\x02" +
-	"無法結束服務： %[1]v。\x0a您可能需要手動從服務管理中結束 WireGuard 服務。\x02更新的 WireGuard 已經為您準" +
-	"備好了。\x0a強烈建議您立即進行更新。\x02狀態：等待使用者\x02立即更新\x02狀態：等待更新服務\x02錯誤： %[1]v。請稍後" +
-	"再試。\x02狀態：已完成！\x02http2: Framer %[1]p: failed to decode just-written f" +
-	"rame\x02http2: Framer %[1]p: wrote %[2]v\x02http2: Framer %[1]p: read %[" +
-	"2]v\x02http2: decoded hpack field %+[1]v"
+	"\x02離開 (&X)\x02WireGuard 隧道錯誤\x02WireGuard - %[1]s\x02[狀態] %[1]s\x02Wire" +
+	"Guard 已連線\x02已連線至隧道 - %[1]s\x02WireGuard 已中斷連線\x02已中斷與隧道的連線 - %[1]s\x02更" +
+	"新\x02WireGuard 更新\x02更新的 WireGuard 已經為您準備好了。\x0a強烈建議您立即更新 WireGuard。" +
+	"\x02隧道\x02編輯 (&E)\x02新增隧道精靈 (&E)\x02新增隧道\x02刪除選取隧道\x02匯出所有隧道（ZIP 格式）\x02" +
+	"切換連線狀態 (&T)\x02匯出所有隧道至 &ZIP 壓縮檔\x02編輯選取隧道 (&S)\x02刪除已選取隧道 (&R)\x02無法匯入" +
+	"設定： %[1]v\x02無法列出隧道： %[1]v\x02已有另一個同名的隧道「%[1]s」\x02無法匯入設定： %[1]v\x02已匯" +
+	"入隧道\x14\x01\x81\x01\x00\x00\x1a\x02已匯入 %[1]d 個隧道\x14\x02\x80\x01\x00-" +
+	"\x02已匯入 %[1]d 個隧道（共 %[2]d 個）\x02無法建立隧道\x14\x01\x81\x01\x00\x00\x17\x02刪除" +
+	" %[1]d 個隧道\x14\x01\x81\x01\x00\x00)\x02您確定要刪除 %[1]d 個隧道嗎？\x02刪除隧道 - %[1]" +
+	"s\x02您確定要刪除隧道「%[1]s」嗎？\x02%[1]s\x0a\x0a您將無法復原此操作。\x02無法刪除隧道\x02無法刪除隧道： %" +
+	"[1]s\x02無法刪除隧道\x14\x01\x81\x01\x00\x00\x1d\x02無法刪除 %[1]d 個隧道\x02隧道設定檔 (*" +
+	".zip, *.conf)|*.zip;*.conf|所有檔案 (*.*)|*.*\x02從檔案中匯入隧道…\x02隧道設定檔 (*.zip)|" +
+	"*.zip\x02匯出隧道設定至…\x02%[1]s（未簽署發行版本，無法自動更新）\x02離開 WireGuard 失敗\x02無法結束服務：" +
+	" %[1]v。\x0a您可能需要手動從服務管理中結束 WireGuard 服務。\x02更新的 WireGuard 已經為您準備好了。\x0a強" +
+	"烈建議您立即進行更新。\x02狀態：等待使用者\x02立即更新\x02狀態：等待更新服務\x02錯誤： %[1]v。請稍後再試。\x02狀態" +
+	"：已完成！\x02http2: Framer %[1]p: failed to decode just-written frame\x02h" +
+	"ttp2: Framer %[1]p: wrote %[2]v\x02http2: Framer %[1]p: read %[2]v\x02ht" +
+	"tp2: decoded hpack field %+[1]v"
 
-	// Total table size 87543 bytes (85KiB); checksum: D2A6D32
+	// Total table size 87060 bytes (85KiB); checksum: C1D7C253